@@ -27,8 +27,10 @@ import (
 	"google.golang.org/grpc/metadata"
 	reflectpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 
+	"go.viam.com/rdk/config"
 	"go.viam.com/rdk/grpc"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
 	"go.viam.com/rdk/robot/client"
 	"go.viam.com/rdk/services/shell"
 )
@@ -419,6 +421,24 @@ func (c *AppClient) RobotPart(orgStr, locStr, robotStr, partStr string) (*apppb.
 	return nil, errors.Errorf("no robot part found for %q", partStr)
 }
 
+// RobotPartConfig connects to a robot part and returns the config it is running.
+func (c *AppClient) RobotPartConfig(orgStr, locStr, robotStr, partStr string, logger golog.Logger) (*config.Config, error) {
+	dialCtx, fqdn, rpcOpts, err := c.prepareDial(orgStr, locStr, robotStr, partStr, false)
+	if err != nil {
+		return nil, err
+	}
+
+	robotClient, err := client.New(dialCtx, fqdn, logger, client.WithDialOptions(rpcOpts...))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		utils.UncheckedError(robotClient.Close(c.c.Context))
+	}()
+
+	return robotClient.Config(c.c.Context)
+}
+
 func (c *AppClient) robotPartLogs(orgStr, locStr, robotStr, partStr string, errorsOnly bool) ([]*apppb.LogEntry, error) {
 	part, err := c.RobotPart(orgStr, locStr, robotStr, partStr)
 	if err != nil {
@@ -666,6 +686,101 @@ func (c *AppClient) RunRobotPartCommand(
 	}
 }
 
+// PrintRobotPartResourceStatuses connects to a robot part and prints the state of each of its
+// resources, along with what keeps the resources that are not ready from being built.
+func (c *AppClient) PrintRobotPartResourceStatuses(
+	orgStr, locStr, robotStr, partStr string,
+	debug bool,
+	logger golog.Logger,
+) error {
+	dialCtx, fqdn, rpcOpts, err := c.prepareDial(orgStr, locStr, robotStr, partStr, debug)
+	if err != nil {
+		return err
+	}
+
+	robotClient, err := client.New(dialCtx, fqdn, logger, client.WithDialOptions(rpcOpts...))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		utils.UncheckedError(robotClient.Close(c.c.Context))
+	}()
+
+	statuses, err := robotClient.ResourceStatuses(c.c.Context)
+	if err != nil {
+		return err
+	}
+	printResourceStatuses(c.c.App.Writer, statuses)
+	return nil
+}
+
+func printResourceStatuses(w io.Writer, statuses []robot.ResourceStatus) {
+	fmt.Fprintln(w, "Resources:")
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "\tnone")
+		return
+	}
+	for _, status := range statuses {
+		line := fmt.Sprintf("\t%s: %s", status.Name, status.State)
+		if !status.LastUpdated.IsZero() {
+			line += fmt.Sprintf(" (since %s)", status.LastUpdated.Local().Format(time.UnixDate))
+		}
+		if status.Error != nil {
+			line += ": " + status.Error.Error()
+		}
+		fmt.Fprintln(w, line)
+		for _, reason := range status.BlockedBy {
+			fmt.Fprintf(w, "\t\tblocked: %s\n", reason)
+		}
+		if status.ModuleHealth != nil {
+			health := "healthy"
+			if healthy, ok := status.ModuleHealth["healthy"].(bool); ok && !healthy {
+				health = "unhealthy"
+			}
+			fmt.Fprintf(w, "\t\tmodule %v: %s\n", status.ModuleHealth["module"], health)
+		}
+	}
+}
+
+// PrintRobotPartResourceGraph connects to a robot part and prints its resources and the
+// dependencies between them, either in the Graphviz DOT language or as JSON.
+func (c *AppClient) PrintRobotPartResourceGraph(
+	orgStr, locStr, robotStr, partStr, format string,
+	debug bool,
+	logger golog.Logger,
+) error {
+	if format != "dot" && format != "json" {
+		return errors.Errorf("unknown graph format %q; must be dot or json", format)
+	}
+	dialCtx, fqdn, rpcOpts, err := c.prepareDial(orgStr, locStr, robotStr, partStr, debug)
+	if err != nil {
+		return err
+	}
+
+	robotClient, err := client.New(dialCtx, fqdn, logger, client.WithDialOptions(rpcOpts...))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		utils.UncheckedError(robotClient.Close(c.c.Context))
+	}()
+
+	snapshot, err := robotClient.ResourceGraph(c.c.Context)
+	if err != nil {
+		return err
+	}
+	if format == "dot" {
+		fmt.Fprint(c.c.App.Writer, snapshot.DOT())
+		return nil
+	}
+	md, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.c.App.Writer, "%s\n", md)
+	return nil
+}
+
 // StartRobotPartShell starts a shell on a robot part.
 func (c *AppClient) StartRobotPartShell(
 	orgStr, locStr, robotStr, partStr string,
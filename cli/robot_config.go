@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/config"
+)
+
+// PrintExpandedConfig prints the robot config at the given path with its includes and overlays
+// merged in and variables substituted. If processed is set, the config is also processed as the
+// robot would, filling in defaults and dropping anything it does not understand.
+func PrintExpandedConfig(ctx context.Context, w io.Writer, path string, processed bool, logger golog.Logger) error {
+	var out []byte
+	if processed {
+		cfg, err := config.ReadLocalConfig(ctx, path, logger)
+		if err != nil {
+			return err
+		}
+		out, err = json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+	} else {
+		expanded, err := config.ExpandFile(path)
+		if err != nil {
+			return err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, expanded, "", "  "); err != nil {
+			return err
+		}
+		out = indented.Bytes()
+	}
+	_, err := fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// ValidateConfig prints every problem found in the structure and dependencies of the robot config
+// at the given path, returning an error if any of them is more than a warning. If checkModels is
+// set, the attributes of every resource are also checked by the Validate of its registered model.
+func ValidateConfig(w io.Writer, path string, checkModels bool) error {
+	problems, err := config.ValidateFile(path, checkModels)
+	if err != nil {
+		return err
+	}
+	return config.PrintValidationProblems(w, path, problems)
+}
+
+// PrintConfigDiff prints what a robot running the against config would change if it were given
+// the config at the given path. If printDiff is set, the differences between the two configs are
+// also printed, including secrets.
+func PrintConfigDiff(
+	ctx context.Context,
+	w io.Writer,
+	path string,
+	against *config.Config,
+	printDiff bool,
+	logger golog.Logger,
+) error {
+	cfg, err := config.ReadLocalConfig(ctx, path, logger)
+	if err != nil {
+		return err
+	}
+	diff, err := config.DiffConfigs(*against, *cfg, printDiff)
+	if err != nil {
+		return err
+	}
+	if diff.ResourcesEqual && diff.NetworkEqual {
+		fmt.Fprintln(w, "no changes")
+		return nil
+	}
+	printConfigChanges(w, "added (started)", diff.Added)
+	if diff.Modified != nil {
+		printConfigChanges(w, "modified (reconfigured or restarted)", &config.Config{
+			Remotes:    diff.Modified.Remotes,
+			Components: diff.Modified.Components,
+			Processes:  diff.Modified.Processes,
+			Services:   diff.Modified.Services,
+			Packages:   diff.Modified.Packages,
+			Modules:    diff.Modified.Modules,
+		})
+	}
+	printConfigChanges(w, "removed (stopped)", diff.Removed)
+	if !diff.NetworkEqual {
+		fmt.Fprintln(w, "network settings changed (web server restarted)")
+	}
+	if printDiff {
+		fmt.Fprintf(w, "\n%s", diff.PrettyDiff)
+	}
+	return nil
+}
+
+func printConfigChanges(w io.Writer, title string, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	var changes []string
+	for _, mod := range cfg.Modules {
+		changes = append(changes, "module "+mod.Name)
+	}
+	for _, p := range cfg.Packages {
+		changes = append(changes, "package "+p.Name)
+	}
+	for _, remote := range cfg.Remotes {
+		changes = append(changes, "remote "+remote.Name)
+	}
+	for _, conf := range cfg.Components {
+		changes = append(changes, conf.ResourceName().String())
+	}
+	for _, conf := range cfg.Services {
+		changes = append(changes, conf.ResourceName().String())
+	}
+	for _, proc := range cfg.Processes {
+		changes = append(changes, "process "+proc.ID)
+	}
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, change := range changes {
+		fmt.Fprintf(w, "\t%s\n", change)
+	}
+}
+
+// PrintConfigHistory prints the configs kept in the config history in the given directory, oldest
+// first.
+func PrintConfigHistory(w io.Writer, dir string) error {
+	entries, err := config.NewHistory(dir, 0).Entries()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "no configs are kept in %s\n", dir)
+		return nil
+	}
+	for _, entry := range entries {
+		source := entry.ConfigFilePath
+		if entry.CloudID != "" {
+			source = "cloud robot part " + entry.CloudID
+		}
+		status := ""
+		if entry.Failed {
+			status = " (rolled back)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s%s\n", entry.Version, entry.Time.Format(time.RFC3339), source, status)
+	}
+	return nil
+}
+
+// RevertConfig writes the config of the given version in the config history in the given
+// directory back to the file it was read from, as it was written, which the robot then
+// reconfigures with. The file is kept with a .bak extension first. Configs read from the cloud cannot be reverted this way.
+func RevertConfig(w io.Writer, dir string, version int) error {
+	entry, err := config.NewHistory(dir, 0).Entry(version)
+	if err != nil {
+		return err
+	}
+	if entry.CloudID != "" {
+		return errors.Errorf("config version %d was read from the cloud; change the robot part's config in the app instead", version)
+	}
+	if entry.ConfigFilePath == "" {
+		return errors.Errorf("config version %d was not read from a file", version)
+	}
+	//nolint:gosec
+	current, err := os.ReadFile(entry.ConfigFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := os.WriteFile(entry.ConfigFilePath+".bak", current, 0o600); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(entry.ConfigFilePath, []byte(entry.Source), 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "reverted %s to config version %d\n", entry.ConfigFilePath, version)
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/edaniels/golog"
@@ -15,6 +16,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	rdkcli "go.viam.com/rdk/cli"
+	"go.viam.com/rdk/config"
 )
 
 const (
@@ -615,6 +617,10 @@ func main() {
 										Name:     "part",
 										Required: true,
 									},
+									&cli.BoolFlag{
+										Name:  "resources",
+										Usage: "connect to the part and print the state of each of its resources",
+									},
 								},
 								Action: func(c *cli.Context) error {
 									client, err := rdkcli.NewAppClient(c)
@@ -652,7 +658,52 @@ func main() {
 										time.Since(part.LastAccess.AsTime()),
 									)
 
-									return nil
+									if !c.Bool("resources") {
+										return nil
+									}
+									return client.PrintRobotPartResourceStatuses(
+										orgStr, locStr, robotStr, c.String("part"), c.Bool("debug"), logger)
+								},
+							},
+							{
+								Name:  "graph",
+								Usage: "print the resources of a part and the dependencies between them",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name: "organization",
+									},
+									&cli.StringFlag{
+										Name: "location",
+									},
+									&cli.StringFlag{
+										Name:     "robot",
+										Required: true,
+									},
+									&cli.StringFlag{
+										Name:     "part",
+										Required: true,
+									},
+									&cli.StringFlag{
+										Name:  "format",
+										Usage: "dot (for Graphviz) or json",
+										Value: "dot",
+									},
+								},
+								Action: func(c *cli.Context) error {
+									client, err := rdkcli.NewAppClient(c)
+									if err != nil {
+										return err
+									}
+
+									return client.PrintRobotPartResourceGraph(
+										c.String("organization"),
+										c.String("location"),
+										c.String("robot"),
+										c.String("part"),
+										c.String("format"),
+										c.Bool("debug"),
+										logger,
+									)
 								},
 							},
 							{
@@ -814,6 +865,137 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "config",
+				Usage: "work with robot configs",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "expand",
+						Usage:     "print a robot config with its includes and overlays merged in and variables substituted",
+						ArgsUsage: "<config file>",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "processed",
+								Usage: "print the config as the robot reads it, with defaults filled in",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("expected the path of a robot config")
+							}
+							return rdkcli.PrintExpandedConfig(c.Context, c.App.Writer, c.Args().First(), c.Bool("processed"), logger)
+						},
+					},
+					{
+						Name: "validate",
+						Usage: "check a robot config for errors, and optionally print what deploying it would change on a robot; " +
+							"the attributes of models are only checked if the CLI is built with the models tag",
+						ArgsUsage: "<config file>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "against",
+								Usage: "print what changes from the robot config in `FILE`",
+							},
+							&cli.StringFlag{
+								Name:  "organization",
+								Usage: "organization of the robot part to print changes from",
+							},
+							&cli.StringFlag{
+								Name:  "location",
+								Usage: "location of the robot part to print changes from",
+							},
+							&cli.StringFlag{
+								Name:  "robot",
+								Usage: "robot of the robot part to print changes from",
+							},
+							&cli.StringFlag{
+								Name:  "part",
+								Usage: "print what changes from the config this robot part is running",
+							},
+							&cli.BoolFlag{
+								Name:  "print-diff",
+								Usage: "also print the differences between the configs, including secrets",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("expected the path of a robot config")
+							}
+							path := c.Args().First()
+							if err := rdkcli.ValidateConfig(c.App.Writer, path, checkModels); err != nil {
+								return err
+							}
+
+							var against *config.Config
+							switch {
+							case c.String("against") != "" && c.String("part") != "":
+								return errors.New("can only print changes from a config file or a robot part, not both")
+							case c.String("against") != "":
+								var err error
+								against, err = config.ReadLocalConfig(c.Context, c.String("against"), logger)
+								if err != nil {
+									return err
+								}
+							case c.String("part") != "":
+								client, err := rdkcli.NewAppClient(c)
+								if err != nil {
+									return err
+								}
+								against, err = client.RobotPartConfig(
+									c.String("organization"),
+									c.String("location"),
+									c.String("robot"),
+									c.String("part"),
+									logger,
+								)
+								if err != nil {
+									return err
+								}
+							default:
+								return nil
+							}
+							fmt.Fprintln(c.App.Writer)
+							return rdkcli.PrintConfigDiff(c.Context, c.App.Writer, path, against, c.Bool("print-diff"), logger)
+						},
+					},
+					{
+						Name:  "history",
+						Usage: "list the configs a robot on this machine has kept after applying them",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "dir",
+								Usage: "config history directory of the robot",
+								Value: config.DefaultHistoryDir,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return rdkcli.PrintConfigHistory(c.App.Writer, c.String("dir"))
+						},
+					},
+					{
+						Name:      "revert",
+						Usage:     "write a kept config back to the file it was read from, reconfiguring the robot with it",
+						ArgsUsage: "<version>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "dir",
+								Usage: "config history directory of the robot",
+								Value: config.DefaultHistoryDir,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("expected the version of a kept config")
+							}
+							version, err := strconv.Atoi(c.Args().First())
+							if err != nil {
+								return errors.Wrapf(err, "invalid config version %q", c.Args().First())
+							}
+							return rdkcli.RevertConfig(c.App.Writer, c.String("dir"), version)
+						},
+					},
+				},
+			},
 		},
 	}
 
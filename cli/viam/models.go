@@ -0,0 +1,13 @@
+//go:build models
+
+package main
+
+import (
+	// register all built-in models so that their attributes are validated.
+	_ "go.viam.com/rdk/components/register"
+	_ "go.viam.com/rdk/services/register"
+)
+
+// checkModels is whether every built-in model is registered, so that validating a config also
+// checks the attributes of its resources. It is set by building with the models tag.
+const checkModels = true
@@ -0,0 +1,8 @@
+//go:build !models
+
+package main
+
+// checkModels is whether every built-in model is registered, so that validating a config also
+// checks the attributes of its resources. It is set by building with the models tag, which links
+// the cgo libraries the models depend on.
+const checkModels = false
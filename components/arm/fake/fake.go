@@ -15,8 +15,10 @@ import (
 	"go.viam.com/rdk/components/arm/xarm"
 	"go.viam.com/rdk/components/arm/yahboom"
 	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/sim"
 	"go.viam.com/rdk/spatialmath"
 )
 
@@ -30,8 +32,14 @@ var Model = resource.DefaultModelFamily.WithModel("fake")
 type Config struct {
 	ArmModel      string `json:"arm-model,omitempty"`
 	ModelFilePath string `json:"model-path,omitempty"`
+	// Simulation is the name of a shared simulated world, the arm joints then move no faster
+	// than MaxJointVelDegsPerSec.
+	Simulation            string  `json:"simulation,omitempty"`
+	MaxJointVelDegsPerSec float64 `json:"max_joint_vel_degs_per_sec,omitempty"`
 }
 
+const defaultMaxJointVelDegsPerSec = 90.
+
 func modelFromName(model, name string) (referenceframe.Model, error) {
 	switch model {
 	case xarm.ModelName6DOF, xarm.ModelName7DOF, xarm.ModelNameLite:
@@ -111,6 +119,11 @@ type Arm struct {
 	mu     sync.RWMutex
 	joints *pb.JointPositions
 	model  referenceframe.Model
+
+	world        *sim.World
+	releaseWorld func()
+	body         *sim.Joints
+	opMgr        operation.SingleOperationManager
 }
 
 // Reconfigure atomically reconfigures this arm in place based on the new config.
@@ -130,9 +143,43 @@ func (a *Arm) Reconfigure(ctx context.Context, deps resource.Dependencies, conf
 	a.joints = &pb.JointPositions{Values: make([]float64, len(model.DoF()))}
 	a.model = model
 
+	if newConf.Simulation == "" {
+		a.closeSimulation()
+		return nil
+	}
+	maxVel := newConf.MaxJointVelDegsPerSec
+	if maxVel <= 0 {
+		maxVel = defaultMaxJointVelDegsPerSec
+	}
+	maxVels := make([]float64, len(model.DoF()))
+	for i := range maxVels {
+		maxVels[i] = maxVel
+	}
+	// the world is acquired before the current one is released so that it keeps its state when the
+	// arm stays in it
+	world, releaseWorld := sim.Acquire(newConf.Simulation)
+	a.closeSimulation()
+	a.world, a.releaseWorld = world, releaseWorld
+	a.body = sim.NewJoints(maxVels)
+	if err := a.world.Add(a.Name().ShortName(), a.body); err != nil {
+		a.closeSimulation()
+		return err
+	}
 	return nil
 }
 
+// closeSimulation removes the arm from its simulated world, assumes the lock is held.
+func (a *Arm) closeSimulation() {
+	if a.world == nil {
+		return
+	}
+	if b, ok := a.world.Body(a.Name().ShortName()); ok && b == a.body {
+		a.world.Remove(a.Name().ShortName())
+	}
+	a.releaseWorld()
+	a.world, a.releaseWorld, a.body = nil, nil, nil
+}
+
 // ModelFrame returns the dynamic frame of the model.
 func (a *Arm) ModelFrame() referenceframe.Model {
 	a.mu.RLock()
@@ -162,30 +209,67 @@ func (a *Arm) MoveToJointPositions(ctx context.Context, joints *pb.JointPosition
 		return err
 	}
 	a.mu.RLock()
-	defer a.mu.RUnlock()
 	inputs := a.model.InputFromProtobuf(joints)
 	pos, err := a.model.Transform(inputs)
 	if err != nil {
+		a.mu.RUnlock()
 		return err
 	}
 	_ = pos
-	copy(a.joints.Values, joints.Values)
+	world, body := a.world, a.body
+	if body == nil {
+		copy(a.joints.Values, joints.Values)
+	}
+	a.mu.RUnlock()
+	if body != nil {
+		return a.moveSimulated(ctx, world, body, joints.Values)
+	}
+	return nil
+}
+
+// moveSimulated waits for the simulated joints to reach values.
+func (a *Arm) moveSimulated(ctx context.Context, world *sim.World, body *sim.Joints, values []float64) error {
+	ctx, done := a.opMgr.New(ctx)
+	defer done()
+	if err := body.SetTargets(values); err != nil {
+		return err
+	}
+	if err := world.WaitUntil(ctx, func() bool { return !body.IsMoving() }); err != nil {
+		body.Stop()
+		return err
+	}
 	return nil
 }
 
 // JointPositions returns joints.
 func (a *Arm) JointPositions(ctx context.Context, extra map[string]interface{}) (*pb.JointPositions, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.body != nil {
+		return &pb.JointPositions{Values: a.body.Positions()}, nil
+	}
 	retJoint := &pb.JointPositions{Values: a.joints.Values}
 	return retJoint, nil
 }
 
-// Stop doesn't do anything for a fake arm.
+// Stop doesn't do anything for a fake arm unless simulated.
 func (a *Arm) Stop(ctx context.Context, extra map[string]interface{}) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.body != nil {
+		a.opMgr.CancelRunning(ctx)
+		a.body.Stop()
+	}
 	return nil
 }
 
-// IsMoving is always false for a fake arm.
+// IsMoving is always false for a fake arm unless simulated.
 func (a *Arm) IsMoving(ctx context.Context) (bool, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.body != nil {
+		return a.body.IsMoving(), nil
+	}
 	return false, nil
 }
 
@@ -214,6 +298,7 @@ func (a *Arm) Close(ctx context.Context) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.CloseCount++
+	a.closeSimulation()
 	return nil
 }
 
@@ -4,6 +4,7 @@ package fake
 import (
 	"bytes"
 	"context"
+	"math"
 
 	"github.com/edaniels/golog"
 	"github.com/golang/geo/r3"
@@ -11,37 +12,72 @@ import (
 
 	"go.viam.com/rdk/components/base"
 	"go.viam.com/rdk/components/base/wheeled"
+	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/services/slam"
+	"go.viam.com/rdk/sim"
 )
 
 func init() {
 	resource.RegisterComponent(
 		base.API,
 		resource.DefaultModelFamily.WithModel("fake"),
-		resource.Registration[base.Base, resource.NoNativeConfig]{
+		resource.Registration[base.Base, *Config]{
 			Constructor: func(
 				ctx context.Context,
 				deps resource.Dependencies,
 				conf resource.Config,
 				logger golog.Logger,
 			) (base.Base, error) {
-				return &Base{Named: conf.ResourceName().AsNamed()}, nil
+				newConf, err := resource.NativeConfig[*Config](conf)
+				if err != nil {
+					return nil, err
+				}
+				b := &Base{Named: conf.ResourceName().AsNamed(), conf: *newConf}
+				if newConf.Simulation != "" {
+					b.world, b.releaseWorld = sim.Acquire(newConf.Simulation)
+					b.body = sim.NewBase(newConf.MaxLinearAccelMmPerSec2, newConf.MaxAngularAccelDegsPerSec2)
+					if err := b.world.Add(b.Name().ShortName(), b.body); err != nil {
+						b.releaseWorld()
+						return nil, err
+					}
+				}
+				return b, nil
 			},
 		},
 	)
 }
 
-const defaultWidth = 600
+const (
+	defaultWidth = 600
+	// speeds reached at full power by a simulated base.
+	simMaxLinearMmPerSec   = 300.
+	simMaxAngularDegPerSec = 90.
+)
+
+// Config is used for converting fake base attributes.
+type Config struct {
+	resource.TriviallyValidateConfig
+	// Simulation is the name of a shared simulated world the base moves in.
+	Simulation                 string  `json:"simulation,omitempty"`
+	MaxLinearAccelMmPerSec2    float64 `json:"max_linear_accel_mm_per_sec2,omitempty"`
+	MaxAngularAccelDegsPerSec2 float64 `json:"max_angular_accel_degs_per_sec2,omitempty"`
+}
 
 // Base is a fake base that returns what it was provided in each method.
+// When it is part of a simulated world it moves within the world instead.
 type Base struct {
 	resource.Named
-	resource.TriviallyReconfigurable
 	CloseCount int
 	geometry   *referenceframe.LinkConfig
+	conf       Config
+
+	world        *sim.World
+	releaseWorld func()
+	body         *sim.Base
+	opMgr        operation.SingleOperationManager
 }
 
 // NewBase instantiates a new base of the fake model type.
@@ -52,23 +88,83 @@ func NewBase(ctx context.Context, conf resource.Config) (base.LocalBase, error)
 	}, nil
 }
 
-// MoveStraight does nothing.
-func (b *Base) MoveStraight(ctx context.Context, distanceMm int, mmPerSec float64, extra map[string]interface{}) error {
+// Reconfigure leaves the base as it is, unless its simulation attributes changed, in which case the
+// base must be rebuilt.
+func (b *Base) Reconfigure(ctx context.Context, deps resource.Dependencies, conf resource.Config) error {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return err
+	}
+	if *newConf != b.conf {
+		return resource.NewMustRebuildError(conf.ResourceName())
+	}
 	return nil
 }
 
-// Spin does nothing.
+// MoveStraight does nothing unless simulated.
+func (b *Base) MoveStraight(ctx context.Context, distanceMm int, mmPerSec float64, extra map[string]interface{}) error {
+	if b.body == nil {
+		return nil
+	}
+	if mmPerSec == 0 && distanceMm != 0 {
+		return errors.New("cannot move a simulated base at 0 mm per sec")
+	}
+	speed := math.Abs(mmPerSec)
+	if distanceMm < 0 {
+		speed = -speed
+	}
+	return b.moveFor(ctx, math.Abs(float64(distanceMm)), speed, 0, func() float64 {
+		dist, _ := b.body.Odometry()
+		return dist
+	})
+}
+
+// Spin does nothing unless simulated.
 func (b *Base) Spin(ctx context.Context, angleDeg, degsPerSec float64, extra map[string]interface{}) error {
-	return nil
+	if b.body == nil {
+		return nil
+	}
+	if degsPerSec == 0 && angleDeg != 0 {
+		return errors.New("cannot spin a simulated base at 0 degs per sec")
+	}
+	speed := math.Abs(degsPerSec)
+	if angleDeg < 0 {
+		speed = -speed
+	}
+	return b.moveFor(ctx, math.Abs(angleDeg), 0, speed, func() float64 {
+		_, turned := b.body.Odometry()
+		return turned
+	})
+}
+
+// moveFor drives the simulated base at the given velocities until odometer has increased by amount.
+func (b *Base) moveFor(ctx context.Context, amount, linear, angular float64, odometer func() float64) error {
+	ctx, done := b.opMgr.New(ctx)
+	defer done()
+	start := odometer()
+	b.body.SetVelocity(linear, angular)
+	err := b.world.WaitUntil(ctx, func() bool {
+		return odometer()-start >= amount
+	})
+	b.body.SetVelocity(0, 0)
+	return err
 }
 
-// SetPower does nothing.
+// SetPower does nothing unless simulated.
 func (b *Base) SetPower(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error {
+	if b.body != nil {
+		b.opMgr.CancelRunning(ctx)
+		b.body.SetVelocity(linear.Y*simMaxLinearMmPerSec, angular.Z*simMaxAngularDegPerSec)
+	}
 	return nil
 }
 
-// SetVelocity does nothing.
+// SetVelocity does nothing unless simulated.
 func (b *Base) SetVelocity(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error {
+	if b.body != nil {
+		b.opMgr.CancelRunning(ctx)
+		b.body.SetVelocity(linear.Y, angular.Z)
+	}
 	return nil
 }
 
@@ -77,19 +173,31 @@ func (b *Base) Width(ctx context.Context) (int, error) {
 	return defaultWidth, nil
 }
 
-// Stop does nothing.
+// Stop does nothing unless simulated.
 func (b *Base) Stop(ctx context.Context, extra map[string]interface{}) error {
+	if b.body != nil {
+		b.opMgr.CancelRunning(ctx)
+		b.body.SetVelocity(0, 0)
+	}
 	return nil
 }
 
-// IsMoving always returns false.
+// IsMoving returns false unless simulated.
 func (b *Base) IsMoving(ctx context.Context) (bool, error) {
+	if b.body != nil {
+		return b.body.IsMoving(), nil
+	}
 	return false, nil
 }
 
-// Close does nothing.
+// Close removes the base from its simulated world if it is in one.
 func (b *Base) Close(ctx context.Context) error {
 	b.CloseCount++
+	if b.world != nil {
+		b.world.Remove(b.Name().ShortName())
+		b.releaseWorld()
+		b.world, b.body = nil, nil
+	}
 	return nil
 }
 
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
 	"go.viam.com/test"
 
+	"go.viam.com/rdk/components/base"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/services/slam"
@@ -37,3 +39,30 @@ func TestFakeBase(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, inputs, test.ShouldResemble, expected)
 }
+
+func TestFakeBaseSimulation(t *testing.T) {
+	ctx := context.Background()
+	conf := resource.Config{
+		Name:                "test",
+		ConvertedAttributes: &Config{Simulation: "fake_base_test"},
+	}
+	reg, ok := resource.LookupRegistration(base.API, resource.DefaultModelFamily.WithModel("fake"))
+	test.That(t, ok, test.ShouldBeTrue)
+	res, err := reg.Constructor(ctx, nil, conf, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	b := res.(*Base)
+	defer func() {
+		test.That(t, b.Close(ctx), test.ShouldBeNil)
+	}()
+
+	err = b.MoveStraight(ctx, 100, 0, nil)
+	test.That(t, err, test.ShouldBeError, errors.New("cannot move a simulated base at 0 mm per sec"))
+	err = b.Spin(ctx, 90, 0, nil)
+	test.That(t, err, test.ShouldBeError, errors.New("cannot spin a simulated base at 0 degs per sec"))
+	test.That(t, b.MoveStraight(ctx, 0, 0, nil), test.ShouldBeNil)
+
+	test.That(t, b.Reconfigure(ctx, nil, conf), test.ShouldBeNil)
+	conf.ConvertedAttributes = &Config{Simulation: "fake_base_test", MaxLinearAccelMmPerSec2: 100}
+	err = b.Reconfigure(ctx, nil, conf)
+	test.That(t, resource.IsMustRebuildError(err), test.ShouldBeTrue)
+}
@@ -3,7 +3,6 @@ package camera
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"image"
 	"sync"
 
@@ -145,15 +144,11 @@ func (c *client) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, err
 		return nil, err
 	}
 
-	if resp.MimeType != utils.MimeTypePCD {
-		return nil, fmt.Errorf("unknown pc mime type %s", resp.MimeType)
-	}
-
 	return func() (pointcloud.PointCloud, error) {
-		_, span := trace.StartSpan(ctx, "camera::client::NextPointCloud::ReadPCD")
+		_, span := trace.StartSpan(ctx, "camera::client::NextPointCloud::ReadPointCloud")
 		defer span.End()
 
-		return pointcloud.ReadPCD(bytes.NewReader(resp.PointCloud))
+		return pointcloud.ReadWithMIMEType(bytes.NewReader(resp.PointCloud), resp.MimeType)
 	}()
 }
 
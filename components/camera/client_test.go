@@ -45,7 +45,7 @@ func TestClient(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 
 	pcA := pointcloud.New()
-	err = pcA.Set(pointcloud.NewVector(5, 5, 5), nil)
+	err = pcA.Set(pointcloud.NewVector(5, 5, 5), pointcloud.NewBasicData().SetIntensity(7).SetLabel(2).SetTimestamp(1.5))
 	test.That(t, err, test.ShouldBeNil)
 
 	var projA transform.Projector
@@ -167,8 +167,11 @@ func TestClient(t *testing.T) {
 
 		pcB, err := camera1Client.NextPointCloud(context.Background())
 		test.That(t, err, test.ShouldBeNil)
-		_, got := pcB.At(5, 5, 5)
+		d, got := pcB.At(5, 5, 5)
 		test.That(t, got, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 7)
+		test.That(t, d.Label(), test.ShouldEqual, 2)
+		test.That(t, d.Timestamp(), test.ShouldEqual, 1.5)
 
 		projB, err := camera1Client.Projector(context.Background())
 		test.That(t, err, test.ShouldBeNil)
@@ -406,7 +409,7 @@ func TestClientWithInterceptor(t *testing.T) {
 	injectCamera := &inject.Camera{}
 
 	pcA := pointcloud.New()
-	err = pcA.Set(pointcloud.NewVector(5, 5, 5), nil)
+	err = pcA.Set(pointcloud.NewVector(5, 5, 5), pointcloud.NewBasicData().SetIntensity(7).SetLabel(2).SetTimestamp(1.5))
 	test.That(t, err, test.ShouldBeNil)
 
 	k, v := "hello", "world"
@@ -8,6 +8,7 @@ import (
 	_ "go.viam.com/rdk/components/camera/ffmpeg"
 	_ "go.viam.com/rdk/components/camera/replaypcd"
 	_ "go.viam.com/rdk/components/camera/rtsp"
+	_ "go.viam.com/rdk/components/camera/simulated"
 	_ "go.viam.com/rdk/components/camera/transformpipeline"
 	_ "go.viam.com/rdk/components/camera/velodyne"
 	_ "go.viam.com/rdk/components/camera/videosource"
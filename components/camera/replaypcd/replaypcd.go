@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"io"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,6 +25,7 @@ import (
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/utils"
 	"go.viam.com/rdk/utils/contextutils"
 )
 
@@ -351,7 +354,7 @@ func (replay *pcdCamera) Reconfigure(ctx context.Context, deps resource.Dependen
 	replay.filter = &datapb.Filter{
 		ComponentName: replayCamConfig.Source,
 		RobotId:       replayCamConfig.RobotID,
-		MimeType:      []string{"pointcloud/pcd"},
+		MimeType:      pointCloudMIMETypes,
 		Interval:      &datapb.CaptureInterval{},
 	}
 	replay.lastData = ""
@@ -410,21 +413,50 @@ func decodeResponseData(respData []*datapb.BinaryData, logger golog.Logger) (poi
 		return nil, errors.New("no response data; this should never happen")
 	}
 
-	r, err := gzip.NewReader(bytes.NewBuffer(respData[0].GetBinary()))
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if err = r.Close(); err != nil {
-			logger.Warnw("Failed to close gzip reader", "warn", err)
+	// Captured point clouds are gzipped while uploaded files may not be
+	var in io.Reader = bytes.NewReader(respData[0].GetBinary())
+	if data := respData[0].GetBinary(); len(data) > 1 && data[0] == 0x1f && data[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
 		}
-	}()
 
-	pc, err := pointcloud.ReadPCD(r)
+		defer func() {
+			if err = r.Close(); err != nil {
+				logger.Warnw("Failed to close gzip reader", "warn", err)
+			}
+		}()
+		in = r
+	}
+
+	pc, err := pointcloud.ReadWithMIMEType(in, mimeTypeOf(respData[0].GetMetadata()))
 	if err != nil {
 		return nil, err
 	}
 
 	return pc, nil
 }
+
+// pointCloudMIMETypes are the MIME types of the data replayed by the camera.
+var pointCloudMIMETypes = []string{utils.MimeTypePCD, utils.MimeTypePLY, utils.MimeTypeXYZ, utils.MimeTypeE57}
+
+// mimeTypeOf returns the MIME type of point cloud data from its capture metadata or, failing that, from
+// its file extension. Data with neither is assumed to be a PCD.
+func mimeTypeOf(metadata *datapb.BinaryMetadata) string {
+	mimeType := metadata.GetCaptureMetadata().GetMimeType()
+	for _, known := range pointCloudMIMETypes {
+		if mimeType == known {
+			return mimeType
+		}
+	}
+	switch strings.ToLower(strings.TrimSuffix(metadata.GetFileExt(), ".gz")) {
+	case ".ply":
+		return utils.MimeTypePLY
+	case ".xyz", ".txt", ".csv":
+		return utils.MimeTypeXYZ
+	case ".e57":
+		return utils.MimeTypeE57
+	default:
+		return utils.MimeTypePCD
+	}
+}
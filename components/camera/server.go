@@ -130,17 +130,24 @@ func (s *serviceServer) GetPointCloud(
 		return nil, err
 	}
 
+	// Point clouds are sent as PCDs unless another supported format is requested
+	mimeType := utils.MimeTypePCD
+	switch req.MimeType {
+	case utils.MimeTypePLY, utils.MimeTypeXYZ, utils.MimeTypeE57:
+		mimeType = req.MimeType
+	}
+
 	var buf bytes.Buffer
 	buf.Grow(200 + (pc.Size() * 4 * 4)) // 4 numbers per point, each 4 bytes
-	_, pcdSpan := trace.StartSpan(ctx, "camera::server::NextPointCloud::ToPCD")
-	err = pointcloud.ToPCD(pc, &buf, pointcloud.PCDBinary)
+	_, pcdSpan := trace.StartSpan(ctx, "camera::server::NextPointCloud::WritePointCloud")
+	err = pointcloud.WriteWithMIMEType(pc, &buf, mimeType)
 	pcdSpan.End()
 	if err != nil {
 		return nil, err
 	}
 
 	return &pb.GetPointCloudResponse{
-		MimeType:   utils.MimeTypePCD,
+		MimeType:   mimeType,
 		PointCloud: buf.Bytes(),
 	}, nil
 }
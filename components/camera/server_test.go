@@ -352,10 +352,22 @@ func TestServer(t *testing.T) {
 		injectCamera.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
 			return pcA, nil
 		}
-		_, err = cameraServer.GetPointCloud(context.Background(), &pb.GetPointCloudRequest{
+		resp, err := cameraServer.GetPointCloud(context.Background(), &pb.GetPointCloudRequest{
 			Name: testCameraName,
 		})
 		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp.MimeType, test.ShouldEqual, utils.MimeTypePCD)
+
+		// other point cloud formats are sent when requested
+		resp, err = cameraServer.GetPointCloud(context.Background(), &pb.GetPointCloudRequest{
+			Name:     testCameraName,
+			MimeType: utils.MimeTypePLY,
+		})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp.MimeType, test.ShouldEqual, utils.MimeTypePLY)
+		pcB, err := pointcloud.ReadPLY(bytes.NewReader(resp.PointCloud))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pcB.Size(), test.ShouldEqual, 1)
 
 		_, err = cameraServer.GetPointCloud(context.Background(), &pb.GetPointCloudRequest{
 			Name: failCameraName,
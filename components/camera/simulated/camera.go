@@ -0,0 +1,252 @@
+// Package simulated implements a depth camera which renders the geometries of the frame system and
+// of a set of obstacles from its pose in the frame system, so that vision and motion planning can be
+// tested against a known ground truth.
+package simulated
+
+import (
+	"context"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var model = resource.DefaultModelFamily.WithModel("simulated")
+
+const defaultMaxDepthMm = 10000.
+
+func init() {
+	resource.RegisterComponent(
+		camera.API,
+		model,
+		resource.Registration[camera.Camera, *Config]{
+			Constructor: func(
+				ctx context.Context,
+				deps resource.Dependencies,
+				conf resource.Config,
+				logger golog.Logger,
+			) (camera.Camera, error) {
+				return newCamera(ctx, deps, conf, logger)
+			},
+		})
+}
+
+// Config is the attribute struct for a simulated camera.
+type Config struct {
+	CameraParameters *transform.PinholeCameraIntrinsics `json:"intrinsic_parameters"`
+	// Frame is the frame the camera renders from, it defaults to the frame of the camera itself.
+	// The camera looks along +Z of that frame with +X to the right of the image and +Y down.
+	Frame string `json:"frame,omitempty"`
+	// Obstacles are geometries in the world frame rendered along with those of the frame system.
+	Obstacles []*spatialmath.GeometryConfig `json:"obstacles,omitempty"`
+	// IgnoreFrames are frames whose geometries are not rendered, the geometries of Frame never are.
+	IgnoreFrames []string `json:"ignore_frames,omitempty"`
+	// MaxDepthMm is the range of the camera, anything further away has no depth.
+	MaxDepthMm float64 `json:"max_depth_mm,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.CameraParameters == nil {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "intrinsic_parameters")
+	}
+	if err := cfg.CameraParameters.CheckValid(); err != nil {
+		return nil, utils.NewConfigValidationError(path, err)
+	}
+	if cfg.MaxDepthMm < 0 || cfg.MaxDepthMm > math.MaxUint16 {
+		return nil, utils.NewConfigValidationError(path, errors.Errorf("max_depth_mm must be between 0 and %d", math.MaxUint16))
+	}
+	for _, o := range cfg.Obstacles {
+		if _, err := o.ParseConfig(); err != nil {
+			return nil, utils.NewConfigValidationError(path, err)
+		}
+	}
+	return []string{framesystem.InternalServiceName.String()}, nil
+}
+
+// simulatedCamera renders depth images and point clouds by casting a ray through every pixel.
+type simulatedCamera struct {
+	intrinsics   *transform.PinholeCameraIntrinsics
+	frame        string
+	obstacles    *referenceframe.WorldState
+	ignoreFrames map[string]bool
+	maxDepth     float64
+	fsService    framesystem.Service
+	logger       golog.Logger
+}
+
+func newCamera(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger golog.Logger,
+) (camera.Camera, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	fsService, err := framesystem.FromDependencies(deps)
+	if err != nil {
+		return nil, err
+	}
+	geoms := make([]spatialmath.Geometry, 0, len(newConf.Obstacles))
+	for _, o := range newConf.Obstacles {
+		g, err := o.ParseConfig()
+		if err != nil {
+			return nil, err
+		}
+		geoms = append(geoms, g)
+	}
+	obstacles, err := referenceframe.NewWorldState(
+		[]*referenceframe.GeometriesInFrame{referenceframe.NewGeometriesInFrame(referenceframe.World, geoms)}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cam := &simulatedCamera{
+		intrinsics:   newConf.CameraParameters,
+		frame:        newConf.Frame,
+		obstacles:    obstacles,
+		ignoreFrames: map[string]bool{},
+		maxDepth:     newConf.MaxDepthMm,
+		fsService:    fsService,
+		logger:       logger,
+	}
+	if cam.frame == "" {
+		cam.frame = conf.Name
+	}
+	if cam.maxDepth == 0 {
+		cam.maxDepth = defaultMaxDepthMm
+	}
+	cam.ignoreFrames[cam.frame] = true
+	for _, f := range newConf.IgnoreFrames {
+		cam.ignoreFrames[f] = true
+	}
+
+	src, err := camera.NewVideoSourceFromReader(
+		ctx, cam, &transform.PinholeCameraModel{PinholeCameraIntrinsics: cam.intrinsics}, camera.DepthStream)
+	if err != nil {
+		return nil, err
+	}
+	return camera.FromVideoSource(conf.ResourceName(), src), nil
+}
+
+// scene returns the pose of the camera and every geometry it can see, all in the world frame.
+func (c *simulatedCamera) scene(ctx context.Context) (spatialmath.Pose, []spatialmath.Geometry, error) {
+	fs, err := c.fsService.FrameSystem(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs, _, err := c.fsService.CurrentInputs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tf, err := fs.Transform(inputs, referenceframe.NewPoseInFrame(c.frame, spatialmath.NewZeroPose()), referenceframe.World)
+	if err != nil {
+		return nil, nil, err
+	}
+	pose := tf.(*referenceframe.PoseInFrame).Pose()
+
+	fsGeoms, err := referenceframe.FrameSystemGeometries(fs, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var geoms []spatialmath.Geometry
+	for name, gif := range fsGeoms {
+		// the geometry of a part is attached to the origin frame of the part
+		if c.ignoreFrames[strings.TrimSuffix(name, "_origin")] {
+			continue
+		}
+		geoms = append(geoms, gif.Geometries()...)
+	}
+	obstacles, err := c.obstacles.ObstaclesInWorldFrame(fs, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	geoms = append(geoms, obstacles.Geometries()...)
+	return pose, geoms, nil
+}
+
+// render casts a ray through every pixel of the camera at pose and returns the depth of the closest geometry
+// it hits along with the points hit, in the frame of the camera.
+func render(
+	intrinsics *transform.PinholeCameraIntrinsics,
+	pose spatialmath.Pose,
+	geoms []spatialmath.Geometry,
+	maxDepth float64,
+) (*rimage.DepthMap, pointcloud.PointCloud, error) {
+	origin := pose.Point()
+	rot := spatialmath.NewPoseFromOrientation(pose.Orientation())
+	axisX := spatialmath.Compose(rot, spatialmath.NewPoseFromPoint(r3.Vector{X: 1})).Point()
+	axisY := spatialmath.Compose(rot, spatialmath.NewPoseFromPoint(r3.Vector{Y: 1})).Point()
+	axisZ := spatialmath.Compose(rot, spatialmath.NewPoseFromPoint(r3.Vector{Z: 1})).Point()
+
+	dm := rimage.NewEmptyDepthMap(intrinsics.Width, intrinsics.Height)
+	pc := pointcloud.New()
+	for y := 0; y < intrinsics.Height; y++ {
+		for x := 0; x < intrinsics.Width; x++ {
+			// direction of the ray in the camera frame, scaled to a depth of 1
+			dx, dy, _ := intrinsics.PixelToPoint(float64(x), float64(y), 1)
+			dir := axisX.Mul(dx).Add(axisY.Mul(dy)).Add(axisZ)
+			dist, hit := math.Inf(1), false
+			for _, g := range geoms {
+				if d, ok := spatialmath.RayIntersection(g, origin, dir); ok && d < dist {
+					dist, hit = d, true
+				}
+			}
+			if !hit {
+				continue
+			}
+			depth := dist / dir.Norm()
+			if depth > maxDepth {
+				continue
+			}
+			dm.Set(x, y, rimage.Depth(math.Round(depth)))
+			if err := pc.Set(r3.Vector{X: dx * depth, Y: dy * depth, Z: depth}, nil); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return dm, pc, nil
+}
+
+// Read renders a depth image.
+func (c *simulatedCamera) Read(ctx context.Context) (image.Image, func(), error) {
+	pose, geoms, err := c.scene(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	dm, _, err := render(c.intrinsics, pose, geoms, c.maxDepth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dm, func() {}, nil
+}
+
+// NextPointCloud renders the points seen by the camera, in the frame of the camera.
+func (c *simulatedCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	pose, geoms, err := c.scene(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, pc, err := render(c.intrinsics, pose, geoms, c.maxDepth)
+	return pc, err
+}
+
+// Close does nothing.
+func (c *simulatedCamera) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,115 @@
+package simulated
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var testIntrinsics = &transform.PinholeCameraIntrinsics{
+	Width:  64,
+	Height: 48,
+	Fx:     50,
+	Fy:     50,
+	Ppx:    32,
+	Ppy:    24,
+}
+
+func TestRender(t *testing.T) {
+	box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{Z: 1050}), r3.Vector{X: 4000, Y: 4000, Z: 100}, "wall")
+	test.That(t, err, test.ShouldBeNil)
+	sphere, err := spatialmath.NewSphere(spatialmath.NewPoseFromPoint(r3.Vector{Z: 500}), 100, "ball")
+	test.That(t, err, test.ShouldBeNil)
+
+	dm, pc, err := render(testIntrinsics, spatialmath.NewZeroPose(), []spatialmath.Geometry{box, sphere}, defaultMaxDepthMm)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, testIntrinsics.Width*testIntrinsics.Height)
+	// the ball is in front of the wall at the center of the image
+	test.That(t, dm.GetDepth(32, 24), test.ShouldEqual, rimage.Depth(400))
+	test.That(t, dm.GetDepth(0, 0), test.ShouldEqual, rimage.Depth(1000))
+	_, ok := pc.At(0, 0, 400)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	// the camera looks along its +Z so turning it away from the scene sees nothing
+	away := spatialmath.NewPoseFromOrientation(&spatialmath.OrientationVectorDegrees{OZ: -1})
+	dm, pc, err = render(testIntrinsics, away, []spatialmath.Geometry{box, sphere}, defaultMaxDepthMm)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldEqual, 0)
+	test.That(t, dm.GetDepth(32, 24), test.ShouldEqual, rimage.Depth(0))
+
+	// the wall is out of range
+	dm, _, err = render(testIntrinsics, spatialmath.NewZeroPose(), []spatialmath.Geometry{box}, 500)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dm.GetDepth(32, 24), test.ShouldEqual, rimage.Depth(0))
+}
+
+func TestSimulatedCamera(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+
+	boxGeom, err := spatialmath.NewBox(spatialmath.NewZeroPose(), r3.Vector{X: 100, Y: 100, Z: 100}, "")
+	test.That(t, err, test.ShouldBeNil)
+	camGeom, err := spatialmath.NewSphere(spatialmath.NewZeroPose(), 10, "")
+	test.That(t, err, test.ShouldBeNil)
+	parts := []*referenceframe.FrameSystemPart{
+		{
+			FrameConfig: referenceframe.NewLinkInFrame(referenceframe.World, spatialmath.NewZeroPose(), "cam", camGeom),
+		},
+		{
+			FrameConfig: referenceframe.NewLinkInFrame(
+				referenceframe.World, spatialmath.NewPoseFromPoint(r3.Vector{Z: 1000}), "box", boxGeom),
+		},
+	}
+	fsSvc, err := framesystem.New(ctx, resource.Dependencies{}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	err = fsSvc.Reconfigure(ctx, resource.Dependencies{}, resource.Config{ConvertedAttributes: &framesystem.Config{Parts: parts}})
+	test.That(t, err, test.ShouldBeNil)
+	deps := resource.Dependencies{framesystem.InternalServiceName: fsSvc}
+
+	conf := &Config{
+		CameraParameters: testIntrinsics,
+		Obstacles: []*spatialmath.GeometryConfig{
+			{Type: spatialmath.SphereType, R: 50, TranslationOffset: r3.Vector{X: 500, Z: 1000}},
+		},
+	}
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	_, err = (&Config{}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cam, err := newCamera(ctx, deps, resource.Config{Name: "cam", ConvertedAttributes: conf}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer cam.Close(ctx)
+
+	img, release, err := camera.ReadImage(ctx, cam)
+	test.That(t, err, test.ShouldBeNil)
+	defer release()
+	dm, ok := img.(*rimage.DepthMap)
+	test.That(t, ok, test.ShouldBeTrue)
+	// the geometry of the camera itself does not hide the box
+	test.That(t, dm.GetDepth(32, 24), test.ShouldEqual, rimage.Depth(950))
+	// the obstacle is to the right of the image, its front is at a depth of 950 on the axis of the camera
+	test.That(t, dm.GetDepth(32+25, 24), test.ShouldEqual, rimage.Depth(955))
+	test.That(t, dm.GetDepth(0, 0), test.ShouldEqual, rimage.Depth(0))
+
+	pc, err := cam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldBeGreaterThan, 0)
+	_, ok = pc.At(0, 0, 950)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	props, err := cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.IntrinsicParams, test.ShouldResemble, testIntrinsics)
+}
@@ -15,6 +15,7 @@ import (
 	"go.uber.org/multierr"
 
 	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/rimage"
 	"go.viam.com/rdk/rimage/transform"
@@ -96,23 +97,30 @@ func newTransformPipeline(
 	// loop through the pipeline and create the image flow
 	pipeline := make([]gostream.VideoSource, 0, len(cfg.Pipeline))
 	lastSource := source
+	// pointClouds is the last stage filtering point clouds, if any, whose point clouds are those of the
+	// pipeline rather than projections of its images
+	var pointClouds camera.PointCloudSource
 	for _, tr := range cfg.Pipeline {
 		src, newStreamType, err := buildTransform(ctx, r, lastSource, streamType, tr)
 		if err != nil {
 			return nil, err
 		}
+		if transformType(tr.Type) == transformTypeCloudFilter {
+			if pcSrc, ok := src.(camera.PointCloudSource); ok {
+				pointClouds = pcSrc
+			}
+		}
 		pipeline = append(pipeline, src)
 		lastSource = src
 		streamType = newStreamType
 	}
 	lastSourceStream := gostream.NewEmbeddedVideoStream(lastSource)
 	cameraModel := camera.NewPinholeModelWithBrownConradyDistortion(cfg.CameraParameters, cfg.DistortionParameters)
-	return camera.NewVideoSourceFromReader(
-		ctx,
-		transformPipeline{pipeline, lastSourceStream, cfg.CameraParameters},
-		&cameraModel,
-		streamType,
-	)
+	tp := transformPipeline{pipeline, lastSourceStream, cfg.CameraParameters}
+	if pointClouds != nil {
+		return camera.NewVideoSourceFromReader(ctx, pointCloudTransformPipeline{tp, pointClouds}, &cameraModel, streamType)
+	}
+	return camera.NewVideoSourceFromReader(ctx, tp, &cameraModel, streamType)
 }
 
 type transformPipeline struct {
@@ -141,3 +149,16 @@ func (tp transformPipeline) Close(ctx context.Context) error {
 	}
 	return multierr.Combine(tp.stream.Close(ctx), errs)
 }
+
+// pointCloudTransformPipeline is a transformPipeline with a stage filtering point clouds, which are
+// returned instead of point clouds projected from the images of the pipeline.
+type pointCloudTransformPipeline struct {
+	transformPipeline
+	pointClouds camera.PointCloudSource
+}
+
+func (tp pointCloudTransformPipeline) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::NextPointCloud")
+	defer span.End()
+	return tp.pointClouds.NextPointCloud(ctx)
+}
@@ -0,0 +1,173 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+
+	"github.com/edaniels/gostream"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// pointCloudFilterConfig holds the filters to apply to point clouds, all of them optional. They are
+// applied in the order of the fields.
+type pointCloudFilterConfig struct {
+	CropBox             *cropBoxConfig              `json:"crop_box,omitempty"`
+	CropGeometry        *spatialmath.GeometryConfig `json:"crop_geometry,omitempty"`
+	StatisticalOutliers *statisticalOutliersConfig  `json:"statistical_outliers,omitempty"`
+	RadiusOutliers      *radiusOutliersConfig       `json:"radius_outliers,omitempty"`
+	VoxelSizeMM         float64                     `json:"voxel_size_mm,omitempty"`
+	// NormalsK is the number of neighbors the normal of each point is estimated from, no normals are
+	// estimated when it is 0.
+	NormalsK int `json:"normals_k,omitempty"`
+}
+
+type cropBoxConfig struct {
+	Min r3.Vector `json:"min"`
+	Max r3.Vector `json:"max"`
+}
+
+type statisticalOutliersConfig struct {
+	MeanK           int     `json:"mean_k"`
+	StdDevThreshold float64 `json:"std_dev_threshold"`
+}
+
+type radiusOutliersConfig struct {
+	RadiusMM     float64 `json:"radius_mm"`
+	MinNeighbors int     `json:"min_neighbors"`
+}
+
+// filters returns the functions applying each of the configured filters in order.
+func (conf *pointCloudFilterConfig) filters() ([]func(pointcloud.PointCloud) (pointcloud.PointCloud, error), error) {
+	var filters []func(pointcloud.PointCloud) (pointcloud.PointCloud, error)
+	if conf.CropBox != nil {
+		box := *conf.CropBox
+		filters = append(filters, func(pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+			return pointcloud.CropBox(pc, box.Min, box.Max)
+		})
+	}
+	if conf.CropGeometry != nil {
+		geometry, err := conf.CropGeometry.ParseConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid crop_geometry")
+		}
+		filters = append(filters, func(pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+			return pointcloud.CropByGeometry(pc, geometry)
+		})
+	}
+	if conf.StatisticalOutliers != nil {
+		filter, err := pointcloud.StatisticalOutlierFilter(conf.StatisticalOutliers.MeanK, conf.StatisticalOutliers.StdDevThreshold)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid statistical_outliers")
+		}
+		filters = append(filters, filter)
+	}
+	if conf.RadiusOutliers != nil {
+		filter, err := pointcloud.RadiusOutlierFilter(conf.RadiusOutliers.RadiusMM, conf.RadiusOutliers.MinNeighbors)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid radius_outliers")
+		}
+		filters = append(filters, filter)
+	}
+	if conf.VoxelSizeMM < 0 {
+		return nil, errors.Errorf("voxel_size_mm cannot be negative, got %.2f", conf.VoxelSizeMM)
+	}
+	if conf.VoxelSizeMM > 0 {
+		voxelSize := conf.VoxelSizeMM
+		filters = append(filters, func(pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+			return pointcloud.VoxelDownsample(pc, voxelSize)
+		})
+	}
+	if conf.NormalsK != 0 {
+		if conf.NormalsK < 3 {
+			return nil, errors.Errorf("normals_k must be at least 3, got %d", conf.NormalsK)
+		}
+		k := conf.NormalsK
+		filters = append(filters, func(pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+			// point clouds are in the frame of the camera, so normals face the camera at the origin
+			return pointcloud.EstimateNormals(pc, k, r3.Vector{})
+		})
+	}
+	if len(filters) == 0 {
+		return nil, errors.New("filter_pointcloud needs at least one filter")
+	}
+	return filters, nil
+}
+
+// pointCloudFilterSource filters the point clouds of the source and passes its images through as they are.
+type pointCloudFilterSource struct {
+	stream      gostream.VideoStream
+	pointClouds camera.PointCloudSource
+	filters     []func(pointcloud.PointCloud) (pointcloud.PointCloud, error)
+}
+
+func newPointCloudFilterTransform(
+	ctx context.Context,
+	source gostream.VideoSource,
+	stream camera.ImageType,
+	am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*pointCloudFilterConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	filters, err := conf.filters()
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	pointClouds, ok := source.(camera.PointCloudSource)
+	if !ok {
+		return nil, camera.UnspecifiedStream, errors.New("source of filter_pointcloud does not produce point clouds")
+	}
+	props, err := propsFromVideoSource(ctx, source)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	var cameraModel transform.PinholeCameraModel
+	cameraModel.PinholeCameraIntrinsics = props.IntrinsicParams
+
+	if props.DistortionParams != nil {
+		cameraModel.Distortion = props.DistortionParams
+	}
+	reader := &pointCloudFilterSource{gostream.NewEmbeddedVideoStream(source), pointClouds, filters}
+	src, err := camera.NewVideoSourceFromReader(ctx, reader, &cameraModel, stream)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	return src, stream, err
+}
+
+// Read returns the next image of the source unchanged.
+func (fs *pointCloudFilterSource) Read(ctx context.Context) (image.Image, func(), error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::pointCloudFilter::Read")
+	defer span.End()
+	return fs.stream.Next(ctx)
+}
+
+// NextPointCloud applies the filters to the next point cloud of the source.
+func (fs *pointCloudFilterSource) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::pointCloudFilter::NextPointCloud")
+	defer span.End()
+	pc, err := fs.pointClouds.NextPointCloud(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, filter := range fs.filters {
+		if pc, err = filter(pc); err != nil {
+			return nil, err
+		}
+	}
+	return pc, nil
+}
+
+func (fs *pointCloudFilterSource) Close(ctx context.Context) error {
+	return fs.stream.Close(ctx)
+}
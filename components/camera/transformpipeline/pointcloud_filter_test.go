@@ -0,0 +1,125 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/edaniels/gostream"
+	"github.com/golang/geo/r3"
+	"github.com/pion/mediadevices/pkg/prop"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+func TestPointCloudFilterTransform(t *testing.T) {
+	ctx := context.Background()
+	intrinsics := &transform.PinholeCameraIntrinsics{
+		Width:  40,
+		Height: 30,
+		Fx:     50,
+		Fy:     50,
+		Ppx:    20,
+		Ppy:    15,
+	}
+	// a wall a meter away with a speck of noise in front of it
+	dm := rimage.NewEmptyDepthMap(40, 30)
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 30; y++ {
+			dm.Set(x, y, 1000)
+		}
+	}
+	dm.Set(0, 0, 300)
+	reader := gostream.VideoReaderFunc(func(ctx context.Context) (image.Image, func(), error) {
+		return dm, func() {}, nil
+	})
+	model := camera.NewPinholeModelWithBrownConradyDistortion(intrinsics, nil)
+	src, err := camera.NewVideoSourceFromReader(ctx, reader, &model, camera.DepthStream)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, src.Close(ctx), test.ShouldBeNil)
+	}()
+	raw, err := src.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.Size(), test.ShouldEqual, 1200)
+
+	t.Run("invalid configs", func(t *testing.T) {
+		for _, am := range []utils.AttributeMap{
+			{},
+			{"voxel_size_mm": -1},
+			{"normals_k": 2},
+			{"radius_outliers": map[string]interface{}{"radius_mm": 0, "min_neighbors": 2}},
+			{"statistical_outliers": map[string]interface{}{"mean_k": 0, "std_dev_threshold": 1}},
+		} {
+			_, _, err := newPointCloudFilterTransform(ctx, src, camera.DepthStream, am)
+			test.That(t, err, test.ShouldNotBeNil)
+		}
+		noPointClouds := gostream.NewVideoSource(reader, prop.Video{})
+		_, _, err := newPointCloudFilterTransform(ctx, noPointClouds, camera.DepthStream, utils.AttributeMap{"voxel_size_mm": 10})
+		test.That(t, err, test.ShouldBeError, "source of filter_pointcloud does not produce point clouds")
+		test.That(t, noPointClouds.Close(ctx), test.ShouldBeNil)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		am := utils.AttributeMap{
+			"crop_box": map[string]interface{}{
+				"min": map[string]float64{"x": -200, "y": -200},
+				"max": map[string]float64{"x": 200, "y": 200, "z": 2000},
+			},
+			"radius_outliers": map[string]interface{}{"radius_mm": 50, "min_neighbors": 2},
+			"voxel_size_mm":   100,
+			"normals_k":       5,
+		}
+		fs, stream, err := newPointCloudFilterTransform(ctx, src, camera.DepthStream, am)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, stream, test.ShouldEqual, camera.DepthStream)
+		pcSrc, ok := fs.(camera.PointCloudSource)
+		test.That(t, ok, test.ShouldBeTrue)
+		filtered, err := pcSrc.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, filtered.Size(), test.ShouldBeGreaterThan, 0)
+		test.That(t, filtered.Size(), test.ShouldBeLessThan, raw.Size())
+		test.That(t, filtered.MetaData().HasNormal, test.ShouldBeTrue)
+		filtered.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+			test.That(t, p.Z, test.ShouldAlmostEqual, 1000)
+			test.That(t, p.X, test.ShouldBeBetweenOrEqual, -200, 200)
+			test.That(t, p.Y, test.ShouldBeBetweenOrEqual, -200, 200)
+			test.That(t, d.Normal().Z, test.ShouldAlmostEqual, -1)
+			return true
+		})
+
+		// images pass through unchanged
+		img, _, err := camera.ReadImage(ctx, fs)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, img, test.ShouldResemble, dm)
+		test.That(t, fs.Close(ctx), test.ShouldBeNil)
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		transformConf := &transformConfig{
+			CameraParameters: intrinsics,
+			Source:           "source",
+			Pipeline: []Transformation{
+				{Type: "filter_pointcloud", Attributes: utils.AttributeMap{
+					"radius_outliers": map[string]interface{}{"radius_mm": 50, "min_neighbors": 2},
+				}},
+				{Type: "depth_preprocess", Attributes: utils.AttributeMap{}},
+			},
+		}
+		pipe, err := newTransformPipeline(ctx, src, transformConf, &inject.Robot{})
+		test.That(t, err, test.ShouldBeNil)
+		props, err := pipe.Properties(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+		filtered, err := pipe.NextPointCloud(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, filtered.Size(), test.ShouldEqual, raw.Size()-1)
+		test.That(t, pipe.Close(ctx), test.ShouldBeNil)
+	})
+}
@@ -28,6 +28,7 @@ const (
 	transformTypeClassifications = transformType("classifications")
 	transformTypeDepthEdges      = transformType("depth_edges")
 	transformTypeDepthPreprocess = transformType("depth_preprocess")
+	transformTypeCloudFilter     = transformType("filter_pointcloud")
 )
 
 // emptyConfig is for transforms that have no attribute fields.
@@ -92,6 +93,11 @@ var registeredTransformConfigs = map[transformType]*transformRegistration{
 		&emptyConfig{},
 		"Applies some basic hole-filling and edge smoothing to a depth map.",
 	},
+	transformTypeCloudFilter: {
+		string(transformTypeCloudFilter),
+		&pointCloudFilterConfig{},
+		"Crops, removes outliers from, downsamples and estimates normals of point clouds. Images pass through unchanged.",
+	},
 }
 
 // Transformation states the type of transformation and the attributes that are specific to the given type.
@@ -144,6 +150,8 @@ func buildTransform(
 		return newDepthEdgesTransform(ctx, source, tr.Attributes)
 	case transformTypeDepthPreprocess:
 		return newDepthPreprocessTransform(ctx, source)
+	case transformTypeCloudFilter:
+		return newPointCloudFilterTransform(ctx, source, stream, tr.Attributes)
 	default:
 		return nil, camera.UnspecifiedStream, errors.Errorf("do not know camera transform of type %q", tr.Type)
 	}
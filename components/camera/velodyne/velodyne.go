@@ -295,7 +295,8 @@ func (c *client) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, err
 				yaw += config[channelID].azimuthOffset
 				err := pc.Set(
 					pointFrom(utils.DegToRad(yaw), utils.DegToRad(pitch), float64(c.Distance)/1000),
-					pointcloud.NewBasicData().SetIntensity(uint16(c.Reflectivity)*255),
+					// packet timestamps are microseconds past the hour
+					pointcloud.NewBasicData().SetIntensity(uint16(c.Reflectivity)*255).SetTimestamp(float64(p.Timestamp)/1e6),
 				)
 				if err != nil {
 					return nil, err
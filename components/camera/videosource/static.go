@@ -27,7 +27,13 @@ func init() {
 				if err != nil {
 					return nil, err
 				}
-				videoSrc := &fileSource{newConf.Color, newConf.Depth, newConf.CameraParameters}
+				videoSrc := &fileSource{
+					ColorFN:      newConf.Color,
+					DepthFN:      newConf.Depth,
+					PointCloudFN: newConf.PointCloud,
+					Intrinsics:   newConf.CameraParameters,
+					logger:       logger,
+				}
 				imgType := camera.ColorStream
 				if newConf.Color == "" {
 					imgType = camera.DepthStream
@@ -47,11 +53,13 @@ func init() {
 		})
 }
 
-// fileSource stores the paths to a color and depth image.
+// fileSource stores the paths to a color and depth image and a point cloud.
 type fileSource struct {
-	ColorFN    string
-	DepthFN    string
-	Intrinsics *transform.PinholeCameraIntrinsics
+	ColorFN      string
+	DepthFN      string
+	PointCloudFN string
+	Intrinsics   *transform.PinholeCameraIntrinsics
+	logger       golog.Logger
 }
 
 // fileSourceConfig is the attribute struct for fileSource.
@@ -62,10 +70,15 @@ type fileSourceConfig struct {
 	Debug                bool                               `json:"debug,omitempty"`
 	Color                string                             `json:"color_image_file_path,omitempty"`
 	Depth                string                             `json:"depth_image_file_path,omitempty"`
+	// PointCloud is a .pcd, .ply, .xyz, .csv, .las or .e57 file returned by NextPointCloud instead of projecting the images.
+	PointCloud string `json:"pointcloud_file_path,omitempty"`
 }
 
 // Read returns just the RGB image if it is present, or the depth map if the RGB image is not present.
 func (fs *fileSource) Read(ctx context.Context) (image.Image, func(), error) {
+	if fs.ColorFN == "" && fs.DepthFN == "" {
+		return nil, nil, errors.New("no image file to read")
+	}
 	if fs.ColorFN == "" { // only depth info
 		img, err := rimage.NewDepthMapFromFile(context.Background(), fs.DepthFN)
 		return img, func() {}, err
@@ -74,8 +87,12 @@ func (fs *fileSource) Read(ctx context.Context) (image.Image, func(), error) {
 	return img, func() {}, err
 }
 
-// NextPointCloud returns the point cloud from projecting the rgb and depth image using the intrinsic parameters.
+// NextPointCloud returns the point cloud file if there is one, otherwise the point cloud from projecting the
+// rgb and depth image using the intrinsic parameters.
 func (fs *fileSource) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	if fs.PointCloudFN != "" {
+		return pointcloud.NewFromFile(fs.PointCloudFN, fs.logger)
+	}
 	if fs.Intrinsics == nil {
 		return nil, transform.NewNoIntrinsicsError("camera intrinsics not found in config")
 	}
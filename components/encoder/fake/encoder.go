@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
 	"go.viam.com/utils"
 
 	"go.viam.com/rdk/components/encoder"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/sim"
 )
 
 var fakeModel = resource.DefaultModelFamily.WithModel("fake")
@@ -57,28 +59,53 @@ func (e *fakeEncoder) Reconfigure(
 		return err
 	}
 	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.updateRate = newConf.UpdateRate
 	if e.updateRate == 0 {
 		e.updateRate = 100
 	}
-	e.mu.Unlock()
+	// the world is acquired before the current one is released so that it keeps its state when the
+	// encoder stays in it
+	var world *sim.World
+	var releaseWorld func()
+	if newConf.Simulation != "" {
+		world, releaseWorld = sim.Acquire(newConf.Simulation)
+		e.simulatedMotor = newConf.SimulatedMotor
+		e.ticksPerRotation = float64(newConf.TicksPerRotation)
+	}
+	if e.releaseWorld != nil {
+		e.releaseWorld()
+	}
+	e.world, e.releaseWorld = world, releaseWorld
 	return nil
 }
 
 // Config describes the configuration of a fake encoder.
 type Config struct {
 	UpdateRate int64 `json:"update_rate_msec,omitempty"`
+	// Simulation is the name of a shared simulated world, the encoder then counts the ticks
+	// of the simulated motor SimulatedMotor instead of the speed it is set to.
+	Simulation       string `json:"simulation,omitempty"`
+	SimulatedMotor   string `json:"simulated_motor,omitempty"`
+	TicksPerRotation int    `json:"ticks_per_rotation,omitempty"`
 }
 
 // Validate ensures all parts of a config is valid.
 func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Simulation != "" {
+		if cfg.SimulatedMotor == "" {
+			return nil, utils.NewConfigValidationFieldRequiredError(path, "simulated_motor")
+		}
+		if cfg.TicksPerRotation <= 0 {
+			return nil, utils.NewConfigValidationError(path, errors.New("ticks_per_rotation should be positive"))
+		}
+	}
 	return nil, nil
 }
 
 // fakeEncoder keeps track of a fake motor position.
 type fakeEncoder struct {
 	resource.Named
-	resource.TriviallyCloseable
 
 	positionType            encoder.PositionType
 	activeBackgroundWorkers sync.WaitGroup
@@ -87,6 +114,32 @@ type fakeEncoder struct {
 	position   int64
 	speed      float64 // ticks per minute
 	updateRate int64   // update position in start every updateRate ms
+
+	world            *sim.World
+	releaseWorld     func()
+	simulatedMotor   string
+	ticksPerRotation float64
+	zeroTicks        int64
+}
+
+// simulatedTicks returns the ticks counted from the simulated motor, assumes the lock is held.
+func (e *fakeEncoder) simulatedTicks() (int64, error) {
+	m, err := sim.BodyAs[*sim.Motor](e.world, e.simulatedMotor)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Floor(m.Position() * e.ticksPerRotation)), nil
+}
+
+// Close releases the simulated world the encoder counts in if it is in one.
+func (e *fakeEncoder) Close(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.releaseWorld != nil {
+		e.releaseWorld()
+		e.world, e.releaseWorld = nil, nil
+	}
+	return nil
 }
 
 // Position returns the current position in terms of ticks or
@@ -101,6 +154,13 @@ func (e *fakeEncoder) Position(
 	}
 	e.mu.RLock()
 	defer e.mu.RUnlock()
+	if e.world != nil {
+		ticks, err := e.simulatedTicks()
+		if err != nil {
+			return math.NaN(), encoder.PositionTypeUnspecified, err
+		}
+		return float64(ticks - e.zeroTicks), e.positionType, nil
+	}
 	return float64(e.position), e.positionType, nil
 }
 
@@ -134,6 +194,14 @@ func (e *fakeEncoder) start(cancelCtx context.Context) {
 func (e *fakeEncoder) ResetPosition(ctx context.Context, extra map[string]interface{}) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	if e.world != nil {
+		ticks, err := e.simulatedTicks()
+		if err != nil {
+			return err
+		}
+		e.zeroTicks = ticks
+		return nil
+	}
 	e.position = int64(0)
 	return nil
 }
@@ -165,6 +233,14 @@ func (e *fakeEncoder) SetSpeed(ctx context.Context, speed float64) error {
 func (e *fakeEncoder) SetPosition(ctx context.Context, position int64) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	if e.world != nil {
+		ticks, err := e.simulatedTicks()
+		if err != nil {
+			return err
+		}
+		e.zeroTicks = ticks - position
+		return nil
+	}
 	e.position = position
 	return nil
 }
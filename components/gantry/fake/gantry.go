@@ -4,28 +4,49 @@ package fake
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/edaniels/golog"
 	"github.com/golang/geo/r3"
 
 	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/sim"
 	"go.viam.com/rdk/testutils"
 )
 
+const defaultSpeedMmPerSec = 100.
+
+// Config is used for converting config attributes.
+type Config struct {
+	// Simulation is the name of a shared simulated world, the gantry axes then move at SpeedMmPerSec.
+	Simulation    string  `json:"simulation,omitempty"`
+	SpeedMmPerSec float64 `json:"speed_mm_per_sec,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	return nil, nil
+}
+
 func init() {
 	resource.RegisterComponent(
 		gantry.API,
 		resource.DefaultModelFamily.WithModel("fake"),
-		resource.Registration[gantry.Gantry, resource.NoNativeConfig]{
+		resource.Registration[gantry.Gantry, *Config]{
 			Constructor: func(
 				ctx context.Context,
-				_ resource.Dependencies,
+				deps resource.Dependencies,
 				conf resource.Config,
 				logger golog.Logger,
 			) (gantry.Gantry, error) {
-				return NewGantry(conf.ResourceName()), nil
+				g := NewGantry(conf.ResourceName())
+				if err := g.Reconfigure(ctx, deps, conf); err != nil {
+					return nil, err
+				}
+				return g, nil
 			},
 		})
 }
@@ -33,29 +54,94 @@ func init() {
 // NewGantry returns a new fake gantry.
 func NewGantry(name resource.Name) gantry.Gantry {
 	return &Gantry{
-		testutils.NewUnimplementedResource(name),
-		resource.TriviallyReconfigurable{},
-		resource.TriviallyCloseable{},
-		[]float64{1.2},
-		[]float64{5},
-		r3.Vector{1, 0, 0},
-		2,
+		Named:        testutils.NewUnimplementedResource(name),
+		positionsMm:  []float64{1.2},
+		lengths:      []float64{5},
+		axis:         r3.Vector{X: 1, Y: 0, Z: 0},
+		lengthMeters: 2,
 	}
 }
 
 // Gantry is a fake gantry that can simply read and set properties.
 type Gantry struct {
 	resource.Named
-	resource.TriviallyReconfigurable
-	resource.TriviallyCloseable
+	mu           sync.RWMutex
 	positionsMm  []float64
 	lengths      []float64
 	axis         r3.Vector
 	lengthMeters float64
+
+	world        *sim.World
+	releaseWorld func()
+	body         *sim.Joints
+	opMgr        operation.SingleOperationManager
+}
+
+// Reconfigure places the gantry in a simulated world if configured to.
+func (g *Gantry) Reconfigure(ctx context.Context, deps resource.Dependencies, conf resource.Config) error {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if newConf.Simulation == "" {
+		g.closeSimulation()
+		return nil
+	}
+	speed := newConf.SpeedMmPerSec
+	if speed <= 0 {
+		speed = defaultSpeedMmPerSec
+	}
+	speeds := make([]float64, len(g.positionsMm))
+	for i := range speeds {
+		speeds[i] = speed
+	}
+	// the world is acquired before the current one is released so that it keeps its state when the
+	// gantry stays in it
+	world, releaseWorld := sim.Acquire(newConf.Simulation)
+	g.closeSimulation()
+	g.world, g.releaseWorld = world, releaseWorld
+	g.body = sim.NewJoints(speeds)
+	if err := g.body.SetPositions(g.positionsMm); err != nil {
+		g.closeSimulation()
+		return err
+	}
+	if err := g.world.Add(g.Name().ShortName(), g.body); err != nil {
+		g.closeSimulation()
+		return err
+	}
+	return nil
+}
+
+// closeSimulation removes the gantry from its simulated world, assumes the lock is held.
+func (g *Gantry) closeSimulation() {
+	if g.world == nil {
+		return
+	}
+	if b, ok := g.world.Body(g.Name().ShortName()); ok && b == g.body {
+		g.world.Remove(g.Name().ShortName())
+	}
+	g.releaseWorld()
+	g.world, g.releaseWorld, g.body = nil, nil, nil
+}
+
+// Close removes the gantry from its simulated world.
+func (g *Gantry) Close(ctx context.Context) error {
+	g.opMgr.CancelRunning(ctx)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closeSimulation()
+	return nil
 }
 
 // Position returns the position in meters.
 func (g *Gantry) Position(ctx context.Context, extra map[string]interface{}) ([]float64, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.body != nil {
+		return g.body.Positions(), nil
+	}
 	return g.positionsMm, nil
 }
 
@@ -66,17 +152,46 @@ func (g *Gantry) Lengths(ctx context.Context, extra map[string]interface{}) ([]f
 
 // MoveToPosition is in meters.
 func (g *Gantry) MoveToPosition(ctx context.Context, positionsMm []float64, extra map[string]interface{}) error {
-	g.positionsMm = positionsMm
+	g.mu.Lock()
+	world, body := g.world, g.body
+	if body == nil {
+		g.positionsMm = positionsMm
+	}
+	g.mu.Unlock()
+	if body == nil {
+		return nil
+	}
+
+	ctx, done := g.opMgr.New(ctx)
+	defer done()
+	if err := body.SetTargets(positionsMm); err != nil {
+		return err
+	}
+	if err := world.WaitUntil(ctx, func() bool { return !body.IsMoving() }); err != nil {
+		body.Stop()
+		return err
+	}
 	return nil
 }
 
-// Stop doesn't do anything for a fake gantry.
+// Stop doesn't do anything for a fake gantry unless simulated.
 func (g *Gantry) Stop(ctx context.Context, extra map[string]interface{}) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.body != nil {
+		g.opMgr.CancelRunning(ctx)
+		g.body.Stop()
+	}
 	return nil
 }
 
-// IsMoving is always false for a fake gantry.
+// IsMoving is always false for a fake gantry unless simulated.
 func (g *Gantry) IsMoving(ctx context.Context) (bool, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.body != nil {
+		return g.body.IsMoving(), nil
+	}
 	return false, nil
 }
 
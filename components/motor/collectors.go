@@ -2,9 +2,12 @@ package motor
 
 import (
 	"context"
+	"time"
 
+	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/anypb"
 
+	"go.viam.com/rdk/control"
 	"go.viam.com/rdk/data"
 )
 
@@ -13,6 +16,7 @@ type method int64
 const (
 	position method = iota
 	isPowered
+	controlLoopTelemetry
 )
 
 func (m method) String() string {
@@ -21,6 +25,8 @@ func (m method) String() string {
 		return "Position"
 	case isPowered:
 		return "IsPowered"
+	case controlLoopTelemetry:
+		return "ControlLoopTelemetry"
 	}
 	return "Unknown"
 }
@@ -68,6 +74,61 @@ func newIsPoweredCollector(resource interface{}, params data.CollectorParams) (d
 	return data.NewCollector(cFunc, params)
 }
 
+// ControlLoopTelemetryReporter is implemented by motors running a control loop with telemetry enabled.
+type ControlLoopTelemetryReporter interface {
+	// ControlLoopTelemetry returns the samples recorded by the control loop strictly after since.
+	ControlLoopTelemetry(ctx context.Context, since time.Time) ([]control.TelemetrySample, error)
+}
+
+// ControlLoopSample wraps a single control loop telemetry sample.
+type ControlLoopSample struct {
+	TimeUnixNano int64
+	Block        string
+	Setpoint     float64
+	Measured     float64
+	Error        float64
+	Output       float64
+}
+
+// ControlLoopTelemetry wraps the control loop samples recorded since the previous capture.
+type ControlLoopTelemetry struct {
+	Samples []ControlLoopSample
+}
+
+func newControlLoopTelemetryCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	if _, err := assertMotor(resource); err != nil {
+		return nil, err
+	}
+	reporter, ok := resource.(ControlLoopTelemetryReporter)
+	if !ok {
+		return nil, errors.Errorf("motor %s does not report control loop telemetry", params.ComponentName)
+	}
+
+	var lastCapture time.Time
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (interface{}, error) {
+		samples, err := reporter.ControlLoopTelemetry(ctx, lastCapture)
+		if err != nil {
+			return nil, data.FailedToReadErr(params.ComponentName, controlLoopTelemetry.String(), err)
+		}
+		out := ControlLoopTelemetry{Samples: make([]ControlLoopSample, 0, len(samples))}
+		for _, s := range samples {
+			if s.Time.After(lastCapture) {
+				lastCapture = s.Time
+			}
+			out.Samples = append(out.Samples, ControlLoopSample{
+				TimeUnixNano: s.Time.UnixNano(),
+				Block:        s.Block,
+				Setpoint:     s.Setpoint,
+				Measured:     s.Measured,
+				Error:        s.Error,
+				Output:       s.Output,
+			})
+		}
+		return out, nil
+	})
+	return data.NewCollector(cFunc, params)
+}
+
 func assertMotor(resource interface{}) (Motor, error) {
 	motor, ok := resource.(Motor)
 	if !ok {
@@ -17,6 +17,7 @@ import (
 	"go.viam.com/rdk/components/motor"
 	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/sim"
 )
 
 var model = resource.DefaultModelFamily.WithModel("fake")
@@ -40,6 +41,10 @@ type Config struct {
 	MaxRPM           float64   `json:"max_rpm,omitempty"`
 	TicksPerRotation int       `json:"ticks_per_rotation,omitempty"`
 	DirectionFlip    bool      `json:"direction_flip,omitempty"`
+	// Simulation is the name of a shared simulated world the motor moves in, the motor then has
+	// inertia and reports its position without needing an encoder.
+	Simulation      string  `json:"simulation,omitempty"`
+	TimeConstantSec float64 `json:"time_constant_sec,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid.
@@ -83,7 +88,6 @@ var _ motor.LocalMotor = &Motor{}
 // direction.
 type Motor struct {
 	resource.Named
-	resource.TriviallyCloseable
 
 	mu                sync.Mutex
 	powerPct          float64
@@ -95,6 +99,11 @@ type Motor struct {
 	DirFlip           bool
 	TicksPerRotation  int
 
+	world        *sim.World
+	releaseWorld func()
+	body         *sim.Motor
+	zeroPos      float64
+
 	opMgr  operation.SingleOperationManager
 	Logger golog.Logger
 }
@@ -150,6 +159,48 @@ func (m *Motor) Reconfigure(ctx context.Context, deps resource.Dependencies, con
 	if newConf.DirectionFlip {
 		m.DirFlip = true
 	}
+
+	if newConf.Simulation == "" {
+		m.closeSimulation()
+		return nil
+	}
+	// the world is acquired before the current one is released so that it keeps its state, and the
+	// motor its position, when the motor stays in it
+	world, releaseWorld := sim.Acquire(newConf.Simulation)
+	timeConstant := time.Duration(newConf.TimeConstantSec * float64(time.Second))
+	if world == m.world {
+		releaseWorld()
+		m.body.Configure(m.MaxRPM, timeConstant)
+	} else {
+		body := sim.NewMotor(m.MaxRPM, timeConstant)
+		if err := world.Add(m.Name().ShortName(), body); err != nil {
+			releaseWorld()
+			return err
+		}
+		m.closeSimulation()
+		m.world, m.releaseWorld, m.body = world, releaseWorld, body
+	}
+	m.PositionReporting = true
+	return nil
+}
+
+// closeSimulation removes the motor from its simulated world, assumes the lock is held.
+func (m *Motor) closeSimulation() {
+	if m.world == nil {
+		return
+	}
+	if b, ok := m.world.Body(m.Name().ShortName()); ok && b == m.body {
+		m.world.Remove(m.Name().ShortName())
+	}
+	m.releaseWorld()
+	m.world, m.releaseWorld, m.body, m.zeroPos = nil, nil, nil, 0
+}
+
+// Close removes the motor from its simulated world if it is in one.
+func (m *Motor) Close(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeSimulation()
 	return nil
 }
 
@@ -158,6 +209,9 @@ func (m *Motor) Position(ctx context.Context, extra map[string]interface{}) (flo
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.body != nil && m.Encoder == nil {
+		return m.body.Position() - m.zeroPos, nil
+	}
 	if m.Encoder == nil {
 		return 0, errors.New("encoder is not defined")
 	}
@@ -190,6 +244,10 @@ func (m *Motor) SetPower(ctx context.Context, powerPct float64, extra map[string
 	m.Logger.Debugf("Motor SetPower %f", powerPct)
 	m.setPowerPct(powerPct)
 
+	if m.body != nil {
+		m.body.SetPower(m.powerPct)
+	}
+	// an encoder which does not count the ticks of the simulated motor follows the power instead
 	if m.Encoder != nil {
 		if m.TicksPerRotation <= 0 {
 			return errors.New("need positive nonzero TicksPerRotation")
@@ -270,7 +328,7 @@ func (m *Motor) GoFor(ctx context.Context, rpm, revolutions float64, extra map[s
 	powerPct, waitDur, dir := goForMath(m.MaxRPM, rpm, revolutions)
 
 	var finalPos float64
-	if m.Encoder != nil {
+	if m.Encoder != nil || m.isSimulated() {
 		curPos, err := m.Position(ctx, nil)
 		if err != nil {
 			return err
@@ -287,6 +345,10 @@ func (m *Motor) GoFor(ctx context.Context, rpm, revolutions float64, extra map[s
 		return nil
 	}
 
+	if m.isSimulated() {
+		return m.waitForPosition(ctx, finalPos, dir)
+	}
+
 	if m.opMgr.NewTimedWaitOp(ctx, waitDur) {
 		err = m.Stop(ctx, nil)
 		if err != nil {
@@ -300,9 +362,40 @@ func (m *Motor) GoFor(ctx context.Context, rpm, revolutions float64, extra map[s
 	return nil
 }
 
+func (m *Motor) isSimulated() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.body != nil
+}
+
+// waitForPosition waits for the simulated motor to reach pos moving in the direction dir then stops it.
+func (m *Motor) waitForPosition(ctx context.Context, pos, dir float64) error {
+	ctx, done := m.opMgr.New(ctx)
+	defer done()
+	m.mu.Lock()
+	world := m.world
+	m.mu.Unlock()
+
+	var posErr error
+	if err := world.WaitUntil(ctx, func() bool {
+		cur, err := m.Position(ctx, nil)
+		if err != nil {
+			posErr = err
+			return true
+		}
+		return (cur-pos)*dir >= 0
+	}); err != nil {
+		return err
+	}
+	if posErr != nil {
+		return posErr
+	}
+	return m.Stop(ctx, nil)
+}
+
 // GoTo sets the given direction and an arbitrary power percentage for now.
 func (m *Motor) GoTo(ctx context.Context, rpm, pos float64, extra map[string]interface{}) error {
-	if m.Encoder == nil {
+	if m.Encoder == nil && !m.isSimulated() {
 		return errors.New("encoder is not defined")
 	}
 
@@ -335,6 +428,10 @@ func (m *Motor) GoTo(ctx context.Context, rpm, pos float64, extra map[string]int
 		return nil
 	}
 
+	if m.isSimulated() {
+		return m.waitForPosition(ctx, pos, math.Copysign(1, revolutions))
+	}
+
 	if m.opMgr.NewTimedWaitOp(ctx, waitDur) {
 		err = m.Stop(ctx, nil)
 		if err != nil {
@@ -354,6 +451,13 @@ func (m *Motor) GoTillStop(ctx context.Context, rpm float64, stopFunc func(ctx c
 
 // ResetZeroPosition resets the zero position.
 func (m *Motor) ResetZeroPosition(ctx context.Context, offset float64, extra map[string]interface{}) error {
+	m.mu.Lock()
+	if m.body != nil && m.Encoder == nil {
+		m.zeroPos = m.body.Position() - offset
+		m.mu.Unlock()
+		return nil
+	}
+	m.mu.Unlock()
 	if m.Encoder == nil {
 		return errors.New("encoder is not defined")
 	}
@@ -377,6 +481,9 @@ func (m *Motor) Stop(ctx context.Context, extra map[string]interface{}) error {
 
 	m.Logger.Debug("Motor Stopped")
 	m.setPowerPct(0.0)
+	if m.body != nil {
+		m.body.SetPower(0)
+	}
 	if m.Encoder != nil {
 		err := m.Encoder.SetSpeed(ctx, 0.0)
 		if err != nil {
@@ -397,5 +504,8 @@ func (m *Motor) IsPowered(ctx context.Context, extra map[string]interface{}) (bo
 func (m *Motor) IsMoving(ctx context.Context) (bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.body != nil {
+		return math.Abs(m.body.RPM()) >= 0.01, nil
+	}
 	return math.Abs(m.powerPct) >= 0.005, nil
 }
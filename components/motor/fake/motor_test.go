@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/edaniels/golog"
 	"go.viam.com/test"
 	"go.viam.com/utils/testutils"
 
+	"go.viam.com/rdk/components/encoder"
 	"go.viam.com/rdk/components/encoder/fake"
 	"go.viam.com/rdk/components/motor"
 	"go.viam.com/rdk/resource"
@@ -196,3 +198,93 @@ func TestPower(t *testing.T) {
 	powerPct = m.PowerPct()
 	test.That(t, powerPct, test.ShouldEqual, 0.0)
 }
+
+func TestSimulatedMotor(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+
+	m := &Motor{Named: motor.Named("m").AsNamed(), Logger: logger}
+	err := m.Reconfigure(ctx, nil, resource.Config{
+		ConvertedAttributes: &Config{MaxRPM: 600, Simulation: t.Name(), TimeConstantSec: 0.01},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	defer m.Close(ctx)
+
+	enc, err := fake.NewEncoder(ctx, resource.Config{
+		Name:                "e",
+		ConvertedAttributes: &fake.Config{Simulation: t.Name(), SimulatedMotor: "m", TicksPerRotation: 10},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	defer enc.Close(ctx)
+
+	featureMap, err := m.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, featureMap[motor.PositionReporting], test.ShouldBeTrue)
+
+	test.That(t, m.GoFor(ctx, 600, 1, nil), test.ShouldBeNil)
+	pos, err := m.Position(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pos, test.ShouldBeGreaterThanOrEqualTo, 1)
+	ticks, _, err := enc.Position(ctx, encoder.PositionTypeUnspecified, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ticks, test.ShouldBeGreaterThanOrEqualTo, 10)
+
+	test.That(t, m.GoTo(ctx, 600, 0, nil), test.ShouldBeNil)
+	pos, err = m.Position(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pos, test.ShouldBeLessThanOrEqualTo, 0)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		moving, err := m.IsMoving(ctx)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, moving, test.ShouldBeFalse)
+	})
+}
+
+func TestSimulatedMotorWithEncoder(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	enc, err := fake.NewEncoder(ctx, resource.Config{Name: "e", ConvertedAttributes: &fake.Config{UpdateRate: 10}})
+	test.That(t, err, test.ShouldBeNil)
+	defer enc.Close(ctx)
+	deps := resource.Dependencies{encoder.Named("e"): enc}
+	conf := resource.Config{
+		ConvertedAttributes: &Config{
+			MaxRPM:           600,
+			Simulation:       t.Name(),
+			TimeConstantSec:  0.01,
+			Encoder:          "e",
+			TicksPerRotation: 10,
+		},
+	}
+
+	m := &Motor{Named: motor.Named("m").AsNamed(), Logger: logger}
+	test.That(t, m.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+	defer m.Close(ctx)
+
+	// the encoder does not count the ticks of the simulated motor, it follows the power instead
+	test.That(t, m.GoFor(ctx, 600, 1, nil), test.ShouldBeNil)
+	ticks, _, err := enc.Position(ctx, encoder.PositionTypeUnspecified, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ticks, test.ShouldBeGreaterThanOrEqualTo, 10)
+	test.That(t, m.GoTo(ctx, 600, 0, nil), test.ShouldBeNil)
+
+	// the motor keeps its simulated position across reconfigurations
+	test.That(t, m.SetPower(ctx, 1, nil), test.ShouldBeNil)
+	m.mu.Lock()
+	body := m.body
+	m.mu.Unlock()
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		test.That(tb, body.Position(), test.ShouldBeGreaterThan, 1)
+	})
+	test.That(t, m.Reconfigure(ctx, deps, conf), test.ShouldBeNil)
+	m.mu.Lock()
+	test.That(t, m.body, test.ShouldEqual, body)
+	m.mu.Unlock()
+	test.That(t, body.Position(), test.ShouldBeGreaterThan, 1)
+	test.That(t, m.Stop(ctx, nil), test.ShouldBeNil)
+}
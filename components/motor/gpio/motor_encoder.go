@@ -2,16 +2,20 @@
 package gpio
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/edaniels/golog"
 	"github.com/pkg/errors"
+	"go.uber.org/multierr"
 	"go.viam.com/utils"
+	"go.viam.com/utils/protoutils"
 
 	"go.viam.com/rdk/components/encoder"
 	"go.viam.com/rdk/components/encoder/single"
@@ -197,7 +201,9 @@ type EncodedMotor struct {
 	logger          golog.Logger
 	cancelCtx       context.Context
 	cancel          func()
+	loopMu          sync.Mutex
 	loop            *control.Loop
+	tuning          bool
 	opMgr           operation.SingleOperationManager
 }
 
@@ -637,9 +643,11 @@ func (m *EncodedMotor) IsPowered(ctx context.Context, extra map[string]interface
 
 // Close cleanly shuts down the motor.
 func (m *EncodedMotor) Close(ctx context.Context) error {
+	m.loopMu.Lock()
 	if m.loop != nil {
 		m.loop.Stop()
 	}
+	m.loopMu.Unlock()
 	m.cancel()
 	m.activeBackgroundWorkers.Wait()
 	return nil
@@ -741,3 +749,150 @@ func (m *EncodedMotor) GoTillStop(ctx context.Context, rpm float64, stopFunc fun
 func (m *EncodedMotor) ResetZeroPosition(ctx context.Context, offset float64, extra map[string]interface{}) error {
 	return m.encoder.ResetPosition(ctx, extra)
 }
+
+// DoCommand executes additional commands beyond the Motor{} interface.
+func (m *EncodedMotor) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"]
+	if !ok {
+		return nil, errors.New("missing 'command' value")
+	}
+	switch name {
+	case tunePIDCommand:
+		return m.tunePID(ctx, rdkutils.AttributeMap(cmd))
+	case telemetryCSVCommand:
+		samples, err := m.ControlLoopTelemetry(ctx, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := control.WriteTelemetryCSV(&buf, samples); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"csv": buf.String()}, nil
+	default:
+		return nil, fmt.Errorf("no such command: %s", name)
+	}
+}
+
+const (
+	tunePIDCommand      = "tune_pid"
+	telemetryCSVCommand = "control_telemetry_csv"
+)
+
+// ControlLoopTelemetry returns the samples recorded strictly after since by every block
+// of the control loop which has telemetry enabled.
+func (m *EncodedMotor) ControlLoopTelemetry(ctx context.Context, since time.Time) ([]control.TelemetrySample, error) {
+	m.loopMu.Lock()
+	defer m.loopMu.Unlock()
+	if m.loop == nil {
+		return nil, errors.New("motor has no control loop")
+	}
+	blocks := m.loop.TelemetryBlockList()
+	if len(blocks) == 0 {
+		return nil, errors.New("control loop telemetry is not enabled")
+	}
+	var out []control.TelemetrySample
+	for _, name := range blocks {
+		buf, err := m.loop.Telemetry(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, buf.SamplesSince(since)...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// tunePID runs an on-demand PID tuning experiment and returns the computed gains. Bounds default to
+// the motor's max_power_pct and max_rpm. When "apply_to_loop" is true the gains are written into the PID
+// block of the running control loop, which is restarted, and the updated control_config is returned. The
+// robot config is left as it is, so the returned control_config must be copied into the motor's config for
+// the gains to outlive the motor.
+func (m *EncodedMotor) tunePID(ctx context.Context, cmd rdkutils.AttributeMap) (map[string]interface{}, error) {
+	tuneCfg := control.TuneConfig{
+		Method:    cmd.String("tune_method"),
+		StepPct:   cmd.Float64("tune_step_pct", 0),
+		SSRValue:  cmd.Float64("tune_ssr_value", 0),
+		MaxPower:  cmd.Float64("max_power_pct", m.maxPowerPct),
+		MaxRPM:    cmd.Float64("max_rpm", m.cfg.MaxRPM),
+		Frequency: cmd.Float64("frequency", 0),
+		Timeout:   time.Duration(cmd.Float64("timeout_sec", 0) * float64(time.Second)),
+	}
+	if tuneCfg.MaxPower > m.maxPowerPct {
+		return nil, fmt.Errorf("max_power_pct %v exceeds the motor's max power pct %v", tuneCfg.MaxPower, m.maxPowerPct)
+	}
+
+	m.loopMu.Lock()
+	if m.tuning {
+		m.loopMu.Unlock()
+		return nil, errors.New("PID tuning is already running")
+	}
+	m.tuning = true
+	// other operations on the motor still cancel the tuning
+	ctx, done := m.opMgr.New(ctx)
+	defer done()
+	if m.loop != nil {
+		// the loop drives the motor power too, it is rebuilt once the experiment is over
+		m.loop.Stop()
+	}
+	loopCfg := m.cfg.ControlLoop
+	m.loopMu.Unlock()
+
+	m.stateMu.Lock()
+	m.state.desiredRPM = 0
+	m.state.regulated = false
+	m.stateMu.Unlock()
+
+	// the loop lock is not held while tuning so that the loop can still be inspected or closed
+	res, tuneErr := control.Tune(ctx, m, tuneCfg, m.logger)
+
+	m.loopMu.Lock()
+	defer m.loopMu.Unlock()
+	m.tuning = false
+	ret := map[string]interface{}{}
+	if tuneErr == nil {
+		ret = map[string]interface{}{
+			"tune_method": res.Method,
+			"kP":          res.KP,
+			"kI":          res.KI,
+			"kD":          res.KD,
+			"step_response": map[string]interface{}{
+				"steady_state_rpm":  res.StepResponse.SteadyState,
+				"rise_time_sec":     res.StepResponse.RiseTime.Seconds(),
+				"settling_time_sec": res.StepResponse.SettlingTime.Seconds(),
+				"overshoot_pct":     res.StepResponse.OvershootPct,
+				"samples":           res.StepResponse.Samples,
+			},
+		}
+		m.logger.Infof("tuned gains are Kp %1.6f, Ki: %1.6f, Kd: %1.6f", res.KP, res.KI, res.KD)
+		if cmd.Bool("apply_to_loop", false) && len(loopCfg.Blocks) != 0 {
+			newCfg, err := res.ApplyTo(loopCfg, cmd.String("block_name"))
+			if err != nil {
+				tuneErr = err
+			} else {
+				loopCfg = newCfg
+				m.cfg.ControlLoop = newCfg
+				cfgMap, err := protoutils.InterfaceToMap(newCfg)
+				if err != nil {
+					return nil, err
+				}
+				ret["control_config"] = cfgMap
+			}
+		}
+	}
+
+	if m.loop != nil && m.cancelCtx.Err() == nil {
+		cLoop, err := control.NewLoop(m.logger, loopCfg, m)
+		if err != nil {
+			return nil, multierr.Combine(tuneErr, err)
+		}
+		if err := cLoop.Start(); err != nil {
+			return nil, multierr.Combine(tuneErr, err)
+		}
+		m.loop = cLoop
+	}
+	if tuneErr != nil {
+		return nil, tuneErr
+	}
+	return ret, nil
+}
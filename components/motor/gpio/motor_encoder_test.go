@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
 	"go.viam.com/test"
 	"go.viam.com/utils/testutils"
 
@@ -690,3 +691,66 @@ func TestDirFlipMotor(t *testing.T) {
 		test.That(t, dirflipFakeMotor.Direction(), test.ShouldEqual, 1)
 	})
 }
+
+func TestEncodedMotorTunePID(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	cfg := Config{TicksPerRotation: 100, MaxRPM: 100, MaxPowerPct: 0.5}
+	fakeMotor := &fakemotor.Motor{
+		MaxRPM:           100,
+		Logger:           logger,
+		TicksPerRotation: 100,
+	}
+	interrupt := &board.BasicDigitalInterrupt{}
+
+	e := &single.Encoder{I: interrupt, CancelCtx: context.Background()}
+	e.AttachDirectionalAwareness(&fakeDirectionAware{m: fakeMotor})
+	e.Start(context.Background())
+	m, err := NewEncodedMotor(resource.Config{}, cfg, fakeMotor, e, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, m.Close(context.Background()), test.ShouldBeNil)
+	}()
+
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{})
+	test.That(t, err, test.ShouldBeError, errors.New("missing 'command' value"))
+
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{"command": "foo"})
+	test.That(t, err, test.ShouldBeError, errors.New("no such command: foo"))
+
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{"command": "tune_pid", "max_power_pct": 0.8})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "exceeds the motor's max power pct")
+
+	// the encoder never ticks so the step response never settles
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{"command": "tune_pid", "timeout_sec": 0.3})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "tuning did not complete")
+	test.That(t, fakeMotor.PowerPct(), test.ShouldEqual, 0)
+
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{"command": "control_telemetry_csv"})
+	test.That(t, err, test.ShouldBeError, errors.New("motor has no control loop"))
+
+	// only one tuning runs at a time, and the loop can still be inspected while it runs
+	tuneDone := make(chan struct{})
+	go func() {
+		defer close(tuneDone)
+		m.DoCommand(context.Background(), map[string]interface{}{"command": "tune_pid", "timeout_sec": 2.0})
+	}()
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		test.That(tb, fakeMotor.PowerPct(), test.ShouldBeGreaterThan, 0)
+	})
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{"command": "tune_pid", "timeout_sec": 0.1})
+	test.That(t, err, test.ShouldBeError, errors.New("PID tuning is already running"))
+	_, err = m.DoCommand(context.Background(), map[string]interface{}{"command": "control_telemetry_csv"})
+	test.That(t, err, test.ShouldBeError, errors.New("motor has no control loop"))
+	// the tuning is still driving the motor once the loop has been inspected
+	select {
+	case <-tuneDone:
+		t.Fatal("inspecting the loop waited for the tuning to end")
+	default:
+	}
+	test.That(t, fakeMotor.PowerPct(), test.ShouldBeGreaterThan, 0)
+	<-tuneDone
+	test.That(t, fakeMotor.PowerPct(), test.ShouldEqual, 0)
+}
@@ -26,6 +26,10 @@ func init() {
 		API:        API,
 		MethodName: isPowered.String(),
 	}, newIsPoweredCollector)
+	data.RegisterCollector(data.MethodMetadata{
+		API:        API,
+		MethodName: controlLoopTelemetry.String(),
+	}, newControlLoopTelemetryCollector)
 }
 
 // SubtypeName is a constant that identifies the component resource API string "motor".
@@ -3,13 +3,16 @@ package fake
 
 import (
 	"context"
+	"math"
 
 	"github.com/edaniels/golog"
 	"github.com/golang/geo/r3"
 	geo "github.com/kellydunn/golang-geo"
+	"go.viam.com/utils"
 
 	"go.viam.com/rdk/components/movementsensor"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/sim"
 	"go.viam.com/rdk/spatialmath"
 )
 
@@ -17,8 +20,22 @@ var model = resource.DefaultModelFamily.WithModel("fake")
 
 // Config is used for converting fake movementsensor attributes.
 type Config struct {
-	resource.TriviallyValidateConfig
 	ConnectionType string `json:"connection_type,omitempty"`
+	// Simulation is the name of a shared simulated world, the sensor then reports the motion
+	// of the simulated base Base.
+	Simulation string `json:"simulation,omitempty"`
+	Base       string `json:"base,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid, a simulated sensor depends on its base.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Simulation == "" {
+		return nil, nil
+	}
+	if cfg.Base == "" {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "base")
+	}
+	return []string{cfg.Base}, nil
 }
 
 func init() {
@@ -32,9 +49,16 @@ func init() {
 				conf resource.Config,
 				logger golog.Logger,
 			) (movementsensor.MovementSensor, error) {
-				return movementsensor.MovementSensor(&MovementSensor{
-					Named: conf.ResourceName().AsNamed(),
-				}), nil
+				newConf, err := resource.NativeConfig[*Config](conf)
+				if err != nil {
+					return nil, err
+				}
+				f := &MovementSensor{Named: conf.ResourceName().AsNamed()}
+				if newConf.Simulation != "" {
+					f.world, f.releaseWorld = sim.Acquire(newConf.Simulation)
+					f.base = newConf.Base
+				}
+				return movementsensor.MovementSensor(f), nil
 			},
 		})
 }
@@ -43,16 +67,47 @@ func init() {
 type MovementSensor struct {
 	resource.Named
 	resource.AlwaysRebuild
+
+	world        *sim.World
+	releaseWorld func()
+	base         string
+}
+
+// simulatedBase returns the simulated base the sensor is attached to, nil if it is not simulated.
+func (f *MovementSensor) simulatedBase() (*sim.Base, error) {
+	if f.world == nil {
+		return nil, nil
+	}
+	return sim.BodyAs[*sim.Base](f.world, f.base)
 }
 
-// Position gets the position of a fake movementsensor.
+// Position gets the position of a fake movementsensor. A simulated sensor starts at the same
+// point with +Y pointing north.
 func (f *MovementSensor) Position(ctx context.Context, extra map[string]interface{}) (*geo.Point, float64, error) {
 	p := geo.NewPoint(40.7, -73.98)
+	b, err := f.simulatedBase()
+	if err != nil {
+		return nil, 0, err
+	}
+	if b != nil {
+		pt := b.Pose().Point()
+		distKm := math.Hypot(pt.X, pt.Y) / 1e6
+		bearing := math.Atan2(pt.X, pt.Y) * 180 / math.Pi
+		p = p.PointAtDistanceAndBearing(distKm, bearing)
+	}
 	return p, 50.5, nil
 }
 
 // LinearVelocity gets the linear velocity of a fake movementsensor.
 func (f *MovementSensor) LinearVelocity(ctx context.Context, extra map[string]interface{}) (r3.Vector, error) {
+	b, err := f.simulatedBase()
+	if err != nil {
+		return r3.Vector{}, err
+	}
+	if b != nil {
+		linear, _ := b.Velocity()
+		return r3.Vector{Y: linear}, nil
+	}
 	return r3.Vector{Y: 5.4}, nil
 }
 
@@ -63,16 +118,39 @@ func (f *MovementSensor) LinearAcceleration(ctx context.Context, extra map[strin
 
 // AngularVelocity gets the angular velocity of a fake movementsensor.
 func (f *MovementSensor) AngularVelocity(ctx context.Context, extra map[string]interface{}) (spatialmath.AngularVelocity, error) {
+	b, err := f.simulatedBase()
+	if err != nil {
+		return spatialmath.AngularVelocity{}, err
+	}
+	if b != nil {
+		_, angular := b.Velocity()
+		return spatialmath.AngularVelocity{Z: angular}, nil
+	}
 	return spatialmath.AngularVelocity{Z: 1}, nil
 }
 
 // CompassHeading gets the compass headings of a fake movementsensor.
 func (f *MovementSensor) CompassHeading(ctx context.Context, extra map[string]interface{}) (float64, error) {
+	b, err := f.simulatedBase()
+	if err != nil {
+		return 0, err
+	}
+	if b != nil {
+		// the simulated heading is counterclockwise while compass headings are clockwise
+		return math.Mod(360-b.Heading(), 360), nil
+	}
 	return 25, nil
 }
 
 // Orientation gets the orientation of a fake movementsensor.
 func (f *MovementSensor) Orientation(ctx context.Context, extra map[string]interface{}) (spatialmath.Orientation, error) {
+	b, err := f.simulatedBase()
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		return b.Pose().Orientation(), nil
+	}
 	return spatialmath.NewZeroOrientation(), nil
 }
 
@@ -106,8 +184,12 @@ func (f *MovementSensor) Properties(ctx context.Context, extra map[string]interf
 // Start returns the fix of a fake gps movementsensor.
 func (f *MovementSensor) Start(ctx context.Context) error { return nil }
 
-// Close returns the fix of a fake gps movementsensor.
+// Close releases the simulated world of the movementsensor if it is in one.
 func (f *MovementSensor) Close(ctx context.Context) error {
+	if f.releaseWorld != nil {
+		f.releaseWorld()
+		f.world, f.releaseWorld = nil, nil
+	}
 	return nil
 }
 
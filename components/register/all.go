@@ -19,4 +19,7 @@ import (
 	_ "go.viam.com/rdk/components/posetracker"
 	_ "go.viam.com/rdk/components/sensor/register"
 	_ "go.viam.com/rdk/components/servo/register"
+
+	// register models wrapping components of several APIs.
+	_ "go.viam.com/rdk/components/safeguard"
 )
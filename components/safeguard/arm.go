@@ -0,0 +1,114 @@
+package safeguard
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	pb "go.viam.com/api/component/arm/v1"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Arm guards another arm.
+type Arm struct {
+	resource.Named
+	resource.AlwaysRebuild
+	*guard
+	actual arm.Arm
+}
+
+func newArm(deps resource.Dependencies, conf resource.Config, clk clock.Clock, logger golog.Logger) (*Arm, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := newConf.checkLimits(arm.API); err != nil {
+		return nil, err
+	}
+	actual, err := arm.FromDependencies(deps, newConf.Actuator)
+	if err != nil {
+		return nil, err
+	}
+	a := &Arm{Named: conf.ResourceName().AsNamed(), actual: actual}
+	a.guard = newGuard(newConf, clk, a.stop, logger)
+	return a, nil
+}
+
+// ModelFrame returns the model of the actual arm.
+func (a *Arm) ModelFrame() referenceframe.Model {
+	return a.actual.ModelFrame()
+}
+
+// EndPosition returns the end position of the actual arm.
+func (a *Arm) EndPosition(ctx context.Context, extra map[string]interface{}) (spatialmath.Pose, error) {
+	return a.actual.EndPosition(ctx, extra)
+}
+
+// MoveToPosition plans a motion to pose and moves the arm along it so that every waypoint is checked
+// against the workspace.
+func (a *Arm) MoveToPosition(ctx context.Context, pose spatialmath.Pose, extra map[string]interface{}) error {
+	defer a.watchdog.command()()
+	if len(a.cfg.MinPosition) == 0 {
+		return a.actual.MoveToPosition(ctx, pose, extra)
+	}
+	return arm.Move(ctx, a.logger, a, pose)
+}
+
+// MoveToJointPositions moves the arm to joint positions within the workspace.
+func (a *Arm) MoveToJointPositions(ctx context.Context, positionDegs *pb.JointPositions, extra map[string]interface{}) error {
+	defer a.watchdog.command()()
+	if err := a.checkBounds(positionDegs.Values); err != nil {
+		return err
+	}
+	return a.actual.MoveToJointPositions(ctx, positionDegs, extra)
+}
+
+// JointPositions returns the joint positions of the actual arm.
+func (a *Arm) JointPositions(ctx context.Context, extra map[string]interface{}) (*pb.JointPositions, error) {
+	return a.actual.JointPositions(ctx, extra)
+}
+
+// CurrentInputs returns the current inputs of the actual arm.
+func (a *Arm) CurrentInputs(ctx context.Context) ([]referenceframe.Input, error) {
+	return a.actual.CurrentInputs(ctx)
+}
+
+// GoToInputs moves the arm to goal, which must be within the workspace.
+func (a *Arm) GoToInputs(ctx context.Context, goal []referenceframe.Input) error {
+	return a.MoveToJointPositions(ctx, a.actual.ModelFrame().ProtobufFromInput(goal), nil)
+}
+
+// Geometries returns the geometries of the actual arm.
+func (a *Arm) Geometries(ctx context.Context) ([]spatialmath.Geometry, error) {
+	return a.actual.Geometries(ctx)
+}
+
+// IsMoving returns whether the actual arm is moving.
+func (a *Arm) IsMoving(ctx context.Context) (bool, error) {
+	return a.actual.IsMoving(ctx)
+}
+
+// Stop stops the actual arm.
+func (a *Arm) Stop(ctx context.Context, extra map[string]interface{}) error {
+	a.watchdog.disarm()
+	return a.actual.Stop(ctx, extra)
+}
+
+func (a *Arm) stop(ctx context.Context) error {
+	return a.Stop(ctx, nil)
+}
+
+// DoCommand feeds the watchdog or passes the command to the actual arm.
+func (a *Arm) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return a.doCommand(ctx, a.actual, cmd)
+}
+
+// Close stops guarding the arm.
+func (a *Arm) Close(ctx context.Context) error {
+	a.watchdog.close()
+	return nil
+}
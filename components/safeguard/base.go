@@ -0,0 +1,111 @@
+package safeguard
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/base"
+	"go.viam.com/rdk/resource"
+)
+
+// Base guards another base.
+type Base struct {
+	resource.Named
+	resource.AlwaysRebuild
+	*guard
+	actual   base.Base
+	velocity *ramp
+}
+
+func newBase(deps resource.Dependencies, conf resource.Config, clk clock.Clock, logger golog.Logger) (*Base, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := newConf.checkLimits(base.API); err != nil {
+		return nil, err
+	}
+	actual, err := base.FromDependencies(deps, newConf.Actuator)
+	if err != nil {
+		return nil, err
+	}
+	b := &Base{Named: conf.ResourceName().AsNamed(), actual: actual}
+	linear, angular := newConf.MaxAcceleration, newConf.MaxAngularAcceleration
+	b.velocity = newRamp(clk, []float64{linear, linear, linear, angular, angular, angular},
+		func(ctx context.Context, values []float64) error {
+			return b.actual.SetVelocity(ctx,
+				r3.Vector{X: values[0], Y: values[1], Z: values[2]},
+				r3.Vector{X: values[3], Y: values[4], Z: values[5]},
+				nil)
+		}, logger)
+	b.guard = newGuard(newConf, clk, b.stop, logger)
+	return b, nil
+}
+
+// MoveStraight moves the base no faster than the max velocity.
+func (b *Base) MoveStraight(ctx context.Context, distanceMm int, mmPerSec float64, extra map[string]interface{}) error {
+	defer b.watchdog.command()()
+	b.velocity.reset(make([]float64, 6))
+	return b.actual.MoveStraight(ctx, distanceMm, b.limit(mmPerSec, b.cfg.MaxVelocity), extra)
+}
+
+// Spin spins the base no faster than the max angular velocity.
+func (b *Base) Spin(ctx context.Context, angleDeg, degsPerSec float64, extra map[string]interface{}) error {
+	defer b.watchdog.command()()
+	b.velocity.reset(make([]float64, 6))
+	return b.actual.Spin(ctx, angleDeg, b.limit(degsPerSec, b.cfg.MaxAngularVelocity), extra)
+}
+
+// SetPower sets the power of the actual base.
+func (b *Base) SetPower(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error {
+	defer b.watchdog.command()()
+	b.velocity.reset(make([]float64, 6))
+	return b.actual.SetPower(ctx, linear, angular, extra)
+}
+
+// SetVelocity ramps the velocity of the base to the given velocities, capped to the max velocities.
+func (b *Base) SetVelocity(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error {
+	defer b.watchdog.command()()
+	linear = limitVector(linear, b.cfg.MaxVelocity)
+	angular = limitVector(angular, b.cfg.MaxAngularVelocity)
+	return b.velocity.set(ctx, []float64{linear.X, linear.Y, linear.Z, angular.X, angular.Y, angular.Z})
+}
+
+// limitVector returns v scaled down to a norm of max if it is longer, a max of 0 is no limit.
+func limitVector(v r3.Vector, max float64) r3.Vector {
+	if norm := v.Norm(); max > 0 && norm > max {
+		return v.Mul(max / norm)
+	}
+	return v
+}
+
+// IsMoving returns whether the actual base is moving.
+func (b *Base) IsMoving(ctx context.Context) (bool, error) {
+	return b.actual.IsMoving(ctx)
+}
+
+// Stop stops the base immediately.
+func (b *Base) Stop(ctx context.Context, extra map[string]interface{}) error {
+	b.watchdog.disarm()
+	b.velocity.reset(make([]float64, 6))
+	return b.actual.Stop(ctx, extra)
+}
+
+func (b *Base) stop(ctx context.Context) error {
+	return b.Stop(ctx, nil)
+}
+
+// DoCommand feeds the watchdog or passes the command to the actual base.
+func (b *Base) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return b.doCommand(ctx, b.actual, cmd)
+}
+
+// Close stops guarding the base.
+func (b *Base) Close(ctx context.Context) error {
+	b.watchdog.close()
+	b.velocity.close()
+	return nil
+}
@@ -0,0 +1,97 @@
+package safeguard
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+)
+
+// Gantry guards another gantry.
+type Gantry struct {
+	resource.Named
+	resource.AlwaysRebuild
+	*guard
+	actual gantry.Gantry
+}
+
+func newGantry(deps resource.Dependencies, conf resource.Config, clk clock.Clock, logger golog.Logger) (*Gantry, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := newConf.checkLimits(gantry.API); err != nil {
+		return nil, err
+	}
+	actual, err := gantry.FromDependencies(deps, newConf.Actuator)
+	if err != nil {
+		return nil, err
+	}
+	g := &Gantry{Named: conf.ResourceName().AsNamed(), actual: actual}
+	g.guard = newGuard(newConf, clk, g.stop, logger)
+	return g, nil
+}
+
+// Position returns the position of the actual gantry.
+func (g *Gantry) Position(ctx context.Context, extra map[string]interface{}) ([]float64, error) {
+	return g.actual.Position(ctx, extra)
+}
+
+// MoveToPosition moves the gantry to positions within the workspace.
+func (g *Gantry) MoveToPosition(ctx context.Context, positionsMm []float64, extra map[string]interface{}) error {
+	defer g.watchdog.command()()
+	if err := g.checkBounds(positionsMm); err != nil {
+		return err
+	}
+	return g.actual.MoveToPosition(ctx, positionsMm, extra)
+}
+
+// Lengths returns the lengths of the actual gantry.
+func (g *Gantry) Lengths(ctx context.Context, extra map[string]interface{}) ([]float64, error) {
+	return g.actual.Lengths(ctx, extra)
+}
+
+// ModelFrame returns the model of the actual gantry.
+func (g *Gantry) ModelFrame() referenceframe.Model {
+	return g.actual.ModelFrame()
+}
+
+// CurrentInputs returns the current inputs of the actual gantry.
+func (g *Gantry) CurrentInputs(ctx context.Context) ([]referenceframe.Input, error) {
+	return g.actual.CurrentInputs(ctx)
+}
+
+// GoToInputs moves the gantry to goal, which must be within the workspace.
+func (g *Gantry) GoToInputs(ctx context.Context, goal []referenceframe.Input) error {
+	return g.MoveToPosition(ctx, referenceframe.InputsToFloats(goal), nil)
+}
+
+// IsMoving returns whether the actual gantry is moving.
+func (g *Gantry) IsMoving(ctx context.Context) (bool, error) {
+	return g.actual.IsMoving(ctx)
+}
+
+// Stop stops the actual gantry.
+func (g *Gantry) Stop(ctx context.Context, extra map[string]interface{}) error {
+	g.watchdog.disarm()
+	return g.actual.Stop(ctx, extra)
+}
+
+func (g *Gantry) stop(ctx context.Context) error {
+	return g.Stop(ctx, nil)
+}
+
+// DoCommand feeds the watchdog or passes the command to the actual gantry.
+func (g *Gantry) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return g.doCommand(ctx, g.actual, cmd)
+}
+
+// Close stops guarding the gantry.
+func (g *Gantry) Close(ctx context.Context) error {
+	g.watchdog.close()
+	return nil
+}
@@ -0,0 +1,133 @@
+package safeguard
+
+import (
+	"context"
+	"math"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/components/motor"
+	"go.viam.com/rdk/resource"
+)
+
+// Motor guards another motor.
+type Motor struct {
+	resource.Named
+	resource.AlwaysRebuild
+	*guard
+	actual motor.Motor
+	power  *ramp
+}
+
+func newMotor(deps resource.Dependencies, conf resource.Config, clk clock.Clock, logger golog.Logger) (*Motor, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := newConf.checkLimits(motor.API); err != nil {
+		return nil, err
+	}
+	actual, err := motor.FromDependencies(deps, newConf.Actuator)
+	if err != nil {
+		return nil, err
+	}
+	m := &Motor{Named: conf.ResourceName().AsNamed(), actual: actual}
+	m.power = newRamp(clk, []float64{newConf.MaxAcceleration}, func(ctx context.Context, values []float64) error {
+		return m.actual.SetPower(ctx, values[0], nil)
+	}, logger)
+	m.guard = newGuard(newConf, clk, m.stop, logger)
+	return m, nil
+}
+
+// SetPower ramps the power of the motor to powerPct. Only stopping is allowed when the motor has workspace
+// bounds, since nothing would stop it at them.
+func (m *Motor) SetPower(ctx context.Context, powerPct float64, extra map[string]interface{}) error {
+	defer m.watchdog.command()()
+	if powerPct == 0 {
+		return m.stop(ctx)
+	}
+	if len(m.cfg.MinPosition) != 0 {
+		return errors.New("cannot set the power of a motor with workspace bounds, use GoFor or GoTo instead")
+	}
+	return m.power.set(ctx, []float64{powerPct})
+}
+
+// GoFor moves the motor no faster than the max velocity and only if it ends within the workspace.
+func (m *Motor) GoFor(ctx context.Context, rpm, revolutions float64, extra map[string]interface{}) error {
+	defer m.watchdog.command()()
+	if len(m.cfg.MinPosition) != 0 {
+		if revolutions == 0 {
+			return errors.New("cannot move a motor with workspace bounds for an unbounded number of revolutions")
+		}
+		pos, err := m.actual.Position(ctx, nil)
+		if err != nil {
+			return err
+		}
+		dir := math.Copysign(1, rpm) * math.Copysign(1, revolutions)
+		if err := m.checkBounds([]float64{pos + dir*math.Abs(revolutions)}); err != nil {
+			return err
+		}
+	}
+	m.power.reset([]float64{0})
+	return m.actual.GoFor(ctx, m.limit(rpm, m.cfg.MaxVelocity), revolutions, extra)
+}
+
+// GoTo moves the motor to a position within the workspace no faster than the max velocity.
+func (m *Motor) GoTo(ctx context.Context, rpm, positionRevolutions float64, extra map[string]interface{}) error {
+	defer m.watchdog.command()()
+	if err := m.checkBounds([]float64{positionRevolutions}); err != nil {
+		return err
+	}
+	m.power.reset([]float64{0})
+	return m.actual.GoTo(ctx, m.limit(rpm, m.cfg.MaxVelocity), positionRevolutions, extra)
+}
+
+// ResetZeroPosition resets the zero position of the actual motor.
+func (m *Motor) ResetZeroPosition(ctx context.Context, offset float64, extra map[string]interface{}) error {
+	return m.actual.ResetZeroPosition(ctx, offset, extra)
+}
+
+// Position returns the position of the actual motor.
+func (m *Motor) Position(ctx context.Context, extra map[string]interface{}) (float64, error) {
+	return m.actual.Position(ctx, extra)
+}
+
+// Properties returns the properties of the actual motor.
+func (m *Motor) Properties(ctx context.Context, extra map[string]interface{}) (map[motor.Feature]bool, error) {
+	return m.actual.Properties(ctx, extra)
+}
+
+// IsPowered returns whether the actual motor is powered.
+func (m *Motor) IsPowered(ctx context.Context, extra map[string]interface{}) (bool, float64, error) {
+	return m.actual.IsPowered(ctx, extra)
+}
+
+// IsMoving returns whether the actual motor is moving.
+func (m *Motor) IsMoving(ctx context.Context) (bool, error) {
+	return m.actual.IsMoving(ctx)
+}
+
+// Stop stops the motor immediately.
+func (m *Motor) Stop(ctx context.Context, extra map[string]interface{}) error {
+	m.watchdog.disarm()
+	m.power.reset([]float64{0})
+	return m.actual.Stop(ctx, extra)
+}
+
+func (m *Motor) stop(ctx context.Context) error {
+	return m.Stop(ctx, nil)
+}
+
+// DoCommand feeds the watchdog or passes the command to the actual motor.
+func (m *Motor) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return m.doCommand(ctx, m.actual, cmd)
+}
+
+// Close stops guarding the motor.
+func (m *Motor) Close(ctx context.Context) error {
+	m.watchdog.close()
+	m.power.close()
+	return nil
+}
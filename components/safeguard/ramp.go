@@ -0,0 +1,137 @@
+package safeguard
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"go.viam.com/utils"
+)
+
+// rampStep is the period at which a ramp applies its command.
+const rampStep = 50 * time.Millisecond
+
+// ramp moves a command towards its target no faster than the rate limit of each of its values, applying it
+// at every step. The first step is applied when the target is set while the ramp is idle so that its errors
+// reach the caller.
+type ramp struct {
+	clock  clock.Clock
+	rates  []float64 // per second, 0 is no limit
+	apply  func(ctx context.Context, values []float64) error
+	logger golog.Logger
+
+	mu      sync.Mutex
+	current []float64
+	target  []float64
+
+	cancelCtx               context.Context
+	cancel                  func()
+	running                 bool
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+func newRamp(clk clock.Clock, rates []float64, apply func(context.Context, []float64) error, logger golog.Logger) *ramp {
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	return &ramp{
+		clock:     clk,
+		rates:     rates,
+		apply:     apply,
+		logger:    logger,
+		current:   make([]float64, len(rates)),
+		target:    make([]float64, len(rates)),
+		cancelCtx: cancelCtx,
+		cancel:    cancel,
+	}
+}
+
+// set starts moving the command towards target.
+func (r *ramp) set(ctx context.Context, target []float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy(r.target, target)
+	if r.running {
+		return nil
+	}
+	if err := r.stepLocked(ctx, rampStep); err != nil {
+		copy(r.target, r.current)
+		return err
+	}
+	if r.reachedLocked() {
+		return nil
+	}
+	r.running = true
+	ticker := r.clock.Ticker(rampStep)
+	r.activeBackgroundWorkers.Add(1)
+	utils.ManagedGo(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.cancelCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			if done := r.backgroundStep(); done {
+				return
+			}
+		}
+	}, r.activeBackgroundWorkers.Done)
+	return nil
+}
+
+// backgroundStep applies the next step and returns whether the target was reached.
+func (r *ramp) backgroundStep() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.reachedLocked() {
+		if err := r.stepLocked(r.cancelCtx, rampStep); err != nil {
+			r.logger.Errorw("failed to ramp command, holding it", "error", err)
+			copy(r.target, r.current)
+		}
+	}
+	if r.reachedLocked() {
+		r.running = false
+		return true
+	}
+	return false
+}
+
+func (r *ramp) stepLocked(ctx context.Context, dt time.Duration) error {
+	next := make([]float64, len(r.current))
+	for i := range next {
+		next[i] = r.target[i]
+		if maxDelta := r.rates[i] * dt.Seconds(); r.rates[i] > 0 && math.Abs(r.target[i]-r.current[i]) > maxDelta {
+			next[i] = r.current[i] + math.Copysign(maxDelta, r.target[i]-r.current[i])
+		}
+	}
+	if err := r.apply(ctx, next); err != nil {
+		return err
+	}
+	r.current = next
+	return nil
+}
+
+func (r *ramp) reachedLocked() bool {
+	for i := range r.current {
+		if r.current[i] != r.target[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reset makes the ramp consider the command to be values without applying it, for when the actuator
+// was commanded some other way.
+func (r *ramp) reset(values []float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy(r.current, values)
+	copy(r.target, values)
+}
+
+func (r *ramp) close() {
+	r.cancel()
+	r.activeBackgroundWorkers.Wait()
+}
@@ -0,0 +1,322 @@
+// Package safeguard implements a model which wraps another actuator and guards it by limiting the
+// speed and acceleration of its commands, rejecting commands that leave its workspace and stopping it
+// when its commands stop arriving.
+package safeguard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/base"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/motor"
+	"go.viam.com/rdk/components/servo"
+	"go.viam.com/rdk/resource"
+)
+
+// Model is the model of every safeguard, whatever the API of the actuator it wraps.
+var Model = resource.DefaultModelFamily.WithModel("safeguard")
+
+func init() {
+	resource.RegisterComponent(motor.API, Model, resource.Registration[motor.Motor, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (motor.Motor, error) {
+			return newMotor(deps, conf, clock.New(), logger)
+		},
+	})
+	resource.RegisterComponent(base.API, Model, resource.Registration[base.Base, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (base.Base, error) {
+			return newBase(deps, conf, clock.New(), logger)
+		},
+	})
+	resource.RegisterComponent(arm.API, Model, resource.Registration[arm.Arm, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (arm.Arm, error) {
+			return newArm(deps, conf, clock.New(), logger)
+		},
+	})
+	resource.RegisterComponent(gantry.API, Model, resource.Registration[gantry.Gantry, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (gantry.Gantry, error) {
+			return newGantry(deps, conf, clock.New(), logger)
+		},
+	})
+	resource.RegisterComponent(servo.API, Model, resource.Registration[servo.Servo, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (servo.Servo, error) {
+			return newServo(deps, conf, clock.New(), logger)
+		},
+	})
+}
+
+// Config is used for converting config attributes. The units of the limits depend on the API of the actuator:
+//
+//   - motor: max_velocity is in rpm and caps GoFor and GoTo. max_acceleration is in fractions of full power per
+//     second and ramps SetPower. The position bounds are a single position in revolutions, SetPower is refused
+//     when they are set since it cannot keep the motor within them.
+//   - base: max_velocity is in mm/s and max_angular_velocity in deg/s, they cap MoveStraight, Spin and SetVelocity.
+//     max_acceleration in mm/s^2 and max_angular_acceleration in deg/s^2 ramp SetVelocity. There are no position
+//     bounds.
+//   - arm: the position bounds are the joint positions in degrees.
+//   - gantry: the position bounds are the positions of the axes in mm.
+//   - servo: the position bounds are a single angle in degrees.
+//
+// A limit that the safeguard of an actuator does not enforce, such as any velocity or acceleration limit
+// of an arm or the position bounds of a base, fails the construction of the safeguard rather than being
+// ignored.
+type Config struct {
+	Actuator string `json:"actuator"`
+	// WatchdogMs is how long the actuator may go without a command before it is stopped, 0 disables the watchdog.
+	WatchdogMs             int       `json:"watchdog_ms,omitempty"`
+	MaxVelocity            float64   `json:"max_velocity,omitempty"`
+	MaxAngularVelocity     float64   `json:"max_angular_velocity,omitempty"`
+	MaxAcceleration        float64   `json:"max_acceleration,omitempty"`
+	MaxAngularAcceleration float64   `json:"max_angular_acceleration,omitempty"`
+	MinPosition            []float64 `json:"min_position,omitempty"`
+	MaxPosition            []float64 `json:"max_position,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Actuator == "" {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "actuator")
+	}
+	if cfg.WatchdogMs < 0 {
+		return nil, utils.NewConfigValidationError(path, errors.New("watchdog_ms cannot be negative"))
+	}
+	for _, limit := range cfg.limits() {
+		if limit.value < 0 {
+			return nil, utils.NewConfigValidationError(path, errors.Errorf("%s cannot be negative", limit.name))
+		}
+	}
+	if len(cfg.MinPosition) != len(cfg.MaxPosition) {
+		return nil, utils.NewConfigValidationError(path, errors.New("min_position and max_position must have the same length"))
+	}
+	for i := range cfg.MinPosition {
+		if cfg.MinPosition[i] > cfg.MaxPosition[i] {
+			return nil, utils.NewConfigValidationError(path,
+				errors.Errorf("min_position %v is greater than max_position %v", cfg.MinPosition[i], cfg.MaxPosition[i]))
+		}
+	}
+	return []string{cfg.Actuator}, nil
+}
+
+type namedLimit struct {
+	name  string
+	value float64
+}
+
+func (cfg *Config) limits() []namedLimit {
+	return []namedLimit{
+		{"max_velocity", cfg.MaxVelocity},
+		{"max_angular_velocity", cfg.MaxAngularVelocity},
+		{"max_acceleration", cfg.MaxAcceleration},
+		{"max_angular_acceleration", cfg.MaxAngularAcceleration},
+	}
+}
+
+// enforcedLimits are the limits the safeguard of an actuator of each API enforces.
+var enforcedLimits = map[resource.API]map[string]bool{
+	motor.API: {"max_velocity": true, "max_acceleration": true},
+	base.API: {
+		"max_velocity":             true,
+		"max_angular_velocity":     true,
+		"max_acceleration":         true,
+		"max_angular_acceleration": true,
+	},
+}
+
+// boundedAPIs are the APIs of the actuators whose safeguard enforces the position bounds.
+var boundedAPIs = map[resource.API]bool{
+	motor.API:  true,
+	arm.API:    true,
+	gantry.API: true,
+	servo.API:  true,
+}
+
+// checkLimits returns an error if a limit is set that the safeguard of an actuator of the given API
+// does not enforce.
+func (cfg *Config) checkLimits(api resource.API) error {
+	for _, limit := range cfg.limits() {
+		if limit.value != 0 && !enforcedLimits[api][limit.name] {
+			return errors.Errorf("%s is not enforced for %s actuators", limit.name, api.SubtypeName)
+		}
+	}
+	if len(cfg.MinPosition) != 0 && !boundedAPIs[api] {
+		return errors.Errorf("min_position and max_position are not enforced for %s actuators", api.SubtypeName)
+	}
+	return nil
+}
+
+// guard holds what every safeguard shares whatever the API of its actuator.
+type guard struct {
+	cfg      *Config
+	clock    clock.Clock
+	watchdog *watchdog
+	logger   golog.Logger
+}
+
+func newGuard(cfg *Config, clk clock.Clock, stop func(context.Context) error, logger golog.Logger) *guard {
+	return &guard{
+		cfg:      cfg,
+		clock:    clk,
+		watchdog: newWatchdog(clk, time.Duration(cfg.WatchdogMs)*time.Millisecond, stop, logger),
+		logger:   logger,
+	}
+}
+
+// checkBounds returns an error if positions is outside of the configured workspace.
+func (g *guard) checkBounds(positions []float64) error {
+	if len(g.cfg.MinPosition) == 0 {
+		return nil
+	}
+	if len(positions) != len(g.cfg.MinPosition) {
+		return errors.Errorf("expected %d positions to check against the workspace bounds got %d", len(g.cfg.MinPosition), len(positions))
+	}
+	for i, p := range positions {
+		if p < g.cfg.MinPosition[i] || p > g.cfg.MaxPosition[i] {
+			return errors.Errorf("position %v of axis %d is outside of the workspace [%v, %v]",
+				p, i, g.cfg.MinPosition[i], g.cfg.MaxPosition[i])
+		}
+	}
+	return nil
+}
+
+// limit returns v with its magnitude capped to max, a max of 0 is no limit.
+func (g *guard) limit(v, max float64) float64 {
+	if max <= 0 || v <= max && v >= -max {
+		return v
+	}
+	g.logger.Debugf("limiting %v to %v", v, max)
+	if v < 0 {
+		return -max
+	}
+	return max
+}
+
+// doCommand handles the commands of the safeguard itself and passes any other to the actuator.
+func (g *guard) doCommand(ctx context.Context, actual resource.Resource, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if name, ok := cmd["command"]; ok && name == "feed_watchdog" {
+		g.watchdog.feed()
+		return map[string]interface{}{}, nil
+	}
+	return actual.DoCommand(ctx, cmd)
+}
+
+// watchdog stops an actuator once it has gone for too long without a command.
+type watchdog struct {
+	clock   clock.Clock
+	timeout time.Duration
+	stop    func(context.Context) error
+	logger  golog.Logger
+
+	mu       sync.Mutex
+	last     time.Time
+	inFlight int
+	armed    bool
+
+	cancel                  func()
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+// newWatchdog returns a watchdog calling stop once timeout has passed without a command, a zero timeout
+// returns a nil watchdog which never stops anything.
+func newWatchdog(clk clock.Clock, timeout time.Duration, stop func(context.Context) error, logger golog.Logger) *watchdog {
+	if timeout <= 0 {
+		return nil
+	}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	w := &watchdog{
+		clock:   clk,
+		timeout: timeout,
+		stop:    stop,
+		logger:  logger,
+		last:    clk.Now(),
+		cancel:  cancel,
+	}
+	period := timeout / 4
+	if period < time.Millisecond {
+		period = time.Millisecond
+	}
+	ticker := clk.Ticker(period)
+	w.activeBackgroundWorkers.Add(1)
+	utils.ManagedGo(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-cancelCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !w.expired() {
+				continue
+			}
+			w.logger.Warnf("no command received for %v, stopping", w.timeout)
+			if err := w.stop(cancelCtx); err != nil {
+				w.logger.Errorw("failed to stop after the watchdog expired", "error", err)
+			}
+		}
+	}, w.activeBackgroundWorkers.Done)
+	return w
+}
+
+// expired returns whether the actuator should be stopped and disarms the watchdog if so.
+func (w *watchdog) expired() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.armed || w.inFlight > 0 || w.clock.Since(w.last) < w.timeout {
+		return false
+	}
+	w.armed = false
+	return true
+}
+
+// command arms the watchdog for a command that moves the actuator, the returned function must be called
+// once the command returns. The watchdog never expires while a command is running.
+func (w *watchdog) command() func() {
+	if w == nil {
+		return func() {}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armed = true
+	w.inFlight++
+	w.last = w.clock.Now()
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.inFlight--
+		w.last = w.clock.Now()
+	}
+}
+
+// feed resets the time since the last command.
+func (w *watchdog) feed() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = w.clock.Now()
+}
+
+// disarm prevents the watchdog from stopping the actuator until the next command.
+func (w *watchdog) disarm() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armed = false
+}
+
+func (w *watchdog) close() {
+	if w == nil {
+		return
+	}
+	w.cancel()
+	w.activeBackgroundWorkers.Wait()
+}
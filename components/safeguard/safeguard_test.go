@@ -0,0 +1,264 @@
+package safeguard
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	pb "go.viam.com/api/component/arm/v1"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/base"
+	"go.viam.com/rdk/components/motor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+func TestValidate(t *testing.T) {
+	deps, err := (&Config{Actuator: "m"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"m"})
+
+	for _, cfg := range []*Config{
+		{},
+		{Actuator: "m", WatchdogMs: -1},
+		{Actuator: "m", MaxVelocity: -1},
+		{Actuator: "m", MinPosition: []float64{0}},
+		{Actuator: "m", MinPosition: []float64{1}, MaxPosition: []float64{0}},
+	} {
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
+
+func TestUnenforcedLimits(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	deps := resource.Dependencies{arm.Named("actual"): inject.NewArm("actual"), motor.Named("actual"): newFakeMotor()}
+
+	conf := resource.Config{Name: "guard", ConvertedAttributes: &Config{Actuator: "actual", MaxVelocity: 10}}
+	_, err := newArm(deps, conf, clock.NewMock(), logger)
+	test.That(t, err, test.ShouldBeError, errors.New("max_velocity is not enforced for arm actuators"))
+
+	conf.ConvertedAttributes = &Config{Actuator: "actual", MaxVelocity: 10, MaxAngularAcceleration: 1}
+	_, err = newMotor(deps, conf, clock.NewMock(), logger)
+	test.That(t, err, test.ShouldBeError, errors.New("max_angular_acceleration is not enforced for motor actuators"))
+
+	deps[base.Named("actual")] = inject.NewBase("actual")
+	conf.ConvertedAttributes = &Config{Actuator: "actual", MinPosition: []float64{0}, MaxPosition: []float64{1}}
+	_, err = newBase(deps, conf, clock.NewMock(), logger)
+	test.That(t, err, test.ShouldBeError, errors.New("min_position and max_position are not enforced for base actuators"))
+	m, err := newMotor(deps, conf, clock.NewMock(), logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.Close(context.Background()), test.ShouldBeNil)
+}
+
+// fakeMotor records the power set on an injected motor.
+type fakeMotor struct {
+	*inject.Motor
+	mu    sync.Mutex
+	power float64
+	stops int
+	rpm   float64
+}
+
+func newFakeMotor() *fakeMotor {
+	m := &fakeMotor{Motor: inject.NewMotor("actual")}
+	m.SetPowerFunc = func(ctx context.Context, powerPct float64, extra map[string]interface{}) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.power = powerPct
+		return nil
+	}
+	m.StopFunc = func(ctx context.Context, extra map[string]interface{}) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.power = 0
+		m.stops++
+		return nil
+	}
+	m.PositionFunc = func(ctx context.Context, extra map[string]interface{}) (float64, error) {
+		return 5, nil
+	}
+	m.GoForFunc = func(ctx context.Context, rpm, revolutions float64, extra map[string]interface{}) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.rpm = rpm
+		return nil
+	}
+	m.GoToFunc = m.GoForFunc
+	return m
+}
+
+func (m *fakeMotor) state() (float64, int, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.power, m.stops, m.rpm
+}
+
+func TestMotorWatchdog(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+	mock := clock.NewMock()
+	actual := newFakeMotor()
+	deps := resource.Dependencies{motor.Named("actual"): actual}
+
+	m, err := newMotor(deps, resource.Config{Name: "guard", ConvertedAttributes: &Config{Actuator: "actual", WatchdogMs: 100}}, mock, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer m.Close(ctx)
+
+	// nothing is stopped until a command is sent
+	mock.Add(time.Second)
+	_, stops, _ := actual.state()
+	test.That(t, stops, test.ShouldEqual, 0)
+
+	test.That(t, m.SetPower(ctx, 0.5, nil), test.ShouldBeNil)
+	power, _, _ := actual.state()
+	test.That(t, power, test.ShouldEqual, 0.5)
+
+	mock.Add(75 * time.Millisecond)
+	_, err = m.DoCommand(ctx, map[string]interface{}{"command": "feed_watchdog"})
+	test.That(t, err, test.ShouldBeNil)
+	mock.Add(75 * time.Millisecond)
+	_, stops, _ = actual.state()
+	test.That(t, stops, test.ShouldEqual, 0)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		mock.Add(25 * time.Millisecond)
+		power, stops, _ := actual.state()
+		test.That(tb, stops, test.ShouldEqual, 1)
+		test.That(tb, power, test.ShouldEqual, 0)
+	})
+
+	// the watchdog is disarmed until the next command
+	mock.Add(time.Second)
+	_, stops, _ = actual.state()
+	test.That(t, stops, test.ShouldEqual, 1)
+}
+
+func TestMotorLimits(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+	mock := clock.NewMock()
+	actual := newFakeMotor()
+	deps := resource.Dependencies{motor.Named("actual"): actual}
+
+	conf := &Config{
+		Actuator:        "actual",
+		MaxVelocity:     60,
+		MaxAcceleration: 1,
+		MinPosition:     []float64{0},
+		MaxPosition:     []float64{10},
+	}
+	m, err := newMotor(deps, resource.Config{Name: "guard", ConvertedAttributes: conf}, mock, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer m.Close(ctx)
+
+	test.That(t, m.GoFor(ctx, -100, 2, nil), test.ShouldBeNil)
+	_, _, rpm := actual.state()
+	test.That(t, rpm, test.ShouldEqual, -60)
+	test.That(t, m.GoFor(ctx, 10, 6, nil), test.ShouldNotBeNil)
+	test.That(t, m.GoFor(ctx, 10, 0, nil), test.ShouldNotBeNil)
+	test.That(t, m.GoTo(ctx, 10, 11, nil), test.ShouldNotBeNil)
+	test.That(t, m.GoTo(ctx, 10, 10, nil), test.ShouldBeNil)
+
+	// the power cannot be set within the workspace bounds, only stopped
+	err = m.SetPower(ctx, 1, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "workspace bounds")
+	test.That(t, m.SetPower(ctx, 0, nil), test.ShouldBeNil)
+	_, stops, _ := actual.state()
+	test.That(t, stops, test.ShouldEqual, 1)
+
+	conf.MinPosition, conf.MaxPosition = nil, nil
+	m, err = newMotor(deps, resource.Config{Name: "guard", ConvertedAttributes: conf}, mock, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer m.Close(ctx)
+
+	// full power is reached after a second
+	test.That(t, m.SetPower(ctx, 1, nil), test.ShouldBeNil)
+	power, _, _ := actual.state()
+	test.That(t, power, test.ShouldAlmostEqual, 0.05)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		mock.Add(rampStep)
+		power, _, _ := actual.state()
+		test.That(tb, power, test.ShouldEqual, 1)
+	})
+
+	// stopping is never ramped
+	test.That(t, m.SetPower(ctx, 0, nil), test.ShouldBeNil)
+	power, stops, _ = actual.state()
+	test.That(t, power, test.ShouldEqual, 0)
+	test.That(t, stops, test.ShouldEqual, 2)
+}
+
+func TestBaseLimits(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+	actual := inject.NewBase("actual")
+	var mu sync.Mutex
+	var linear, angular r3.Vector
+	var speed float64
+	actual.SetVelocityFunc = func(ctx context.Context, l, a r3.Vector, extra map[string]interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		linear, angular = l, a
+		return nil
+	}
+	actual.MoveStraightFunc = func(ctx context.Context, distanceMm int, mmPerSec float64, extra map[string]interface{}) error {
+		speed = mmPerSec
+		return nil
+	}
+	actual.SpinFunc = func(ctx context.Context, angleDeg, degsPerSec float64, extra map[string]interface{}) error {
+		speed = degsPerSec
+		return nil
+	}
+	deps := resource.Dependencies{base.Named("actual"): actual}
+
+	conf := &Config{Actuator: "actual", MaxVelocity: 100, MaxAngularVelocity: 30}
+	b, err := newBase(deps, resource.Config{Name: "guard", ConvertedAttributes: conf}, clock.NewMock(), logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer b.Close(ctx)
+
+	test.That(t, b.MoveStraight(ctx, 100, 200, nil), test.ShouldBeNil)
+	test.That(t, speed, test.ShouldEqual, 100)
+	test.That(t, b.Spin(ctx, 90, -45, nil), test.ShouldBeNil)
+	test.That(t, speed, test.ShouldEqual, -30)
+
+	test.That(t, b.SetVelocity(ctx, r3.Vector{X: 300, Y: 400}, r3.Vector{Z: 10}, nil), test.ShouldBeNil)
+	mu.Lock()
+	test.That(t, linear, test.ShouldResemble, r3.Vector{X: 60, Y: 80})
+	test.That(t, angular, test.ShouldResemble, r3.Vector{Z: 10})
+	mu.Unlock()
+}
+
+func TestArmBounds(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+	actual := inject.NewArm("actual")
+	var moved *pb.JointPositions
+	actual.MoveToJointPositionsFunc = func(ctx context.Context, jp *pb.JointPositions, extra map[string]interface{}) error {
+		moved = jp
+		return nil
+	}
+	deps := resource.Dependencies{arm.Named("actual"): actual}
+
+	conf := &Config{Actuator: "actual", MinPosition: []float64{-90, 0}, MaxPosition: []float64{90, 45}}
+	a, err := newArm(deps, resource.Config{Name: "guard", ConvertedAttributes: conf}, clock.NewMock(), logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer a.Close(ctx)
+
+	test.That(t, a.MoveToJointPositions(ctx, &pb.JointPositions{Values: []float64{0, 50}}, nil), test.ShouldNotBeNil)
+	test.That(t, a.MoveToJointPositions(ctx, &pb.JointPositions{Values: []float64{0}}, nil), test.ShouldNotBeNil)
+	test.That(t, moved, test.ShouldBeNil)
+	test.That(t, a.MoveToJointPositions(ctx, &pb.JointPositions{Values: []float64{0, 45}}, nil), test.ShouldBeNil)
+	test.That(t, moved.Values, test.ShouldResemble, []float64{0, 45})
+}
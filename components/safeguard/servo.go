@@ -0,0 +1,76 @@
+package safeguard
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+
+	"go.viam.com/rdk/components/servo"
+	"go.viam.com/rdk/resource"
+)
+
+// Servo guards another servo.
+type Servo struct {
+	resource.Named
+	resource.AlwaysRebuild
+	*guard
+	actual servo.Servo
+}
+
+func newServo(deps resource.Dependencies, conf resource.Config, clk clock.Clock, logger golog.Logger) (*Servo, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := newConf.checkLimits(servo.API); err != nil {
+		return nil, err
+	}
+	actual, err := servo.FromDependencies(deps, newConf.Actuator)
+	if err != nil {
+		return nil, err
+	}
+	s := &Servo{Named: conf.ResourceName().AsNamed(), actual: actual}
+	s.guard = newGuard(newConf, clk, s.stop, logger)
+	return s, nil
+}
+
+// Move moves the servo to an angle within the workspace.
+func (s *Servo) Move(ctx context.Context, angleDeg uint32, extra map[string]interface{}) error {
+	defer s.watchdog.command()()
+	if err := s.checkBounds([]float64{float64(angleDeg)}); err != nil {
+		return err
+	}
+	return s.actual.Move(ctx, angleDeg, extra)
+}
+
+// Position returns the position of the actual servo.
+func (s *Servo) Position(ctx context.Context, extra map[string]interface{}) (uint32, error) {
+	return s.actual.Position(ctx, extra)
+}
+
+// IsMoving returns whether the actual servo is moving.
+func (s *Servo) IsMoving(ctx context.Context) (bool, error) {
+	return s.actual.IsMoving(ctx)
+}
+
+// Stop stops the actual servo.
+func (s *Servo) Stop(ctx context.Context, extra map[string]interface{}) error {
+	s.watchdog.disarm()
+	return s.actual.Stop(ctx, extra)
+}
+
+func (s *Servo) stop(ctx context.Context) error {
+	return s.Stop(ctx, nil)
+}
+
+// DoCommand feeds the watchdog or passes the command to the actual servo.
+func (s *Servo) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return s.doCommand(ctx, s.actual, cmd)
+}
+
+// Close stops guarding the servo.
+func (s *Servo) Close(ctx context.Context) error {
+	s.watchdog.close()
+	return nil
+}
@@ -48,6 +48,12 @@ func Named(name string) resource.Name {
 	return resource.NewName(API, name)
 }
 
+// FromDependencies is a helper for getting the named servo from a collection of
+// dependencies.
+func FromDependencies(deps resource.Dependencies, name string) (Servo, error) {
+	return resource.FromDependencies[Servo](deps, Named(name))
+}
+
 // FromRobot is a helper for getting the named servo from the given Robot.
 func FromRobot(r robot.Robot, name string) (Servo, error) {
 	return robot.ResourceFromRobot[Servo](r, Named(name))
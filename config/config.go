@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
 	"regexp"
 	"sync"
@@ -858,6 +859,8 @@ func ProcessConfig(in *Config, tlsCfg *TLSConfig) (*Config, error) {
 // Regex to match if a config is referencing a Package. Group is the package name.
 var packageReferenceRegex = regexp.MustCompile(`^\$\{packages\.([A-Za-z0-9_\/-]+)}(.*)`)
 
+var packageChecksumRegex = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
 // DefaultPackageVersionValue default value of the package version used when empty.
 const DefaultPackageVersionValue = "latest"
 
@@ -869,6 +872,13 @@ type PackageConfig struct {
 	Package string `json:"package"`
 	// Version of the package ID hosted by a remote PackageService. If not specified "latest" is assumed.
 	Version string `json:"version,omitempty"`
+	// Source is the URL of the archive of the package, when it is not hosted by the PackageService of
+	// the cloud. It is a local path or file URL, an HTTP(S) URL, or an OCI reference such as
+	// "oci://registry.local:5000/models/detector:v2" ("oci+http://" for registries without TLS).
+	Source string `json:"source,omitempty"`
+	// Checksum is the SHA-256 checksum of the archive of the package, as "sha256:<hex>". It is
+	// required for HTTP(S) sources and checked for all sources when set.
+	Checksum string `json:"checksum,omitempty"`
 }
 
 // Validate package config is valid.
@@ -885,6 +895,20 @@ func (p *PackageConfig) Validate(path string) error {
 		return rutils.ErrInvalidName(p.Name)
 	}
 
+	if p.Checksum != "" && !packageChecksumRegex.MatchString(p.Checksum) {
+		return utils.NewConfigValidationError(path, errors.Errorf("checksum %q must be of the form sha256:<hex>", p.Checksum))
+	}
+
+	if p.Source != "" {
+		u, err := url.Parse(p.Source)
+		if err != nil {
+			return utils.NewConfigValidationError(path, errors.Wrap(err, "invalid package source"))
+		}
+		if (u.Scheme == "http" || u.Scheme == "https") && p.Checksum == "" {
+			return utils.NewConfigValidationError(path, errors.New("packages from HTTP sources need a checksum"))
+		}
+	}
+
 	return nil
 }
 
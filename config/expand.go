@@ -0,0 +1,466 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Keys of a config file that describe how to expand it rather than being part of the config.
+const (
+	// ExpandIncludesKey lists the files the config is based on. Each is expanded and merged onto
+	// the previous one, and the config is merged onto the result.
+	ExpandIncludesKey = "includes"
+	// ExpandOverlaysKey lists the files merged onto the config, in order.
+	ExpandOverlaysKey = "overlays"
+	// ExpandVariablesKey holds the values of variables substituted into the config.
+	ExpandVariablesKey = "variables"
+	// ExpandSecretsFilesKey lists files holding the values of variables, either as a JSON object
+	// or as KEY=VALUE lines.
+	ExpandSecretsFilesKey = "secrets_files"
+)
+
+// expandDeleteKey marks an entry of a named list that an overlay removes.
+const expandDeleteKey = "$delete"
+
+var (
+	expandKeys = []string{ExpandIncludesKey, ExpandOverlaysKey, ExpandVariablesKey, ExpandSecretsFilesKey}
+
+	// variableRegex matches $NAME, ${NAME}, ${NAME-default} and ${NAME:-default}. Anything else
+	// starting with a $, such as a package reference, is left as is.
+	variableRegex     = regexp.MustCompile(`\$(?:([A-Za-z_][A-Za-z0-9_]*)|\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\})`)
+	placeholderRegex  = regexp.MustCompile(`\$\{[^}]*\}`)
+	variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// configFile is a config file along with the files it includes and is overlaid by.
+type configFile struct {
+	path         string
+	raw          []byte
+	includes     []*configFile
+	overlays     []*configFile
+	variables    map[string]string
+	secretsFiles []string
+}
+
+// ExpandFile reads the config file at the given path and returns it as JSON with its includes
+// and overlays merged in and variables substituted. Variables are looked up in the environment
+// first, then in the secrets files and last in the variables of the config files, with files
+// merged later taking precedence over earlier ones. An unset ${NAME} is replaced by its default,
+// if it has one, and any other unset variable by nothing, as envsubst does. The $delete key of
+// overlays is not a variable.
+//
+// Objects are merged key by key, and a null value removes the key. Lists of objects that all have
+// a name, such as components, are merged by name, and an entry with "$delete": true removes the
+// entry of the same name. Any other value replaces the one it is merged onto.
+func ExpandFile(path string) ([]byte, error) {
+	expanded, _, err := expandFile(path)
+	return expanded, err
+}
+
+// expandFile is ExpandFile that also returns the paths of all the files read to expand the config.
+func expandFile(path string) ([]byte, []string, error) {
+	root, err := loadConfigFile(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	expanded, err := root.expandAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	return expanded, root.paths(), nil
+}
+
+// expandSource is ExpandFile for a config file that holds the given source rather than what is on
+// disk, such as a config kept in a History. The files it includes and is overlaid by are read from
+// disk.
+func expandSource(path string, source []byte) ([]byte, error) {
+	root, err := loadConfigSource(path, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	return root.expandAll()
+}
+
+// expandAll returns the file as JSON with its includes and overlays merged in and variables
+// substituted.
+func (f *configFile) expandAll() ([]byte, error) {
+	// a config that is not expanded is only substituted so that it reads exactly as before
+	if len(f.includes) == 0 && len(f.overlays) == 0 && len(f.variables) == 0 && len(f.secretsFiles) == 0 {
+		return []byte(substituteVariables(string(f.raw), environ())), nil
+	}
+
+	vars := map[string]string{}
+	f.collectVariables(vars)
+	secrets := map[string]string{}
+	if err := f.collectSecrets(secrets); err != nil {
+		return nil, err
+	}
+	for name, value := range secrets {
+		vars[name] = value
+	}
+	for name, value := range environ() {
+		vars[name] = value
+	}
+
+	doc, err := f.expand(vars)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+func loadConfigFile(path string, stack []string) (*configFile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, included := range stack {
+		if included == abs {
+			return nil, errors.Errorf("config files include each other: %s", strings.Join(append(stack[i:], abs), " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	//nolint:gosec
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return loadConfigSource(path, raw, stack)
+}
+
+// loadConfigSource loads the config file at the given path as holding the given source, along with
+// the files it includes and is overlaid by. The stack holds the absolute paths of the file and of
+// the files including it, or is nil for a file no other includes.
+func loadConfigSource(path string, raw []byte, stack []string) (*configFile, error) {
+	if stack == nil {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		stack = []string{abs}
+	}
+	file := &configFile{path: path, raw: raw}
+
+	// only the keys describing the expansion are needed here, and they cannot hold variables, so
+	// placeholders are replaced to keep them from breaking the JSON
+	var directives struct {
+		Includes     []string                   `json:"includes"`
+		Overlays     []string                   `json:"overlays"`
+		Variables    map[string]json.RawMessage `json:"variables"`
+		SecretsFiles []string                   `json:"secrets_files"`
+	}
+	if err := json.Unmarshal(placeholderRegex.ReplaceAll(raw, []byte("null")), &directives); err != nil {
+		// not a config that can be expanded; decoding it reports what is wrong with it
+		//nolint:nilerr
+		return file, nil
+	}
+
+	dir := filepath.Dir(path)
+	for _, include := range directives.Includes {
+		included, err := loadConfigFile(resolveRelative(dir, include), stack)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot include %q in %s", include, path)
+		}
+		file.includes = append(file.includes, included)
+	}
+	for _, overlay := range directives.Overlays {
+		overlaid, err := loadConfigFile(resolveRelative(dir, overlay), stack)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot overlay %q on %s", overlay, path)
+		}
+		file.overlays = append(file.overlays, overlaid)
+	}
+	if len(directives.Variables) > 0 {
+		file.variables = map[string]string{}
+		for name, value := range directives.Variables {
+			if !variableNameRegex.MatchString(name) {
+				return nil, errors.Errorf("invalid variable name %q in %s", name, path)
+			}
+			var str string
+			if err := json.Unmarshal(value, &str); err != nil {
+				// numbers, booleans and objects are substituted as their JSON
+				str = string(value)
+			}
+			file.variables[name] = str
+		}
+	}
+	for _, secretsFile := range directives.SecretsFiles {
+		file.secretsFiles = append(file.secretsFiles, resolveRelative(dir, secretsFile))
+	}
+	return file, nil
+}
+
+func resolveRelative(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// paths returns the paths of the file and of all the files it includes and is overlaid by.
+func (f *configFile) paths() []string {
+	var paths []string
+	f.walk(func(file *configFile) {
+		paths = append(paths, file.path)
+		paths = append(paths, file.secretsFiles...)
+	})
+	return paths
+}
+
+// walk calls fn on the file and all the files it includes and is overlaid by, in the order they
+// are merged.
+func (f *configFile) walk(fn func(file *configFile)) {
+	for _, include := range f.includes {
+		include.walk(fn)
+	}
+	fn(f)
+	for _, overlay := range f.overlays {
+		overlay.walk(fn)
+	}
+}
+
+func (f *configFile) collectVariables(vars map[string]string) {
+	f.walk(func(file *configFile) {
+		for name, value := range file.variables {
+			vars[name] = value
+		}
+	})
+}
+
+func (f *configFile) collectSecrets(secrets map[string]string) error {
+	var err error
+	f.walk(func(file *configFile) {
+		for _, path := range file.secretsFiles {
+			if err != nil {
+				return
+			}
+			err = readSecretsFile(path, secrets)
+		}
+	})
+	return err
+}
+
+// expand returns the file with its includes and overlays merged in and variables substituted.
+func (f *configFile) expand(vars map[string]string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	for _, include := range f.includes {
+		included, err := include.expand(vars)
+		if err != nil {
+			return nil, err
+		}
+		doc = mergeConfigObjects(doc, included)
+	}
+
+	var own map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(substituteVariables(string(f.raw), vars)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&own); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s after substituting variables", f.path)
+	}
+	for _, key := range expandKeys {
+		delete(own, key)
+	}
+	doc = mergeConfigObjects(doc, own)
+
+	for _, overlay := range f.overlays {
+		overlaid, err := overlay.expand(vars)
+		if err != nil {
+			return nil, err
+		}
+		doc = mergeConfigObjects(doc, overlaid)
+	}
+	return doc, nil
+}
+
+func substituteVariables(text string, vars map[string]string) string {
+	return variableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		if match == expandDeleteKey {
+			return match
+		}
+		groups := variableRegex.FindStringSubmatch(match)
+		name := groups[1] + groups[2]
+		value, ok := vars[name]
+		switch {
+		case groups[3] == "-" && !ok, groups[3] == ":-" && value == "":
+			return groups[4]
+		default:
+			return value
+		}
+	})
+}
+
+func environ() map[string]string {
+	env := map[string]string{}
+	for _, pair := range os.Environ() {
+		if name, value, ok := strings.Cut(pair, "="); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// readSecretsFile reads variables from either a JSON object or lines of KEY=VALUE, where empty
+// lines and lines starting with # are skipped.
+func readSecretsFile(path string, secrets map[string]string) error {
+	//nolint:gosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "cannot read secrets file")
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var values map[string]interface{}
+		if err := json.Unmarshal(trimmed, &values); err != nil {
+			return errors.Wrapf(err, "cannot decode secrets file %s", path)
+		}
+		for name, value := range values {
+			if str, ok := value.(string); ok {
+				secrets[name] = str
+				continue
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			secrets[name] = string(encoded)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || !variableNameRegex.MatchString(name) {
+			return errors.Errorf("secrets file %s line %d is not KEY=VALUE", path, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		secrets[name] = value
+	}
+	return scanner.Err()
+}
+
+// mergeConfigObjects merges the overlay onto the base, modifying and returning the base.
+func mergeConfigObjects(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = map[string]interface{}{}
+	}
+	for key, value := range overlay {
+		if value == nil {
+			delete(base, key)
+			continue
+		}
+		base[key] = mergeConfigValues(base[key], value)
+	}
+	return base
+}
+
+func mergeConfigValues(base, overlay interface{}) interface{} {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		if base, ok := base.(map[string]interface{}); ok {
+			return mergeConfigObjects(base, overlay)
+		}
+		return removeDeleted(overlay)
+	case []interface{}:
+		base, ok := base.([]interface{})
+		if ok && isNamedList(base) && isNamedList(overlay) {
+			return mergeNamedLists(base, overlay)
+		}
+		return removeDeleted(overlay)
+	default:
+		return overlay
+	}
+}
+
+func isNamedList(list []interface{}) bool {
+	for _, elem := range list {
+		obj, ok := elem.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := obj["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeNamedLists(base, overlay []interface{}) []interface{} {
+	index := map[string]int{}
+	for i, elem := range base {
+		index[elem.(map[string]interface{})["name"].(string)] = i
+	}
+	deleted := map[int]bool{}
+	for _, elem := range overlay {
+		obj := elem.(map[string]interface{})
+		i, ok := index[obj["name"].(string)]
+		if obj[expandDeleteKey] == true {
+			if ok {
+				deleted[i] = true
+			}
+			continue
+		}
+		if !ok {
+			index[obj["name"].(string)] = len(base)
+			base = append(base, obj)
+			continue
+		}
+		if deleted[i] {
+			// removed and added again by the same overlay
+			delete(deleted, i)
+			base[i] = obj
+			continue
+		}
+		base[i] = mergeConfigObjects(base[i].(map[string]interface{}), obj)
+	}
+
+	merged := make([]interface{}, 0, len(base))
+	for i, elem := range base {
+		if !deleted[i] {
+			merged = append(merged, removeDeleted(elem))
+		}
+	}
+	return merged
+}
+
+// removeDeleted removes the deletion markers and null values from a value that is not merged onto
+// anything.
+func removeDeleted(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range value {
+			if elem == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = removeDeleted(elem)
+		}
+		return value
+	case []interface{}:
+		kept := value[:0]
+		for _, elem := range value {
+			if obj, ok := elem.(map[string]interface{}); ok && obj[expandDeleteKey] == true {
+				continue
+			}
+			kept = append(kept, removeDeleted(elem))
+		}
+		return kept
+	default:
+		return value
+	}
+}
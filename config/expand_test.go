@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	test.That(t, os.MkdirAll(filepath.Dir(path), 0o700), test.ShouldBeNil)
+	test.That(t, os.WriteFile(path, []byte(content), 0o600), test.ShouldBeNil)
+}
+
+func TestExpandFile(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "fleet", "base.json"), `{
+		"variables": {"CAMERA_FPS": 30, "SERIAL": "unset"},
+		"components": [
+			{"name": "arm", "type": "arm", "model": "fake", "attributes": {"serial": "${SERIAL}", "speed": 10}},
+			{"name": "camera", "type": "camera", "model": "fake", "attributes": {"fps": ${CAMERA_FPS}}},
+			{"name": "gripper", "type": "gripper", "model": "fake"}
+		],
+		"network": {"bind_address": ":8080"},
+		"packages": [{"name": "labels", "package": "labels", "source": "${LABELS_DIR:-/opt}/labels.tar.gz"}]
+	}`)
+	writeConfigFile(t, filepath.Join(dir, "fleet", "secrets.env"), "# robot 12\nexport SERIAL=\"A-12\"\nTOKEN=abc\n")
+	writeConfigFile(t, filepath.Join(dir, "calibration.json"), `{
+		"components": [{"name": "arm", "attributes": {"offset": 0.25}}]
+	}`)
+	robot := filepath.Join(dir, "robot.json")
+	writeConfigFile(t, robot, `{
+		"includes": ["fleet/base.json"],
+		"overlays": ["calibration.json"],
+		"secrets_files": ["fleet/secrets.env"],
+		"components": [
+			{"name": "gripper", "$delete": true},
+			{"name": "arm", "attributes": {"speed": null, "token": "$TOKEN", "model_path": "${packages.labels}/arm.json"}},
+			{"name": "base", "type": "base", "model": "fake"}
+		],
+		"network": {"fqdn": "robot-12"}
+	}`)
+	t.Setenv("CAMERA_FPS", "15")
+
+	expanded, files, err := expandFile(robot)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, files, test.ShouldResemble, []string{
+		filepath.Join(dir, "fleet", "base.json"),
+		robot,
+		filepath.Join(dir, "fleet", "secrets.env"),
+		filepath.Join(dir, "calibration.json"),
+	})
+
+	var doc map[string]interface{}
+	test.That(t, json.Unmarshal(expanded, &doc), test.ShouldBeNil)
+	for _, key := range expandKeys {
+		test.That(t, doc, test.ShouldNotContainKey, key)
+	}
+	test.That(t, doc["network"], test.ShouldResemble, map[string]interface{}{"bind_address": ":8080", "fqdn": "robot-12"})
+	test.That(t, doc["packages"].([]interface{})[0].(map[string]interface{})["source"], test.ShouldEqual, "/opt/labels.tar.gz")
+
+	components := doc["components"].([]interface{})
+	test.That(t, components, test.ShouldHaveLength, 3)
+	arm := components[0].(map[string]interface{})
+	test.That(t, arm["name"], test.ShouldEqual, "arm")
+	test.That(t, arm["attributes"], test.ShouldResemble, map[string]interface{}{
+		"serial":     "A-12",
+		"token":      "abc",
+		"offset":     0.25,
+		"model_path": "${packages.labels}/arm.json",
+	})
+	camera := components[1].(map[string]interface{})
+	test.That(t, camera["attributes"], test.ShouldResemble, map[string]interface{}{"fps": 15.0})
+	test.That(t, components[2].(map[string]interface{})["name"], test.ShouldEqual, "base")
+
+	// the expanded config is read like any other
+	cfg, err := ReadLocalConfig(context.Background(), robot, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cfg.ConfigFilePath, test.ShouldEqual, robot)
+	test.That(t, cfg.Components, test.ShouldHaveLength, 3)
+	test.That(t, cfg.Components[0].Attributes["serial"], test.ShouldEqual, "A-12")
+	test.That(t, cfg.Network.FQDN, test.ShouldEqual, "robot-12")
+	test.That(t, cfg.Packages[0].Source, test.ShouldEqual, "/opt/labels.tar.gz")
+}
+
+func TestExpandFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	// a config without includes, overlays or variables is only substituted
+	plain := filepath.Join(dir, "plain.json")
+	writeConfigFile(t, plain, `{"network": {"fqdn": "${FQDN}"}, "components": [{"name": "${packages.x}"}]}`)
+	t.Setenv("FQDN", "robot")
+	expanded, err := ExpandFile(plain)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(expanded), test.ShouldEqual, `{"network": {"fqdn": "robot"}, "components": [{"name": "${packages.x}"}]}`)
+
+	// unset variables without a default are replaced by nothing
+	unset := filepath.Join(dir, "unset.json")
+	writeConfigFile(t, unset, `{"network": {"fqdn": "a$EXPAND_TEST_UNSET-${EXPAND_TEST_UNSET}-${EXPAND_TEST_UNSET:-c}"}}`)
+	expanded, err = ExpandFile(unset)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(expanded), test.ShouldEqual, `{"network": {"fqdn": "a--c"}}`)
+
+	writeConfigFile(t, filepath.Join(dir, "a.json"), `{"includes": ["b.json"]}`)
+	writeConfigFile(t, filepath.Join(dir, "b.json"), `{"overlays": ["a.json"]}`)
+	_, err = ExpandFile(filepath.Join(dir, "a.json"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "include each other")
+
+	writeConfigFile(t, filepath.Join(dir, "missing.json"), `{"includes": ["nowhere.json"]}`)
+	_, err = ExpandFile(filepath.Join(dir, "missing.json"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "nowhere.json")
+
+	writeConfigFile(t, filepath.Join(dir, "bad-secrets.env"), "not a variable\n")
+	writeConfigFile(t, filepath.Join(dir, "secrets.json"), `{"secrets_files": ["bad-secrets.env"]}`)
+	_, err = ExpandFile(filepath.Join(dir, "secrets.json"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "line 1")
+
+	writeConfigFile(t, filepath.Join(dir, "variables.json"), `{"variables": {"not-a-name": 1}}`)
+	_, err = ExpandFile(filepath.Join(dir, "variables.json"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestMergeConfigValues(t *testing.T) {
+	base := map[string]interface{}{
+		"tags":  []interface{}{"a", "b"},
+		"named": []interface{}{map[string]interface{}{"name": "x", "v": 1.0}},
+		"keep":  "yes",
+	}
+	merged := mergeConfigObjects(base, map[string]interface{}{
+		"tags": []interface{}{"c"},
+		"named": []interface{}{
+			map[string]interface{}{"name": "x", "$delete": true},
+			map[string]interface{}{"name": "x", "v": 2.0},
+		},
+		"keep": nil,
+		"new":  map[string]interface{}{"a": nil, "b": 1.0},
+	})
+	test.That(t, merged, test.ShouldResemble, map[string]interface{}{
+		"tags":  []interface{}{"c"},
+		"named": []interface{}{map[string]interface{}{"name": "x", "v": 2.0}},
+		"new":   map[string]interface{}{"b": 1.0},
+	})
+}
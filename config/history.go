@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/utils/artifact"
+)
+
+// DefaultHistoryDir is where the configs applied to a robot are kept by default.
+var DefaultHistoryDir = filepath.Join(viamDotDir, "config_history")
+
+// A History keeps the configs most recently applied to a robot on disk, so that the robot can go
+// back to one of them. Configs are kept numbered by version as the source they were read from,
+// that is the config file as written, before its includes, overlays and variables are expanded, or
+// the config from the cloud as cached. The source of a cloud config holds the secret of the robot
+// part.
+type History struct {
+	mu          sync.Mutex
+	dir         string
+	maxVersions int
+}
+
+// A HistoryEntry is a config kept in a History.
+type HistoryEntry struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	// ConfigFilePath is the file the config was read from, if it was not read from the cloud.
+	ConfigFilePath string `json:"config_file_path,omitempty"`
+	// CloudID is the ID of the robot part the config was read from, if it was read from the cloud.
+	CloudID string `json:"cloud_id,omitempty"`
+	// Failed is set when too many resources failed to build with the config.
+	Failed bool `json:"failed,omitempty"`
+	// Source is the config as read from its file or the cloud.
+	Source string `json:"source"`
+}
+
+// NewHistory returns a History keeping up to maxVersions configs in the given directory, or all of
+// them if maxVersions is 0.
+func NewHistory(dir string, maxVersions int) *History {
+	return &History{dir: dir, maxVersions: maxVersions}
+}
+
+// Record keeps the given config, which must have been read from a file or from the cloud, as the
+// latest version, and returns its entry. If the config is the same as the latest version, that
+// version is returned instead. It should be called before the robot is reconfigured with the
+// config, since reconfiguring may change it.
+func (h *History) Record(cfg *Config, logger golog.Logger) (*HistoryEntry, error) {
+	entry := &HistoryEntry{Version: 1, Time: time.Now(), ConfigFilePath: cfg.ConfigFilePath}
+	var source []byte
+	var err error
+	switch {
+	case cfg.Cloud != nil:
+		entry.CloudID = cfg.Cloud.ID
+		//nolint:gosec
+		source, err = os.ReadFile(getCloudCacheFilePath(cfg.Cloud.ID))
+	case cfg.ConfigFilePath != "":
+		//nolint:gosec
+		source, err = os.ReadFile(cfg.ConfigFilePath)
+	default:
+		return nil, errors.New("only configs read from a file or from the cloud can be kept")
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry.Source = string(source)
+	unprocessed, err := entry.unprocessed()
+	if err != nil {
+		return nil, err
+	}
+	// the source may have changed since the config was read
+	processed, err := processConfig(unprocessed, cfg.Cloud != nil, logger)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := DiffConfigs(*cfg, *processed, false)
+	if err != nil {
+		return nil, err
+	}
+	if !diff.ResourcesEqual {
+		return nil, errors.New("config changed at its source since it was read")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries, err := h.entries()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		latest := entries[len(entries)-1]
+		if latest.ConfigFilePath == entry.ConfigFilePath && latest.CloudID == entry.CloudID && latest.Source == entry.Source {
+			return latest, nil
+		}
+		entry.Version = latest.Version + 1
+	}
+	if err := h.store(entry); err != nil {
+		return nil, err
+	}
+	for h.maxVersions > 0 && len(entries) >= h.maxVersions {
+		if err := os.Remove(h.entryPath(entries[0].Version)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		entries = entries[1:]
+	}
+	return entry, nil
+}
+
+// MarkFailed marks the given version as one that too many resources failed to build with, so that
+// it is not rolled back to.
+func (h *History) MarkFailed(version int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, err := h.entry(version)
+	if err != nil {
+		return err
+	}
+	entry.Failed = true
+	return h.store(entry)
+}
+
+// Entries returns all the kept configs, oldest first.
+func (h *History) Entries() ([]*HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entries()
+}
+
+// Entry returns the kept config of the given version.
+func (h *History) Entry(version int) (*HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entry(version)
+}
+
+// LastGood returns the latest config before the given version that is not marked as failed, or
+// nil if there is none.
+func (h *History) LastGood(before int) (*HistoryEntry, error) {
+	entries, err := h.Entries()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Version < before && !entries[i].Failed {
+			return entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Process processes the kept config. The files a config file includes or is overlaid by, and the
+// variables it uses, are read as they are now.
+func (e *HistoryEntry) Process(logger golog.Logger) (*Config, error) {
+	unprocessed, err := e.unprocessed()
+	if err != nil {
+		return nil, err
+	}
+	return processConfig(unprocessed, e.CloudID != "", logger)
+}
+
+// unprocessed decodes the source of the kept config, expanding it if it is that of a config file.
+func (e *HistoryEntry) unprocessed() (*Config, error) {
+	data := []byte(e.Source)
+	if e.CloudID == "" {
+		var err error
+		data, err = expandSource(e.ConfigFilePath, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	unprocessed := &Config{ConfigFilePath: e.ConfigFilePath}
+	if err := json.Unmarshal(data, unprocessed); err != nil {
+		return nil, errors.Wrap(err, "cannot decode config")
+	}
+	return unprocessed, nil
+}
+
+func (h *History) entryPath(version int) string {
+	return filepath.Join(h.dir, fmt.Sprintf("%d.json", version))
+}
+
+func (h *History) entry(version int) (*HistoryEntry, error) {
+	//nolint:gosec
+	data, err := os.ReadFile(h.entryPath(version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no config version %d is kept", version)
+		}
+		return nil, err
+	}
+	var entry HistoryEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Wrapf(err, "cannot decode config version %d", version)
+	}
+	return &entry, nil
+}
+
+func (h *History) entries() ([]*HistoryEntry, error) {
+	files, err := os.ReadDir(h.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var versions []int
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	entries := make([]*HistoryEntry, 0, len(versions))
+	for _, version := range versions {
+		entry, err := h.entry(version)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (h *History) store(entry *HistoryEntry) error {
+	if err := os.MkdirAll(h.dir, 0o700); err != nil {
+		return err
+	}
+	md, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return artifact.AtomicStore(h.entryPath(entry.Version), bytes.NewReader(md), strconv.Itoa(entry.Version))
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+)
+
+func TestHistory(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "robot.json")
+	history := NewHistory(filepath.Join(dir, "history"), 2)
+
+	record := func(content string) *HistoryEntry {
+		t.Helper()
+		writeConfigFile(t, path, content)
+		cfg, err := ReadLocalConfig(context.Background(), path, logger)
+		test.That(t, err, test.ShouldBeNil)
+		entry, err := history.Record(cfg, logger)
+		test.That(t, err, test.ShouldBeNil)
+		return entry
+	}
+
+	first := record(`{"components": [{"name": "arm", "type": "arm", "model": "fake"}]}`)
+	test.That(t, first.Version, test.ShouldEqual, 1)
+	test.That(t, first.ConfigFilePath, test.ShouldEqual, path)
+
+	// the same config is not kept twice
+	same := record(`{"components": [{"name": "arm", "type": "arm", "model": "fake"}]}`)
+	test.That(t, same.Version, test.ShouldEqual, 1)
+
+	second := record(`{"components": [{"name": "arm", "type": "arm", "model": "fake"}, {"name": "base", "type": "base", "model": "fake"}]}`)
+	test.That(t, second.Version, test.ShouldEqual, 2)
+	third := record(`{"components": [{"name": "base", "type": "base", "model": "fake"}]}`)
+	test.That(t, third.Version, test.ShouldEqual, 3)
+
+	// only the latest two are kept
+	entries, err := history.Entries()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, entries, test.ShouldHaveLength, 2)
+	test.That(t, entries[0].Version, test.ShouldEqual, 2)
+	test.That(t, entries[1].Version, test.ShouldEqual, 3)
+	_, err = history.Entry(1)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	good, err := history.LastGood(3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, good.Version, test.ShouldEqual, 2)
+	cfg, err := good.Process(logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cfg.ConfigFilePath, test.ShouldEqual, path)
+	test.That(t, cfg.Components, test.ShouldHaveLength, 2)
+
+	test.That(t, history.MarkFailed(2), test.ShouldBeNil)
+	failed, err := history.Entry(2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, failed.Failed, test.ShouldBeTrue)
+	good, err = history.LastGood(3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, good, test.ShouldBeNil)
+
+	// a config whose file changed after it was read cannot be kept
+	cfg, err = ReadLocalConfig(context.Background(), path, logger)
+	test.That(t, err, test.ShouldBeNil)
+	writeConfigFile(t, path, `{"components": [{"name": "gripper", "type": "gripper", "model": "fake"}]}`)
+	_, err = history.Record(cfg, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "changed at its source")
+
+	_, err = history.Record(&Config{}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestHistoryKeepsSource(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "base.json"), `{"components": [{"name": "arm", "type": "arm", "model": "fake"}]}`)
+	path := filepath.Join(dir, "robot.json")
+	source := `{"includes": ["base.json"], "components": [{"name": "base", "type": "base", "model": "fake",
+		"attributes": {"token": "${HISTORY_TEST_TOKEN}"}}]}`
+	writeConfigFile(t, path, source)
+	t.Setenv("HISTORY_TEST_TOKEN", "secret")
+	history := NewHistory(filepath.Join(dir, "history"), 0)
+
+	cfg, err := ReadLocalConfig(context.Background(), path, logger)
+	test.That(t, err, test.ShouldBeNil)
+	entry, err := history.Record(cfg, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	// the file is kept as written, without its includes and variables expanded
+	kept, err := history.Entry(entry.Version)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kept.Source, test.ShouldEqual, source)
+	test.That(t, kept.Source, test.ShouldNotContainSubstring, "secret")
+
+	cfg, err = kept.Process(logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cfg.Components, test.ShouldHaveLength, 2)
+	test.That(t, cfg.Components[1].Attributes["token"], test.ShouldEqual, "secret")
+}
@@ -1,22 +1,45 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"regexp"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
-var moduleNameRegEx = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+var (
+	moduleNameRegEx = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	envVarNameRegEx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
 
 const reservedModuleName = "parent"
 
+// checkedBundles holds the result of checking each module bundle along with the stamp of the bundle
+// it was checked at, so that a bundle is only read again once it changes.
+var (
+	checkedBundlesMu sync.Mutex
+	checkedBundles   = map[string]checkedBundle{}
+)
+
+type checkedBundle struct {
+	stamp string
+	err   error
+}
+
 // Module represents an external resource module, with a path to the binary module file.
 type Module struct {
 	// Name is an arbitrary name used to identify the module, and is used to name it's socket as well.
 	Name string `json:"name"`
 	// ExePath is the path (either absolute, or relative to the working directory) to the executable module file.
 	ExePath string `json:"executable_path"`
+	// Bundle is the path or file URL of a module bundle to install the module from instead of
+	// ExePath, or of a directory holding the bundle as <name>.tar.gz. The module runs the entrypoint
+	// of the manifest of the bundle.
+	Bundle string `json:"bundle,omitempty"`
 	// LogLevel represents the level at which the module should log its messages. It will be passed as a commandline
 	// argument "log-level" (i.e. preceded by "--log-level=") to the module executable. If unset or set to an empty
 	// string, "--log-level=debug" will be passed to the module executable if the server was started with "-debug".
@@ -25,12 +48,143 @@ type Module struct {
 	// value besides "" or "debug" is used for LogLevel ("log_level" in JSON). In other words, setting a LogLevel
 	// of something like "info" will ignore the debug setting on the server.
 	LogLevel string `json:"log_level"`
+	// Environment holds environment variables set for the module in addition to those of the server.
+	Environment map[string]string `json:"env,omitempty"`
+	// Args are extra arguments passed to the module executable after the socket path and log level.
+	Args []string `json:"args,omitempty"`
+	// WorkingDir is the directory the module runs in, that of the server if unset.
+	WorkingDir string `json:"working_dir,omitempty"`
+	// Limits bounds the resources the module process can use.
+	Limits *ModuleLimits `json:"limits,omitempty"`
+	// HealthCheck enables periodic checks that the module, and optionally its resources, still
+	// respond, restarting it when they don't.
+	HealthCheck *ModuleHealthCheck `json:"health_check,omitempty"`
+	// Watch restarts the module whenever its executable, or a file under WatchDir if set, changes.
+	// It is meant for developing modules locally, and saves editing the config after each build.
+	Watch bool `json:"watch,omitempty"`
+	// WatchDir is a directory to watch instead of the executable, e.g. that of a module that is run
+	// by a script or interpreter.
+	WatchDir string `json:"watch_dir,omitempty"`
+
+	// bundleStamp identifies the bundle file, by its size and modification time, as of validation.
+	// A bundle replaced in place then makes the module differ from its previous config, which
+	// reconfigures it and so installs the new bundle.
+	bundleStamp string
+}
+
+// ModuleHealthCheck configures the health checks of a module. Zero values are replaced by defaults.
+//
+// The module is checked with a Ready RPC every interval, and restarted once the number of checks in
+// a row that failed or timed out reaches the failure threshold. When ProbeResources is set, each of
+// its resources is also probed with a DoCommand of the "liveness_probe" command, which a resource
+// answering with anything but a timeout passes, so resources need not implement the command.
+type ModuleHealthCheck struct {
+	// IntervalSec is the time between checks, 10 seconds by default.
+	IntervalSec float64 `json:"interval_sec,omitempty"`
+	// TimeoutSec is how long the module has to answer a check, 5 seconds by default.
+	TimeoutSec float64 `json:"timeout_sec,omitempty"`
+	// FailureThreshold is the number of checks in a row a module or one of its resources fails
+	// before the module is restarted, 3 by default.
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+	// ProbeResources enables the liveness probes of the resources of the module.
+	ProbeResources bool `json:"probe_resources,omitempty"`
+	// ResourceTimeoutsSec are the timeouts of the probes of resources by their name, TimeoutSec for
+	// the other resources.
+	ResourceTimeoutsSec map[string]float64 `json:"resource_timeouts_sec,omitempty"`
+}
+
+// Default health check settings.
+const (
+	DefaultModuleHealthCheckInterval         = 10 * time.Second
+	DefaultModuleHealthCheckTimeout          = 5 * time.Second
+	DefaultModuleHealthCheckFailureThreshold = 3
+)
+
+// Interval returns the time between checks.
+func (hc *ModuleHealthCheck) Interval() time.Duration {
+	return secondsOrDefault(hc.IntervalSec, DefaultModuleHealthCheckInterval)
+}
+
+// Timeout returns how long the module has to answer a check.
+func (hc *ModuleHealthCheck) Timeout() time.Duration {
+	return secondsOrDefault(hc.TimeoutSec, DefaultModuleHealthCheckTimeout)
+}
+
+// ResourceTimeout returns how long the named resource has to answer a probe.
+func (hc *ModuleHealthCheck) ResourceTimeout(name string) time.Duration {
+	if sec, ok := hc.ResourceTimeoutsSec[name]; ok && sec > 0 {
+		return time.Duration(sec * float64(time.Second))
+	}
+	return hc.Timeout()
+}
+
+// Failures returns the number of checks in a row that must fail for the module to be restarted.
+func (hc *ModuleHealthCheck) Failures() int {
+	if hc.FailureThreshold == 0 {
+		return DefaultModuleHealthCheckFailureThreshold
+	}
+	return hc.FailureThreshold
+}
+
+func secondsOrDefault(sec float64, def time.Duration) time.Duration {
+	if sec == 0 {
+		return def
+	}
+	return time.Duration(sec * float64(time.Second))
+}
+
+// MinModuleCPUs is the smallest CPU limit of a module, as the kernel does not enforce CPU quotas
+// shorter than 1ms of the 100ms period of module cgroups.
+const MinModuleCPUs = 0.01
+
+// ModuleLimits are the resource limits of a module process. Zero values are no limit.
+//
+// On Linux, memory and CPU limits are enforced with a cgroup (v2) of the module when the server can
+// create one, which lets the module manager tell when the module was killed for running out of
+// memory. Otherwise memory is limited with an rlimit on the address space of the module, and CPU
+// is not limited.
+type ModuleLimits struct {
+	// MemoryMB is the most memory the module can use, in megabytes.
+	MemoryMB int `json:"memory_mb,omitempty"`
+	// CPUs is the most CPU time the module can use, in CPU cores (e.g. 0.5 for half a core). It cannot
+	// be less than MinModuleCPUs.
+	CPUs float64 `json:"cpus,omitempty"`
+	// MaxOpenFiles is the most files the module can have open at once.
+	MaxOpenFiles int `json:"max_open_files,omitempty"`
+}
+
+// checkModuleBundle checks that the bundle has a valid manifest for this platform and version of
+// the RDK, unless the bundle was already checked at the stamp.
+func checkModuleBundle(bundlePath, stamp string) error {
+	checkedBundlesMu.Lock()
+	defer checkedBundlesMu.Unlock()
+	if checked, ok := checkedBundles[bundlePath]; ok && checked.stamp == stamp {
+		return checked.err
+	}
+	_, err := ReadModuleBundleManifest(bundlePath)
+	checkedBundles[bundlePath] = checkedBundle{stamp: stamp, err: err}
+	return err
 }
 
 // Validate checks if the config is valid.
 func (m *Module) Validate(path string) error {
-	_, err := os.Stat(m.ExePath)
-	if err != nil {
+	if m.Bundle != "" {
+		if m.ExePath != "" {
+			return errors.Errorf("module %s cannot have both an executable path and a bundle", path)
+		}
+		bundlePath, err := m.BundlePath()
+		if err != nil {
+			return errors.Wrapf(err, "module %s bundle error", path)
+		}
+		info, err := os.Stat(bundlePath)
+		if err != nil {
+			return errors.Wrapf(err, "module %s bundle error", path)
+		}
+		m.bundleStamp = fmt.Sprintf("%d@%d", info.Size(), info.ModTime().UnixNano())
+		if err := checkModuleBundle(bundlePath, m.bundleStamp); err != nil {
+			return errors.WithMessagef(err, "module %s bundle error", path)
+		}
+	} else if _, err := os.Stat(m.ExePath); err != nil {
 		return errors.Wrapf(err, "module %s executable path error", path)
 	}
 
@@ -43,5 +197,65 @@ func (m *Module) Validate(path string) error {
 		return errors.Errorf("module %s cannot use the reserved name of %s", path, reservedModuleName)
 	}
 
+	for _, name := range m.EnvironmentNames() {
+		if !envVarNameRegEx.MatchString(name) {
+			return errors.Errorf("module %s environment variable name %q must contain only letters, numbers and underscores, "+
+				"and not start with a number", path, name)
+		}
+	}
+
+	if m.WorkingDir != "" {
+		info, err := os.Stat(m.WorkingDir)
+		if err != nil {
+			return errors.Wrapf(err, "module %s working directory error", path)
+		}
+		if !info.IsDir() {
+			return errors.Errorf("module %s working directory %q is not a directory", path, m.WorkingDir)
+		}
+	}
+
+	if m.WatchDir != "" {
+		if !m.Watch {
+			return errors.Errorf("module %s watch_dir is only used with watch enabled", path)
+		}
+		info, err := os.Stat(m.WatchDir)
+		if err != nil {
+			return errors.Wrapf(err, "module %s watch directory error", path)
+		}
+		if !info.IsDir() {
+			return errors.Errorf("module %s watch directory %q is not a directory", path, m.WatchDir)
+		}
+	}
+
+	if m.Limits != nil {
+		if m.Limits.MemoryMB < 0 || m.Limits.CPUs < 0 || m.Limits.MaxOpenFiles < 0 {
+			return errors.Errorf("module %s limits cannot be negative", path)
+		}
+		if m.Limits.CPUs > 0 && m.Limits.CPUs < MinModuleCPUs {
+			return errors.Errorf("module %s cpus limit must be at least %v", path, MinModuleCPUs)
+		}
+	}
+
+	if hc := m.HealthCheck; hc != nil {
+		if hc.IntervalSec < 0 || hc.TimeoutSec < 0 || hc.FailureThreshold < 0 {
+			return errors.Errorf("module %s health check settings cannot be negative", path)
+		}
+		for name, sec := range hc.ResourceTimeoutsSec {
+			if sec < 0 {
+				return errors.Errorf("module %s health check timeout of resource %q cannot be negative", path, name)
+			}
+		}
+	}
+
 	return nil
 }
+
+// EnvironmentNames returns the names of the environment variables of the module, sorted.
+func (m *Module) EnvironmentNames() []string {
+	names := make([]string, 0, len(m.Environment))
+	for name := range m.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
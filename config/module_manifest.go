@@ -0,0 +1,209 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/resource"
+)
+
+// ModuleManifestFileName is the name of the manifest file at the root of a module bundle.
+const ModuleManifestFileName = "meta.json"
+
+// ModuleBundleExtension is the file extension of module bundles.
+const ModuleBundleExtension = ".tar.gz"
+
+// ModuleManifest describes a module bundle, a gzipped tarball holding a module with its manifest.
+type ModuleManifest struct {
+	// Entrypoint is the path of the module executable in the bundle.
+	Entrypoint string `json:"entrypoint"`
+	// Models are the API/model pairs the module serves.
+	Models []ModuleManifestModel `json:"models"`
+	// MinRDKVersion is the oldest version of the RDK the module works with, e.g. "v0.2.40".
+	MinRDKVersion string `json:"min_rdk_version,omitempty"`
+	// Platforms are the platforms the module runs on, as "os/arch" or just "os" (e.g. "linux/arm64"),
+	// all of them if empty.
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+// ModuleManifestModel is an API/model pair served by a module.
+type ModuleManifestModel struct {
+	API   string `json:"api"`
+	Model string `json:"model"`
+}
+
+// Validate checks that the manifest is well formed and that the module runs on this platform and
+// version of the RDK.
+func (m *ModuleManifest) Validate(manifestPath string) error {
+	if m.Entrypoint == "" {
+		return errors.Errorf("module manifest %s entrypoint is required", manifestPath)
+	}
+	if entrypoint := path.Clean(m.Entrypoint); path.IsAbs(entrypoint) || entrypoint == ".." || strings.HasPrefix(entrypoint, "../") {
+		return errors.Errorf("module manifest %s entrypoint %q must be a relative path inside the bundle", manifestPath, m.Entrypoint)
+	}
+	for idx, model := range m.Models {
+		if _, err := resource.NewAPIFromString(model.API); err != nil {
+			return errors.Wrapf(err, "module manifest %s models.%d", manifestPath, idx)
+		}
+		if _, err := resource.NewModelFromString(model.Model); err != nil {
+			return errors.Wrapf(err, "module manifest %s models.%d", manifestPath, idx)
+		}
+	}
+	if m.MinRDKVersion != "" {
+		if _, ok := parseVersion(m.MinRDKVersion); !ok {
+			return errors.Errorf("module manifest %s min_rdk_version %q is not a version", manifestPath, m.MinRDKVersion)
+		}
+		// development builds have no version, and run anything
+		if Version != "" && compareVersions(Version, m.MinRDKVersion) < 0 {
+			return errors.Errorf("module manifest %s needs RDK %s or newer, this is %s", manifestPath, m.MinRDKVersion, Version)
+		}
+	}
+	if len(m.Platforms) > 0 && !m.SupportsPlatform(runtime.GOOS, runtime.GOARCH) {
+		return errors.Errorf("module manifest %s platforms %v do not include %s/%s", manifestPath, m.Platforms, runtime.GOOS, runtime.GOARCH)
+	}
+	return nil
+}
+
+// SupportsPlatform returns whether the module runs on the OS and architecture.
+func (m *ModuleManifest) SupportsPlatform(goos, goarch string) bool {
+	if len(m.Platforms) == 0 {
+		return true
+	}
+	for _, platform := range m.Platforms {
+		if platform == goos || platform == goos+"/"+goarch {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersion parses a version like "v1.2.3" or "1.2", ignoring any pre-release or build suffix.
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// compareVersions returns -1, 0 or 1 as version a is older than, the same as or newer than b.
+// Versions that do not parse compare as the same.
+func compareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0
+	}
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1
+		case va[i] > vb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// BundlePath returns the path of the bundle of the module. The bundle is given as a path or file
+// URL of a bundle, or of a directory holding bundles named after their module, e.g. a USB stick
+// with a my-module.tar.gz file.
+func (m *Module) BundlePath() (string, error) {
+	bundle := m.Bundle
+	if strings.HasPrefix(bundle, "file:") {
+		u, err := url.Parse(bundle)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid module bundle URL %q", bundle)
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return "", errors.Errorf("module bundle URL %q must be of a local file", bundle)
+		}
+		bundle = filepath.FromSlash(u.Path)
+	}
+	info, err := os.Stat(bundle)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		bundle = filepath.Join(bundle, m.Name+ModuleBundleExtension)
+		if _, err := os.Stat(bundle); err != nil {
+			return "", err
+		}
+	}
+	return bundle, nil
+}
+
+// ReadModuleBundleManifest reads the manifest of a module bundle, and checks that the bundle holds
+// its entrypoint.
+func ReadModuleBundleManifest(bundlePath string) (*ModuleManifest, error) {
+	//nolint:gosec
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer f.Close()
+	archive, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "module bundle %s is not gzipped", bundlePath)
+	}
+	//nolint:errcheck
+	defer archive.Close()
+
+	var manifest *ModuleManifest
+	files := map[string]bool{}
+	tarReader := tar.NewReader(archive)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read module bundle %s", bundlePath)
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink {
+			files[name] = true
+		}
+		if name != ModuleManifestFileName {
+			continue
+		}
+		manifest = &ModuleManifest{}
+		if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+			return nil, errors.Wrapf(err, "cannot decode manifest of module bundle %s", bundlePath)
+		}
+	}
+	if manifest == nil {
+		return nil, errors.Errorf("module bundle %s has no %s", bundlePath, ModuleManifestFileName)
+	}
+	if err := manifest.Validate(bundlePath); err != nil {
+		return nil, err
+	}
+	if !files[path.Clean(manifest.Entrypoint)] {
+		return nil, errors.Errorf("module bundle %s has no entrypoint %q", bundlePath, manifest.Entrypoint)
+	}
+	return manifest, nil
+}
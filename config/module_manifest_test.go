@@ -0,0 +1,161 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func writeModuleBundle(t *testing.T, path string, manifest *ModuleManifest, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	test.That(t, err, test.ShouldBeNil)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	if manifest != nil {
+		data, err := json.Marshal(manifest)
+		test.That(t, err, test.ShouldBeNil)
+		files[ModuleManifestFileName] = string(data)
+	}
+	for name, content := range files {
+		test.That(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content))}), test.ShouldBeNil)
+		_, err := tw.Write([]byte(content))
+		test.That(t, err, test.ShouldBeNil)
+	}
+	test.That(t, tw.Close(), test.ShouldBeNil)
+	test.That(t, gz.Close(), test.ShouldBeNil)
+}
+
+func TestModuleManifestValidate(t *testing.T) {
+	manifest := ModuleManifest{
+		Entrypoint:    "bin/module",
+		Models:        []ModuleManifestModel{{API: "rdk:component:camera", Model: "acme:cameras:fake"}},
+		MinRDKVersion: "v0.2.0",
+		Platforms:     []string{runtime.GOOS + "/" + runtime.GOARCH, "darwin"},
+	}
+	test.That(t, manifest.Validate("meta.json"), test.ShouldBeNil)
+
+	bad := manifest
+	bad.Entrypoint = ""
+	test.That(t, bad.Validate("meta.json"), test.ShouldNotBeNil)
+	bad.Entrypoint = "../module"
+	test.That(t, bad.Validate("meta.json").Error(), test.ShouldContainSubstring, "inside the bundle")
+	bad.Entrypoint = "/bin/module"
+	test.That(t, bad.Validate("meta.json"), test.ShouldNotBeNil)
+
+	bad = manifest
+	bad.Models = []ModuleManifestModel{{API: "camera", Model: "acme:cameras:fake"}}
+	test.That(t, bad.Validate("meta.json"), test.ShouldNotBeNil)
+	bad.Models = []ModuleManifestModel{{API: "rdk:component:camera", Model: "acme::fake"}}
+	test.That(t, bad.Validate("meta.json"), test.ShouldNotBeNil)
+
+	bad = manifest
+	bad.Platforms = []string{"plan9/mips"}
+	test.That(t, bad.Validate("meta.json").Error(), test.ShouldContainSubstring, "platforms")
+	test.That(t, bad.SupportsPlatform("plan9", "mips"), test.ShouldBeTrue)
+	bad.Platforms = []string{"plan9"}
+	test.That(t, bad.SupportsPlatform("plan9", "amd64"), test.ShouldBeTrue)
+
+	bad = manifest
+	bad.MinRDKVersion = "latest"
+	test.That(t, bad.Validate("meta.json"), test.ShouldNotBeNil)
+	defer func(orig string) {
+		Version = orig
+	}(Version)
+	Version = "v0.1.9"
+	test.That(t, manifest.Validate("meta.json").Error(), test.ShouldContainSubstring, "needs RDK v0.2.0")
+	Version = "v0.10.0-rc1"
+	test.That(t, manifest.Validate("meta.json"), test.ShouldBeNil)
+
+	test.That(t, compareVersions("1.2", "v1.2.0"), test.ShouldEqual, 0)
+	test.That(t, compareVersions("v1.10.0", "v1.9.3"), test.ShouldEqual, 1)
+	test.That(t, compareVersions("v0.9.3", "v1"), test.ShouldEqual, -1)
+}
+
+func TestModuleBundle(t *testing.T) {
+	dir := t.TempDir()
+	manifest := &ModuleManifest{Entrypoint: "bin/module"}
+	bundle := filepath.Join(dir, "my-module.tar.gz")
+	writeModuleBundle(t, bundle, manifest, map[string]string{"bin/module": "#!/bin/sh\n"})
+
+	read, err := ReadModuleBundleManifest(bundle)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, read, test.ShouldResemble, manifest)
+
+	// a bundle is found by path, file URL or in a directory of bundles
+	for _, source := range []string{bundle, "file://" + filepath.ToSlash(bundle), dir} {
+		mod := Module{Name: "my-module", Bundle: source}
+		path, err := mod.BundlePath()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, path, test.ShouldEqual, bundle)
+		test.That(t, mod.Validate("modules.0"), test.ShouldBeNil)
+	}
+	mod := Module{Name: "other-module", Bundle: dir}
+	test.That(t, mod.Validate("modules.0"), test.ShouldNotBeNil)
+	mod = Module{Name: "my-module", Bundle: "file://host/my-module.tar.gz"}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "local file")
+	mod = Module{Name: "my-module", Bundle: bundle, ExePath: bundle}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "both")
+
+	noEntrypoint := filepath.Join(dir, "no-entrypoint.tar.gz")
+	writeModuleBundle(t, noEntrypoint, manifest, map[string]string{"module": "#!/bin/sh\n"})
+	_, err = ReadModuleBundleManifest(noEntrypoint)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "no entrypoint")
+
+	noManifest := filepath.Join(dir, "no-manifest.tar.gz")
+	writeModuleBundle(t, noManifest, nil, map[string]string{"bin/module": "#!/bin/sh\n"})
+	_, err = ReadModuleBundleManifest(noManifest)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "has no meta.json")
+
+	notGzipped := filepath.Join(dir, "plain.tar.gz")
+	test.That(t, os.WriteFile(notGzipped, []byte("plain"), 0o600), test.ShouldBeNil)
+	_, err = ReadModuleBundleManifest(notGzipped)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "not gzipped")
+	mod = Module{Name: "plain", Bundle: dir}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "not gzipped")
+	mod = Module{Name: "no-entrypoint", Bundle: dir}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "no entrypoint")
+
+	// a bundle for another platform or a newer RDK is rejected with the config
+	unsupported := &ModuleManifest{Entrypoint: "bin/module", Platforms: []string{"plan9/mips"}}
+	writeModuleBundle(t, filepath.Join(dir, "unsupported.tar.gz"), unsupported, map[string]string{"bin/module": "#!/bin/sh\n"})
+	mod = Module{Name: "unsupported", Bundle: dir}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "platforms")
+	defer func(orig string) {
+		Version = orig
+	}(Version)
+	Version = "v0.10.0"
+	newer := &ModuleManifest{Entrypoint: "bin/module", MinRDKVersion: "v1000.0.0"}
+	writeModuleBundle(t, filepath.Join(dir, "newer.tar.gz"), newer, map[string]string{"bin/module": "#!/bin/sh\n"})
+	mod = Module{Name: "newer", Bundle: dir}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "needs RDK v1000.0.0")
+
+	// a bundle replaced in place modifies the module
+	before := Config{Modules: []Module{{Name: "my-module", Bundle: dir}}}
+	test.That(t, before.Modules[0].Validate("modules.0"), test.ShouldBeNil)
+	same := Config{Modules: []Module{{Name: "my-module", Bundle: dir}}}
+	test.That(t, same.Modules[0].Validate("modules.0"), test.ShouldBeNil)
+	diff, err := DiffConfigs(before, same, false)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, diff.ResourcesEqual, test.ShouldBeTrue)
+
+	writeModuleBundle(t, bundle, manifest, map[string]string{"bin/module": "#!/bin/sh\necho replaced\n"})
+	after := Config{Modules: []Module{{Name: "my-module", Bundle: dir}}}
+	test.That(t, after.Modules[0].Validate("modules.0"), test.ShouldBeNil)
+	diff, err = DiffConfigs(before, after, false)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, diff.ResourcesEqual, test.ShouldBeFalse)
+	test.That(t, diff.Modified.Modules, test.ShouldResemble, after.Modules)
+
+	// and is checked again
+	writeModuleBundle(t, bundle, unsupported, map[string]string{"bin/module": "#!/bin/sh\necho unsupported\n"})
+	mod = Module{Name: "my-module", Bundle: dir}
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, "platforms")
+}
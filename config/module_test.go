@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestModuleValidate(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "module")
+	test.That(t, os.WriteFile(exe, []byte("#!/bin/sh\n"), 0o700), test.ShouldBeNil)
+
+	mod := Module{
+		Name:        "my-module",
+		ExePath:     exe,
+		Environment: map[string]string{"VIAM_TOKEN": "abc", "_DEBUG": "1"},
+		Args:        []string{"--fast"},
+		WorkingDir:  dir,
+		Limits:      &ModuleLimits{MemoryMB: 512, CPUs: 0.5, MaxOpenFiles: 256},
+	}
+	test.That(t, mod.Validate("modules.0"), test.ShouldBeNil)
+	test.That(t, mod.EnvironmentNames(), test.ShouldResemble, []string{"VIAM_TOKEN", "_DEBUG"})
+
+	bad := mod
+	bad.Name = "parent"
+	test.That(t, bad.Validate("modules.0"), test.ShouldNotBeNil)
+
+	bad = mod
+	bad.Environment = map[string]string{"1ST": "x"}
+	err := bad.Validate("modules.0")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, `"1ST"`)
+
+	bad = mod
+	bad.WorkingDir = filepath.Join(dir, "missing")
+	test.That(t, bad.Validate("modules.0"), test.ShouldNotBeNil)
+	bad.WorkingDir = exe
+	test.That(t, bad.Validate("modules.0").Error(), test.ShouldContainSubstring, "not a directory")
+
+	bad = mod
+	bad.WatchDir = dir
+	test.That(t, bad.Validate("modules.0").Error(), test.ShouldContainSubstring, "watch enabled")
+	bad.Watch = true
+	test.That(t, bad.Validate("modules.0"), test.ShouldBeNil)
+	bad.WatchDir = exe
+	test.That(t, bad.Validate("modules.0").Error(), test.ShouldContainSubstring, "not a directory")
+
+	bad = mod
+	bad.Limits = &ModuleLimits{MemoryMB: -1}
+	test.That(t, bad.Validate("modules.0").Error(), test.ShouldContainSubstring, "cannot be negative")
+	// the kernel would refuse a quota this small
+	bad.Limits = &ModuleLimits{CPUs: 0.005}
+	test.That(t, bad.Validate("modules.0").Error(), test.ShouldContainSubstring, "cpus limit must be at least 0.01")
+	bad.Limits = &ModuleLimits{CPUs: MinModuleCPUs}
+	test.That(t, bad.Validate("modules.0"), test.ShouldBeNil)
+}
+
+func TestModuleHealthCheck(t *testing.T) {
+	hc := &ModuleHealthCheck{}
+	test.That(t, hc.Interval(), test.ShouldEqual, DefaultModuleHealthCheckInterval)
+	test.That(t, hc.Timeout(), test.ShouldEqual, DefaultModuleHealthCheckTimeout)
+	test.That(t, hc.Failures(), test.ShouldEqual, DefaultModuleHealthCheckFailureThreshold)
+	test.That(t, hc.ResourceTimeout("camera"), test.ShouldEqual, DefaultModuleHealthCheckTimeout)
+
+	hc = &ModuleHealthCheck{
+		IntervalSec:         0.5,
+		TimeoutSec:          2,
+		FailureThreshold:    1,
+		ResourceTimeoutsSec: map[string]float64{"detector": 30},
+	}
+	test.That(t, hc.Interval(), test.ShouldEqual, 500*time.Millisecond)
+	test.That(t, hc.Failures(), test.ShouldEqual, 1)
+	test.That(t, hc.ResourceTimeout("camera"), test.ShouldEqual, 2*time.Second)
+	test.That(t, hc.ResourceTimeout("detector"), test.ShouldEqual, 30*time.Second)
+
+	dir := t.TempDir()
+	mod := Module{Name: "my-module", ExePath: dir, HealthCheck: hc}
+	test.That(t, mod.Validate("modules.0"), test.ShouldBeNil)
+	hc.ResourceTimeoutsSec["camera"] = -1
+	test.That(t, mod.Validate("modules.0").Error(), test.ShouldContainSubstring, `"camera"`)
+	mod.HealthCheck = &ModuleHealthCheck{TimeoutSec: -1}
+	test.That(t, mod.Validate("modules.0"), test.ShouldNotBeNil)
+}
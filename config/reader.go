@@ -11,7 +11,6 @@ import (
 	"path/filepath"
 	"runtime"
 
-	"github.com/a8m/envsubst"
 	"github.com/edaniels/golog"
 	"github.com/pkg/errors"
 	apppb "go.viam.com/api/app/v1"
@@ -278,13 +277,13 @@ func readFromCloud(
 	return cfg, nil
 }
 
-// Read reads a config from the given file.
+// Read reads a config from the given file, expanding it as described by ExpandFile.
 func Read(
 	ctx context.Context,
 	filePath string,
 	logger golog.Logger,
 ) (*Config, error) {
-	buf, err := envsubst.ReadFile(filePath)
+	buf, err := ExpandFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -298,7 +297,7 @@ func ReadLocalConfig(
 	filePath string,
 	logger golog.Logger,
 ) (*Config, error) {
-	buf, err := envsubst.ReadFile(filePath)
+	buf, err := ExpandFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/resource"
+)
+
+// A ValidationProblem is something wrong with a config.
+type ValidationProblem struct {
+	// Path is the JSON path of the part of the config the problem is in, such as components.2.
+	Path string
+	Err  error
+	// Warning is set for problems that may turn out not to be problems on the robot, such as a
+	// model that is not built in and may be served by a module.
+	Warning bool
+}
+
+func (p ValidationProblem) String() string {
+	kind := "error"
+	if p.Warning {
+		kind = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", kind, p.Path, p.Err)
+}
+
+// ValidateAll validates every part of the config and checks that the dependencies of resources
+// exist and do not form a cycle. With checkModels, the model of every resource must also be
+// registered, or possibly served by a module, and the attributes of registered models are
+// validated, which finds the dependencies they imply. Checking models only makes sense in a
+// program that registers them, such as viam-server. Unlike Ensure, it does not stop at the first
+// error or skip the parts of the config that are not valid, but returns every problem it finds.
+// The config is left as it is.
+func (c *Config) ValidateAll(checkModels bool) ([]ValidationProblem, error) {
+	cfg, err := c.CopyOnlyPublicFields()
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []ValidationProblem
+	addProblem := func(path string, err error, warning bool) {
+		problems = append(problems, ValidationProblem{Path: path, Err: err, Warning: warning})
+	}
+
+	if cfg.Cloud != nil {
+		if err := cfg.Cloud.Validate("cloud", false); err != nil {
+			addProblem("cloud", err, false)
+		}
+	}
+	if err := cfg.Network.Validate("network"); err != nil {
+		addProblem("network", err, false)
+	}
+	if err := cfg.Auth.Validate("auth"); err != nil {
+		addProblem("auth", err, false)
+	}
+	for idx := range cfg.Modules {
+		path := fmt.Sprintf("modules.%d", idx)
+		if err := cfg.Modules[idx].Validate(path); err != nil {
+			addProblem(path, err, false)
+		}
+	}
+	for idx := range cfg.Remotes {
+		path := fmt.Sprintf("remotes.%d", idx)
+		if _, err := cfg.Remotes[idx].Validate(path); err != nil {
+			addProblem(path, err, false)
+		}
+	}
+	for idx := range cfg.Processes {
+		path := fmt.Sprintf("processes.%d", idx)
+		if err := cfg.Processes[idx].Validate(path); err != nil {
+			addProblem(path, err, false)
+		}
+	}
+	for idx := range cfg.Packages {
+		path := fmt.Sprintf("packages.%d", idx)
+		if err := cfg.Packages[idx].Validate(path); err != nil {
+			addProblem(path, err, false)
+		}
+	}
+
+	// resources that are not valid are still part of the graph, so that what depends on them is
+	// not reported as well
+	type configuredResource struct {
+		path string
+		conf *resource.Config
+		deps []string
+	}
+	var resources []configuredResource
+	validateResources := func(section, apiType string, confs []resource.Config) {
+		for idx := range confs {
+			path := fmt.Sprintf("%s.%d", section, idx)
+			conf := &confs[idx]
+			conf.AdjustPartialNames(apiType)
+
+			reg, ok := resource.LookupRegistration(conf.API, conf.Model)
+			switch {
+			case !checkModels:
+			case !ok && len(cfg.Modules) == 0:
+				addProblem(path, errors.Errorf("unknown model %q for %q", conf.Model, conf.API), false)
+			case !ok:
+				addProblem(path, errors.Errorf("model %q for %q is not built in; it can only be validated by "+
+					"the module serving it", conf.Model, conf.API), true)
+			case reg.AttributeMapConverter != nil:
+				converted, err := reg.AttributeMapConverter(conf.Attributes)
+				if err != nil {
+					addProblem(path+".attributes", err, false)
+					break
+				}
+				conf.ConvertedAttributes = converted
+			}
+
+			deps, err := conf.Validate(path, apiType)
+			if err != nil {
+				addProblem(path, err, false)
+			}
+			conf.ImplicitDependsOn = deps
+			resources = append(resources, configuredResource{path: path, conf: conf, deps: conf.Dependencies()})
+		}
+	}
+	validateResources("components", resource.APITypeComponentName, cfg.Components)
+	validateResources("services", resource.APITypeServiceName, cfg.Services)
+
+	graph := resource.NewGraph()
+	byShortName := map[string][]resource.Name{}
+	addNode := func(name resource.Name) bool {
+		if _, ok := graph.Node(name); ok {
+			return false
+		}
+		if err := graph.AddNode(name, resource.NewUninitializedNode()); err != nil {
+			return false
+		}
+		byShortName[name.ShortName()] = append(byShortName[name.ShortName()], name)
+		return true
+	}
+	for _, res := range resources {
+		if !addNode(res.conf.ResourceName()) {
+			addProblem(res.path, errors.Errorf("more than one resource is named %q", res.conf.ResourceName()), false)
+		}
+	}
+	// the robot adds the default services that are not configured
+	for _, name := range resource.DefaultServices() {
+		addNode(name)
+	}
+
+	for _, res := range resources {
+		name := res.conf.ResourceName()
+		for _, dep := range res.deps {
+			depName, err := resource.NewFromString(dep)
+			if err != nil {
+				// a dependency by name alone, as resolved by the robot
+				switch matches := byShortName[dep]; len(matches) {
+				case 0:
+					addProblem(res.path, errors.Errorf("depends on %q, which is not configured", dep), len(cfg.Remotes) > 0)
+					continue
+				case 1:
+					depName = matches[0]
+				default:
+					addProblem(res.path, errors.Errorf("depends on %q, which names more than one resource: %v", dep, matches), false)
+					continue
+				}
+			} else if _, ok := graph.Node(depName); !ok {
+				// resources of remotes cannot be checked without connecting to them
+				addProblem(res.path, errors.Errorf("depends on %q, which is not configured", dep), depName.ContainsRemoteNames())
+				continue
+			}
+			if err := graph.AddChild(name, depName); err != nil {
+				addProblem(res.path, err, false)
+			}
+		}
+	}
+	return problems, nil
+}
+
+// ValidateFile reads the config file at the given path, expanding it as Read does, and returns
+// every problem ValidateAll finds in it. An error is returned if the file cannot be read or decoded.
+func ValidateFile(path string, checkModels bool) ([]ValidationProblem, error) {
+	expanded, err := ExpandFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := Config{ConfigFilePath: path}
+	if err := json.Unmarshal(expanded, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode Config from json")
+	}
+	return cfg.ValidateAll(checkModels)
+}
+
+// PrintValidationProblems prints the problems found in the config at the given path, returning an
+// error if any of them is more than a warning.
+func PrintValidationProblems(w io.Writer, path string, problems []ValidationProblem) error {
+	var errCount int
+	for _, problem := range problems {
+		if !problem.Warning {
+			errCount++
+		}
+		fmt.Fprintln(w, problem)
+	}
+	if errCount > 0 {
+		return errors.Errorf("%s has %d error(s)", path, errCount)
+	}
+	fmt.Fprintf(w, "%s is valid\n", path)
+	return nil
+}
@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/test"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/resource"
+)
+
+type gizmoConfig struct {
+	Motor string  `json:"motor"`
+	Speed float64 `json:"speed"`
+}
+
+func (cfg *gizmoConfig) Validate(path string) ([]string, error) {
+	if cfg.Speed <= 0 {
+		return nil, goutils.NewConfigValidationFieldRequiredError(path, "speed")
+	}
+	if cfg.Motor == "" {
+		return nil, nil
+	}
+	return []string{cfg.Motor}, nil
+}
+
+var gizmoAPI = resource.APINamespaceRDK.WithComponentType("validate_gizmo")
+
+func init() {
+	resource.RegisterComponent(gizmoAPI, resource.DefaultModelFamily.WithModel("fake"), resource.Registration[resource.Resource, *gizmoConfig]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (resource.Resource, error) {
+			return nil, errors.New("not used")
+		},
+	})
+}
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "robot.json")
+	test.That(t, os.WriteFile(path, []byte(`{
+		"components": [
+			{"name": "g1", "type": "validate_gizmo", "model": "fake", "attributes": {"speed": 1, "motor": "g2"}},
+			{"name": "g2", "type": "validate_gizmo", "model": "fake", "attributes": {"speed": 1, "motor": "g1"}},
+			{"name": "g3", "type": "validate_gizmo", "model": "fake", "attributes": {"speed": 0}},
+			{"name": "g4", "type": "validate_gizmo", "model": "fake", "attributes": {"speed": "fast"}},
+			{"name": "g5", "type": "validate_gizmo", "model": "fake", "attributes": {"speed": 1}, "depends_on": ["nowhere"]},
+			{"name": "g5", "type": "validate_gizmo", "model": "fake", "attributes": {"speed": 1}},
+			{"name": "g6", "type": "validate_gizmo", "model": "acme:gizmos:other", "attributes": {"speed": 1}}
+		],
+		"processes": [{"id": "", "name": "echo"}]
+	}`), 0o600), test.ShouldBeNil)
+
+	problems, err := config.ValidateFile(path, true)
+	test.That(t, err, test.ShouldBeNil)
+	byPath := map[string]config.ValidationProblem{}
+	for _, problem := range problems {
+		byPath[problem.Path] = problem
+	}
+	test.That(t, byPath, test.ShouldHaveLength, 7)
+	test.That(t, byPath["components.1"].Err.Error(), test.ShouldContainSubstring, "circular dependency")
+	test.That(t, byPath["components.2"].Err.Error(), test.ShouldContainSubstring, "speed")
+	test.That(t, byPath, test.ShouldContainKey, "components.3.attributes")
+	test.That(t, byPath["components.4"].Err.Error(), test.ShouldContainSubstring, `depends on "nowhere"`)
+	test.That(t, byPath["components.5"].Err.Error(), test.ShouldContainSubstring, "more than one")
+	test.That(t, byPath["components.6"].Err.Error(), test.ShouldContainSubstring, "unknown model")
+	test.That(t, byPath["components.6"].Warning, test.ShouldBeFalse)
+	test.That(t, byPath, test.ShouldContainKey, "processes.0")
+	test.That(t, byPath["processes.0"].String(), test.ShouldStartWith, "error: processes.0: ")
+
+	// without checking models, neither attributes nor the dependencies they imply are checked
+	problems, err = config.ValidateFile(path, false)
+	test.That(t, err, test.ShouldBeNil)
+	byPath = map[string]config.ValidationProblem{}
+	for _, problem := range problems {
+		byPath[problem.Path] = problem
+	}
+	test.That(t, byPath, test.ShouldHaveLength, 3)
+	test.That(t, byPath, test.ShouldContainKey, "components.4")
+	test.That(t, byPath, test.ShouldContainKey, "components.5")
+	test.That(t, byPath, test.ShouldContainKey, "processes.0")
+
+	// with modules, unknown models may be served by them
+	cfg := &config.Config{
+		Modules: []config.Module{{Name: "gizmos", ExePath: path}},
+		Components: []resource.Config{
+			{Name: "g1", API: gizmoAPI, Model: resource.NewModel("acme", "gizmos", "other"), DependsOn: []string{"g2"}},
+			{Name: "g2", API: gizmoAPI, Model: resource.DefaultModelFamily.WithModel("fake"), Attributes: map[string]interface{}{"speed": 1}},
+		},
+	}
+	problems, err = cfg.ValidateAll(true)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, problems, test.ShouldHaveLength, 1)
+	test.That(t, problems[0].Warning, test.ShouldBeTrue)
+	test.That(t, cfg.Components[1].ConvertedAttributes, test.ShouldBeNil)
+
+	_, err = config.ValidateFile(filepath.Join(t.TempDir(), "missing.json"), true)
+	test.That(t, err, test.ShouldNotBeNil)
+}
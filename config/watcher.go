@@ -3,7 +3,6 @@ package config
 import (
 	"bytes"
 	"context"
-	"os"
 	"time"
 
 	"github.com/bep/debounce"
@@ -121,6 +120,23 @@ func newFSWatcher(ctx context.Context, configPath string, logger golog.Logger) (
 	if err := fsWatcher.Add(configPath); err != nil {
 		return nil, err
 	}
+	// the files the config includes and is overlaid by are watched too
+	watched := map[string]bool{configPath: true}
+	watchFiles := func(paths []string) {
+		for _, path := range paths {
+			if watched[path] {
+				continue
+			}
+			if err := fsWatcher.Add(path); err != nil {
+				logger.Debugw("cannot watch file the config is expanded from", "path", path, "error", err)
+				continue
+			}
+			watched[path] = true
+		}
+	}
+	if _, paths, err := expandFile(configPath); err == nil {
+		watchFiles(paths)
+	}
 	configCh := make(chan *Config)
 	watcherDoneCh := make(chan struct{})
 	cancelCtx, cancel := context.WithCancel(ctx)
@@ -137,12 +153,12 @@ func newFSWatcher(ctx context.Context, configPath string, logger golog.Logger) (
 			case event := <-fsWatcher.Events:
 				if event.Op&fsnotify.Write == fsnotify.Write {
 					debounced(func() {
-						//nolint:gosec
-						rd, err := os.ReadFile(configPath)
+						rd, paths, err := expandFile(configPath)
 						if err != nil {
 							logger.Errorw("error reading config file after write", "error", err)
 							return
 						}
+						watchFiles(paths)
 						if bytes.Equal(rd, lastRd) {
 							return
 						}
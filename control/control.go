@@ -16,8 +16,9 @@ type Controllable interface {
 
 // Config configuration of the control loop.
 type Config struct {
-	Blocks    []BlockConfig `json:"blocks"`    // Blocks Control Block Config
-	Frequency float64       `json:"frequency"` // Frequency loop Frequency
+	Blocks    []BlockConfig    `json:"blocks"`              // Blocks Control Block Config
+	Frequency float64          `json:"frequency"`           // Frequency loop Frequency
+	Telemetry *TelemetryConfig `json:"telemetry,omitempty"` // Telemetry optional recording of block signals
 }
 
 // Control control interface can be used to interfact with a control loop to query signals, change config, start/stop the loop etc...
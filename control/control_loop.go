@@ -37,6 +37,9 @@ type Loop struct {
 	cancelCtx               context.Context
 	cancel                  context.CancelFunc
 	running                 bool
+	telemetry               map[string]*TelemetryBuffer
+	lastInputsMu            sync.Mutex
+	lastInputs              map[string][]float64
 }
 
 // NewLoop construct a new control loop for a specific endpoint.
@@ -58,6 +61,9 @@ func createLoop(logger golog.Logger, cfg Config, m Controllable) (*Loop, error)
 		return nil, errors.New("loop frequency shouldn't be 0 or above 200Hz")
 	}
 	l.dt = time.Duration(float64(time.Second) * (1.0 / (l.cfg.Frequency)))
+	if err := l.setupTelemetry(); err != nil {
+		return nil, err
+	}
 	for _, bcfg := range cfg.Blocks {
 		blk, err := createBlock(bcfg, logger)
 		if err != nil {
@@ -99,6 +105,7 @@ func createLoop(logger golog.Logger, cfg Config, m Controllable) (*Loop, error)
 						return
 					}
 					v, _ := b.blk.Next(l.cancelCtx, nil, l.dt)
+					l.recordTelemetry(b.blk.Config(l.cancelCtx), nil, v)
 					for _, out := range b.outs {
 						out <- v
 					}
@@ -137,6 +144,7 @@ func createLoop(logger golog.Logger, cfg Config, m Controllable) (*Loop, error)
 					}
 					v, ok := b.blk.Next(l.cancelCtx, sw, l.dt)
 					if ok {
+						l.recordTelemetry(b.blk.Config(l.cancelCtx), sw, v)
 						for _, out := range b.outs {
 							out <- v
 						}
@@ -185,6 +193,122 @@ func (l *Loop) BlockList(ctx context.Context) ([]string, error) {
 	return out, nil
 }
 
+// Telemetry returns the telemetry buffer of the block name, error when the block isn't recorded.
+func (l *Loop) Telemetry(name string) (*TelemetryBuffer, error) {
+	buf, ok := l.telemetry[name]
+	if !ok {
+		return nil, errors.Errorf("no telemetry recorded for block %s", name)
+	}
+	return buf, nil
+}
+
+// TelemetryBlockList returns the list of blocks for which telemetry is recorded.
+func (l *Loop) TelemetryBlockList() []string {
+	var out []string
+	for k := range l.telemetry {
+		out = append(out, k)
+	}
+	return out
+}
+
+func (l *Loop) setupTelemetry() error {
+	if l.cfg.Telemetry == nil {
+		return nil
+	}
+	l.telemetry = make(map[string]*TelemetryBuffer)
+	l.lastInputs = make(map[string][]float64)
+	names := l.cfg.Telemetry.Blocks
+	if len(names) == 0 {
+		for _, bcfg := range l.cfg.Blocks {
+			names = append(names, bcfg.Name)
+		}
+	}
+	for _, name := range names {
+		found := false
+		for _, bcfg := range l.cfg.Blocks {
+			found = found || bcfg.Name == name
+		}
+		if !found {
+			return errors.Errorf("cannot record telemetry for non existing block %s", name)
+		}
+		l.telemetry[name] = newTelemetryBuffer(l.cfg.Telemetry.BufferSize)
+	}
+	return nil
+}
+
+// recordTelemetry stores the last inputs of a block and adds a sample to its buffer if it is recorded.
+// The inputs are kept in the order the block depends on them, in a buffer reused across iterations.
+func (l *Loop) recordTelemetry(cfg BlockConfig, x, y []*Signal) {
+	if l.telemetry == nil {
+		return
+	}
+	l.lastInputsMu.Lock()
+	defer l.lastInputsMu.Unlock()
+	inputs := l.lastInputs[cfg.Name][:0]
+	for _, dep := range cfg.DependsOn {
+		for _, s := range x {
+			if s != nil && s.name == dep {
+				inputs = append(inputs, s.GetSignalValueAt(0))
+				break
+			}
+		}
+	}
+	l.lastInputs[cfg.Name] = inputs
+	buf, ok := l.telemetry[cfg.Name]
+	if !ok {
+		return
+	}
+	sample := TelemetrySample{Time: time.Now(), Block: cfg.Name}
+	if len(y) > 0 {
+		sample.Output = y[0].GetSignalValueAt(0)
+	}
+	switch len(inputs) {
+	case 0:
+		sample.Measured = sample.Output
+	case 1:
+		sample.Error = inputs[0]
+		if len(cfg.DependsOn) == 1 {
+			if up, ok := l.blocks[cfg.DependsOn[0]]; ok {
+				upCfg := up.blk.Config(l.cancelCtx)
+				if upInputs := l.lastInputs[upCfg.Name]; len(upInputs) == 2 {
+					sample.Setpoint, sample.Measured = setpointAndMeasured(upCfg, upInputs)
+				}
+			}
+		}
+	default:
+		sample.Setpoint, sample.Measured = setpointAndMeasured(cfg, inputs)
+		sample.Error = sample.Setpoint - sample.Measured
+		if controlBlockType(cfg.Type) == blockSum {
+			// the sum already applies the sign of each input
+			sample.Error = sample.Output
+		}
+	}
+	buf.add(sample)
+}
+
+// setpointAndMeasured returns the first two inputs of a block as its setpoint and measured value. The
+// inputs of a sum block are taken by sign instead, the added one being the setpoint and the subtracted
+// one the measured value.
+func setpointAndMeasured(cfg BlockConfig, inputs []float64) (float64, float64) {
+	setpoint, measured := inputs[0], inputs[1]
+	if controlBlockType(cfg.Type) != blockSum {
+		return setpoint, measured
+	}
+	signs := cfg.Attribute.String("sum_string")
+	if len(signs) != len(inputs) {
+		return setpoint, measured
+	}
+	for i := len(signs) - 1; i >= 0; i-- {
+		switch sumOperand(signs[i]) {
+		case addition:
+			setpoint = inputs[i]
+		case subtraction:
+			measured = inputs[i]
+		}
+	}
+	return setpoint, measured
+}
+
 // Frequency returns the loop's frequency.
 func (l *Loop) Frequency(ctx context.Context) (float64, error) {
 	return l.cfg.Frequency, nil
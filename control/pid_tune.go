@@ -0,0 +1,194 @@
+package control
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+)
+
+// TuneConfig bounds an on-demand PID tuning experiment run by Tune.
+type TuneConfig struct {
+	// Method is one of the tune_method values accepted by a PID block, defaults to ziegerNicholsPI.
+	// Cohen-Coons methods only run a step experiment, the other methods follow it with a relay experiment.
+	Method string `json:"tune_method,omitempty"`
+	// StepPct is the fraction of MaxPower applied during the step experiment.
+	StepPct float64 `json:"tune_step_pct,omitempty"`
+	// SSRValue is the steady state ratio under which the step response is considered settled.
+	SSRValue float64 `json:"tune_ssr_value,omitempty"`
+	// MaxPower is the largest absolute power the experiment is allowed to apply, between 0 and 1.
+	MaxPower float64 `json:"max_power_pct,omitempty"`
+	// MaxRPM aborts the experiment when the measured speed exceeds it, 0 disables the check.
+	MaxRPM float64 `json:"max_rpm,omitempty"`
+	// Frequency is the sampling frequency of the experiment in Hz.
+	Frequency float64 `json:"frequency,omitempty"`
+	// Timeout is the hard limit on the duration of the whole experiment.
+	Timeout time.Duration `json:"-"`
+}
+
+// StepResponse summarizes the response of the system to the step experiment.
+type StepResponse struct {
+	SteadyState  float64       `json:"steady_state_rpm"`
+	RiseTime     time.Duration `json:"rise_time"`
+	SettlingTime time.Duration `json:"settling_time"`
+	OvershootPct float64       `json:"overshoot_pct"`
+	Samples      int           `json:"samples"`
+}
+
+// TuneResult holds the gains computed by Tune along with the measured step response.
+type TuneResult struct {
+	Method       string       `json:"tune_method"`
+	KP           float64      `json:"kP"`
+	KI           float64      `json:"kI"`
+	KD           float64      `json:"kD"`
+	StepResponse StepResponse `json:"step_response"`
+}
+
+func (cfg *TuneConfig) setDefaults() error {
+	if cfg.Method == "" {
+		cfg.Method = string(tuneMethodZiegerNicholsPI)
+	}
+	if cfg.StepPct == 0 {
+		cfg.StepPct = 0.35
+	}
+	if cfg.SSRValue == 0 {
+		cfg.SSRValue = 2.0
+	}
+	if cfg.MaxPower == 0 {
+		cfg.MaxPower = 1.0
+	}
+	if cfg.Frequency == 0 {
+		cfg.Frequency = 50
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.StepPct < 0 || cfg.StepPct > 1 {
+		return errors.Errorf("tune_step_pct should be a percentage value between 0-1 got %1.3f", cfg.StepPct)
+	}
+	if cfg.MaxPower < 0 || cfg.MaxPower > 1 {
+		return errors.Errorf("max_power_pct should be a percentage value between 0-1 got %1.3f", cfg.MaxPower)
+	}
+	if cfg.MaxRPM < 0 {
+		return errors.New("max_rpm should be positive or zero")
+	}
+	if cfg.Frequency < 0 || cfg.Frequency > 200 {
+		return errors.New("tuning frequency shouldn't be negative or above 200Hz")
+	}
+	return nil
+}
+
+// Tune runs a step (and, depending on the method, relay) experiment on c within the bounds of cfg
+// and returns the PID gains computed from the response. The speed fed to the tuner is derived
+// from c.Position, so positions are expected in revolutions. Power is always set back to 0 on return.
+func Tune(ctx context.Context, c Controllable, cfg TuneConfig, logger golog.Logger) (TuneResult, error) {
+	if err := cfg.setDefaults(); err != nil {
+		return TuneResult{}, err
+	}
+	tuner := pidTuner{
+		limUp:      cfg.MaxPower,
+		limLo:      -cfg.MaxPower,
+		ssRValue:   cfg.SSRValue,
+		tuneMethod: tuneCalcMethod(cfg.Method),
+		stepPct:    cfg.StepPct,
+	}
+	if err := tuner.reset(); err != nil {
+		return TuneResult{}, err
+	}
+	defer func() {
+		if err := c.SetPower(context.Background(), 0, nil); err != nil {
+			logger.Errorf("failed to stop after tuning: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	dt := time.Duration(float64(time.Second) / cfg.Frequency)
+	lastPos, err := c.Position(ctx, nil)
+	if err != nil {
+		return TuneResult{}, err
+	}
+	lastT := time.Now()
+	for {
+		if !utils.SelectContextOrWait(ctx, dt) {
+			return TuneResult{}, errors.Wrap(ctx.Err(), "tuning did not complete")
+		}
+		pos, err := c.Position(ctx, nil)
+		if err != nil {
+			return TuneResult{}, err
+		}
+		now := time.Now()
+		rpm := (pos - lastPos) * 60.0 / now.Sub(lastT).Seconds()
+		lastPos, lastT = pos, now
+		if cfg.MaxRPM > 0 && math.Abs(rpm) > cfg.MaxRPM {
+			return TuneResult{}, errors.Errorf("measured speed %1.2f rpm exceeds max_rpm %1.2f, aborting tuning", rpm, cfg.MaxRPM)
+		}
+		out, done := tuner.pidTunerStep(math.Abs(rpm), logger)
+		if done {
+			break
+		}
+		out = math.Max(math.Min(out, cfg.MaxPower), -cfg.MaxPower)
+		if err := c.SetPower(ctx, out, nil); err != nil {
+			return TuneResult{}, err
+		}
+	}
+	if tuner.kP == 0 && tuner.kI == 0 && tuner.kD == 0 {
+		return TuneResult{}, errors.New("tuning failed to compute gains, the system did not reach steady state")
+	}
+	return TuneResult{
+		Method:       cfg.Method,
+		KP:           tuner.kP,
+		KI:           tuner.kI,
+		KD:           tuner.kD,
+		StepResponse: stepResponseMetrics(tuner.stepRsp, tuner.stepRespT, tuner.avgSpeedSS),
+	}, nil
+}
+
+// stepResponseMetrics computes the 10-90% rise time, the 5% settling time and the overshoot
+// of a step response sampled at times.
+func stepResponseMetrics(rsp []float64, times []time.Time, steadyState float64) StepResponse {
+	metrics := StepResponse{SteadyState: steadyState, Samples: len(rsp)}
+	if len(rsp) == 0 || steadyState == 0 {
+		return metrics
+	}
+	metrics.RiseTime = pidTunerFindTCat(rsp, times, 0.9*steadyState) - pidTunerFindTCat(rsp, times, 0.1*steadyState)
+	peak := rsp[0]
+	for i, v := range rsp {
+		peak = math.Max(peak, v)
+		if math.Abs(v-steadyState) > 0.05*math.Abs(steadyState) {
+			metrics.SettlingTime = times[i].Sub(times[0])
+		}
+	}
+	metrics.OvershootPct = math.Max(0, 100*(peak-steadyState)/steadyState)
+	return metrics
+}
+
+// ApplyTo returns a copy of cfg where the gains of the PID block named blockName are replaced by
+// the tuned gains. When blockName is empty the first PID block is used.
+func (r TuneResult) ApplyTo(cfg Config, blockName string) (Config, error) {
+	out := Config{Frequency: cfg.Frequency, Blocks: make([]BlockConfig, len(cfg.Blocks))}
+	found := false
+	for i, b := range cfg.Blocks {
+		out.Blocks[i] = b
+		if found || b.Type != blockPID || (blockName != "" && b.Name != blockName) {
+			continue
+		}
+		attrs := make(map[string]interface{}, len(b.Attribute)+3)
+		for k, v := range b.Attribute {
+			attrs[k] = v
+		}
+		attrs["kP"] = r.KP
+		attrs["kI"] = r.KI
+		attrs["kD"] = r.KD
+		out.Blocks[i].Attribute = attrs
+		found = true
+	}
+	if !found {
+		return Config{}, errors.Errorf("cannot apply tuned gains, no PID block %q in control config", blockName)
+	}
+	return out, nil
+}
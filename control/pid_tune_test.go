@@ -0,0 +1,97 @@
+package control
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/utils"
+)
+
+// firstOrderMotor simulates a motor whose speed follows the applied power with a first order lag.
+type firstOrderMotor struct {
+	mu       sync.Mutex
+	power    float64
+	maxPower float64
+	speed    float64
+	pos      float64
+	last     time.Time
+}
+
+func (m *firstOrderMotor) update() {
+	now := time.Now()
+	dt := now.Sub(m.last).Seconds()
+	m.last = now
+	m.speed += (300*m.power - m.speed) * (1 - math.Exp(-dt/0.05))
+	m.pos += m.speed * dt / 60
+}
+
+func (m *firstOrderMotor) SetPower(ctx context.Context, power float64, extra map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.update()
+	m.power = power
+	m.maxPower = math.Max(m.maxPower, math.Abs(power))
+	return nil
+}
+
+func (m *firstOrderMotor) Position(ctx context.Context, extra map[string]interface{}) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.update()
+	return m.pos, nil
+}
+
+func TestTune(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+
+	_, err := Tune(ctx, &firstOrderMotor{last: time.Now()}, TuneConfig{StepPct: 2}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "tune_step_pct")
+
+	m := &firstOrderMotor{last: time.Now()}
+	_, err = Tune(ctx, m, TuneConfig{Method: string(tuneMethodCohenCoonsPI), MaxPower: 0.5, MaxRPM: 10}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "exceeds max_rpm")
+	test.That(t, m.power, test.ShouldEqual, 0)
+
+	m = &firstOrderMotor{last: time.Now()}
+	res, err := Tune(ctx, m, TuneConfig{Method: string(tuneMethodCohenCoonsPI), MaxPower: 0.5, Frequency: 100}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res.KP, test.ShouldBeGreaterThan, 0)
+	test.That(t, res.KI, test.ShouldBeGreaterThan, 0)
+	test.That(t, res.StepResponse.SteadyState, test.ShouldAlmostEqual, 300*0.5*0.35, 5)
+	test.That(t, res.StepResponse.RiseTime, test.ShouldBeGreaterThan, 0)
+	test.That(t, res.StepResponse.Samples, test.ShouldBeGreaterThan, 20)
+	test.That(t, m.maxPower, test.ShouldBeLessThanOrEqualTo, 0.5)
+	test.That(t, m.power, test.ShouldEqual, 0)
+}
+
+func TestTuneResultApplyTo(t *testing.T) {
+	cfg := Config{
+		Frequency: 100,
+		Blocks: []BlockConfig{
+			{Name: "A", Type: "endpoint", Attribute: utils.AttributeMap{"motor_name": "m"}},
+			{Name: "PID", Type: "PID", Attribute: utils.AttributeMap{"kP": 1.0, "limit_up": 100.0}, DependsOn: []string{"A"}},
+		},
+	}
+	res := TuneResult{KP: 0.5, KI: 0.25, KD: 0.1}
+
+	_, err := res.ApplyTo(cfg, "B")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	out, err := res.ApplyTo(cfg, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out.Frequency, test.ShouldEqual, 100)
+	test.That(t, out.Blocks[1].Attribute.Float64("kP", 0), test.ShouldEqual, 0.5)
+	test.That(t, out.Blocks[1].Attribute.Float64("kI", 0), test.ShouldEqual, 0.25)
+	test.That(t, out.Blocks[1].Attribute.Float64("kD", 0), test.ShouldEqual, 0.1)
+	test.That(t, out.Blocks[1].Attribute.Float64("limit_up", 0), test.ShouldEqual, 100)
+	test.That(t, cfg.Blocks[1].Attribute.Float64("kP", 0), test.ShouldEqual, 1.0)
+}
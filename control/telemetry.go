@@ -0,0 +1,114 @@
+package control
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultTelemetryBufferSize = 1000
+
+// TelemetryConfig enables recording of the signals flowing through blocks of a loop.
+type TelemetryConfig struct {
+	Blocks     []string `json:"blocks,omitempty"`      // names of the blocks to record, all blocks when empty
+	BufferSize int      `json:"buffer_size,omitempty"` // number of samples kept per block, defaults to 1000
+}
+
+// TelemetrySample is a snapshot of a block's signals at one iteration of the loop.
+// A block with two inputs (typically a sum) reports them as setpoint and measured value, a block
+// with a single input (typically a PID) reports it as the error and borrows the setpoint and measured
+// value from the two inputs block it depends on if there is one. Source blocks only report their output
+// as the measured value.
+type TelemetrySample struct {
+	Time     time.Time
+	Block    string
+	Setpoint float64
+	Measured float64
+	Error    float64
+	Output   float64
+}
+
+// TelemetryBuffer is a fixed size ring buffer of telemetry samples, safe for concurrent use.
+type TelemetryBuffer struct {
+	mu      sync.Mutex
+	samples []TelemetrySample
+	next    int
+	full    bool
+}
+
+func newTelemetryBuffer(size int) *TelemetryBuffer {
+	if size <= 0 {
+		size = defaultTelemetryBufferSize
+	}
+	return &TelemetryBuffer{samples: make([]TelemetrySample, size)}
+}
+
+func (b *TelemetryBuffer) add(s TelemetrySample) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.samples[b.next] = s
+	b.next = (b.next + 1) % len(b.samples)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Len returns the number of samples currently held in the buffer.
+func (b *TelemetryBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.full {
+		return len(b.samples)
+	}
+	return b.next
+}
+
+// Samples returns a copy of the samples held in the buffer, oldest first.
+func (b *TelemetryBuffer) Samples() []TelemetrySample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		return append([]TelemetrySample{}, b.samples[:b.next]...)
+	}
+	out := make([]TelemetrySample, 0, len(b.samples))
+	out = append(out, b.samples[b.next:]...)
+	return append(out, b.samples[:b.next]...)
+}
+
+// SamplesSince returns the samples recorded strictly after t, oldest first.
+func (b *TelemetryBuffer) SamplesSince(t time.Time) []TelemetrySample {
+	samples := b.Samples()
+	for i, s := range samples {
+		if s.Time.After(t) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
+
+// WriteTelemetryCSV writes samples to w as CSV preceded by a header row, times are in unix nanoseconds.
+func WriteTelemetryCSV(w io.Writer, samples []TelemetrySample) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time_unix_nano", "block", "setpoint", "measured", "error", "output"}); err != nil {
+		return err
+	}
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	for _, s := range samples {
+		if err := cw.Write([]string{
+			strconv.FormatInt(s.Time.UnixNano(), 10),
+			s.Block,
+			formatFloat(s.Setpoint),
+			formatFloat(s.Measured),
+			formatFloat(s.Error),
+			formatFloat(s.Output),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
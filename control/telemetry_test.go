@@ -0,0 +1,144 @@
+package control
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/utils"
+)
+
+func TestTelemetryBuffer(t *testing.T) {
+	buf := newTelemetryBuffer(3)
+	test.That(t, buf.Len(), test.ShouldEqual, 0)
+	test.That(t, buf.Samples(), test.ShouldBeEmpty)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		buf.add(TelemetrySample{Time: start.Add(time.Duration(i) * time.Second), Output: float64(i)})
+	}
+	test.That(t, buf.Len(), test.ShouldEqual, 3)
+	samples := buf.Samples()
+	test.That(t, len(samples), test.ShouldEqual, 3)
+	for i, s := range samples {
+		test.That(t, s.Output, test.ShouldEqual, float64(i+2))
+	}
+
+	since := buf.SamplesSince(start.Add(3 * time.Second))
+	test.That(t, len(since), test.ShouldEqual, 1)
+	test.That(t, since[0].Output, test.ShouldEqual, 4.0)
+	test.That(t, buf.SamplesSince(start.Add(time.Minute)), test.ShouldBeEmpty)
+}
+
+func TestWriteTelemetryCSV(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteTelemetryCSV(&b, []TelemetrySample{
+		{Time: time.Unix(0, 42), Block: "PID", Setpoint: 10, Measured: 7.5, Error: 2.5, Output: 0.25},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	test.That(t, lines, test.ShouldResemble, []string{
+		"time_unix_nano,block,setpoint,measured,error,output",
+		"42,PID,10,7.5,2.5,0.25",
+	})
+}
+
+func TestControlLoopTelemetry(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	cfg := Config{
+		Blocks: []BlockConfig{
+			{
+				Name:      "A",
+				Type:      "constant",
+				Attribute: utils.AttributeMap{"constant_val": 10.0},
+			},
+			{
+				Name:      "S1",
+				Type:      "constant",
+				Attribute: utils.AttributeMap{"constant_val": 3.0},
+			},
+			{
+				Name:      "B",
+				Type:      "sum",
+				Attribute: utils.AttributeMap{"sum_string": "+-"},
+				DependsOn: []string{"A", "S1"},
+			},
+			{
+				Name:      "C",
+				Type:      "gain",
+				Attribute: utils.AttributeMap{"gain": 2.0},
+				DependsOn: []string{"B"},
+			},
+			{
+				Name:      "D",
+				Type:      "sum",
+				Attribute: utils.AttributeMap{"sum_string": "-+"},
+				DependsOn: []string{"S1", "A"},
+			},
+		},
+		Frequency: 50.0,
+		Telemetry: &TelemetryConfig{Blocks: []string{"B", "C", "D"}, BufferSize: 10},
+	}
+
+	bad := cfg
+	bad.Telemetry = &TelemetryConfig{Blocks: []string{"Z"}}
+	_, err := createLoop(logger, bad, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldEqual, "cannot record telemetry for non existing block Z")
+
+	cLoop, err := createLoop(logger, cfg, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cLoop.Start(), test.ShouldBeNil)
+	time.Sleep(300 * time.Millisecond)
+	cLoop.Stop()
+
+	test.That(t, cLoop.TelemetryBlockList(), test.ShouldHaveLength, 3)
+	_, err = cLoop.Telemetry("A")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	sumBuf, err := cLoop.Telemetry("B")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sumBuf.Len(), test.ShouldBeGreaterThan, 0)
+	last := sumBuf.Samples()[sumBuf.Len()-1]
+	test.That(t, last.Block, test.ShouldEqual, "B")
+	test.That(t, last.Setpoint, test.ShouldEqual, 10.0)
+	test.That(t, last.Measured, test.ShouldEqual, 3.0)
+	test.That(t, last.Error, test.ShouldEqual, 7.0)
+	test.That(t, last.Output, test.ShouldEqual, 7.0)
+
+	gainBuf, err := cLoop.Telemetry("C")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, gainBuf.Len(), test.ShouldBeGreaterThan, 0)
+	last = gainBuf.Samples()[gainBuf.Len()-1]
+	test.That(t, last.Setpoint, test.ShouldEqual, 10.0)
+	test.That(t, last.Measured, test.ShouldEqual, 3.0)
+	test.That(t, last.Error, test.ShouldEqual, 7.0)
+	test.That(t, last.Output, test.ShouldEqual, 14.0)
+
+	// the signs of the sum string tell the setpoint from the measured value
+	reversedBuf, err := cLoop.Telemetry("D")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, reversedBuf.Len(), test.ShouldBeGreaterThan, 0)
+	last = reversedBuf.Samples()[reversedBuf.Len()-1]
+	test.That(t, last.Setpoint, test.ShouldEqual, 10.0)
+	test.That(t, last.Measured, test.ShouldEqual, 3.0)
+	test.That(t, last.Error, test.ShouldEqual, 7.0)
+	test.That(t, last.Output, test.ShouldEqual, 7.0)
+
+	// recording a sample does not allocate once the inputs of the block are known
+	a, s1, d := makeSignal("A"), makeSignal("S1"), makeSignal("D")
+	a.SetSignalValueAt(0, 10)
+	s1.SetSignalValueAt(0, 3)
+	d.SetSignalValueAt(0, 7)
+	dCfg := cfg.Blocks[4]
+	x, y := []*Signal{a, s1}, []*Signal{d}
+	allocs := testing.AllocsPerRun(100, func() { cLoop.recordTelemetry(dCfg, x, y) })
+	test.That(t, allocs, test.ShouldEqual, 0)
+	last = reversedBuf.Samples()[reversedBuf.Len()-1]
+	test.That(t, last.Setpoint, test.ShouldEqual, 10.0)
+	test.That(t, last.Error, test.ShouldEqual, 7.0)
+}
@@ -12,6 +12,9 @@ import (
 
 // GoLearnClassifier TODO.
 type GoLearnClassifier struct {
+	// K is the number of nearest neighbors that vote on the class, 2 by default.
+	K int
+
 	theClassifier base.Classifier
 	format        *base.DenseInstances
 }
@@ -37,7 +40,11 @@ func (c *GoLearnClassifier) Train(data [][]float64, correct []int) error {
 
 	c.format = base.NewStructuralCopy(rawData)
 
-	c.theClassifier = knn.NewKnnClassifier("euclidean", "linear", 2)
+	k := c.K
+	if k <= 0 {
+		k = 2
+	}
+	c.theClassifier = knn.NewKnnClassifier("euclidean", "linear", k)
 
 	return c.theClassifier.Fit(rawData)
 }
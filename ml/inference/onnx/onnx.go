@@ -0,0 +1,264 @@
+// Package onnx runs ONNX models on the CPU in pure Go. It supports the operators of the default ONNX
+// domain that classifiers, detectors and multilayer perceptrons exported from PyTorch are commonly
+// made of, and reports the models using other operators as unsupported when they are loaded.
+package onnx
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TensorInfo describes an input or output of the graph of a model.
+type TensorInfo struct {
+	Name        string
+	Description string
+	// DataType is the Go name of the element type, e.g. float32 or uint8.
+	DataType string
+	// Shape holds -1 for the dimensions without a fixed size, such as the batch size.
+	Shape []int
+}
+
+// Info describes a model and the inputs and outputs of its graph.
+type Info struct {
+	GraphName    string
+	ProducerName string
+	Description  string
+	// MetadataProps are the key value pairs stored in the model by its producer.
+	MetadataProps map[string]string
+	Inputs        []TensorInfo
+	Outputs       []TensorInfo
+}
+
+// Tensor is an input or output of a model, with its elements flattened in row major order into a
+// slice such as []float32 or []uint8.
+type Tensor struct {
+	Shape []int
+	Data  interface{}
+}
+
+// Model is a loaded ONNX model. Inference does not modify it, so it can run concurrently.
+type Model struct {
+	Info         Info
+	nodes        []*node
+	initializers map[string]*tensor
+	inputs       []*valueInfoProto
+}
+
+// node is a node of the graph along with the opset version its operator is defined by.
+type node struct {
+	*nodeProto
+	opset int64
+	op    opFunc
+}
+
+// Load reads the ONNX model at the path.
+func Load(path string) (*Model, error) {
+	//nolint:gosec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load ONNX model %s", path)
+	}
+	return m, nil
+}
+
+// Parse decodes a serialized ONNX ModelProto. It fails if the graph uses operators that are not
+// supported or nodes that lack the inputs or attributes their operator needs.
+func Parse(b []byte) (*Model, error) {
+	mp, err := parseModel(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ONNX model")
+	}
+	g := mp.graph
+	m := &Model{
+		Info: Info{
+			GraphName:     g.name,
+			ProducerName:  mp.producerName,
+			Description:   mp.docString,
+			MetadataProps: mp.metadataProps,
+		},
+		initializers: map[string]*tensor{},
+	}
+	if m.Info.Description == "" {
+		m.Info.Description = g.docString
+	}
+	for _, tp := range g.initializers {
+		t, err := tensorFromProto(tp)
+		if err != nil {
+			return nil, err
+		}
+		m.initializers[tp.name] = t
+	}
+
+	opset, ok := mp.opsetImports[""]
+	if !ok {
+		return nil, errors.New("model does not import the default ONNX operator set")
+	}
+	unsupported := map[string]bool{}
+	for _, np := range g.nodes {
+		op, ok := operators[np.opType]
+		if np.domain != "" || !ok {
+			unsupported[strings.TrimPrefix(np.domain+"."+np.opType, ".")] = true
+			continue
+		}
+		n := &node{nodeProto: np, opset: opset, op: op}
+		if err := checkNode(n); err != nil {
+			return nil, err
+		}
+		m.nodes = append(m.nodes, n)
+	}
+	if len(unsupported) > 0 {
+		ops := make([]string, 0, len(unsupported))
+		for op := range unsupported {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		return nil, errors.Errorf("model uses unsupported operators %s", strings.Join(ops, ", "))
+	}
+
+	// older exporters list the initializers among the inputs as well, they are constants here
+	for _, vi := range g.inputs {
+		if _, ok := m.initializers[vi.name]; ok {
+			continue
+		}
+		m.inputs = append(m.inputs, vi)
+		m.Info.Inputs = append(m.Info.Inputs, tensorInfo(vi))
+	}
+	for _, vi := range g.outputs {
+		m.Info.Outputs = append(m.Info.Outputs, tensorInfo(vi))
+	}
+	return m, nil
+}
+
+func tensorInfo(vi *valueInfoProto) TensorInfo {
+	return TensorInfo{
+		Name:        vi.name,
+		Description: vi.docString,
+		DataType:    vi.elemType.String(),
+		Shape:       append([]int{}, vi.dims...),
+	}
+}
+
+// Infer runs the graph on the inputs, which are keyed by the names of the graph inputs, and returns
+// its outputs keyed by their names. Inputs whose shape has no dimension of unknown size may leave
+// their Shape empty.
+func (m *Model) Infer(inputs map[string]Tensor) (map[string]Tensor, error) {
+	values := make(map[string]*tensor, len(m.initializers)+len(m.nodes))
+	for name, t := range m.initializers {
+		values[name] = t
+	}
+	for _, vi := range m.inputs {
+		in, ok := inputs[vi.name]
+		if !ok {
+			return nil, errors.Errorf("missing input %q", vi.name)
+		}
+		shape := in.Shape
+		if shape == nil {
+			shape = vi.dims
+		}
+		for _, d := range shape {
+			if d < 0 {
+				return nil, errors.Errorf("input %q needs a shape, its dimensions are %v", vi.name, vi.dims)
+			}
+		}
+		if vi.hasShape && !shapeMatches(vi.dims, shape) {
+			return nil, errors.Errorf("input %q has shape %v, expected %v", vi.name, shape, vi.dims)
+		}
+		t, err := tensorFromSlice(in.Data, vi.elemType, shape)
+		if err != nil {
+			return nil, errors.Wrapf(err, "input %q", vi.name)
+		}
+		values[vi.name] = t
+	}
+
+	for _, n := range m.nodes {
+		args := make([]*tensor, len(n.inputs))
+		for k, name := range n.inputs {
+			if name == "" {
+				continue
+			}
+			v, ok := values[name]
+			if !ok {
+				return nil, errors.Errorf("node %s needs %q which is not computed before it", n.describe(), name)
+			}
+			args[k] = v
+		}
+		if len(args) == 0 && n.opType != "Constant" {
+			return nil, errors.Errorf("node %s has no inputs", n.describe())
+		}
+		results, err := n.op(n, args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "node %s", n.describe())
+		}
+		for k, name := range n.outputs {
+			if k < len(results) && name != "" {
+				values[name] = results[k]
+			}
+		}
+	}
+
+	outputs := make(map[string]Tensor, len(m.Info.Outputs))
+	for _, info := range m.Info.Outputs {
+		t, ok := values[info.Name]
+		if !ok {
+			return nil, errors.Errorf("output %q was not computed", info.Name)
+		}
+		outputs[info.Name] = Tensor{Shape: t.shape, Data: t.toSlice()}
+	}
+	return outputs, nil
+}
+
+// shapeMatches returns whether the shape fits the dimensions of a graph input.
+func shapeMatches(dims, shape []int) bool {
+	if len(dims) != len(shape) {
+		return false
+	}
+	for k, d := range dims {
+		if d >= 0 && d != shape[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *node) describe() string {
+	if n.name != "" {
+		return fmt.Sprintf("%s (%s)", n.name, n.opType)
+	}
+	return n.opType
+}
+
+func (n *node) attrInt(name string, def int64) int64 {
+	if a, ok := n.attributes[name]; ok {
+		return a.i
+	}
+	return def
+}
+
+func (n *node) attrFloat(name string, def float64) float64 {
+	if a, ok := n.attributes[name]; ok {
+		return float64(a.f)
+	}
+	return def
+}
+
+func (n *node) attrString(name, def string) string {
+	if a, ok := n.attributes[name]; ok {
+		return string(a.s)
+	}
+	return def
+}
+
+func (n *node) attrInts(name string) ([]int64, bool) {
+	if a, ok := n.attributes[name]; ok {
+		return a.ints, true
+	}
+	return nil, false
+}
@@ -0,0 +1,372 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/test"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The helpers below encode the parts of the ONNX protobuf schema the tests need.
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendVarint(b []byte, num protowire.Number, v int64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func floatTensor(name string, dims []int64, values []float32) []byte {
+	var b []byte
+	for _, d := range dims {
+		b = appendVarint(b, 1, d)
+	}
+	b = appendVarint(b, 2, int64(typeFloat))
+	b = appendString(b, 8, name)
+	raw := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(v))
+	}
+	return appendMessage(b, 9, raw)
+}
+
+func intTensor(name string, dims, values []int64) []byte {
+	var b []byte
+	for _, d := range dims {
+		b = appendVarint(b, 1, d)
+	}
+	b = appendVarint(b, 2, int64(typeInt64))
+	b = appendString(b, 8, name)
+	var packed []byte
+	for _, v := range values {
+		packed = protowire.AppendVarint(packed, uint64(v))
+	}
+	return appendMessage(b, 7, packed)
+}
+
+// valueInfo describes a tensor of the type, where negative dimensions have no fixed size.
+func valueInfo(name string, dt dataType, dims ...int64) []byte {
+	var shape []byte
+	for _, d := range dims {
+		var dim []byte
+		if d < 0 {
+			dim = appendString(dim, 2, "batch")
+		} else {
+			dim = appendVarint(dim, 1, d)
+		}
+		shape = appendMessage(shape, 1, dim)
+	}
+	tensorType := appendVarint(nil, 1, int64(dt))
+	tensorType = appendMessage(tensorType, 2, shape)
+	b := appendString(nil, 1, name)
+	return appendMessage(b, 2, appendMessage(nil, 1, tensorType))
+}
+
+func intAttr(name string, v int64) []byte {
+	return appendVarint(appendString(nil, 1, name), 3, v)
+}
+
+func intsAttr(name string, v ...int64) []byte {
+	b := appendString(nil, 1, name)
+	for _, x := range v {
+		b = appendVarint(b, 8, x)
+	}
+	return b
+}
+
+func floatAttr(name string, v float32) []byte {
+	b := appendString(nil, 1, name)
+	b = protowire.AppendTag(b, 2, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, math.Float32bits(v))
+}
+
+func tensorAttr(name string, t []byte) []byte {
+	return appendMessage(appendString(nil, 1, name), 5, t)
+}
+
+func nodeProtoBytes(op string, inputs, outputs []string, attrs ...[]byte) []byte {
+	var b []byte
+	for _, in := range inputs {
+		b = appendString(b, 1, in)
+	}
+	for _, out := range outputs {
+		b = appendString(b, 2, out)
+	}
+	b = appendString(b, 4, op)
+	for _, a := range attrs {
+		b = appendMessage(b, 5, a)
+	}
+	return b
+}
+
+type testGraph struct {
+	opset        int64
+	nodes        [][]byte
+	initializers [][]byte
+	inputs       [][]byte
+	outputs      [][]byte
+	props        map[string]string
+}
+
+func (g testGraph) encode() []byte {
+	var graph []byte
+	for _, n := range g.nodes {
+		graph = appendMessage(graph, 1, n)
+	}
+	graph = appendString(graph, 2, "test_graph")
+	for _, t := range g.initializers {
+		graph = appendMessage(graph, 5, t)
+	}
+	for _, in := range g.inputs {
+		graph = appendMessage(graph, 11, in)
+	}
+	for _, out := range g.outputs {
+		graph = appendMessage(graph, 12, out)
+	}
+	b := appendVarint(nil, 1, 8)
+	b = appendString(b, 2, "pytorch")
+	b = appendString(b, 6, "a test model")
+	b = appendMessage(b, 7, graph)
+	opset := g.opset
+	if opset == 0 {
+		opset = 13
+	}
+	b = appendMessage(b, 8, appendVarint(appendString(nil, 1, ""), 2, opset))
+	for k, v := range g.props {
+		b = appendMessage(b, 14, appendString(appendString(nil, 1, k), 2, v))
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse([]byte{0xff, 0xff})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	_, err = Parse(testGraph{
+		nodes: [][]byte{
+			nodeProtoBytes("LSTM", []string{"x"}, []string{"y"}),
+			nodeProtoBytes("NonMaxSuppression", []string{"y"}, []string{"z"}),
+			nodeProtoBytes("Relu", []string{"z"}, []string{"out"}),
+		},
+		inputs:  [][]byte{valueInfo("x", typeFloat, 1, 3)},
+		outputs: [][]byte{valueInfo("out", typeFloat, 1, 3)},
+	}.encode())
+	test.That(t, err, test.ShouldBeError, "model uses unsupported operators LSTM, NonMaxSuppression")
+
+	// malformed nodes are rejected when loading instead of failing every inference
+	for _, tc := range []struct {
+		node []byte
+		err  string
+	}{
+		{nodeProtoBytes("Add", []string{"x"}, []string{"out"}), "node Add needs 2 inputs, got 1"},
+		{nodeProtoBytes("Sum", nil, []string{"out"}), "node Sum needs 1 inputs, got 0"},
+		{nodeProtoBytes("Gather", []string{"x", ""}, []string{"out"}), "node Gather is missing its required input 1"},
+		{nodeProtoBytes("Transpose", []string{"x"}, []string{"out"}, intsAttr("perm", 0, 2)), "node Transpose has invalid permutation [0 2]"},
+		{nodeProtoBytes("Transpose", []string{"x"}, []string{"out"}, intsAttr("perm", 1, 1)), "node Transpose has invalid permutation [1 1]"},
+		{nodeProtoBytes("Transpose", []string{"x"}, []string{"out"}, intsAttr("perm", -1, 0)), "node Transpose has invalid permutation [-1 0]"},
+	} {
+		_, err = Parse(testGraph{
+			nodes:   [][]byte{tc.node},
+			inputs:  [][]byte{valueInfo("x", typeFloat, 1, 3)},
+			outputs: [][]byte{valueInfo("out", typeFloat, 1, 3)},
+		}.encode())
+		test.That(t, err, test.ShouldBeError, tc.err)
+	}
+
+	m, err := Parse(testGraph{
+		nodes:        [][]byte{nodeProtoBytes("Add", []string{"image", "bias"}, []string{"scores"})},
+		initializers: [][]byte{floatTensor("bias", []int64{3}, []float32{1, 2, 3})},
+		// the initializer is listed among the inputs like older exporters do
+		inputs:  [][]byte{valueInfo("image", typeUInt8, -1, 3), valueInfo("bias", typeFloat, 3)},
+		outputs: [][]byte{valueInfo("scores", typeFloat, -1, 3)},
+		props:   map[string]string{"model_type": "classifier"},
+	}.encode())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.Info.GraphName, test.ShouldEqual, "test_graph")
+	test.That(t, m.Info.ProducerName, test.ShouldEqual, "pytorch")
+	test.That(t, m.Info.Description, test.ShouldEqual, "a test model")
+	test.That(t, m.Info.MetadataProps, test.ShouldResemble, map[string]string{"model_type": "classifier"})
+	test.That(t, m.Info.Inputs, test.ShouldResemble, []TensorInfo{{Name: "image", DataType: "uint8", Shape: []int{-1, 3}}})
+	test.That(t, m.Info.Outputs, test.ShouldResemble, []TensorInfo{{Name: "scores", DataType: "float32", Shape: []int{-1, 3}}})
+
+	// uint8 inputs are added to float initializers as floats
+	out, err := m.Infer(map[string]Tensor{"image": {Shape: []int{2, 3}, Data: []uint8{0, 1, 2, 3, 4, 5}}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["scores"].Shape, test.ShouldResemble, []int{2, 3})
+	test.That(t, out["scores"].Data, test.ShouldResemble, []float32{1, 3, 5, 4, 6, 8})
+
+	_, err = m.Infer(map[string]Tensor{})
+	test.That(t, err, test.ShouldBeError, `missing input "image"`)
+	_, err = m.Infer(map[string]Tensor{"image": {Data: []uint8{0, 1, 2}}})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "needs a shape")
+	_, err = m.Infer(map[string]Tensor{"image": {Shape: []int{1, 4}, Data: []uint8{0, 1, 2, 3}}})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "expected [-1 3]")
+	_, err = m.Infer(map[string]Tensor{"image": {Shape: []int{1, 3}, Data: []uint8{0, 1}}})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "has 2 elements")
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.onnx"))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	path := filepath.Join(t.TempDir(), "leaky_relu.onnx")
+	test.That(t, os.WriteFile(path, testGraph{
+		nodes:   [][]byte{nodeProtoBytes("LeakyRelu", []string{"x"}, []string{"y"}, floatAttr("alpha", 0.5))},
+		inputs:  [][]byte{valueInfo("x", typeFloat, 4)},
+		outputs: [][]byte{valueInfo("y", typeFloat, 4)},
+	}.encode(), 0o600), test.ShouldBeNil)
+	m, err := Load(path)
+	test.That(t, err, test.ShouldBeNil)
+	// a fixed shape does not need to be given
+	out, err := m.Infer(map[string]Tensor{"x": {Data: []float32{-1, 2, -3, 4}}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["y"].Data, test.ShouldResemble, []float32{-0.5, 2, -1.5, 4})
+}
+
+func TestMultilayerPerceptron(t *testing.T) {
+	m, err := Parse(testGraph{
+		nodes: [][]byte{
+			nodeProtoBytes("Gemm", []string{"x", "w1", "b1"}, []string{"h"}, intAttr("transB", 1)),
+			nodeProtoBytes("Relu", []string{"h"}, []string{"a"}),
+			nodeProtoBytes("MatMul", []string{"a", "w2"}, []string{"logits"}),
+			nodeProtoBytes("Softmax", []string{"logits"}, []string{"probability"}, intAttr("axis", -1)),
+		},
+		initializers: [][]byte{
+			floatTensor("w1", []int64{2, 3}, []float32{1, 0, -1, 0, 1, 1}),
+			floatTensor("b1", []int64{2}, []float32{0, -1}),
+			floatTensor("w2", []int64{2, 2}, []float32{1, 0, 0, 2}),
+		},
+		inputs:  [][]byte{valueInfo("x", typeFloat, -1, 3)},
+		outputs: [][]byte{valueInfo("probability", typeFloat, -1, 2)},
+	}.encode())
+	test.That(t, err, test.ShouldBeNil)
+	out, err := m.Infer(map[string]Tensor{"x": {Shape: []int{2, 3}, Data: []float32{3, 1, 1, 0, 1, 2}}})
+	test.That(t, err, test.ShouldBeNil)
+	// the hidden layer is [2, 1] and [0, 2] so the logits are [2, 2] and [0, 4]
+	probs := out["probability"].Data.([]float32)
+	test.That(t, out["probability"].Shape, test.ShouldResemble, []int{2, 2})
+	test.That(t, probs[0], test.ShouldAlmostEqual, 0.5, 1e-6)
+	test.That(t, probs[1], test.ShouldAlmostEqual, 0.5, 1e-6)
+	test.That(t, probs[2], test.ShouldAlmostEqual, 1/(1+math.Exp(4)), 1e-6)
+	test.That(t, probs[3], test.ShouldAlmostEqual, 1/(1+math.Exp(-4)), 1e-6)
+}
+
+func TestConvolutionAndPooling(t *testing.T) {
+	image := make([]float32, 16)
+	for i := range image {
+		image[i] = float32(i)
+	}
+	ones := func(n int) []float32 {
+		out := make([]float32, n)
+		for i := range out {
+			out[i] = 1
+		}
+		return out
+	}
+	m, err := Parse(testGraph{
+		nodes: [][]byte{
+			nodeProtoBytes("Conv", []string{"x", "k2", "b"}, []string{"c"}, intsAttr("kernel_shape", 2, 2)),
+			nodeProtoBytes("MaxPool", []string{"c"}, []string{"max"}, intsAttr("kernel_shape", 2, 2)),
+			nodeProtoBytes("AveragePool", []string{"c"}, []string{"avg"}, intsAttr("kernel_shape", 3, 3), intsAttr("pads", 1, 1, 1, 1)),
+			nodeProtoBytes("GlobalAveragePool", []string{"max"}, []string{"global"}),
+			nodeProtoBytes("Flatten", []string{"global"}, []string{"flat"}),
+			nodeProtoBytes("Conv", []string{"x", "k3"}, []string{"strided"}, intsAttr("strides", 2, 2), intsAttr("pads", 1, 1, 1, 1)),
+		},
+		initializers: [][]byte{
+			floatTensor("k2", []int64{1, 1, 2, 2}, ones(4)),
+			floatTensor("k3", []int64{1, 1, 3, 3}, ones(9)),
+			floatTensor("b", []int64{1}, []float32{1}),
+		},
+		inputs: [][]byte{valueInfo("x", typeFloat, 1, 1, 4, 4)},
+		outputs: [][]byte{
+			valueInfo("c", typeFloat), valueInfo("max", typeFloat), valueInfo("avg", typeFloat),
+			valueInfo("flat", typeFloat), valueInfo("strided", typeFloat),
+		},
+	}.encode())
+	test.That(t, err, test.ShouldBeNil)
+	out, err := m.Infer(map[string]Tensor{"x": {Data: image}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["c"].Shape, test.ShouldResemble, []int{1, 1, 3, 3})
+	test.That(t, out["c"].Data, test.ShouldResemble, []float32{11, 15, 19, 27, 31, 35, 43, 47, 51})
+	test.That(t, out["max"].Data, test.ShouldResemble, []float32{31, 35, 47, 51})
+	// padding is left out of the averages
+	avg := out["avg"].Data.([]float32)
+	test.That(t, out["avg"].Shape, test.ShouldResemble, []int{1, 1, 3, 3})
+	test.That(t, avg[0], test.ShouldAlmostEqual, 21)
+	test.That(t, avg[4], test.ShouldAlmostEqual, 31)
+	test.That(t, out["flat"].Shape, test.ShouldResemble, []int{1, 1})
+	test.That(t, out["flat"].Data, test.ShouldResemble, []float32{41})
+	test.That(t, out["strided"].Shape, test.ShouldResemble, []int{1, 1, 2, 2})
+	test.That(t, out["strided"].Data, test.ShouldResemble, []float32{10, 24, 51, 90})
+}
+
+func TestShapeOperators(t *testing.T) {
+	// the graph of x.view(x.size(0), -1) followed by slicing and transposing
+	m, err := Parse(testGraph{
+		nodes: [][]byte{
+			nodeProtoBytes("Shape", []string{"x"}, []string{"shape"}),
+			nodeProtoBytes("Constant", nil, []string{"zero"}, tensorAttr("value", intTensor("", nil, []int64{0}))),
+			nodeProtoBytes("Gather", []string{"shape", "zero"}, []string{"batch"}, intAttr("axis", 0)),
+			nodeProtoBytes("Unsqueeze", []string{"batch", "axes"}, []string{"batch1"}),
+			nodeProtoBytes("Concat", []string{"batch1", "minus_one"}, []string{"new_shape"}, intAttr("axis", 0)),
+			nodeProtoBytes("Reshape", []string{"x", "new_shape"}, []string{"flat"}),
+			nodeProtoBytes("Slice", []string{"flat", "starts", "ends", "slice_axes", "steps"}, []string{"sliced"}),
+			nodeProtoBytes("Transpose", []string{"sliced"}, []string{"transposed"}),
+			nodeProtoBytes("ReduceSum", []string{"x", "axes"}, []string{"sum"}, intAttr("keepdims", 0)),
+			nodeProtoBytes("Cast", []string{"sum"}, []string{"sum_int"}, intAttr("to", int64(typeInt32))),
+			nodeProtoBytes("Clip", []string{"flat", "lo", "hi"}, []string{"clipped"}),
+		},
+		initializers: [][]byte{
+			intTensor("axes", []int64{1}, []int64{0}),
+			intTensor("slice_axes", []int64{1}, []int64{1}),
+			intTensor("minus_one", []int64{1}, []int64{-1}),
+			intTensor("starts", []int64{1}, []int64{-1}),
+			intTensor("ends", []int64{1}, []int64{math.MinInt64}),
+			intTensor("steps", []int64{1}, []int64{-2}),
+			floatTensor("lo", nil, []float32{1}),
+			floatTensor("hi", nil, []float32{4}),
+		},
+		inputs: [][]byte{valueInfo("x", typeFloat, -1, 2, 3)},
+		outputs: [][]byte{
+			valueInfo("flat", typeFloat), valueInfo("transposed", typeFloat),
+			valueInfo("sum_int", typeInt32), valueInfo("clipped", typeFloat),
+		},
+	}.encode())
+	test.That(t, err, test.ShouldBeNil)
+	x := []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	out, err := m.Infer(map[string]Tensor{"x": {Shape: []int{2, 2, 3}, Data: x}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["flat"].Shape, test.ShouldResemble, []int{2, 6})
+	test.That(t, out["flat"].Data, test.ShouldResemble, x)
+	// every other column from the last one backwards, transposed
+	test.That(t, out["transposed"].Shape, test.ShouldResemble, []int{3, 2})
+	test.That(t, out["transposed"].Data, test.ShouldResemble, []float32{5, 11, 3, 9, 1, 7})
+	test.That(t, out["sum_int"].Shape, test.ShouldResemble, []int{2, 3})
+	test.That(t, out["sum_int"].Data, test.ShouldResemble, []int32{6, 8, 10, 12, 14, 16})
+	test.That(t, out["clipped"].Data, test.ShouldResemble, []float32{1, 1, 2, 3, 4, 4, 4, 4, 4, 4, 4, 4})
+}
+
+func TestBroadcast(t *testing.T) {
+	shape, err := broadcastShape([]int{2, 1, 3}, []int{4, 1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, shape, test.ShouldResemble, []int{2, 4, 3})
+	_, err = broadcastShape([]int{2, 3}, []int{4})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, broadcastOffsets([]int{2, 3}, []int{3}), test.ShouldResemble, []int{0, 1, 2, 0, 1, 2})
+	test.That(t, broadcastOffsets([]int{2, 3}, []int{2, 1}), test.ShouldResemble, []int{0, 0, 0, 1, 1, 1})
+}
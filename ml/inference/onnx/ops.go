@@ -0,0 +1,1234 @@
+package onnx
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// opFunc computes the outputs of a node from its inputs, where inputs left out of the node are nil.
+type opFunc func(n *node, in []*tensor) ([]*tensor, error)
+
+// operators holds the supported operators of the default ONNX domain. They cover the graphs of the
+// common classifiers, detector backbones and multilayer perceptrons exported from PyTorch.
+var operators map[string]opFunc
+
+func init() {
+	operators = map[string]opFunc{
+		"Abs":                unaryOp(math.Abs),
+		"Add":                binaryOp(func(a, b float64) float64 { return a + b }, func(a, b int64) int64 { return a + b }),
+		"AveragePool":        averagePool,
+		"BatchNormalization": batchNormalization,
+		"Cast":               castOp,
+		"Ceil":               unaryOp(math.Ceil),
+		"Clip":               clip,
+		"Concat":             concat,
+		"Constant":           constant,
+		"ConstantOfShape":    constantOfShape,
+		"Conv":               conv,
+		"Div":                binaryOp(func(a, b float64) float64 { return a / b }, divInt),
+		"Dropout":            identity,
+		"Elu":                activation(elu),
+		"Erf":                unaryOp(math.Erf),
+		"Exp":                unaryOp(math.Exp),
+		"Expand":             expand,
+		"Flatten":            flatten,
+		"Floor":              unaryOp(math.Floor),
+		"Gather":             gather,
+		"Gelu":               gelu,
+		"Gemm":               gemm,
+		"GlobalAveragePool":  globalPool(false),
+		"GlobalMaxPool":      globalPool(true),
+		"HardSigmoid":        activation(hardSigmoid),
+		"HardSwish":          unaryOp(func(x float64) float64 { return x * math.Max(0, math.Min(1, x/6+0.5)) }),
+		"Identity":           identity,
+		"LeakyRelu":          activation(leakyRelu),
+		"Log":                unaryOp(math.Log),
+		"LogSoftmax":         softmax(true),
+		"MatMul":             matMul,
+		"Max":                variadicOp(math.Max, func(a, b int64) int64 { return maxInt(a, b) }),
+		"MaxPool":            maxPool,
+		"Min":                variadicOp(math.Min, func(a, b int64) int64 { return -maxInt(-a, -b) }),
+		"Mul":                binaryOp(func(a, b float64) float64 { return a * b }, func(a, b int64) int64 { return a * b }),
+		"Neg":                unaryOp(func(x float64) float64 { return -x }),
+		"Pow":                binaryOp(math.Pow, powInt),
+		"PRelu":              binaryOp(prelu, func(a, b int64) int64 { return int64(prelu(float64(a), float64(b))) }),
+		"Reciprocal":         unaryOp(func(x float64) float64 { return 1 / x }),
+		"ReduceMax":          reduce(reduceMax),
+		"ReduceMean":         reduce(reduceMean),
+		"ReduceMin":          reduce(reduceMin),
+		"ReduceSum":          reduce(reduceSum),
+		"Relu":               unaryOp(func(x float64) float64 { return math.Max(x, 0) }),
+		"Reshape":            reshape,
+		"Selu":               activation(selu),
+		"Shape":              shapeOp,
+		"Sigmoid":            unaryOp(func(x float64) float64 { return 1 / (1 + math.Exp(-x)) }),
+		"Slice":              slice,
+		"Softmax":            softmax(false),
+		"Softplus":           unaryOp(func(x float64) float64 { return math.Log1p(math.Exp(x)) }),
+		"Sqrt":               unaryOp(math.Sqrt),
+		"Squeeze":            squeeze,
+		"Sub":                binaryOp(func(a, b float64) float64 { return a - b }, func(a, b int64) int64 { return a - b }),
+		"Sum":                variadicOp(func(a, b float64) float64 { return a + b }, func(a, b int64) int64 { return a + b }),
+		"Tanh":               unaryOp(math.Tanh),
+		"Transpose":          transpose,
+		"Unsqueeze":          unsqueeze,
+	}
+}
+
+// requiredInputs holds the number of leading inputs an operator cannot run without, for the
+// operators needing more than one.
+var requiredInputs = map[string]int{
+	"Add":                2,
+	"BatchNormalization": 5,
+	"Conv":               2,
+	"Div":                2,
+	"Expand":             2,
+	"Gather":             2,
+	"Gemm":               2,
+	"MatMul":             2,
+	"Mul":                2,
+	"Pow":                2,
+	"PRelu":              2,
+	"Reshape":            2,
+	"Sub":                2,
+}
+
+// checkNode returns an error if the node lacks inputs its operator needs or has attributes that
+// would otherwise only fail when the model runs.
+func checkNode(n *node) error {
+	required, ok := requiredInputs[n.opType]
+	switch {
+	case n.opType == "Constant":
+		required = 0
+	case n.opType == "Slice" && n.opset >= 10:
+		required = 3
+	case !ok:
+		required = 1
+	}
+	if len(n.inputs) < required {
+		return errors.Errorf("node %s needs %d inputs, got %d", n.describe(), required, len(n.inputs))
+	}
+	for k, name := range n.inputs[:required] {
+		if name == "" {
+			return errors.Errorf("node %s is missing its required input %d", n.describe(), k)
+		}
+	}
+	if n.opType == "Transpose" {
+		if perm, ok := n.attrInts("perm"); ok {
+			seen := make([]bool, len(perm))
+			for _, p := range perm {
+				if p < 0 || p >= int64(len(perm)) || seen[p] {
+					return errors.Errorf("node %s has invalid permutation %v", n.describe(), perm)
+				}
+				seen[p] = true
+			}
+		}
+	}
+	return nil
+}
+
+func maxInt(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func divInt(a, b int64) int64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func powInt(a, b int64) int64 {
+	return int64(math.Pow(float64(a), float64(b)))
+}
+
+func prelu(x, slope float64) float64 {
+	if x < 0 {
+		return slope * x
+	}
+	return x
+}
+
+func identity(n *node, in []*tensor) ([]*tensor, error) {
+	return []*tensor{in[0]}, nil
+}
+
+// unaryOp applies f to every element, integer tensors keep their type.
+func unaryOp(f func(float64) float64) opFunc {
+	return activation(func(*node) func(float64) float64 { return f })
+}
+
+// activation is a unary operator whose function depends on the attributes of the node.
+func activation(makeF func(n *node) func(float64) float64) opFunc {
+	return func(n *node, in []*tensor) ([]*tensor, error) {
+		f := makeF(n)
+		x := in[0]
+		out := &tensor{dtype: x.dtype, shape: x.shape}
+		if x.dtype.isFloat() {
+			out.f = make([]float32, len(x.f))
+			for i, v := range x.f {
+				out.f[i] = float32(f(float64(v)))
+			}
+		} else {
+			out.i = make([]int64, len(x.i))
+			for i, v := range x.i {
+				out.i[i] = wrapInt(int64(f(float64(v))), x.dtype)
+			}
+		}
+		return []*tensor{out}, nil
+	}
+}
+
+func elu(n *node) func(float64) float64 {
+	alpha := n.attrFloat("alpha", 1)
+	return func(x float64) float64 {
+		if x < 0 {
+			return alpha * (math.Exp(x) - 1)
+		}
+		return x
+	}
+}
+
+func leakyRelu(n *node) func(float64) float64 {
+	alpha := n.attrFloat("alpha", 0.01)
+	return func(x float64) float64 { return prelu(x, alpha) }
+}
+
+func hardSigmoid(n *node) func(float64) float64 {
+	alpha, beta := n.attrFloat("alpha", 0.2), n.attrFloat("beta", 0.5)
+	return func(x float64) float64 { return math.Max(0, math.Min(1, alpha*x+beta)) }
+}
+
+func selu(n *node) func(float64) float64 {
+	alpha := n.attrFloat("alpha", 1.67326319217681884765625)
+	gamma := n.attrFloat("gamma", 1.05070102214813232421875)
+	return func(x float64) float64 {
+		if x <= 0 {
+			return gamma * (alpha*math.Exp(x) - alpha)
+		}
+		return gamma * x
+	}
+}
+
+func gelu(n *node, in []*tensor) ([]*tensor, error) {
+	f := func(x float64) float64 { return 0.5 * x * (1 + math.Erf(x/math.Sqrt2)) }
+	if n.attrString("approximate", "none") == "tanh" {
+		f = func(x float64) float64 {
+			return 0.5 * x * (1 + math.Tanh(math.Sqrt(2/math.Pi)*(x+0.044715*x*x*x)))
+		}
+	}
+	return unaryOp(f)(n, in)
+}
+
+// binaryOp applies the function to the elements of two tensors broadcast together. The result is a
+// float tensor if either input is one.
+func binaryOp(ff func(a, b float64) float64, fi func(a, b int64) int64) opFunc {
+	return func(n *node, in []*tensor) ([]*tensor, error) {
+		out, err := broadcastBinary(in[0], in[1], ff, fi)
+		if err != nil {
+			return nil, err
+		}
+		return []*tensor{out}, nil
+	}
+}
+
+func broadcastBinary(a, b *tensor, ff func(a, b float64) float64, fi func(a, b int64) int64) (*tensor, error) {
+	shape, err := broadcastShape(a.shape, b.shape)
+	if err != nil {
+		return nil, err
+	}
+	offA, offB := broadcastOffsets(shape, a.shape), broadcastOffsets(shape, b.shape)
+	if a.dtype.isFloat() || b.dtype.isFloat() {
+		dt := a.dtype
+		if !dt.isFloat() {
+			dt = b.dtype
+		}
+		out := &tensor{dtype: dt, shape: shape, f: make([]float32, len(offA))}
+		for k := range out.f {
+			out.f[k] = float32(ff(float64(a.float(offA[k])), float64(b.float(offB[k]))))
+		}
+		return out, nil
+	}
+	out := &tensor{dtype: a.dtype, shape: shape, i: make([]int64, len(offA))}
+	for k := range out.i {
+		out.i[k] = wrapInt(fi(a.i[offA[k]], b.i[offB[k]]), a.dtype)
+	}
+	return out, nil
+}
+
+// variadicOp folds the binary function over any number of inputs.
+func variadicOp(ff func(a, b float64) float64, fi func(a, b int64) int64) opFunc {
+	return func(n *node, in []*tensor) ([]*tensor, error) {
+		out := in[0]
+		for _, t := range in[1:] {
+			var err error
+			if out, err = broadcastBinary(out, t, ff, fi); err != nil {
+				return nil, err
+			}
+		}
+		return []*tensor{out}, nil
+	}
+}
+
+func clip(n *node, in []*tensor) ([]*tensor, error) {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if n.opset < 11 {
+		lo, hi = n.attrFloat("min", lo), n.attrFloat("max", hi)
+	} else {
+		if len(in) > 1 && in[1] != nil {
+			lo = float64(in[1].float(0))
+		}
+		if len(in) > 2 && in[2] != nil {
+			hi = float64(in[2].float(0))
+		}
+	}
+	return unaryOp(func(x float64) float64 { return math.Max(lo, math.Min(hi, x)) })(n, in)
+}
+
+func castOp(n *node, in []*tensor) ([]*tensor, error) {
+	to := dataType(n.attrInt("to", 0))
+	if _, ok := dataTypeNames[to]; !ok {
+		return nil, errors.Errorf("cannot cast to unsupported data type %d", to)
+	}
+	return []*tensor{in[0].cast(to)}, nil
+}
+
+func constant(n *node, in []*tensor) ([]*tensor, error) {
+	if a, ok := n.attributes["value"]; ok && a.t != nil {
+		t, err := tensorFromProto(a.t)
+		if err != nil {
+			return nil, err
+		}
+		return []*tensor{t}, nil
+	}
+	if a, ok := n.attributes["value_float"]; ok {
+		return []*tensor{newFloatTensor([]int{}, []float32{a.f})}, nil
+	}
+	if a, ok := n.attributes["value_floats"]; ok {
+		return []*tensor{newFloatTensor([]int{len(a.floats)}, a.floats)}, nil
+	}
+	if a, ok := n.attributes["value_int"]; ok {
+		return []*tensor{newIntTensor([]int{}, []int64{a.i})}, nil
+	}
+	if a, ok := n.attributes["value_ints"]; ok {
+		return []*tensor{newIntTensor([]int{len(a.ints)}, a.ints)}, nil
+	}
+	return nil, errors.New("Constant has no supported value attribute")
+}
+
+func constantOfShape(n *node, in []*tensor) ([]*tensor, error) {
+	shape := toShape(in[0].ints())
+	value := newFloatTensor([]int{1}, []float32{0})
+	if a, ok := n.attributes["value"]; ok && a.t != nil {
+		var err error
+		if value, err = tensorFromProto(a.t); err != nil {
+			return nil, err
+		}
+	}
+	out := &tensor{dtype: value.dtype, shape: shape}
+	if value.dtype.isFloat() {
+		out.f = make([]float32, size(shape))
+		for k := range out.f {
+			out.f[k] = value.f[0]
+		}
+	} else {
+		out.i = make([]int64, size(shape))
+		for k := range out.i {
+			out.i[k] = value.i[0]
+		}
+	}
+	return []*tensor{out}, nil
+}
+
+func toShape(dims []int64) []int {
+	shape := make([]int, len(dims))
+	for k, d := range dims {
+		shape[k] = int(d)
+	}
+	return shape
+}
+
+// gather returns the elements of a tensor at the given indices along an axis.
+func gather(n *node, in []*tensor) ([]*tensor, error) {
+	data, indices := in[0], in[1]
+	axis, err := normalizeAxis(n.attrInt("axis", 0), len(data.shape))
+	if err != nil {
+		return nil, err
+	}
+	outer := size(data.shape[:axis])
+	dim := data.shape[axis]
+	inner := size(data.shape[axis+1:])
+	idx := indices.ints()
+	shape := append(append(append([]int{}, data.shape[:axis]...), indices.shape...), data.shape[axis+1:]...)
+	out := &tensor{dtype: data.dtype, shape: shape}
+	if data.dtype.isFloat() {
+		out.f = make([]float32, 0, size(shape))
+	} else {
+		out.i = make([]int64, 0, size(shape))
+	}
+	for o := 0; o < outer; o++ {
+		for _, i := range idx {
+			if i < 0 {
+				i += int64(dim)
+			}
+			if i < 0 || i >= int64(dim) {
+				return nil, errors.Errorf("index %d is out of range for axis of size %d", i, dim)
+			}
+			start := (o*dim + int(i)) * inner
+			if data.dtype.isFloat() {
+				out.f = append(out.f, data.f[start:start+inner]...)
+			} else {
+				out.i = append(out.i, data.i[start:start+inner]...)
+			}
+		}
+	}
+	return []*tensor{out}, nil
+}
+
+// sameData returns a tensor sharing the elements of t with a different shape.
+func sameData(t *tensor, shape []int) *tensor {
+	return &tensor{dtype: t.dtype, shape: shape, f: t.f, i: t.i}
+}
+
+func flatten(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	axis := n.attrInt("axis", 1)
+	if axis < 0 {
+		axis += int64(len(x.shape))
+	}
+	if axis < 0 || axis > int64(len(x.shape)) {
+		return nil, errors.Errorf("flatten axis %d is out of range for rank %d", axis, len(x.shape))
+	}
+	return []*tensor{sameData(x, []int{size(x.shape[:axis]), size(x.shape[axis:])})}, nil
+}
+
+func reshape(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	dims := in[1].ints()
+	shape := make([]int, len(dims))
+	inferred := -1
+	known := 1
+	for k, d := range dims {
+		switch {
+		case d == 0 && n.attrInt("allowzero", 0) == 0:
+			if k >= len(x.shape) {
+				return nil, errors.Errorf("cannot copy dimension %d of shape %v", k, x.shape)
+			}
+			shape[k] = x.shape[k]
+		case d == -1:
+			if inferred >= 0 {
+				return nil, errors.New("reshape can only infer one dimension")
+			}
+			inferred = k
+			continue
+		default:
+			shape[k] = int(d)
+		}
+		known *= shape[k]
+	}
+	if inferred >= 0 {
+		if known == 0 || x.size()%known != 0 {
+			return nil, errors.Errorf("cannot reshape %v to %v", x.shape, dims)
+		}
+		shape[inferred] = x.size() / known
+	}
+	if size(shape) != x.size() {
+		return nil, errors.Errorf("cannot reshape %v to %v", x.shape, dims)
+	}
+	return []*tensor{sameData(x, shape)}, nil
+}
+
+func expand(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	shape, err := broadcastShape(x.shape, toShape(in[1].ints()))
+	if err != nil {
+		return nil, err
+	}
+	return []*tensor{gatherOffsets(x, shape, broadcastOffsets(shape, x.shape))}, nil
+}
+
+// gatherOffsets returns the tensor of the given shape made of the elements of x at the offsets.
+func gatherOffsets(x *tensor, shape, offsets []int) *tensor {
+	out := &tensor{dtype: x.dtype, shape: shape}
+	if x.dtype.isFloat() {
+		out.f = make([]float32, len(offsets))
+		for k, o := range offsets {
+			out.f[k] = x.f[o]
+		}
+	} else {
+		out.i = make([]int64, len(offsets))
+		for k, o := range offsets {
+			out.i[k] = x.i[o]
+		}
+	}
+	return out
+}
+
+func transpose(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	rank := len(x.shape)
+	perm, ok := n.attrInts("perm")
+	if !ok {
+		for k := rank - 1; k >= 0; k-- {
+			perm = append(perm, int64(k))
+		}
+	}
+	if len(perm) != rank {
+		return nil, errors.Errorf("transpose permutation %v does not match rank %d", perm, rank)
+	}
+	shape := make([]int, rank)
+	inStrides := strides(x.shape)
+	// the strides of the input along the axes of the output
+	s := make([]int, rank)
+	for k, p := range perm {
+		shape[k] = x.shape[p]
+		s[k] = inStrides[p]
+	}
+	offsets := make([]int, x.size())
+	index := make([]int, rank)
+	offset := 0
+	for k := range offsets {
+		offsets[k] = offset
+		for axis := rank - 1; axis >= 0; axis-- {
+			index[axis]++
+			offset += s[axis]
+			if index[axis] < shape[axis] {
+				break
+			}
+			offset -= s[axis] * index[axis]
+			index[axis] = 0
+		}
+	}
+	return []*tensor{gatherOffsets(x, shape, offsets)}, nil
+}
+
+func concat(n *node, in []*tensor) ([]*tensor, error) {
+	first := in[0]
+	axis, err := normalizeAxis(n.attrInt("axis", 0), len(first.shape))
+	if err != nil {
+		return nil, err
+	}
+	shape := append([]int{}, first.shape...)
+	shape[axis] = 0
+	for _, t := range in {
+		if len(t.shape) != len(shape) {
+			return nil, errors.Errorf("cannot concatenate tensors of shapes %v and %v", first.shape, t.shape)
+		}
+		shape[axis] += t.shape[axis]
+	}
+	outer := size(shape[:axis])
+	out := &tensor{dtype: first.dtype, shape: shape}
+	for o := 0; o < outer; o++ {
+		for _, t := range in {
+			chunk := size(t.shape[axis:])
+			if out.dtype.isFloat() {
+				out.f = append(out.f, t.floats()[o*chunk:(o+1)*chunk]...)
+			} else {
+				out.i = append(out.i, t.ints()[o*chunk:(o+1)*chunk]...)
+			}
+		}
+	}
+	return []*tensor{out}, nil
+}
+
+func shapeOp(n *node, in []*tensor) ([]*tensor, error) {
+	rank := int64(len(in[0].shape))
+	start, end := n.attrInt("start", 0), n.attrInt("end", rank)
+	if start < 0 {
+		start += rank
+	}
+	if end < 0 {
+		end += rank
+	}
+	start = clampInt(start, 0, rank)
+	end = clampInt(end, start, rank)
+	dims := make([]int64, 0, end-start)
+	for _, d := range in[0].shape[start:end] {
+		dims = append(dims, int64(d))
+	}
+	return []*tensor{newIntTensor([]int{len(dims)}, dims)}, nil
+}
+
+func clampInt(v, lo, hi int64) int64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// axesInput returns the axes of a node, which are an attribute before opset version since and the
+// second input from then on.
+func axesInput(n *node, in []*tensor, since int64) ([]int64, bool) {
+	if n.opset < since {
+		return n.attrInts("axes")
+	}
+	if len(in) > 1 && in[1] != nil {
+		return in[1].ints(), true
+	}
+	return nil, false
+}
+
+func unsqueeze(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	axes, ok := axesInput(n, in, 13)
+	if !ok {
+		return nil, errors.New("Unsqueeze needs axes")
+	}
+	rank := len(x.shape) + len(axes)
+	expanded := make([]bool, rank)
+	for _, a := range axes {
+		axis, err := normalizeAxis(a, rank)
+		if err != nil {
+			return nil, err
+		}
+		expanded[axis] = true
+	}
+	shape := make([]int, 0, rank)
+	k := 0
+	for axis := 0; axis < rank; axis++ {
+		if expanded[axis] {
+			shape = append(shape, 1)
+			continue
+		}
+		shape = append(shape, x.shape[k])
+		k++
+	}
+	return []*tensor{sameData(x, shape)}, nil
+}
+
+func squeeze(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	squeezed := make([]bool, len(x.shape))
+	if axes, ok := axesInput(n, in, 13); ok {
+		for _, a := range axes {
+			axis, err := normalizeAxis(a, len(x.shape))
+			if err != nil {
+				return nil, err
+			}
+			if x.shape[axis] != 1 {
+				return nil, errors.Errorf("cannot squeeze axis %d of shape %v", axis, x.shape)
+			}
+			squeezed[axis] = true
+		}
+	} else {
+		for axis, d := range x.shape {
+			squeezed[axis] = d == 1
+		}
+	}
+	shape := []int{}
+	for axis, d := range x.shape {
+		if !squeezed[axis] {
+			shape = append(shape, d)
+		}
+	}
+	return []*tensor{sameData(x, shape)}, nil
+}
+
+func slice(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	rank := len(x.shape)
+	var starts, ends, axes, steps []int64
+	if n.opset < 10 {
+		starts, _ = n.attrInts("starts")
+		ends, _ = n.attrInts("ends")
+		axes, _ = n.attrInts("axes")
+	} else {
+		starts, ends = in[1].ints(), in[2].ints()
+		if len(in) > 3 && in[3] != nil {
+			axes = in[3].ints()
+		}
+		if len(in) > 4 && in[4] != nil {
+			steps = in[4].ints()
+		}
+	}
+	if len(starts) != len(ends) {
+		return nil, errors.New("Slice needs as many starts as ends")
+	}
+	// the first index and step of the slice along each axis of the input
+	first := make([]int, rank)
+	step := make([]int, rank)
+	shape := append([]int{}, x.shape...)
+	for axis := range step {
+		step[axis] = 1
+	}
+	for k := range starts {
+		axis := k
+		if axes != nil {
+			var err error
+			if axis, err = normalizeAxis(axes[k], rank); err != nil {
+				return nil, err
+			}
+		}
+		st := int64(1)
+		if steps != nil {
+			st = steps[k]
+		}
+		if st == 0 {
+			return nil, errors.New("Slice step cannot be 0")
+		}
+		dim := int64(x.shape[axis])
+		start, end := starts[k], ends[k]
+		if start < 0 {
+			start += dim
+		}
+		if end < 0 {
+			end += dim
+		}
+		var count int64
+		if st > 0 {
+			start, end = clampInt(start, 0, dim), clampInt(end, 0, dim)
+			count = (end - start + st - 1) / st
+		} else {
+			start, end = clampInt(start, 0, dim-1), clampInt(end, -1, dim-1)
+			count = (start - end - st - 1) / -st
+		}
+		if count < 0 {
+			count = 0
+		}
+		first[axis], step[axis], shape[axis] = int(start), int(st), int(count)
+	}
+	inStrides := strides(x.shape)
+	offsets := make([]int, size(shape))
+	index := make([]int, rank)
+	for k := range offsets {
+		offset := 0
+		for axis := range index {
+			offset += (first[axis] + index[axis]*step[axis]) * inStrides[axis]
+		}
+		offsets[k] = offset
+		for axis := rank - 1; axis >= 0; axis-- {
+			index[axis]++
+			if index[axis] < shape[axis] {
+				break
+			}
+			index[axis] = 0
+		}
+	}
+	return []*tensor{gatherOffsets(x, shape, offsets)}, nil
+}
+
+// softmax normalizes along an axis, which is the last one by default from opset version 13. Earlier
+// versions flatten the tensor into 2D at the axis, 1 by default, and normalize its rows.
+func softmax(logarithm bool) opFunc {
+	return func(n *node, in []*tensor) ([]*tensor, error) {
+		x := in[0]
+		rank := len(x.shape)
+		var outer, dim, inner int
+		if n.opset < 13 {
+			axis, err := normalizeAxis(n.attrInt("axis", 1), rank)
+			if err != nil {
+				return nil, err
+			}
+			outer, dim, inner = size(x.shape[:axis]), size(x.shape[axis:]), 1
+		} else {
+			axis, err := normalizeAxis(n.attrInt("axis", -1), rank)
+			if err != nil {
+				return nil, err
+			}
+			outer, dim, inner = size(x.shape[:axis]), x.shape[axis], size(x.shape[axis+1:])
+		}
+		xf := x.floats()
+		out := newFloatTensor(x.shape, make([]float32, len(xf)))
+		for o := 0; o < outer; o++ {
+			for i := 0; i < inner; i++ {
+				base := o*dim*inner + i
+				maxV := math.Inf(-1)
+				for d := 0; d < dim; d++ {
+					maxV = math.Max(maxV, float64(xf[base+d*inner]))
+				}
+				sum := 0.
+				for d := 0; d < dim; d++ {
+					sum += math.Exp(float64(xf[base+d*inner]) - maxV)
+				}
+				for d := 0; d < dim; d++ {
+					v := float64(xf[base+d*inner]) - maxV
+					if logarithm {
+						out.f[base+d*inner] = float32(v - math.Log(sum))
+					} else {
+						out.f[base+d*inner] = float32(math.Exp(v) / sum)
+					}
+				}
+			}
+		}
+		return []*tensor{out}, nil
+	}
+}
+
+type reducer struct {
+	init   float64
+	add    func(acc, v float64) float64
+	finish func(acc float64, n int) float64
+}
+
+var (
+	reduceSum  = reducer{0, func(acc, v float64) float64 { return acc + v }, func(acc float64, n int) float64 { return acc }}
+	reduceMean = reducer{0, func(acc, v float64) float64 { return acc + v }, func(acc float64, n int) float64 { return acc / float64(n) }}
+	reduceMax  = reducer{math.Inf(-1), math.Max, func(acc float64, n int) float64 { return acc }}
+	reduceMin  = reducer{math.Inf(1), math.Min, func(acc float64, n int) float64 { return acc }}
+)
+
+// reduce combines the elements along the axes, from the attributes or the second input depending
+// on the opset version, or along all axes when there are none.
+func reduce(r reducer) opFunc {
+	return func(n *node, in []*tensor) ([]*tensor, error) {
+		x := in[0]
+		rank := len(x.shape)
+		since := int64(18)
+		if n.opType == "ReduceSum" {
+			since = 13
+		}
+		axes, ok := axesInput(n, in, since)
+		if (!ok || len(axes) == 0) && n.attrInt("noop_with_empty_axes", 0) == 1 {
+			return []*tensor{x}, nil
+		}
+		reduced := make([]bool, rank)
+		if !ok || len(axes) == 0 {
+			for axis := range reduced {
+				reduced[axis] = true
+			}
+		}
+		for _, a := range axes {
+			axis, err := normalizeAxis(a, rank)
+			if err != nil {
+				return nil, err
+			}
+			reduced[axis] = true
+		}
+		keptShape := make([]int, rank)
+		shape := []int{}
+		for axis, d := range x.shape {
+			keptShape[axis] = d
+			if reduced[axis] {
+				keptShape[axis] = 1
+				if n.attrInt("keepdims", 1) == 1 {
+					shape = append(shape, 1)
+				}
+				continue
+			}
+			shape = append(shape, d)
+		}
+		// the output element of each input element is the input offset in the kept shape
+		offsets := broadcastOffsets(x.shape, keptShape)
+		acc := make([]float64, size(keptShape))
+		for k := range acc {
+			acc[k] = r.init
+		}
+		for k, o := range offsets {
+			acc[o] = r.add(acc[o], float64(x.float(k)))
+		}
+		count := x.size() / len(acc)
+		out := &tensor{dtype: x.dtype, shape: shape}
+		if x.dtype.isFloat() {
+			out.f = make([]float32, len(acc))
+			for k, v := range acc {
+				out.f[k] = float32(r.finish(v, count))
+			}
+		} else {
+			out.i = make([]int64, len(acc))
+			for k, v := range acc {
+				out.i[k] = int64(r.finish(v, count))
+			}
+		}
+		return []*tensor{out}, nil
+	}
+}
+
+func batchNormalization(n *node, in []*tensor) ([]*tensor, error) {
+	x := in[0]
+	scale, bias, mean, variance := in[1].floats(), in[2].floats(), in[3].floats(), in[4].floats()
+	eps := n.attrFloat("epsilon", 1e-5)
+	if len(x.shape) < 2 || len(scale) != x.shape[1] {
+		return nil, errors.Errorf("batch normalization of %d channels cannot apply to shape %v", len(scale), x.shape)
+	}
+	if len(bias) != len(scale) || len(mean) != len(scale) || len(variance) != len(scale) {
+		return nil, errors.Errorf("batch normalization needs %d values of bias, mean and variance", len(scale))
+	}
+	channels := x.shape[1]
+	inner := size(x.shape[2:])
+	xf := x.floats()
+	out := newFloatTensor(x.shape, make([]float32, len(xf)))
+	for k, v := range xf {
+		c := (k / inner) % channels
+		out.f[k] = float32((float64(v)-float64(mean[c]))/math.Sqrt(float64(variance[c])+eps)*float64(scale[c]) + float64(bias[c]))
+	}
+	return []*tensor{out}, nil
+}
+
+// gemm computes alpha*A*B + beta*C, with A and B optionally transposed.
+func gemm(n *node, in []*tensor) ([]*tensor, error) {
+	a, b := in[0], in[1]
+	if len(a.shape) != 2 || len(b.shape) != 2 {
+		return nil, errors.Errorf("Gemm needs 2D inputs, got shapes %v and %v", a.shape, b.shape)
+	}
+	transA, transB := n.attrInt("transA", 0) == 1, n.attrInt("transB", 0) == 1
+	m, k := a.shape[0], a.shape[1]
+	if transA {
+		m, k = k, m
+	}
+	kb, cols := b.shape[0], b.shape[1]
+	if transB {
+		kb, cols = cols, kb
+	}
+	if k != kb {
+		return nil, errors.Errorf("Gemm cannot multiply shapes %v and %v", a.shape, b.shape)
+	}
+	alpha, beta := n.attrFloat("alpha", 1), n.attrFloat("beta", 1)
+	af, bf := a.floats(), b.floats()
+	out := newFloatTensor([]int{m, cols}, make([]float32, m*cols))
+	for r := 0; r < m; r++ {
+		for c := 0; c < cols; c++ {
+			sum := 0.
+			for j := 0; j < k; j++ {
+				var av, bv float32
+				if transA {
+					av = af[j*m+r]
+				} else {
+					av = af[r*k+j]
+				}
+				if transB {
+					bv = bf[c*k+j]
+				} else {
+					bv = bf[j*cols+c]
+				}
+				sum += float64(av) * float64(bv)
+			}
+			out.f[r*cols+c] = float32(alpha * sum)
+		}
+	}
+	if len(in) > 2 && in[2] != nil && beta != 0 {
+		offsets := broadcastOffsets(out.shape, in[2].shape)
+		for k, o := range offsets {
+			out.f[k] += float32(beta * float64(in[2].float(o)))
+		}
+	}
+	return []*tensor{out}, nil
+}
+
+// matMul multiplies matrices like numpy.matmul, broadcasting the leading dimensions as batches.
+func matMul(n *node, in []*tensor) ([]*tensor, error) {
+	a, b := in[0], in[1]
+	aShape, bShape := a.shape, b.shape
+	if len(aShape) == 1 {
+		aShape = []int{1, aShape[0]}
+	}
+	if len(bShape) == 1 {
+		bShape = []int{bShape[0], 1}
+	}
+	m, k := aShape[len(aShape)-2], aShape[len(aShape)-1]
+	kb, cols := bShape[len(bShape)-2], bShape[len(bShape)-1]
+	if k != kb {
+		return nil, errors.Errorf("MatMul cannot multiply shapes %v and %v", a.shape, b.shape)
+	}
+	batch, err := broadcastShape(aShape[:len(aShape)-2], bShape[:len(bShape)-2])
+	if err != nil {
+		return nil, err
+	}
+	offA := broadcastOffsets(batch, aShape[:len(aShape)-2])
+	offB := broadcastOffsets(batch, bShape[:len(bShape)-2])
+	af, bf := a.floats(), b.floats()
+	out := make([]float32, len(offA)*m*cols)
+	for bi := range offA {
+		aBase, bBase, oBase := offA[bi]*m*k, offB[bi]*k*cols, bi*m*cols
+		for r := 0; r < m; r++ {
+			for j := 0; j < k; j++ {
+				av := af[aBase+r*k+j]
+				if av == 0 {
+					continue
+				}
+				row := bf[bBase+j*cols : bBase+(j+1)*cols]
+				dst := out[oBase+r*cols : oBase+(r+1)*cols]
+				for c, bv := range row {
+					dst[c] += av * bv
+				}
+			}
+		}
+	}
+	shape := append([]int{}, batch...)
+	if len(a.shape) > 1 {
+		shape = append(shape, m)
+	}
+	if len(b.shape) > 1 {
+		shape = append(shape, cols)
+	}
+	return []*tensor{newFloatTensor(shape, out)}, nil
+}
+
+// window is the geometry of a convolution or pooling over the two spatial axes of an image, 1D
+// operators are run as 2D ones of height 1.
+type window struct {
+	kernel, stride, dilation, padBegin, padEnd [2]int
+	in, out                                    [2]int
+}
+
+// spatial returns the input shape as [N, C, H, W] and its number of spatial axes.
+func spatial(shape []int) ([4]int, int, error) {
+	switch len(shape) {
+	case 3:
+		return [4]int{shape[0], shape[1], 1, shape[2]}, 1, nil
+	case 4:
+		return [4]int{shape[0], shape[1], shape[2], shape[3]}, 2, nil
+	default:
+		return [4]int{}, 0, errors.Errorf("only 1D and 2D convolutions and pooling are supported, got input shape %v", shape)
+	}
+}
+
+// expandAttr returns the attribute as two values for the spatial axes, padding 1D attributes.
+func expandAttr(n *node, name string, dims, def int) ([2]int, error) {
+	v, ok := n.attrInts(name)
+	if !ok {
+		return [2]int{def, def}, nil
+	}
+	if len(v) != dims {
+		return [2]int{}, errors.Errorf("attribute %s %v does not have %d values", name, v, dims)
+	}
+	if dims == 1 {
+		return [2]int{def, int(v[0])}, nil
+	}
+	return [2]int{int(v[0]), int(v[1])}, nil
+}
+
+func newWindow(n *node, in [2]int, kernel [2]int, dims int, ceil bool) (*window, error) {
+	w := &window{kernel: kernel, in: in}
+	var err error
+	if w.stride, err = expandAttr(n, "strides", dims, 1); err != nil {
+		return nil, err
+	}
+	if w.dilation, err = expandAttr(n, "dilations", dims, 1); err != nil {
+		return nil, err
+	}
+	if dims == 1 {
+		w.kernel[0], w.stride[0], w.dilation[0] = 1, 1, 1
+	}
+	autoPad := n.attrString("auto_pad", "NOTSET")
+	for axis := 0; axis < 2; axis++ {
+		extent := (w.kernel[axis]-1)*w.dilation[axis] + 1
+		switch autoPad {
+		case "SAME_UPPER", "SAME_LOWER":
+			w.out[axis] = (in[axis] + w.stride[axis] - 1) / w.stride[axis]
+			total := (w.out[axis]-1)*w.stride[axis] + extent - in[axis]
+			if total < 0 {
+				total = 0
+			}
+			w.padBegin[axis] = total / 2
+			if autoPad == "SAME_LOWER" {
+				w.padBegin[axis] = total - total/2
+			}
+			w.padEnd[axis] = total - w.padBegin[axis]
+			continue
+		case "VALID", "NOTSET":
+		default:
+			return nil, errors.Errorf("unsupported auto_pad %q", autoPad)
+		}
+		if pads, ok := n.attrInts("pads"); ok && autoPad == "NOTSET" {
+			if len(pads) != 2*dims {
+				return nil, errors.Errorf("attribute pads %v does not have %d values", pads, 2*dims)
+			}
+			if dims == 2 || axis == 1 {
+				w.padBegin[axis], w.padEnd[axis] = int(pads[axis+dims-2]), int(pads[axis+2*dims-2])
+			}
+		}
+		span := in[axis] + w.padBegin[axis] + w.padEnd[axis] - extent
+		if span < 0 {
+			return nil, errors.Errorf("kernel %v is larger than padded input %v", w.kernel, in)
+		}
+		w.out[axis] = span/w.stride[axis] + 1
+		if ceil && span%w.stride[axis] != 0 {
+			w.out[axis]++
+			// the last window has to start inside of the input or its beginning padding
+			if (w.out[axis]-1)*w.stride[axis] >= in[axis]+w.padBegin[axis] {
+				w.out[axis]--
+			}
+		}
+	}
+	return w, nil
+}
+
+// outShape returns the shape of the output with the given channels in the rank of the input.
+func (w *window) outShape(batch, channels, dims int) []int {
+	if dims == 1 {
+		return []int{batch, channels, w.out[1]}
+	}
+	return []int{batch, channels, w.out[0], w.out[1]}
+}
+
+func conv(n *node, in []*tensor) ([]*tensor, error) {
+	x, weights := in[0], in[1]
+	xs, dims, err := spatial(x.shape)
+	if err != nil {
+		return nil, err
+	}
+	ws, _, err := spatial(weights.shape)
+	if err != nil {
+		return nil, err
+	}
+	group := int(n.attrInt("group", 1))
+	batch, channels := xs[0], xs[1]
+	filters, groupChannels := ws[0], ws[1]
+	if group <= 0 || channels != groupChannels*group || filters%group != 0 {
+		return nil, errors.Errorf("Conv weights %v do not match input %v in %d groups", weights.shape, x.shape, group)
+	}
+	kernel := [2]int{ws[2], ws[3]}
+	if k, ok := n.attrInts("kernel_shape"); ok && len(k) == dims {
+		kernel, _ = expandAttr(n, "kernel_shape", dims, 1)
+	}
+	w, err := newWindow(n, [2]int{xs[2], xs[3]}, kernel, dims, false)
+	if err != nil {
+		return nil, err
+	}
+	var bias []float32
+	if len(in) > 2 && in[2] != nil {
+		bias = in[2].floats()
+	}
+	xf, wf := x.floats(), weights.floats()
+	h, wd := xs[2], xs[3]
+	oh, ow := w.out[0], w.out[1]
+	kh, kw := ws[2], ws[3]
+	out := make([]float32, batch*filters*oh*ow)
+	filtersPerGroup := filters / group
+	for b := 0; b < batch; b++ {
+		for f := 0; f < filters; f++ {
+			g := f / filtersPerGroup
+			dst := out[(b*filters+f)*oh*ow : (b*filters+f+1)*oh*ow]
+			if bias != nil {
+				for k := range dst {
+					dst[k] = bias[f]
+				}
+			}
+			for c := 0; c < groupChannels; c++ {
+				src := xf[(b*channels+g*groupChannels+c)*h*wd:]
+				kernelBase := (f*groupChannels + c) * kh * kw
+				for ky := 0; ky < kh; ky++ {
+					for kx := 0; kx < kw; kx++ {
+						kv := wf[kernelBase+ky*kw+kx]
+						if kv == 0 {
+							continue
+						}
+						for oy := 0; oy < oh; oy++ {
+							iy := oy*w.stride[0] - w.padBegin[0] + ky*w.dilation[0]
+							if iy < 0 || iy >= h {
+								continue
+							}
+							row := src[iy*wd : (iy+1)*wd]
+							dstRow := dst[oy*ow : (oy+1)*ow]
+							for ox := range dstRow {
+								ix := ox*w.stride[1] - w.padBegin[1] + kx*w.dilation[1]
+								if ix < 0 || ix >= wd {
+									continue
+								}
+								dstRow[ox] += kv * row[ix]
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return []*tensor{newFloatTensor(w.outShape(batch, filters, dims), out)}, nil
+}
+
+// pool combines the elements of each window of each channel, skipping padding, and finish turns the
+// combined value and the number of elements of the window in and out of the padding into the output.
+func pool(n *node, x *tensor, init float64, add func(acc, v float64) float64, finish func(acc float64, inside, padded int) float64,
+) ([]*tensor, error) {
+	xs, dims, err := spatial(x.shape)
+	if err != nil {
+		return nil, err
+	}
+	kernel, err := expandAttr(n, "kernel_shape", dims, 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := n.attrInts("kernel_shape"); !ok {
+		return nil, errors.New("pooling needs a kernel_shape")
+	}
+	w, err := newWindow(n, [2]int{xs[2], xs[3]}, kernel, dims, n.attrInt("ceil_mode", 0) == 1)
+	if err != nil {
+		return nil, err
+	}
+	xf := x.floats()
+	h, wd := xs[2], xs[3]
+	oh, ow := w.out[0], w.out[1]
+	out := make([]float32, xs[0]*xs[1]*oh*ow)
+	for bc := 0; bc < xs[0]*xs[1]; bc++ {
+		src := xf[bc*h*wd : (bc+1)*h*wd]
+		for oy := 0; oy < oh; oy++ {
+			for ox := 0; ox < ow; ox++ {
+				acc := init
+				inside, padded := 0, 0
+				for ky := 0; ky < w.kernel[0]; ky++ {
+					iy := oy*w.stride[0] - w.padBegin[0] + ky*w.dilation[0]
+					for kx := 0; kx < w.kernel[1]; kx++ {
+						ix := ox*w.stride[1] - w.padBegin[1] + kx*w.dilation[1]
+						if iy >= -w.padBegin[0] && iy < h+w.padEnd[0] && ix >= -w.padBegin[1] && ix < wd+w.padEnd[1] {
+							padded++
+						}
+						if iy < 0 || iy >= h || ix < 0 || ix >= wd {
+							continue
+						}
+						inside++
+						acc = add(acc, float64(src[iy*wd+ix]))
+					}
+				}
+				out[(bc*oh+oy)*ow+ox] = float32(finish(acc, inside, padded))
+			}
+		}
+	}
+	return []*tensor{newFloatTensor(w.outShape(xs[0], xs[1], dims), out)}, nil
+}
+
+func maxPool(n *node, in []*tensor) ([]*tensor, error) {
+	return pool(n, in[0], math.Inf(-1), math.Max, func(acc float64, inside, padded int) float64 { return acc })
+}
+
+func averagePool(n *node, in []*tensor) ([]*tensor, error) {
+	includePad := n.attrInt("count_include_pad", 0) == 1
+	return pool(n, in[0], 0, func(acc, v float64) float64 { return acc + v }, func(acc float64, inside, padded int) float64 {
+		if includePad {
+			return acc / float64(padded)
+		}
+		return acc / float64(inside)
+	})
+}
+
+// globalPool averages or takes the maximum over all the spatial axes.
+func globalPool(isMax bool) opFunc {
+	return func(n *node, in []*tensor) ([]*tensor, error) {
+		x := in[0]
+		if len(x.shape) < 3 {
+			return nil, errors.Errorf("global pooling needs spatial axes, got shape %v", x.shape)
+		}
+		shape := []int{x.shape[0], x.shape[1]}
+		for range x.shape[2:] {
+			shape = append(shape, 1)
+		}
+		inner := size(x.shape[2:])
+		xf := x.floats()
+		out := make([]float32, x.shape[0]*x.shape[1])
+		for k := range out {
+			window := xf[k*inner : (k+1)*inner]
+			acc := 0.
+			if isMax {
+				acc = math.Inf(-1)
+			}
+			for _, v := range window {
+				if isMax {
+					acc = math.Max(acc, float64(v))
+				} else {
+					acc += float64(v)
+				}
+			}
+			if !isMax {
+				acc /= float64(inner)
+			}
+			out[k] = float32(acc)
+		}
+		return []*tensor{newFloatTensor(shape, out)}, nil
+	}
+}
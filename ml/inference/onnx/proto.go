@@ -0,0 +1,388 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages below hold the parts of the ONNX protobuf schema that inference needs, decoded by hand
+// from the wire format so that no generated code is needed. Field numbers are those of onnx.proto3.
+// https://github.com/onnx/onnx/blob/main/onnx/onnx.proto3
+
+type modelProto struct {
+	producerName  string
+	docString     string
+	graph         *graphProto
+	opsetImports  map[string]int64
+	metadataProps map[string]string
+}
+
+type graphProto struct {
+	name         string
+	docString    string
+	nodes        []*nodeProto
+	initializers []*tensorProto
+	inputs       []*valueInfoProto
+	outputs      []*valueInfoProto
+}
+
+type nodeProto struct {
+	name       string
+	opType     string
+	domain     string
+	inputs     []string
+	outputs    []string
+	attributes map[string]*attributeProto
+}
+
+type attributeProto struct {
+	f       float32
+	i       int64
+	s       []byte
+	t       *tensorProto
+	floats  []float32
+	ints    []int64
+	strings [][]byte
+}
+
+type tensorProto struct {
+	name         string
+	dims         []int64
+	dataType     dataType
+	floatData    []float32
+	int32Data    []int32
+	int64Data    []int64
+	doubleData   []float64
+	uint64Data   []uint64
+	rawData      []byte
+	dataLocation int64
+}
+
+type valueInfoProto struct {
+	name      string
+	docString string
+	elemType  dataType
+	// dims holds -1 for dimensions without a fixed size
+	dims     []int
+	hasShape bool
+}
+
+// fieldFunc handles a single field of a message, v is the value for varint and fixed fields and b the
+// bytes of length delimited fields.
+type fieldFunc func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error
+
+// walkMessage calls f on each field of the encoded message in order.
+func walkMessage(b []byte, f fieldFunc) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v uint64
+		var value []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		case protowire.StartGroupType, protowire.EndGroupType:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		default:
+			return errors.Errorf("unknown wire type %d of field %d", typ, num)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := f(num, typ, v, value); err != nil {
+			return errors.Wrapf(err, "field %d", num)
+		}
+	}
+	return nil
+}
+
+// appendVarints appends a repeated varint field, which is either a single value or packed.
+func appendVarints(dst []int64, typ protowire.Type, v uint64, b []byte) ([]int64, error) {
+	if typ != protowire.BytesType {
+		return append(dst, int64(v)), nil
+	}
+	for len(b) > 0 {
+		x, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		dst = append(dst, int64(x))
+		b = b[n:]
+	}
+	return dst, nil
+}
+
+// appendFloats appends a repeated float field, which is either a single value or packed.
+func appendFloats(dst []float32, typ protowire.Type, v uint64, b []byte) ([]float32, error) {
+	if typ != protowire.BytesType {
+		return append(dst, math.Float32frombits(uint32(v))), nil
+	}
+	if len(b)%4 != 0 {
+		return nil, errors.New("packed floats are not a multiple of 4 bytes")
+	}
+	for i := 0; i < len(b); i += 4 {
+		dst = append(dst, math.Float32frombits(binary.LittleEndian.Uint32(b[i:])))
+	}
+	return dst, nil
+}
+
+// appendDoubles appends a repeated double field, which is either a single value or packed.
+func appendDoubles(dst []float64, typ protowire.Type, v uint64, b []byte) ([]float64, error) {
+	if typ != protowire.BytesType {
+		return append(dst, math.Float64frombits(v)), nil
+	}
+	if len(b)%8 != 0 {
+		return nil, errors.New("packed doubles are not a multiple of 8 bytes")
+	}
+	for i := 0; i < len(b); i += 8 {
+		dst = append(dst, math.Float64frombits(binary.LittleEndian.Uint64(b[i:])))
+	}
+	return dst, nil
+}
+
+func parseModel(b []byte) (*modelProto, error) {
+	m := &modelProto{opsetImports: map[string]int64{}, metadataProps: map[string]string{}}
+	err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		var err error
+		switch num {
+		case 2:
+			m.producerName = string(b)
+		case 6:
+			m.docString = string(b)
+		case 7:
+			m.graph, err = parseGraph(b)
+		case 8:
+			var domain string
+			var version int64
+			err = walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					domain = string(b)
+				case 2:
+					version = int64(v)
+				}
+				return nil
+			})
+			if domain == "ai.onnx" {
+				domain = ""
+			}
+			m.opsetImports[domain] = version
+		case 14:
+			var key, value string
+			err = walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					key = string(b)
+				case 2:
+					value = string(b)
+				}
+				return nil
+			})
+			m.metadataProps[key] = value
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m.graph == nil {
+		return nil, errors.New("model has no graph")
+	}
+	return m, nil
+}
+
+func parseGraph(b []byte) (*graphProto, error) {
+	g := &graphProto{}
+	err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			n, err := parseNode(b)
+			if err != nil {
+				return err
+			}
+			g.nodes = append(g.nodes, n)
+		case 2:
+			g.name = string(b)
+		case 5:
+			t, err := parseTensor(b)
+			if err != nil {
+				return err
+			}
+			g.initializers = append(g.initializers, t)
+		case 10:
+			g.docString = string(b)
+		case 11, 12:
+			vi, err := parseValueInfo(b)
+			if err != nil {
+				return err
+			}
+			if num == 11 {
+				g.inputs = append(g.inputs, vi)
+			} else {
+				g.outputs = append(g.outputs, vi)
+			}
+		}
+		return nil
+	})
+	return g, err
+}
+
+func parseNode(b []byte) (*nodeProto, error) {
+	n := &nodeProto{attributes: map[string]*attributeProto{}}
+	err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			n.inputs = append(n.inputs, string(b))
+		case 2:
+			n.outputs = append(n.outputs, string(b))
+		case 3:
+			n.name = string(b)
+		case 4:
+			n.opType = string(b)
+		case 5:
+			name, a, err := parseAttribute(b)
+			if err != nil {
+				return err
+			}
+			n.attributes[name] = a
+		case 7:
+			n.domain = string(b)
+		}
+		return nil
+	})
+	if n.domain == "ai.onnx" {
+		n.domain = ""
+	}
+	return n, err
+}
+
+func parseAttribute(b []byte) (string, *attributeProto, error) {
+	var name string
+	a := &attributeProto{}
+	err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(b)
+		case 2:
+			a.f = math.Float32frombits(uint32(v))
+		case 3:
+			a.i = int64(v)
+		case 4:
+			a.s = b
+		case 5:
+			a.t, err = parseTensor(b)
+		case 7:
+			a.floats, err = appendFloats(a.floats, typ, v, b)
+		case 8:
+			a.ints, err = appendVarints(a.ints, typ, v, b)
+		case 9:
+			a.strings = append(a.strings, b)
+		}
+		return err
+	})
+	return name, a, err
+}
+
+func parseTensor(b []byte) (*tensorProto, error) {
+	t := &tensorProto{}
+	err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			t.dims, err = appendVarints(t.dims, typ, v, b)
+		case 2:
+			t.dataType = dataType(v)
+		case 4:
+			t.floatData, err = appendFloats(t.floatData, typ, v, b)
+		case 5:
+			var ints []int64
+			ints, err = appendVarints(nil, typ, v, b)
+			for _, x := range ints {
+				t.int32Data = append(t.int32Data, int32(x))
+			}
+		case 7:
+			t.int64Data, err = appendVarints(t.int64Data, typ, v, b)
+		case 8:
+			t.name = string(b)
+		case 9:
+			t.rawData = b
+		case 10:
+			t.doubleData, err = appendDoubles(t.doubleData, typ, v, b)
+		case 11:
+			var ints []int64
+			ints, err = appendVarints(nil, typ, v, b)
+			for _, x := range ints {
+				t.uint64Data = append(t.uint64Data, uint64(x))
+			}
+		case 14:
+			t.dataLocation = int64(v)
+		}
+		return err
+	})
+	return t, err
+}
+
+func parseValueInfo(b []byte) (*valueInfoProto, error) {
+	vi := &valueInfoProto{}
+	err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			vi.name = string(b)
+		case 3:
+			vi.docString = string(b)
+		case 2:
+			// TypeProto, of which only tensor types are supported
+			return walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				if num != 1 {
+					return nil
+				}
+				return walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+					switch num {
+					case 1:
+						vi.elemType = dataType(v)
+					case 2:
+						vi.hasShape = true
+						return walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+							if num != 1 {
+								return nil
+							}
+							dim := -1
+							err := walkMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+								if num == 1 {
+									dim = int(int64(v))
+								}
+								return nil
+							})
+							vi.dims = append(vi.dims, dim)
+							return err
+						})
+					}
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+	return vi, err
+}
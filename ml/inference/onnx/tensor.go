@@ -0,0 +1,418 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// dataType is the element type of a tensor, with the values of TensorProto.DataType.
+type dataType int32
+
+const (
+	typeFloat  dataType = 1
+	typeUInt8  dataType = 2
+	typeInt8   dataType = 3
+	typeUInt16 dataType = 4
+	typeInt16  dataType = 5
+	typeInt32  dataType = 6
+	typeInt64  dataType = 7
+	typeBool   dataType = 9
+	typeDouble dataType = 11
+	typeUInt32 dataType = 12
+	typeUInt64 dataType = 13
+)
+
+var dataTypeNames = map[dataType]string{
+	typeFloat:  "float32",
+	typeUInt8:  "uint8",
+	typeInt8:   "int8",
+	typeUInt16: "uint16",
+	typeInt16:  "int16",
+	typeInt32:  "int32",
+	typeInt64:  "int64",
+	typeBool:   "bool",
+	typeDouble: "float64",
+	typeUInt32: "uint32",
+	typeUInt64: "uint64",
+}
+
+func (dt dataType) String() string {
+	if name, ok := dataTypeNames[dt]; ok {
+		return name
+	}
+	return "unsupported"
+}
+
+// isFloat returns whether tensors of the type keep their values as floats rather than integers.
+func (dt dataType) isFloat() bool {
+	return dt == typeFloat || dt == typeDouble
+}
+
+// tensor is a value of the graph during inference. Floating point tensors keep their elements in f
+// and all others, booleans included, in i, so that operators only have two cases to handle.
+type tensor struct {
+	dtype dataType
+	shape []int
+	f     []float32
+	i     []int64
+}
+
+func newFloatTensor(shape []int, f []float32) *tensor {
+	return &tensor{dtype: typeFloat, shape: shape, f: f}
+}
+
+func newIntTensor(shape []int, i []int64) *tensor {
+	return &tensor{dtype: typeInt64, shape: shape, i: i}
+}
+
+// size returns the number of elements of a tensor of the given shape.
+func size(shape []int) int {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	return n
+}
+
+func (t *tensor) size() int {
+	return size(t.shape)
+}
+
+// float returns the i-th element as a float whatever the type of the tensor.
+func (t *tensor) float(i int) float32 {
+	if t.dtype.isFloat() {
+		return t.f[i]
+	}
+	return float32(t.i[i])
+}
+
+// ints returns the elements of the tensor as integers, which is how shapes and indices are passed.
+func (t *tensor) ints() []int64 {
+	if !t.dtype.isFloat() {
+		return t.i
+	}
+	out := make([]int64, len(t.f))
+	for i, v := range t.f {
+		out[i] = int64(v)
+	}
+	return out
+}
+
+// floats returns the elements of the tensor as floats.
+func (t *tensor) floats() []float32 {
+	if t.dtype.isFloat() {
+		return t.f
+	}
+	out := make([]float32, len(t.i))
+	for i, v := range t.i {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+// cast returns the tensor converted to the data type, wrapping integers to the range of the type.
+func (t *tensor) cast(dt dataType) *tensor {
+	out := &tensor{dtype: dt, shape: t.shape}
+	if dt.isFloat() {
+		out.f = t.floats()
+		return out
+	}
+	out.i = make([]int64, t.size())
+	for i := range out.i {
+		var v int64
+		if t.dtype.isFloat() {
+			v = int64(t.f[i])
+		} else {
+			v = t.i[i]
+		}
+		out.i[i] = wrapInt(v, dt)
+	}
+	return out
+}
+
+// wrapInt converts v to the integer type as a cast in Go would.
+func wrapInt(v int64, dt dataType) int64 {
+	switch dt {
+	case typeUInt8:
+		return int64(uint8(v))
+	case typeInt8:
+		return int64(int8(v))
+	case typeUInt16:
+		return int64(uint16(v))
+	case typeInt16:
+		return int64(int16(v))
+	case typeInt32:
+		return int64(int32(v))
+	case typeUInt32:
+		return int64(uint32(v))
+	case typeBool:
+		if v != 0 {
+			return 1
+		}
+		return 0
+	default:
+		return v
+	}
+}
+
+// tensorFromProto decodes an initializer or constant of the graph.
+func tensorFromProto(tp *tensorProto) (*tensor, error) {
+	if tp.dataLocation != 0 {
+		return nil, errors.Errorf("tensor %q stores its data in an external file, which is not supported", tp.name)
+	}
+	if _, ok := dataTypeNames[tp.dataType]; !ok {
+		return nil, errors.Errorf("tensor %q has unsupported data type %d", tp.name, tp.dataType)
+	}
+	shape := make([]int, len(tp.dims))
+	for i, d := range tp.dims {
+		shape[i] = int(d)
+	}
+	t := &tensor{dtype: tp.dataType, shape: shape}
+	n := t.size()
+	if tp.rawData != nil {
+		if err := t.decodeRaw(tp.rawData, n); err != nil {
+			return nil, errors.Wrapf(err, "tensor %q", tp.name)
+		}
+		return t, nil
+	}
+	switch tp.dataType {
+	case typeFloat:
+		t.f = tp.floatData
+	case typeDouble:
+		t.f = make([]float32, len(tp.doubleData))
+		for i, v := range tp.doubleData {
+			t.f[i] = float32(v)
+		}
+	case typeInt64:
+		t.i = tp.int64Data
+	case typeUInt32, typeUInt64:
+		t.i = make([]int64, len(tp.uint64Data))
+		for i, v := range tp.uint64Data {
+			t.i[i] = int64(v)
+		}
+	default:
+		// the smaller integer types and booleans are all stored in int32_data
+		t.i = make([]int64, len(tp.int32Data))
+		for i, v := range tp.int32Data {
+			t.i[i] = wrapInt(int64(v), tp.dataType)
+		}
+	}
+	if got := len(t.f) + len(t.i); got != n {
+		return nil, errors.Errorf("tensor %q of shape %v has %d elements", tp.name, shape, got)
+	}
+	return t, nil
+}
+
+// decodeRaw fills the tensor from the little endian raw_data of a TensorProto.
+func (t *tensor) decodeRaw(raw []byte, n int) error {
+	width := map[dataType]int{
+		typeFloat: 4, typeDouble: 8, typeUInt8: 1, typeInt8: 1, typeBool: 1, typeUInt16: 2,
+		typeInt16: 2, typeInt32: 4, typeUInt32: 4, typeInt64: 8, typeUInt64: 8,
+	}[t.dtype]
+	if len(raw) != n*width {
+		return errors.Errorf("raw data has %d bytes for %d elements of %v", len(raw), n, t.dtype)
+	}
+	if t.dtype.isFloat() {
+		t.f = make([]float32, n)
+	} else {
+		t.i = make([]int64, n)
+	}
+	for k := 0; k < n; k++ {
+		b := raw[k*width:]
+		switch t.dtype {
+		case typeFloat:
+			t.f[k] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case typeDouble:
+			t.f[k] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case typeUInt8, typeBool:
+			t.i[k] = int64(b[0])
+		case typeInt8:
+			t.i[k] = int64(int8(b[0]))
+		case typeUInt16:
+			t.i[k] = int64(binary.LittleEndian.Uint16(b))
+		case typeInt16:
+			t.i[k] = int64(int16(binary.LittleEndian.Uint16(b)))
+		case typeInt32:
+			t.i[k] = int64(int32(binary.LittleEndian.Uint32(b)))
+		case typeUInt32:
+			t.i[k] = int64(binary.LittleEndian.Uint32(b))
+		default:
+			t.i[k] = int64(binary.LittleEndian.Uint64(b))
+		}
+	}
+	return nil
+}
+
+// tensorFromSlice builds a tensor of the given type and shape from a flat slice of any numeric type.
+func tensorFromSlice(data interface{}, dt dataType, shape []int) (*tensor, error) {
+	var src *tensor
+	switch v := data.(type) {
+	case []float32:
+		src = &tensor{dtype: typeFloat, f: v}
+	case []float64:
+		src = &tensor{dtype: typeFloat, f: make([]float32, len(v))}
+		for i, x := range v {
+			src.f[i] = float32(x)
+		}
+	case []uint8:
+		src = &tensor{dtype: typeInt64, i: make([]int64, len(v))}
+		for i, x := range v {
+			src.i[i] = int64(x)
+		}
+	case []int8:
+		src = &tensor{dtype: typeInt64, i: make([]int64, len(v))}
+		for i, x := range v {
+			src.i[i] = int64(x)
+		}
+	case []int32:
+		src = &tensor{dtype: typeInt64, i: make([]int64, len(v))}
+		for i, x := range v {
+			src.i[i] = int64(x)
+		}
+	case []int64:
+		src = &tensor{dtype: typeInt64, i: v}
+	case []int:
+		src = &tensor{dtype: typeInt64, i: make([]int64, len(v))}
+		for i, x := range v {
+			src.i[i] = int64(x)
+		}
+	case []bool:
+		src = &tensor{dtype: typeInt64, i: make([]int64, len(v))}
+		for i, x := range v {
+			if x {
+				src.i[i] = 1
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported input of type %T, expected a flat slice of numbers", data)
+	}
+	if n := len(src.f) + len(src.i); n != size(shape) {
+		return nil, errors.Errorf("input has %d elements but its shape %v needs %d", n, shape, size(shape))
+	}
+	src.shape = shape
+	if src.dtype == dt {
+		return src, nil
+	}
+	return src.cast(dt), nil
+}
+
+// toSlice returns the elements of the tensor as a flat slice of the Go type matching its data type.
+func (t *tensor) toSlice() interface{} {
+	switch t.dtype {
+	case typeFloat:
+		return t.f
+	case typeDouble:
+		out := make([]float64, len(t.f))
+		for i, v := range t.f {
+			out[i] = float64(v)
+		}
+		return out
+	case typeUInt8:
+		out := make([]uint8, len(t.i))
+		for i, v := range t.i {
+			out[i] = uint8(v)
+		}
+		return out
+	case typeInt8:
+		out := make([]int8, len(t.i))
+		for i, v := range t.i {
+			out[i] = int8(v)
+		}
+		return out
+	case typeInt16, typeUInt16, typeInt32:
+		out := make([]int32, len(t.i))
+		for i, v := range t.i {
+			out[i] = int32(v)
+		}
+		return out
+	case typeBool:
+		out := make([]bool, len(t.i))
+		for i, v := range t.i {
+			out[i] = v != 0
+		}
+		return out
+	default:
+		return t.i
+	}
+}
+
+// strides returns the number of elements between consecutive indices along each axis.
+func strides(shape []int) []int {
+	s := make([]int, len(shape))
+	n := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		s[i] = n
+		n *= shape[i]
+	}
+	return s
+}
+
+// broadcastShape returns the shape two tensors are broadcast to following the numpy rules.
+func broadcastShape(a, b []int) ([]int, error) {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	out := make([]int, n)
+	for i := 0; i < n; i++ {
+		da, db := 1, 1
+		if k := len(a) - n + i; k >= 0 {
+			da = a[k]
+		}
+		if k := len(b) - n + i; k >= 0 {
+			db = b[k]
+		}
+		switch {
+		case da == db || db == 1:
+			out[i] = da
+		case da == 1:
+			out[i] = db
+		default:
+			return nil, errors.Errorf("shapes %v and %v cannot be broadcast together", a, b)
+		}
+	}
+	return out, nil
+}
+
+// broadcastOffsets returns, for each element of a tensor of shape out in order, the offset of the
+// element of a tensor of shape in that is broadcast to it.
+func broadcastOffsets(out, in []int) []int {
+	inStrides := strides(in)
+	// the strides of the input along the axes of the output, 0 along broadcast axes
+	s := make([]int, len(out))
+	for i := range out {
+		if k := len(in) - len(out) + i; k >= 0 && in[k] != 1 {
+			s[i] = inStrides[k]
+		}
+	}
+	offsets := make([]int, size(out))
+	index := make([]int, len(out))
+	offset := 0
+	for n := range offsets {
+		offsets[n] = offset
+		// advance the index like an odometer, keeping the offset up to date
+		for axis := len(out) - 1; axis >= 0; axis-- {
+			index[axis]++
+			offset += s[axis]
+			if index[axis] < out[axis] {
+				break
+			}
+			offset -= s[axis] * index[axis]
+			index[axis] = 0
+		}
+	}
+	return offsets
+}
+
+// normalizeAxis turns a negative axis counted from the end into one counted from the start.
+func normalizeAxis(axis int64, rank int) (int, error) {
+	if axis < 0 {
+		axis += int64(rank)
+	}
+	if axis < 0 || axis >= int64(rank) {
+		return 0, errors.Errorf("axis %d is out of range for rank %d", axis, rank)
+	}
+	return int(axis), nil
+}
@@ -0,0 +1,310 @@
+package modmanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	commonpb "go.viam.com/api/common/v1"
+	pb "go.viam.com/api/module/v1"
+	"go.viam.com/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go.viam.com/rdk/resource"
+)
+
+const (
+	// LivenessProbeCommand is the DoCommand command the resources of modules are probed with.
+	LivenessProbeCommand = "liveness_probe"
+	// maxRestartHistory is the number of restarts of a module that are remembered.
+	maxRestartHistory = 10
+)
+
+// moduleHealth is the health of a module and its resources, kept across restarts of its process.
+type moduleHealth struct {
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastErr   error
+	failures  int
+	restarts  []restartEvent
+	resources map[resource.Name]*resourceHealth
+	// oomKills is the number of times the module was killed for running out of memory.
+	oomKills int
+}
+
+type restartEvent struct {
+	time    time.Time
+	reason  string
+	success bool
+}
+
+type resourceHealth struct {
+	lastProbe time.Time
+	latency   time.Duration
+	lastErr   error
+	failures  int
+}
+
+// recordCheck records the result of a health check of the module and returns the number of checks
+// in a row that failed.
+func (h *moduleHealth) recordCheck(at time.Time, err error) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCheck = at
+	h.lastErr = err
+	if err == nil {
+		h.failures = 0
+	} else {
+		h.failures++
+	}
+	return h.failures
+}
+
+// recordProbe records the result of a liveness probe of a resource and returns the number of probes
+// in a row that failed.
+func (h *moduleHealth) recordProbe(name resource.Name, at time.Time, latency time.Duration, err error) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.resources == nil {
+		h.resources = map[resource.Name]*resourceHealth{}
+	}
+	rh, ok := h.resources[name]
+	if !ok {
+		rh = &resourceHealth{}
+		h.resources[name] = rh
+	}
+	rh.lastProbe = at
+	rh.latency = latency
+	rh.lastErr = err
+	if err == nil {
+		rh.failures = 0
+	} else {
+		rh.failures++
+	}
+	return rh.failures
+}
+
+// recordRestart records a restart of the module, forgetting the failures that caused it.
+func (h *moduleHealth) recordRestart(at time.Time, reason string, success bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.restarts = append(h.restarts, restartEvent{time: at, reason: reason, success: success})
+	if len(h.restarts) > maxRestartHistory {
+		h.restarts = h.restarts[len(h.restarts)-maxRestartHistory:]
+	}
+	h.failures = 0
+	h.resources = nil
+}
+
+// recordOOMKill counts a kill of the module for running out of memory and returns the number of them.
+func (h *moduleHealth) recordOOMKill() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.oomKills++
+	return h.oomKills
+}
+
+// forget drops the probe results of a resource the module no longer serves.
+func (h *moduleHealth) forget(name resource.Name) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.resources, name)
+}
+
+// ModuleHealth returns the health of the module serving the resource, with the results of the
+// liveness probes of the resource, and false if no module serves it. It is meant for the status of
+// the resource, so it only holds primitives, lists and maps.
+func (mgr *Manager) ModuleHealth(name resource.Name) (map[string]interface{}, bool) {
+	mgr.mu.RLock()
+	mod, ok := mgr.rMap[name]
+	mgr.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return mod.healthStatus(name), true
+}
+
+func (m *module) healthStatus(name resource.Name) map[string]interface{} {
+	h := &m.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	restarts := make([]interface{}, 0, len(h.restarts))
+	for _, r := range h.restarts {
+		restarts = append(restarts, map[string]interface{}{
+			"time":    r.time.UTC().Format(time.RFC3339Nano),
+			"reason":  r.reason,
+			"success": r.success,
+		})
+	}
+	out := map[string]interface{}{
+		"module":               m.name,
+		"health_checks":        m.healthConf != nil,
+		"healthy":              h.failures == 0,
+		"consecutive_failures": h.failures,
+		"restarts":             restarts,
+		"oom_kills":            h.oomKills,
+	}
+	if !h.lastCheck.IsZero() {
+		out["last_check"] = h.lastCheck.UTC().Format(time.RFC3339Nano)
+	}
+	if h.lastErr != nil {
+		out["last_error"] = h.lastErr.Error()
+	}
+	if rh, ok := h.resources[name]; ok {
+		probe := map[string]interface{}{
+			"last_probe":           rh.lastProbe.UTC().Format(time.RFC3339Nano),
+			"latency_ms":           float64(rh.latency.Microseconds()) / 1000,
+			"healthy":              rh.failures == 0,
+			"consecutive_failures": rh.failures,
+		}
+		if rh.lastErr != nil {
+			probe["last_error"] = rh.lastErr.Error()
+		}
+		out["liveness_probe"] = probe
+		if rh.failures > 0 {
+			out["healthy"] = false
+		}
+	}
+	return out
+}
+
+// startHealthChecks checks the health of the module periodically if its config enables it, until
+// stopHealthChecks is called.
+func (mgr *Manager) startHealthChecks(mod *module) {
+	if mod.healthConf == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mod.healthCancel = cancel
+	mod.healthWorkers.Add(1)
+	utils.ManagedGo(func() {
+		ticker := time.NewTicker(mod.healthConf.Interval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// a crashed module is being taken care of by its OnUnexpectedExit function
+			if mod.inRecovery.Load() {
+				continue
+			}
+			if reason := mgr.checkHealth(ctx, mod); reason != "" {
+				mgr.restartUnresponsive(ctx, mod, reason)
+			}
+			mgr.mu.RLock()
+			removed := mgr.modules[mod.name] != mod
+			mgr.mu.RUnlock()
+			if removed {
+				return
+			}
+		}
+	}, mod.healthWorkers.Done)
+}
+
+// stopHealthChecks stops the health checks of the module and waits for a check or restart in
+// progress to finish. It must not be called with the lock of the manager held.
+func (m *module) stopHealthChecks() {
+	if m.healthCancel == nil {
+		return
+	}
+	m.healthCancel()
+	m.healthWorkers.Wait()
+	m.healthCancel = nil
+}
+
+// checkHealth checks that the module, and its resources if enabled, answer in time. It returns why
+// the module should be restarted, or the empty string if it should not.
+func (mgr *Manager) checkHealth(ctx context.Context, mod *module) string {
+	conf := mod.healthConf
+	// restarts of the module replace its connection
+	mgr.mu.RLock()
+	client, conn := mod.client, mod.conn
+	mgr.mu.RUnlock()
+	checkCtx, cancel := context.WithTimeout(ctx, conf.Timeout())
+	start := time.Now()
+	_, err := client.Ready(checkCtx, &pb.ReadyRequest{ParentAddress: mgr.parentAddr})
+	cancel()
+	if ctx.Err() != nil {
+		return ""
+	}
+	if failures := mod.health.recordCheck(start, err); failures >= conf.Failures() {
+		return fmt.Sprintf("module failed %d health checks in a row: %v", failures, err)
+	}
+	if !conf.ProbeResources {
+		return ""
+	}
+
+	mgr.mu.RLock()
+	names := make([]resource.Name, 0, len(mod.resources))
+	for name := range mod.resources {
+		names = append(names, name)
+	}
+	mgr.mu.RUnlock()
+	for _, name := range names {
+		method, ok := mod.doCommandMethod(name.API)
+		if !ok {
+			continue
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, conf.ResourceTimeout(name.Name))
+		start := time.Now()
+		err := conn.Invoke(probeCtx, method, livenessProbeRequest(name), &commonpb.DoCommandResponse{})
+		cancel()
+		if ctx.Err() != nil {
+			return ""
+		}
+		if failures := mod.health.recordProbe(name, start, time.Since(start), probeError(err)); failures >= conf.Failures() {
+			return fmt.Sprintf("resource %s failed %d liveness probes in a row: %v", name, failures, err)
+		}
+	}
+	return ""
+}
+
+func livenessProbeRequest(name resource.Name) *commonpb.DoCommandRequest {
+	//nolint:errcheck // a map of a string always converts
+	command, _ := structpb.NewStruct(map[string]interface{}{"command": LivenessProbeCommand})
+	return &commonpb.DoCommandRequest{Name: name.ShortName(), Command: command}
+}
+
+// probeError returns the error of a liveness probe if the resource did not answer it. A resource
+// that answers with an error, e.g. because it does not know the command, is alive.
+func probeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	//nolint:exhaustive
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Unavailable, codes.Canceled:
+		return err
+	default:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		return nil
+	}
+}
+
+// doCommandMethod returns the full name of the DoCommand method of the API as served by the module.
+func (m *module) doCommandMethod(api resource.API) (string, bool) {
+	for rpcAPI := range m.handles {
+		if rpcAPI.API != api || rpcAPI.Desc == nil {
+			continue
+		}
+		if rpcAPI.Desc.FindMethodByName("DoCommand") == nil {
+			return "", false
+		}
+		return "/" + rpcAPI.Desc.GetFullyQualifiedName() + "/DoCommand", true
+	}
+	return "", false
+}
+
+// restartUnresponsive stops the process of a module that does not answer and restarts it.
+func (mgr *Manager) restartUnresponsive(ctx context.Context, mod *module, reason string) {
+	mgr.logger.Errorw("module is not responding, restarting it", "module", mod.name, "reason", reason)
+	mgr.stopAndRestart(ctx, mod, reason)
+}
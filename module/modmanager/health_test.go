@@ -0,0 +1,145 @@
+package modmanager
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/config"
+	modmanageroptions "go.viam.com/rdk/module/modmanager/options"
+	"go.viam.com/rdk/resource"
+	rtestutils "go.viam.com/rdk/testutils"
+	"go.viam.com/rdk/utils"
+)
+
+func TestModuleHealthRecords(t *testing.T) {
+	name := generic.Named("helper")
+	mod := &module{name: "test", healthConf: &config.ModuleHealthCheck{}}
+	now := time.Now()
+
+	status := mod.healthStatus(name)
+	test.That(t, status["healthy"], test.ShouldBeTrue)
+	test.That(t, status["health_checks"], test.ShouldBeTrue)
+	test.That(t, status["restarts"], test.ShouldHaveLength, 0)
+	test.That(t, status["last_check"], test.ShouldBeNil)
+
+	test.That(t, mod.health.recordCheck(now, errors.New("timed out")), test.ShouldEqual, 1)
+	test.That(t, mod.health.recordCheck(now, errors.New("timed out")), test.ShouldEqual, 2)
+	status = mod.healthStatus(name)
+	test.That(t, status["healthy"], test.ShouldBeFalse)
+	test.That(t, status["consecutive_failures"], test.ShouldEqual, 2)
+	test.That(t, status["last_error"], test.ShouldEqual, "timed out")
+	test.That(t, mod.health.recordCheck(now, nil), test.ShouldEqual, 0)
+
+	// a resource that does not answer its probes makes the module unhealthy for it only
+	test.That(t, mod.health.recordProbe(name, now, time.Second, errors.New("deadline")), test.ShouldEqual, 1)
+	status = mod.healthStatus(name)
+	test.That(t, status["healthy"], test.ShouldBeFalse)
+	probe := status["liveness_probe"].(map[string]interface{})
+	test.That(t, probe["latency_ms"], test.ShouldEqual, 1000)
+	test.That(t, probe["last_error"], test.ShouldEqual, "deadline")
+	test.That(t, mod.healthStatus(generic.Named("other"))["healthy"], test.ShouldBeTrue)
+	mod.health.forget(name)
+	test.That(t, mod.healthStatus(name)["liveness_probe"], test.ShouldBeNil)
+
+	// restarts forget failures and keep a bounded history
+	mod.health.recordProbe(name, now, time.Second, errors.New("deadline"))
+	for i := 0; i < maxRestartHistory+2; i++ {
+		mod.health.recordRestart(now, "stuck", i%2 == 0)
+	}
+	status = mod.healthStatus(name)
+	test.That(t, status["healthy"], test.ShouldBeTrue)
+	test.That(t, status["liveness_probe"], test.ShouldBeNil)
+	restarts := status["restarts"].([]interface{})
+	test.That(t, restarts, test.ShouldHaveLength, maxRestartHistory)
+	test.That(t, restarts[0].(map[string]interface{})["reason"], test.ShouldEqual, "stuck")
+}
+
+func TestProbeError(t *testing.T) {
+	test.That(t, probeError(nil), test.ShouldBeNil)
+	test.That(t, probeError(status.Error(codes.Unknown, "unknown command")), test.ShouldBeNil)
+	test.That(t, probeError(status.Error(codes.DeadlineExceeded, "slow")), test.ShouldNotBeNil)
+	test.That(t, probeError(status.Error(codes.Unavailable, "gone")), test.ShouldNotBeNil)
+	test.That(t, probeError(errors.Wrap(context.DeadlineExceeded, "probe")), test.ShouldNotBeNil)
+}
+
+func TestModuleHealthChecks(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := golog.NewObservedTestLogger(t)
+
+	// Precompile module to avoid timeout issues when building takes too long.
+	test.That(t, rtestutils.BuildInDir("module/testmodule"), test.ShouldBeNil)
+
+	// This cannot use t.TempDir() as the path it gives on MacOS exceeds module.MaxSocketAddressLength.
+	parentAddr, err := os.MkdirTemp("", "viam-test-*")
+	test.That(t, err, test.ShouldBeNil)
+	defer os.RemoveAll(parentAddr)
+	parentAddr += "/parent.sock"
+
+	mgr := NewManager(parentAddr, logger, modmanageroptions.Options{
+		UntrustedEnv:            false,
+		RemoveOrphanedResources: func(context.Context, []resource.Name) {},
+	})
+	err = mgr.Add(ctx, config.Module{
+		Name:    "test-module",
+		ExePath: utils.ResolveFile("module/testmodule/testmodule"),
+		HealthCheck: &config.ModuleHealthCheck{
+			IntervalSec:         0.05,
+			FailureThreshold:    2,
+			ProbeResources:      true,
+			ResourceTimeoutsSec: map[string]float64{"myhelper": 0.1},
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, mgr.Close(ctx), test.ShouldBeNil)
+	}()
+
+	name := generic.Named("myhelper")
+	h, err := mgr.AddResource(ctx, resource.Config{
+		Name:  "myhelper",
+		API:   generic.API,
+		Model: resource.NewModel("rdk", "test", "helper"),
+	}, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		health, ok := mgr.ModuleHealth(name)
+		test.That(tb, ok, test.ShouldBeTrue)
+		test.That(tb, health["last_check"], test.ShouldNotBeNil)
+		test.That(tb, health["liveness_probe"], test.ShouldNotBeNil)
+	})
+	health, _ := mgr.ModuleHealth(name)
+	test.That(t, health["healthy"], test.ShouldBeTrue)
+	_, ok := mgr.ModuleHealth(generic.Named("missing"))
+	test.That(t, ok, test.ShouldBeFalse)
+
+	// a resource that stops answering gets its module restarted
+	_, err = h.DoCommand(ctx, map[string]interface{}{"command": "hang"})
+	test.That(t, err, test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		test.That(tb, logs.FilterMessageSnippet("module successfully restarted").Len(), test.ShouldEqual, 1)
+	})
+	test.That(t, logs.FilterMessageSnippet("module is not responding").Len(), test.ShouldEqual, 1)
+	health, _ = mgr.ModuleHealth(name)
+	restarts := health["restarts"].([]interface{})
+	test.That(t, restarts, test.ShouldHaveLength, 1)
+	restart := restarts[0].(map[string]interface{})
+	test.That(t, restart["success"], test.ShouldBeTrue)
+	test.That(t, restart["reason"], test.ShouldContainSubstring, "failed 2 liveness probes")
+
+	resp, err := h.DoCommand(ctx, map[string]interface{}{"command": "echo"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["command"], test.ShouldEqual, "echo")
+}
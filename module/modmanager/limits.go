@@ -0,0 +1,216 @@
+package modmanager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/config"
+)
+
+var (
+	// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+	cgroupRoot = "/sys/fs/cgroup"
+	// procSelfCgroup lists the cgroups of the server process.
+	procSelfCgroup = "/proc/self/cgroup"
+)
+
+const (
+	moduleCgroupPrefix = "viam-module-"
+	// serverCgroupName is the subgroup of its delegated cgroup the server is expected to run in, as
+	// the DelegateSubgroup=viam-server option of its systemd service puts it, leaving the delegated
+	// cgroup free of processes to hold the cgroups of modules.
+	serverCgroupName = "viam-server"
+	// cpuPeriod is the period of the CPU quota of module cgroups, in microseconds.
+	cpuPeriod = 100000
+)
+
+// launchCommand returns the command that starts the module executable with the given arguments.
+// When the module has an environment or resource limits, the executable is started by a shell that
+// first joins the module's cgroup, sets the rlimits and exports the environment, then execs it.
+func (m *module) launchCommand(args []string) (string, []string) {
+	var steps []string
+	if m.cgroup != nil {
+		steps = append(steps, "echo $$ > "+shellQuote(filepath.Join(m.cgroup.dir, "cgroup.procs")))
+	}
+	if m.limits != nil {
+		// without a cgroup, memory is limited by the address space of the process
+		if m.limits.MemoryMB > 0 && m.cgroup == nil {
+			steps = append(steps, fmt.Sprintf("ulimit -v %d", m.limits.MemoryMB*1024))
+		}
+		if m.limits.MaxOpenFiles > 0 {
+			steps = append(steps, fmt.Sprintf("ulimit -n %d", m.limits.MaxOpenFiles))
+		}
+	}
+	names := make([]string, 0, len(m.env))
+	for name := range m.env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// names were validated by config.Module.Validate, values are quoted
+	for _, name := range names {
+		steps = append(steps, "export "+name+"="+shellQuote(m.env[name]))
+	}
+	if len(steps) == 0 {
+		return m.exe, args
+	}
+	steps = append(steps, `exec "$0" "$@"`)
+	return "/bin/sh", append([]string{"-c", strings.Join(steps, " && "), m.exe}, args...)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// moduleCgroup is the cgroup (v2) a module process runs in, which limits its memory and CPU use.
+type moduleCgroup struct {
+	dir string
+}
+
+// newModuleCgroup creates or updates the cgroup of the module with the limits, as a child of the
+// cgroup delegated to the server. It returns nil if the limits have no memory or CPU limit.
+func newModuleCgroup(name string, limits *config.ModuleLimits) (*moduleCgroup, error) {
+	if limits == nil || (limits.MemoryMB == 0 && limits.CPUs == 0) {
+		return nil, nil
+	}
+	if runtime.GOOS != "linux" {
+		return nil, errors.Errorf("cgroups are not supported on %s", runtime.GOOS)
+	}
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return nil, errors.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	}
+	self, err := ownCgroup()
+	if err != nil {
+		return nil, err
+	}
+	parent := filepath.Join(cgroupRoot, self)
+	if filepath.Base(self) == serverCgroupName {
+		parent = filepath.Dir(parent)
+	}
+	if err := enableControllers(parent); err != nil {
+		return nil, err
+	}
+
+	cg := &moduleCgroup{dir: filepath.Join(parent, moduleCgroupPrefix+name)}
+	if err := os.Mkdir(cg.dir, 0o755); err != nil && !os.IsExist(err) {
+		return nil, errors.Wrap(err, "cannot create module cgroup")
+	}
+	memoryMax, swapMax, cpuMax := "max", "max", "max"
+	if limits.MemoryMB > 0 {
+		memoryMax = strconv.Itoa(limits.MemoryMB * 1024 * 1024)
+		// the limit would not be much of one if the module could swap past it
+		swapMax = "0"
+	}
+	if limits.CPUs > 0 {
+		cpuMax = fmt.Sprintf("%d %d", int(limits.CPUs*cpuPeriod), cpuPeriod)
+	}
+	if err := writeCgroupFile(cg.dir, "memory.max", memoryMax); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(cg.dir, "memory.swap.max")); err == nil {
+		if err := writeCgroupFile(cg.dir, "memory.swap.max", swapMax); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeCgroupFile(cg.dir, "cpu.max", cpuMax); err != nil {
+		return nil, err
+	}
+	return cg, nil
+}
+
+// ownCgroup returns the path of the cgroup v2 of the server relative to the cgroup root.
+func ownCgroup() (string, error) {
+	//nolint:gosec
+	f, err := os.Open(procSelfCgroup)
+	if err != nil {
+		return "", err
+	}
+	//nolint:errcheck
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if path := strings.TrimPrefix(scanner.Text(), "0::"); path != scanner.Text() {
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("server is not in a cgroup v2")
+}
+
+// enableControllers enables the memory and CPU controllers for the children of the cgroup. A cgroup
+// other than the root cannot both have processes and controllers for its children, so the cgroup
+// must be delegated to the server with the server running in a subgroup of it, e.g. with the
+// Delegate=yes and DelegateSubgroup=viam-server options of its systemd service.
+func enableControllers(dir string) error {
+	if subtreeControls(dir, "memory", "cpu") {
+		return nil
+	}
+	if err := writeCgroupFile(dir, "cgroup.subtree_control", "+memory +cpu"); err != nil {
+		return errors.Wrapf(err,
+			"cannot enable the memory and cpu controllers for module cgroups, run viam-server from a systemd service "+
+				"with Delegate=yes and DelegateSubgroup=%s", serverCgroupName)
+	}
+	return nil
+}
+
+// subtreeControls returns whether the controllers are enabled for the children of the cgroup.
+func subtreeControls(dir string, controllers ...string) bool {
+	//nolint:gosec
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control"))
+	if err != nil {
+		return false
+	}
+	enabled := strings.Fields(string(data))
+	for _, c := range controllers {
+		found := false
+		for _, e := range enabled {
+			found = found || e == c
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCgroupFile(dir, file, value string) error {
+	//nolint:gosec
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0o644); err != nil {
+		return errors.Wrapf(err, "cannot write %s of cgroup %s", file, dir)
+	}
+	return nil
+}
+
+// oomKills returns the number of times a process of the cgroup was killed for running out of memory.
+func (cg *moduleCgroup) oomKills() uint64 {
+	//nolint:gosec
+	data, err := os.ReadFile(filepath.Join(cg.dir, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			n, err := strconv.ParseUint(fields[1], 10, 64)
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// remove removes the cgroup, which must have no processes left.
+func (cg *moduleCgroup) remove() error {
+	return os.RemoveAll(cg.dir)
+}
@@ -0,0 +1,173 @@
+package modmanager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/resource"
+)
+
+func TestLaunchCommand(t *testing.T) {
+	mod := &module{name: "test", exe: "/bin/module"}
+	name, args := mod.launchCommand([]string{"sock"})
+	test.That(t, name, test.ShouldEqual, "/bin/module")
+	test.That(t, args, test.ShouldResemble, []string{"sock"})
+
+	mod.env = map[string]string{"B": "it's", "A": "1"}
+	mod.limits = &config.ModuleLimits{MemoryMB: 2, MaxOpenFiles: 64}
+	name, args = mod.launchCommand([]string{"sock", "--x"})
+	test.That(t, name, test.ShouldEqual, "/bin/sh")
+	test.That(t, args, test.ShouldResemble, []string{
+		"-c",
+		`ulimit -v 2048 && ulimit -n 64 && export A='1' && export B='it'\''s' && exec "$0" "$@"`,
+		"/bin/module", "sock", "--x",
+	})
+
+	// with a cgroup, memory is limited by it rather than an rlimit
+	mod.cgroup = &moduleCgroup{dir: "/sys/fs/cgroup/viam-module-test"}
+	_, args = mod.launchCommand(nil)
+	test.That(t, args[1], test.ShouldStartWith, `echo $$ > '/sys/fs/cgroup/viam-module-test/cgroup.procs' && ulimit -n 64 && `)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// the shell applies the limits and environment and runs the executable with its arguments
+	mod = &module{
+		exe:    "/bin/sh",
+		env:    map[string]string{"GREETING": "it's $HOME"},
+		limits: &config.ModuleLimits{MaxOpenFiles: 100},
+	}
+	name, args = mod.launchCommand([]string{"-c", `echo "$GREETING" $(ulimit -n) "$0"`, "arg"})
+	//nolint:gosec
+	out, err := exec.Command(name, args...).Output()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(out), test.ShouldEqual, "it's $HOME 100 arg\n")
+}
+
+func fakeCgroupRoot(t *testing.T, subtreeControl string) string {
+	t.Helper()
+	root := t.TempDir()
+	test.That(t, os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0o600), test.ShouldBeNil)
+	self := filepath.Join(root, "system.slice", "viam.service")
+	test.That(t, os.MkdirAll(self, 0o700), test.ShouldBeNil)
+	test.That(t, os.WriteFile(filepath.Join(self, "cgroup.subtree_control"), []byte(subtreeControl), 0o600), test.ShouldBeNil)
+	procSelf := filepath.Join(root, "self-cgroup")
+	test.That(t, os.WriteFile(procSelf, []byte("1:name=systemd:/foo\n0::/system.slice/viam.service\n"), 0o600), test.ShouldBeNil)
+
+	origRoot, origProc := cgroupRoot, procSelfCgroup
+	cgroupRoot, procSelfCgroup = root, procSelf
+	t.Cleanup(func() {
+		cgroupRoot, procSelfCgroup = origRoot, origProc
+	})
+	return self
+}
+
+func TestModuleCgroup(t *testing.T) {
+	cg, err := newModuleCgroup("test", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cg, test.ShouldBeNil)
+	cg, err = newModuleCgroup("test", &config.ModuleLimits{MaxOpenFiles: 10})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cg, test.ShouldBeNil)
+	if runtime.GOOS != "linux" {
+		_, err = newModuleCgroup("test", &config.ModuleLimits{MemoryMB: 1})
+		test.That(t, err, test.ShouldNotBeNil)
+		return
+	}
+
+	t.Run("no cgroup v2", func(t *testing.T) {
+		fakeCgroupRoot(t, "")
+		cgroupRoot = t.TempDir()
+		_, err := newModuleCgroup("test", &config.ModuleLimits{MemoryMB: 1})
+		test.That(t, err.Error(), test.ShouldContainSubstring, "cgroup v2 is not mounted")
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		self := fakeCgroupRoot(t, "")
+		cg, err := newModuleCgroup("vision", &config.ModuleLimits{MemoryMB: 256, CPUs: 1.5})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cg.dir, test.ShouldEqual, filepath.Join(self, "viam-module-vision"))
+		for file, expected := range map[string]string{
+			"memory.max":                "268435456",
+			"cpu.max":                   "150000 100000",
+			"../cgroup.subtree_control": "+memory +cpu",
+		} {
+			data, err := os.ReadFile(filepath.Join(cg.dir, file))
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, string(data), test.ShouldEqual, expected)
+		}
+
+		// a cgroup is reused by the restarts of its module, which can lift a limit
+		test.That(t, os.WriteFile(filepath.Join(cg.dir, "memory.swap.max"), []byte("max"), 0o600), test.ShouldBeNil)
+		cg, err = newModuleCgroup("vision", &config.ModuleLimits{CPUs: 0.5})
+		test.That(t, err, test.ShouldBeNil)
+		for file, expected := range map[string]string{"memory.max": "max", "memory.swap.max": "max", "cpu.max": "50000 100000"} {
+			data, err := os.ReadFile(filepath.Join(cg.dir, file))
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, string(data), test.ShouldEqual, expected)
+		}
+
+		test.That(t, cg.oomKills(), test.ShouldEqual, 0)
+		events := "low 0\nhigh 0\nmax 3\noom 2\noom_kill 2\noom_group_kill 0\n"
+		test.That(t, os.WriteFile(filepath.Join(cg.dir, "memory.events"), []byte(events), 0o600), test.ShouldBeNil)
+		test.That(t, cg.oomKills(), test.ShouldEqual, 2)
+
+		// the module process is told apart from exits of other causes by new OOM kills
+		mod := &module{cgroup: cg, startOOMKills: 1}
+		kills, ok := mod.exitedOutOfMemory()
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, kills, test.ShouldEqual, 1)
+		test.That(t, mod.healthStatus(resource.Name{})["oom_kills"], test.ShouldEqual, 1)
+		_, ok = mod.exitedOutOfMemory()
+		test.That(t, ok, test.ShouldBeFalse)
+		_, ok = (&module{}).exitedOutOfMemory()
+		test.That(t, ok, test.ShouldBeFalse)
+
+		test.That(t, cg.remove(), test.ShouldBeNil)
+		_, err = os.Stat(cg.dir)
+		test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+	})
+
+	t.Run("controllers already enabled", func(t *testing.T) {
+		self := fakeCgroupRoot(t, "cpu io memory")
+		_, err := newModuleCgroup("vision", &config.ModuleLimits{MemoryMB: 1})
+		test.That(t, err, test.ShouldBeNil)
+		data, err := os.ReadFile(filepath.Join(self, "cgroup.subtree_control"))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(data), test.ShouldEqual, "cpu io memory")
+	})
+
+	t.Run("delegated subgroup", func(t *testing.T) {
+		self := fakeCgroupRoot(t, "")
+		// the server runs in a subgroup of the cgroup of its service, which holds the module cgroups
+		test.That(t, os.Mkdir(filepath.Join(self, serverCgroupName), 0o700), test.ShouldBeNil)
+		selfCgroup := []byte("0::/system.slice/viam.service/" + serverCgroupName + "\n")
+		test.That(t, os.WriteFile(procSelfCgroup, selfCgroup, 0o600), test.ShouldBeNil)
+		cg, err := newModuleCgroup("vision", &config.ModuleLimits{MemoryMB: 1})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cg.dir, test.ShouldEqual, filepath.Join(self, "viam-module-vision"))
+		data, err := os.ReadFile(filepath.Join(self, "cgroup.subtree_control"))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(data), test.ShouldEqual, "+memory +cpu")
+	})
+
+	t.Run("not delegated", func(t *testing.T) {
+		self := fakeCgroupRoot(t, "")
+		// a directory in place of the control file makes enabling controllers fail
+		control := filepath.Join(self, "cgroup.subtree_control")
+		test.That(t, os.Remove(control), test.ShouldBeNil)
+		test.That(t, os.Mkdir(control, 0o700), test.ShouldBeNil)
+		_, err := newModuleCgroup("vision", &config.ModuleLimits{MemoryMB: 1})
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "Delegate=yes and DelegateSubgroup=viam-server")
+		// the server stays in its cgroup
+		_, err = os.Stat(filepath.Join(self, serverCgroupName))
+		test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+	})
+}
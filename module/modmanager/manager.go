@@ -31,6 +31,7 @@ import (
 	"go.viam.com/rdk/module/modmaninterface"
 	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot/packages"
 	rutils "go.viam.com/rdk/utils"
 )
 
@@ -49,20 +50,25 @@ func NewManager(parentAddr string, logger golog.Logger, options modmanageroption
 		parentAddr:              parentAddr,
 		rMap:                    map[resource.Name]*module{},
 		untrustedEnv:            options.UntrustedEnv,
+		packagesDir:             options.PackagesDir,
 		removeOrphanedResources: options.RemoveOrphanedResources,
 	}
 }
 
 type module struct {
-	name      string
-	exe       string
-	logLevel  string
-	process   pexec.ManagedProcess
-	handles   modlib.HandlerMap
-	conn      *grpc.ClientConn
-	client    pb.ModuleServiceClient
-	addr      string
-	resources map[resource.Name]*addedResource
+	name       string
+	exe        string
+	logLevel   string
+	env        map[string]string
+	args       []string
+	workingDir string
+	limits     *config.ModuleLimits
+	process    pexec.ManagedProcess
+	handles    modlib.HandlerMap
+	conn       *grpc.ClientConn
+	client     pb.ModuleServiceClient
+	addr       string
+	resources  map[resource.Name]*addedResource
 
 	// inRecovery stores whether or not an OnUnexpectedExit function is trying
 	// to recover a crash of this module; inRecoveryLock guards the execution of
@@ -73,6 +79,24 @@ type module struct {
 	// another OUE has finished.
 	inRecovery     atomic.Bool
 	inRecoveryLock sync.Mutex
+
+	// cgroup is the cgroup limiting the memory and CPU of the module process, if it has one.
+	// startOOMKills is the number of processes of the cgroup killed for running out of memory when
+	// the process started.
+	cgroup        *moduleCgroup
+	startOOMKills uint64
+
+	// healthConf enables the health checks of the module, which run until healthCancel is called.
+	healthConf    *config.ModuleHealthCheck
+	healthCancel  func()
+	healthWorkers sync.WaitGroup
+	health        moduleHealth
+
+	// watch enables restarting the module when its executable, or the files under watchDir, change.
+	watch        bool
+	watchDir     string
+	watchCancel  func()
+	watchWorkers sync.WaitGroup
 }
 
 type addedResource struct {
@@ -88,6 +112,7 @@ type Manager struct {
 	parentAddr              string
 	rMap                    map[resource.Name]*module
 	untrustedEnv            bool
+	packagesDir             string
 	removeOrphanedResources func(ctx context.Context, rNames []resource.Name)
 }
 
@@ -118,10 +143,33 @@ func (mgr *Manager) add(ctx context.Context, conf config.Module, conn *grpc.Clie
 	}
 
 	mod := &module{
-		name:      conf.Name,
-		exe:       conf.ExePath,
-		logLevel:  conf.LogLevel,
-		resources: map[resource.Name]*addedResource{},
+		name:       conf.Name,
+		exe:        conf.ExePath,
+		logLevel:   conf.LogLevel,
+		env:        conf.Environment,
+		args:       conf.Args,
+		workingDir: conf.WorkingDir,
+		limits:     conf.Limits,
+		healthConf: conf.HealthCheck,
+		watch:      conf.Watch,
+		watchDir:   conf.WatchDir,
+		resources:  map[resource.Name]*addedResource{},
+	}
+	var manifest *config.ModuleManifest
+	if conf.Bundle != "" {
+		installed, err := packages.InstallModuleBundle(ctx, mgr.packagesDir, conf)
+		if err != nil {
+			return errors.WithMessage(err, "error while installing module "+conf.Name)
+		}
+		mod.exe = installed.ExePath
+		manifest = installed.Manifest
+	} else if conf.WorkingDir != "" {
+		// the executable path is relative to the working directory of the server, not the module's
+		exe, err := filepath.Abs(conf.ExePath)
+		if err != nil {
+			return err
+		}
+		mod.exe = exe
 	}
 	mgr.modules[conf.Name] = mod
 
@@ -148,7 +196,12 @@ func (mgr *Manager) add(ctx context.Context, conf config.Module, conn *grpc.Clie
 		return errors.WithMessage(err, "error while waiting for module to be ready "+mod.name)
 	}
 
+	if manifest != nil {
+		mod.checkManifestModels(manifest, mgr.logger)
+	}
 	mod.registerResources(mgr, mgr.logger)
+	mgr.startHealthChecks(mod)
+	mgr.startWatching(mod)
 
 	success = true
 	return nil
@@ -207,6 +260,10 @@ func (mgr *Manager) Remove(modName string) ([]resource.Name, error) {
 }
 
 func (mgr *Manager) remove(mod *module, reconfigure bool) error {
+	// health checks and the watcher can restart the module, which needs the lock
+	mod.stopHealthChecks()
+	mod.stopWatching()
+
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
@@ -301,6 +358,17 @@ func (mgr *Manager) IsModularResource(name resource.Name) bool {
 	return ok
 }
 
+// ModuleName returns the name of the module serving the given resource, if any.
+func (mgr *Manager) ModuleName(name resource.Name) (string, bool) {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+	mod, ok := mgr.rMap[name]
+	if !ok {
+		return "", false
+	}
+	return mod.name, true
+}
+
 // RemoveResource requests the removal of a resource from a module.
 func (mgr *Manager) RemoveResource(ctx context.Context, name resource.Name) error {
 	mgr.mu.Lock()
@@ -311,6 +379,7 @@ func (mgr *Manager) RemoveResource(ctx context.Context, name resource.Name) erro
 	}
 	delete(mgr.rMap, name)
 	delete(module.resources, name)
+	module.health.forget(name)
 	_, err := module.client.RemoveResource(ctx, &pb.RemoveResourceRequest{Name: name.String()})
 	return err
 }
@@ -399,43 +468,86 @@ func (mgr *Manager) newOnUnexpectedExitHandler(mod *module) func(exitCode int) b
 		defer cancel()
 
 		// Log error immediately, as this is unexpected behavior.
-		mgr.logger.Errorw(
-			"module has unexpectedly exited, attempting to restart it",
-			"module", mod.name,
-			"exit_code", exitCode,
-		)
-
-		// If attemptRestart returns any orphaned resource names, restart failed,
-		// and we should remove orphaned resources. Since we handle process
-		// restarting ourselves, return false here so goutils knows not to attempt
-		// a process restart.
-		if orphanedResourceNames := mgr.attemptRestart(ctx, mod); orphanedResourceNames != nil {
-			if mgr.removeOrphanedResources != nil {
-				mgr.removeOrphanedResources(ctx, orphanedResourceNames)
-			}
-			return false
+		reason := fmt.Sprintf("module exited with code %d", exitCode)
+		if oomKills, ok := mod.exitedOutOfMemory(); ok {
+			reason = "module was killed for exceeding its memory limit"
+			mgr.logger.Errorw(
+				"module was killed for exceeding its memory limit, attempting to restart it",
+				"module", mod.name,
+				"memory_limit_mb", mod.limits.MemoryMB,
+				"oom_kills", oomKills,
+			)
+		} else {
+			mgr.logger.Errorw(
+				"module has unexpectedly exited, attempting to restart it",
+				"module", mod.name,
+				"exit_code", exitCode,
+			)
 		}
 
-		// Otherwise, add old module process' resources to new module; warn if new
-		// module cannot handle old resource, deregister that resource and remove
-		// it from mod.resources. Finally, handle orphaned resources.
-		var orphanedResourceNames []resource.Name
-		for name, res := range mod.resources {
-			if _, err := mgr.AddResource(ctx, res.conf, res.deps); err != nil {
-				mgr.logger.Warnw("error while re-adding resource to module",
-					"resource", name, "module", mod.name, "error", err)
-				resource.Deregister(res.conf.API, res.conf.Model)
-				delete(mod.resources, name)
-				orphanedResourceNames = append(orphanedResourceNames, name)
-			}
-		}
+		mgr.restart(ctx, mod, reason)
+		// Since we handle process restarting ourselves, return false here so
+		// goutils knows not to attempt a process restart.
+		return false
+	}
+}
+
+// restart restarts the process of a module that exited or was stopped for
+// being unresponsive, and adds its resources back to it. The restart is
+// recorded in the health of the module with the reason for it.
+func (mgr *Manager) restart(ctx context.Context, mod *module, reason string) {
+	// If attemptRestart returns any orphaned resource names, restart failed,
+	// and we should remove orphaned resources.
+	if orphanedResourceNames := mgr.attemptRestart(ctx, mod); orphanedResourceNames != nil {
+		mod.health.recordRestart(time.Now(), reason, false)
 		if mgr.removeOrphanedResources != nil {
 			mgr.removeOrphanedResources(ctx, orphanedResourceNames)
 		}
+		return
+	}
+	mod.health.recordRestart(time.Now(), reason, true)
+
+	// Otherwise, add old module process' resources to new module; warn if new
+	// module cannot handle old resource, deregister that resource and remove
+	// it from mod.resources. Finally, handle orphaned resources.
+	var orphanedResourceNames []resource.Name
+	for name, res := range mod.resources {
+		if _, err := mgr.AddResource(ctx, res.conf, res.deps); err != nil {
+			mgr.logger.Warnw("error while re-adding resource to module",
+				"resource", name, "module", mod.name, "error", err)
+			resource.Deregister(res.conf.API, res.conf.Model)
+			delete(mod.resources, name)
+			orphanedResourceNames = append(orphanedResourceNames, name)
+		}
+	}
+	if mgr.removeOrphanedResources != nil {
+		mgr.removeOrphanedResources(ctx, orphanedResourceNames)
+	}
 
-		mgr.logger.Infow("module successfully restarted", "module", mod.name)
+	mgr.logger.Infow("module successfully restarted", "module", mod.name)
+}
+
+// stopAndRestart stops the process of the module on purpose and restarts it, unless an
+// OnUnexpectedExit function or another restart is already recovering the module, in which case it
+// returns false.
+func (mgr *Manager) stopAndRestart(ctx context.Context, mod *module, reason string) bool {
+	mod.inRecoveryLock.Lock()
+	defer mod.inRecoveryLock.Unlock()
+	if mod.inRecovery.Load() {
 		return false
 	}
+	mod.inRecovery.Store(true)
+	defer mod.inRecovery.Store(false)
+
+	ctx, cancel := context.WithTimeout(ctx, oueTimeout)
+	defer cancel()
+
+	// stopping the process on purpose does not call its OnUnexpectedExit function
+	if err := mod.stopProcess(); err != nil {
+		mgr.logger.Errorw("error while stopping module to restart it", "module", mod.name, "reason", reason, "error", err)
+	}
+	mgr.restart(ctx, mod, reason)
+	return true
 }
 
 // attemptRestart will attempt to restart the module up to three times and
@@ -580,24 +692,39 @@ func (m *module) startProcess(
 		return err
 	}
 
-	pconf := pexec.ProcessConfig{
-		ID:               m.name,
-		Name:             m.exe,
-		Args:             []string{m.addr},
-		Log:              true,
-		OnUnexpectedExit: oue,
-	}
+	args := []string{m.addr}
 	// Start module process with supplied log level or "debug" if none is
 	// supplied and module manager has a DebugLevel logger.
 	if m.logLevel != "" {
-		pconf.Args = append(pconf.Args, fmt.Sprintf(logLevelArgumentTemplate, m.logLevel))
+		args = append(args, fmt.Sprintf(logLevelArgumentTemplate, m.logLevel))
 	} else if logger.Level().Enabled(zapcore.DebugLevel) {
-		pconf.Args = append(pconf.Args, fmt.Sprintf(logLevelArgumentTemplate, "debug"))
+		args = append(args, fmt.Sprintf(logLevelArgumentTemplate, "debug"))
+	}
+	args = append(args, m.args...)
+
+	cgroup, err := newModuleCgroup(m.name, m.limits)
+	if err != nil {
+		// fall back to the rlimits of launchCommand, which cannot limit CPU
+		logger.Warnw("cannot limit module with a cgroup, limiting its memory with an rlimit instead",
+			"module", m.name, "error", err)
+	}
+	m.cgroup = cgroup
+	if m.cgroup != nil {
+		m.startOOMKills = m.cgroup.oomKills()
 	}
 
+	name, args := m.launchCommand(args)
+	pconf := pexec.ProcessConfig{
+		ID:               m.name,
+		Name:             name,
+		Args:             args,
+		CWD:              m.workingDir,
+		Log:              true,
+		OnUnexpectedExit: oue,
+	}
 	m.process = pexec.NewManagedProcess(pconf, logger)
 
-	err := m.process.Start(context.Background())
+	err = m.process.Start(context.Background())
 	if err != nil {
 		return errors.WithMessage(err, "module startup failed")
 	}
@@ -636,9 +763,30 @@ func (m *module) stopProcess() error {
 		!strings.Contains(err.Error(), errMessageExitStatus143) {
 		return err
 	}
+	if m.cgroup != nil {
+		if err := m.cgroup.remove(); err != nil {
+			return errors.WithMessage(err, "error while removing module cgroup")
+		}
+		m.cgroup = nil
+	}
 	return nil
 }
 
+// exitedOutOfMemory returns whether the exited module process was killed for running out of
+// memory, counting it if so along with the number of times the module was. Only modules limited by
+// a cgroup can tell.
+func (m *module) exitedOutOfMemory() (int, bool) {
+	if m.cgroup == nil {
+		return 0, false
+	}
+	kills := m.cgroup.oomKills()
+	if kills <= m.startOOMKills {
+		return 0, false
+	}
+	m.startOOMKills = kills
+	return m.health.recordOOMKill(), true
+}
+
 func (m *module) registerResources(mgr modmaninterface.ModuleManager, logger golog.Logger) {
 	for api, models := range m.handles {
 		if _, ok := resource.LookupGenericAPIRegistration(api.API); !ok {
@@ -681,6 +829,38 @@ func (m *module) registerResources(mgr modmaninterface.ModuleManager, logger gol
 	}
 }
 
+// checkManifestModels warns about the models the manifest of the module declares that it does not serve.
+func (m *module) checkManifestModels(manifest *config.ModuleManifest, logger golog.Logger) {
+	for _, declared := range manifest.Models {
+		api, err := resource.NewAPIFromString(declared.API)
+		if err != nil {
+			continue
+		}
+		model, err := resource.NewModelFromString(declared.Model)
+		if err != nil {
+			continue
+		}
+		if !handlesModel(m.handles, api, model) {
+			logger.Warnw("module does not serve a model its manifest declares",
+				"module", m.name, "api", declared.API, "model", declared.Model)
+		}
+	}
+}
+
+func handlesModel(handles modlib.HandlerMap, api resource.API, model resource.Model) bool {
+	for rpcAPI, models := range handles {
+		if rpcAPI.API != api {
+			continue
+		}
+		for _, m := range models {
+			if m == model {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (m *module) deregisterResources() {
 	for api, models := range m.handles {
 		for _, model := range models {
@@ -1,13 +1,18 @@
 package modmanager
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/edaniels/golog"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 	"go.viam.com/test"
 	"go.viam.com/utils/testutils"
@@ -548,3 +553,172 @@ func TestDebugModule(t *testing.T) {
 		})
 	}
 }
+
+func TestModuleProcessConfig(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	// Precompile module to avoid timeout issues when building takes too long.
+	test.That(t, rtestutils.BuildInDir("module/testmodule"), test.ShouldBeNil)
+
+	// This cannot use t.TempDir() as the path it gives on MacOS exceeds module.MaxSocketAddressLength.
+	parentAddr, err := os.MkdirTemp("", "viam-test-*")
+	test.That(t, err, test.ShouldBeNil)
+	defer os.RemoveAll(parentAddr)
+	parentAddr += "/parent.sock"
+
+	workingDir := t.TempDir()
+	mgr := NewManager(parentAddr, logger, modmanageroptions.Options{UntrustedEnv: false})
+	err = mgr.Add(ctx, config.Module{
+		Name:        "test-module",
+		ExePath:     utils.ResolveFile("module/testmodule/testmodule"),
+		LogLevel:    "info",
+		Environment: map[string]string{"TEST_MODULE_GREETING": "it's a 'quoted' $HOME"},
+		Args:        []string{"--flag", "two words"},
+		WorkingDir:  workingDir,
+		Limits:      &config.ModuleLimits{MaxOpenFiles: 512},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, mgr.Close(ctx), test.ShouldBeNil)
+	}()
+
+	h, err := mgr.AddResource(ctx, resource.Config{
+		Name:  "myhelper",
+		API:   generic.API,
+		Model: resource.NewModel("rdk", "test", "helper"),
+	}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	resp, err := h.DoCommand(ctx, map[string]interface{}{"command": "get_process_info", "env": "TEST_MODULE_GREETING"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["env"], test.ShouldEqual, "it's a 'quoted' $HOME")
+	args := resp["args"].([]interface{})
+	test.That(t, args, test.ShouldHaveLength, 4)
+	test.That(t, args[1:], test.ShouldResemble, []interface{}{"--log-level=info", "--flag", "two words"})
+	wd, err := filepath.EvalSymlinks(workingDir)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["working_dir"], test.ShouldEqual, wd)
+}
+
+func TestModuleWatch(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := golog.NewObservedTestLogger(t)
+	// panics of the watch loop are logged globally
+	defer golog.ReplaceGloabl(logger)()
+
+	defer func(orig time.Duration) {
+		watchDebounce = orig
+	}(watchDebounce)
+	watchDebounce = 50 * time.Millisecond
+
+	// Precompile module to avoid timeout issues when building takes too long.
+	test.That(t, rtestutils.BuildInDir("module/testmodule"), test.ShouldBeNil)
+	built, err := os.ReadFile(utils.ResolveFile("module/testmodule/testmodule"))
+	test.That(t, err, test.ShouldBeNil)
+	exePath := filepath.Join(t.TempDir(), "testmodule")
+	test.That(t, os.WriteFile(exePath, built, 0o700), test.ShouldBeNil)
+
+	// This cannot use t.TempDir() as the path it gives on MacOS exceeds module.MaxSocketAddressLength.
+	parentAddr, err := os.MkdirTemp("", "viam-test-*")
+	test.That(t, err, test.ShouldBeNil)
+	defer os.RemoveAll(parentAddr)
+	parentAddr += "/parent.sock"
+
+	mgr := NewManager(parentAddr, logger, modmanageroptions.Options{
+		UntrustedEnv:            false,
+		RemoveOrphanedResources: func(context.Context, []resource.Name) {},
+	})
+	err = mgr.Add(ctx, config.Module{Name: "test-module", ExePath: exePath, Watch: true})
+	test.That(t, err, test.ShouldBeNil)
+
+	h, err := mgr.AddResource(ctx, resource.Config{
+		Name:  "myhelper",
+		API:   generic.API,
+		Model: resource.NewModel("rdk", "test", "helper"),
+	}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	resp, err := h.DoCommand(ctx, map[string]interface{}{"command": "get_process_info"})
+	test.That(t, err, test.ShouldBeNil)
+	pid := resp["pid"]
+
+	// a rebuilt executable restarts the module, with its resources
+	test.That(t, os.Remove(exePath), test.ShouldBeNil)
+	test.That(t, os.WriteFile(exePath, built, 0o700), test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		test.That(tb, logs.FilterMessageSnippet("module successfully restarted").Len(), test.ShouldEqual, 1)
+	})
+	test.That(t, logs.FilterMessageSnippet("module changed, restarting it").Len(), test.ShouldEqual, 1)
+	test.That(t, mgr.IsModularResource(generic.Named("myhelper")), test.ShouldBeTrue)
+	resp, err = h.DoCommand(ctx, map[string]interface{}{"command": "get_process_info"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["pid"], test.ShouldNotEqual, pid)
+	_, ok := resource.LookupRegistration(generic.API, resource.NewModel("rdk", "test", "helper"))
+	test.That(t, ok, test.ShouldBeTrue)
+
+	// closing waits for the watch loop, so anything that went wrong reloading has been logged
+	test.That(t, mgr.Close(ctx), test.ShouldBeNil)
+	test.That(t, logs.FilterLevelExact(zapcore.ErrorLevel).All(), test.ShouldBeEmpty)
+}
+
+func TestModuleBundle(t *testing.T) {
+	ctx := context.Background()
+	logger, logs := golog.NewObservedTestLogger(t)
+
+	// Precompile module to avoid timeout issues when building takes too long.
+	test.That(t, rtestutils.BuildInDir("module/testmodule"), test.ShouldBeNil)
+	built, err := os.ReadFile(utils.ResolveFile("module/testmodule/testmodule"))
+	test.That(t, err, test.ShouldBeNil)
+	manifest, err := json.Marshal(config.ModuleManifest{
+		Entrypoint: "bin/testmodule",
+		Models: []config.ModuleManifestModel{
+			{API: "rdk:component:generic", Model: "rdk:test:helper"},
+			{API: "rdk:component:generic", Model: "rdk:test:missing"},
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	usb := t.TempDir()
+	f, err := os.Create(filepath.Join(usb, "test-module.tar.gz"))
+	test.That(t, err, test.ShouldBeNil)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string][]byte{config.ModuleManifestFileName: manifest, "bin/testmodule": built} {
+		test.That(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content))}), test.ShouldBeNil)
+		_, err := tw.Write(content)
+		test.That(t, err, test.ShouldBeNil)
+	}
+	test.That(t, tw.Close(), test.ShouldBeNil)
+	test.That(t, gz.Close(), test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+
+	// This cannot use t.TempDir() as the path it gives on MacOS exceeds module.MaxSocketAddressLength.
+	parentAddr, err := os.MkdirTemp("", "viam-test-*")
+	test.That(t, err, test.ShouldBeNil)
+	defer os.RemoveAll(parentAddr)
+	parentAddr += "/parent.sock"
+
+	modCfg := config.Module{Name: "test-module", Bundle: "file://" + usb}
+	test.That(t, modCfg.Validate("modules.0"), test.ShouldBeNil)
+
+	mgr := NewManager(parentAddr, logger, modmanageroptions.Options{UntrustedEnv: false})
+	test.That(t, mgr.Add(ctx, modCfg), test.ShouldNotBeNil)
+	test.That(t, mgr.Close(ctx), test.ShouldBeNil)
+
+	mgr = NewManager(parentAddr, logger, modmanageroptions.Options{UntrustedEnv: false, PackagesDir: t.TempDir()})
+	test.That(t, mgr.Add(ctx, modCfg), test.ShouldBeNil)
+	defer func() {
+		test.That(t, mgr.Close(ctx), test.ShouldBeNil)
+	}()
+	test.That(t, logs.FilterMessageSnippet("does not serve a model its manifest declares").Len(), test.ShouldEqual, 1)
+
+	h, err := mgr.AddResource(ctx, resource.Config{
+		Name:  "myhelper",
+		API:   generic.API,
+		Model: resource.NewModel("rdk", "test", "helper"),
+	}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	resp, err := h.DoCommand(ctx, map[string]interface{}{"command": "echo"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["command"], test.ShouldEqual, "echo")
+}
@@ -11,6 +11,9 @@ import (
 type Options struct {
 	UntrustedEnv bool
 
+	// PackagesDir is the directory module bundles are installed to.
+	PackagesDir string
+
 	// RemoveOrphanedResources is a function that the module manager can call to
 	// remove orphaned resources from the resource graph.
 	RemoveOrphanedResources func(ctx context.Context, rNames []resource.Name)
@@ -0,0 +1,162 @@
+package modmanager
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bep/debounce"
+	"github.com/fsnotify/fsnotify"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/resource"
+)
+
+// watchDebounce is how long the watched files of a module must stay unchanged before the module is
+// restarted, so that a build writing them in several steps restarts it once.
+var watchDebounce = 500 * time.Millisecond
+
+// startWatching restarts the module whenever its watched files change if its config enables it,
+// until stopWatching is called.
+func (mgr *Manager) startWatching(mod *module) {
+	if !mod.watch {
+		return
+	}
+	watcher, matches, err := mod.newWatcher()
+	if err != nil {
+		mgr.logger.Warnw("cannot watch module for changes", "module", mod.name, "error", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mod.watchCancel = cancel
+	mod.watchWorkers.Add(1)
+	utils.ManagedGo(func() {
+		defer utils.UncheckedErrorFunc(watcher.Close)
+		changed := make(chan struct{}, 1)
+		debounced := debounce.New(watchDebounce)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				mgr.logger.Warnw("error while watching module for changes", "module", mod.name, "error", err)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !matches(watcher, event) {
+					continue
+				}
+				debounced(func() {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				})
+			case <-changed:
+				mgr.reload(ctx, mod)
+				mgr.mu.RLock()
+				removed := mgr.modules[mod.name] != mod
+				mgr.mu.RUnlock()
+				if removed {
+					return
+				}
+			}
+		}
+	}, mod.watchWorkers.Done)
+}
+
+// stopWatching stops watching the module for changes and waits for a restart in progress to
+// finish. It must not be called with the lock of the manager held.
+func (m *module) stopWatching() {
+	if m.watchCancel == nil {
+		return
+	}
+	m.watchCancel()
+	m.watchWorkers.Wait()
+	m.watchCancel = nil
+}
+
+// newWatcher returns a watcher of the files of the module, with a function telling which of its
+// events are changes to them.
+//
+// The directory of the executable is watched rather than the executable itself, since builds often
+// replace it with a new file, which would end a watch of the old one. A watch directory is watched
+// with all the directories under it, including those created later.
+func (m *module) newWatcher() (*fsnotify.Watcher, func(*fsnotify.Watcher, fsnotify.Event) bool, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.watchDir == "" {
+		exe, err := filepath.Abs(m.exe)
+		if err != nil {
+			utils.UncheckedError(watcher.Close())
+			return nil, nil, err
+		}
+		if err := watcher.Add(filepath.Dir(exe)); err != nil {
+			utils.UncheckedError(watcher.Close())
+			return nil, nil, err
+		}
+		return watcher, func(_ *fsnotify.Watcher, event fsnotify.Event) bool {
+			return filepath.Clean(event.Name) == exe && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+		}, nil
+	}
+
+	err = filepath.WalkDir(m.watchDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		return watcher.Add(path)
+	})
+	if err != nil {
+		utils.UncheckedError(watcher.Close())
+		return nil, nil, err
+	}
+	return watcher, func(watcher *fsnotify.Watcher, event fsnotify.Event) bool {
+		if event.Op == fsnotify.Chmod {
+			return false
+		}
+		if event.Op&fsnotify.Create != 0 {
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				utils.UncheckedError(watcher.Add(event.Name))
+			}
+		}
+		return true
+	}, nil
+}
+
+// reload restarts the module after its files changed, and registers the models its new executable
+// serves in place of those of the old one.
+func (mgr *Manager) reload(ctx context.Context, mod *module) {
+	if _, err := os.Stat(mod.exe); err != nil {
+		mgr.logger.Warnw("not restarting changed module whose executable is missing", "module", mod.name, "error", err)
+		return
+	}
+	mgr.logger.Infow("module changed, restarting it", "module", mod.name)
+	mgr.mu.RLock()
+	oldHandles := mod.handles
+	mgr.mu.RUnlock()
+	if !mgr.stopAndRestart(ctx, mod, "module changed") {
+		return
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.modules[mod.name] != mod {
+		// the module failed to restart and was removed along with its models
+		return
+	}
+	// the models are registered again rather than kept, as registrations cannot be replaced
+	for api, models := range oldHandles {
+		for _, model := range models {
+			resource.Deregister(api.API, model)
+		}
+	}
+	mod.registerResources(mgr, mgr.logger)
+}
@@ -18,9 +18,11 @@ type ModuleManager interface {
 	ReconfigureResource(ctx context.Context, conf resource.Config, deps []string) error
 	RemoveResource(ctx context.Context, name resource.Name) error
 	IsModularResource(name resource.Name) bool
+	ModuleName(name resource.Name) (string, bool)
 	ValidateConfig(ctx context.Context, cfg resource.Config) ([]string, error)
 
 	Provides(cfg resource.Config) bool
+	ModuleHealth(name resource.Name) (map[string]interface{}, bool)
 
 	Close(ctx context.Context) error
 }
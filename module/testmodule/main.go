@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/edaniels/golog"
@@ -19,6 +20,8 @@ import (
 var (
 	myModel = resource.NewModel("rdk", "test", "helper")
 	myMod   *module.Module
+	// hung makes the helper stop answering liveness probes.
+	hung atomic.Bool
 )
 
 func main() {
@@ -87,6 +90,26 @@ func (h *helper) DoCommand(ctx context.Context, req map[string]interface{}) (map
 		return map[string]interface{}{"ops": opsOut}, nil
 	case "echo":
 		return req, nil
+	case "get_process_info":
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		args := []interface{}{}
+		for _, arg := range os.Args[1:] {
+			args = append(args, arg)
+		}
+		envName, _ := req["env"].(string)
+		return map[string]interface{}{"args": args, "working_dir": wd, "env": os.Getenv(envName), "pid": os.Getpid()}, nil
+	case "hang":
+		hung.Store(true)
+		return map[string]interface{}{}, nil
+	case "liveness_probe":
+		if hung.Load() {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return map[string]interface{}{}, nil
 	case "kill_module":
 		os.Exit(1)
 		// unreachable return statement needed for compilation
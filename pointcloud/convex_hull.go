@@ -0,0 +1,193 @@
+package pointcloud
+
+import (
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// ErrDegenerateHull is returned when the points of a cloud all lie on a plane, a line or a point so
+// they do not have a convex hull with volume.
+var ErrDegenerateHull = errors.New("points of the cloud are coplanar and have no 3D convex hull")
+
+// HullFace is a triangular face of a convex hull, its vertices are counter clockwise when seen from
+// outside of the hull.
+type HullFace [3]r3.Vector
+
+// Normal returns the unit normal of the face, pointing out of the hull.
+func (f HullFace) Normal() r3.Vector {
+	return f[1].Sub(f[0]).Cross(f[2].Sub(f[0])).Normalize()
+}
+
+// hullFace is a face under construction, with its plane so that the side points are on can be found.
+type hullFace struct {
+	vertices [3]int
+	normal   r3.Vector
+	offset   float64
+}
+
+func newHullFace(points []r3.Vector, a, b, c int) *hullFace {
+	normal := points[b].Sub(points[a]).Cross(points[c].Sub(points[a])).Normalize()
+	return &hullFace{vertices: [3]int{a, b, c}, normal: normal, offset: normal.Dot(points[a])}
+}
+
+func (f *hullFace) distance(p r3.Vector) float64 {
+	return f.normal.Dot(p) - f.offset
+}
+
+type hullEdge struct {
+	from, to int
+}
+
+// ConvexHull returns the faces of the convex hull of the point cloud. It is built incrementally,
+// adding each point outside of the hull of the points before it by replacing the faces it sees with
+// faces joining it to their horizon. ErrDegenerateHull is returned for flat clouds.
+func ConvexHull(cloud PointCloud) ([]HullFace, error) {
+	points := make([]r3.Vector, 0, cloud.Size())
+	lo := r3.Vector{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)}
+	hi := r3.Vector{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)}
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		points = append(points, p)
+		lo = r3.Vector{X: math.Min(lo.X, p.X), Y: math.Min(lo.Y, p.Y), Z: math.Min(lo.Z, p.Z)}
+		hi = r3.Vector{X: math.Max(hi.X, p.X), Y: math.Max(hi.Y, p.Y), Z: math.Max(hi.Z, p.Z)}
+		return true
+	})
+	if len(points) < 4 {
+		return nil, ErrDegenerateHull
+	}
+	// points closer than this to the plane of a face are considered on it
+	eps := 1e-9 * math.Max(1, hi.Sub(lo).Norm())
+
+	mean := r3.Vector{}
+	for _, p := range points {
+		mean = mean.Add(p)
+	}
+	mean = mean.Mul(1 / float64(len(points)))
+
+	simplex, err := initialSimplex(points, mean, eps)
+	if err != nil {
+		return nil, err
+	}
+	a, b, c, d := simplex[0], simplex[1], simplex[2], simplex[3]
+	faces := []*hullFace{
+		newHullFace(points, a, b, c), newHullFace(points, a, c, d),
+		newHullFace(points, a, d, b), newHullFace(points, b, d, c),
+	}
+	// orient the faces outwards, away from the centroid of the simplex
+	centroid := points[a].Add(points[b]).Add(points[c]).Add(points[d]).Mul(0.25)
+	for i, f := range faces {
+		if f.distance(centroid) > 0 {
+			v := f.vertices
+			faces[i] = newHullFace(points, v[0], v[2], v[1])
+		}
+	}
+
+	// adding the points farthest from the mean first adds the corners of the hull before the points
+	// on its edges and faces, which would otherwise become vertices of it
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return points[order[i]].Sub(mean).Norm2() > points[order[j]].Sub(mean).Norm2()
+	})
+	for _, i := range order {
+		if i == a || i == b || i == c || i == d {
+			continue
+		}
+		p := points[i]
+		visible := map[hullEdge]struct{}{}
+		kept := faces[:0:0]
+		for _, f := range faces {
+			if f.distance(p) <= eps {
+				kept = append(kept, f)
+				continue
+			}
+			v := f.vertices
+			visible[hullEdge{v[0], v[1]}] = struct{}{}
+			visible[hullEdge{v[1], v[2]}] = struct{}{}
+			visible[hullEdge{v[2], v[0]}] = struct{}{}
+		}
+		if len(visible) == 0 {
+			continue
+		}
+		// the horizon is made of the edges of visible faces whose neighboring face is not visible
+		for e := range visible {
+			if _, ok := visible[hullEdge{e.to, e.from}]; ok {
+				continue
+			}
+			kept = append(kept, newHullFace(points, e.from, e.to, i))
+		}
+		faces = kept
+	}
+
+	hull := make([]HullFace, 0, len(faces))
+	for _, f := range faces {
+		hull = append(hull, HullFace{points[f.vertices[0]], points[f.vertices[1]], points[f.vertices[2]]})
+	}
+	return hull, nil
+}
+
+// initialSimplex returns the indices of four points which span a tetrahedron to start the hull from:
+// the extreme points along x, the point farthest from the line between them and the point farthest
+// from the plane of the three. Ties are broken by the distance from the mean of the points so that
+// the corners of the hull are picked rather than points on its edges or faces.
+func initialSimplex(points []r3.Vector, mean r3.Vector, eps float64) ([4]int, error) {
+	// farthest returns the point with the largest score, taking the point farthest from the mean
+	// among those within eps of it
+	farthest := func(score func(p r3.Vector) float64) (int, float64) {
+		best, bestScore := 0, math.Inf(-1)
+		for i, p := range points {
+			s := score(p)
+			if s > bestScore+eps || (s > bestScore-eps && p.Sub(mean).Norm2() > points[best].Sub(mean).Norm2()) {
+				best, bestScore = i, math.Max(s, bestScore)
+			}
+		}
+		return best, bestScore
+	}
+
+	var simplex [4]int
+	simplex[0], _ = farthest(func(p r3.Vector) float64 { return -p.X })
+	p0 := points[simplex[0]]
+	// the other extreme along x, or the point farthest from the first when all points share their x
+	simplex[1], _ = farthest(func(p r3.Vector) float64 { return p.X })
+	if points[simplex[1]].Sub(p0).Norm() <= eps {
+		simplex[1], _ = farthest(func(p r3.Vector) float64 { return p.Sub(p0).Norm() })
+	}
+	p1 := points[simplex[1]]
+	if p0.Sub(p1).Norm() <= eps {
+		return simplex, ErrDegenerateHull
+	}
+
+	line := p1.Sub(p0).Normalize()
+	var dist float64
+	simplex[2], dist = farthest(func(p r3.Vector) float64 { return p.Sub(p0).Cross(line).Norm() })
+	if dist <= eps {
+		return simplex, ErrDegenerateHull
+	}
+
+	normal := p1.Sub(p0).Cross(points[simplex[2]].Sub(p0)).Normalize()
+	simplex[3], dist = farthest(func(p r3.Vector) float64 { return math.Abs(normal.Dot(p.Sub(p0))) })
+	if dist <= eps {
+		return simplex, ErrDegenerateHull
+	}
+	return simplex, nil
+}
+
+// HullVertices returns the distinct vertices of the faces of a convex hull.
+func HullVertices(hull []HullFace) []r3.Vector {
+	seen := map[r3.Vector]struct{}{}
+	vertices := []r3.Vector{}
+	for _, f := range hull {
+		for _, v := range f {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+			seen[v] = struct{}{}
+			vertices = append(vertices, v)
+		}
+	}
+	return vertices
+}
@@ -0,0 +1,72 @@
+package pointcloud
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestConvexHull(t *testing.T) {
+	t.Run("cube", func(t *testing.T) {
+		cloud := New()
+		for x := 0.; x <= 10; x += 2 {
+			for y := 0.; y <= 10; y += 2 {
+				for z := 0.; z <= 10; z += 2 {
+					test.That(t, cloud.Set(r3.Vector{X: x, Y: y, Z: z}, nil), test.ShouldBeNil)
+				}
+			}
+		}
+		hull, err := ConvexHull(cloud)
+		test.That(t, err, test.ShouldBeNil)
+		vertices := HullVertices(hull)
+		test.That(t, vertices, test.ShouldHaveLength, 8)
+		for _, v := range vertices {
+			test.That(t, v.X == 0 || v.X == 10, test.ShouldBeTrue)
+			test.That(t, v.Y == 0 || v.Y == 10, test.ShouldBeTrue)
+			test.That(t, v.Z == 0 || v.Z == 10, test.ShouldBeTrue)
+		}
+		// every face of the cube is split in two triangles
+		test.That(t, hull, test.ShouldHaveLength, 12)
+		for _, f := range hull {
+			n := f.Normal()
+			// the normal points out of the cube
+			test.That(t, n.Dot(f[0].Sub(r3.Vector{X: 5, Y: 5, Z: 5})), test.ShouldBeGreaterThan, 0)
+		}
+	})
+
+	t.Run("random points", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(7))
+		cloud := New()
+		for i := 0; i < 500; i++ {
+			p := r3.Vector{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}.Mul(100)
+			test.That(t, cloud.Set(p, nil), test.ShouldBeNil)
+		}
+		hull, err := ConvexHull(cloud)
+		test.That(t, err, test.ShouldBeNil)
+		// a closed triangulated surface of genus 0 has V - E + F = 2
+		vertices := HullVertices(hull)
+		test.That(t, len(vertices)-3*len(hull)/2+len(hull), test.ShouldEqual, 2)
+		// all points are inside of the hull
+		cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			for _, f := range hull {
+				test.That(t, f.Normal().Dot(p.Sub(f[0])), test.ShouldBeLessThan, 1e-6)
+			}
+			return true
+		})
+	})
+
+	t.Run("degenerate", func(t *testing.T) {
+		flat := New()
+		for x := 0.; x <= 10; x++ {
+			for y := 0.; y <= 10; y++ {
+				test.That(t, flat.Set(r3.Vector{X: x, Y: y, Z: x + y}, nil), test.ShouldBeNil)
+			}
+		}
+		_, err := ConvexHull(flat)
+		test.That(t, err, test.ShouldEqual, ErrDegenerateHull)
+		_, err = ConvexHull(makeClouds(t)[0])
+		test.That(t, err, test.ShouldEqual, ErrDegenerateHull)
+	})
+}
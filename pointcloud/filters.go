@@ -0,0 +1,197 @@
+package pointcloud
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// RadiusOutlierFilter implements the function from PCL to remove points which have fewer than
+// minNeighbors other points within radius of them.
+// https://pcl.readthedocs.io/projects/tutorials/en/latest/remove_outliers.html
+// This returns a function that can be used to filter on point clouds.
+func RadiusOutlierFilter(radius float64, minNeighbors int) (func(PointCloud) (PointCloud, error), error) {
+	if radius <= 0 {
+		return nil, errors.Errorf("argument radius must be a positive float, got %.2f", radius)
+	}
+	if minNeighbors <= 0 {
+		return nil, errors.Errorf("argument minNeighbors must be a positive int, got %d", minNeighbors)
+	}
+	filterFunc := func(pc PointCloud) (PointCloud, error) {
+		kd := ToKDTree(pc)
+		filteredCloud := New()
+		var err error
+		kd.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			if len(kd.RadiusNearestNeighbors(p, radius, false)) < minNeighbors {
+				return true
+			}
+			err = filteredCloud.Set(p, d)
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return filteredCloud, nil
+	}
+	return filterFunc, nil
+}
+
+// EstimateNormals returns a copy of the point cloud where each point has the normal of the plane best
+// fitting its k nearest neighbors, itself included. Normals are flipped to face the viewpoint, which
+// is usually the origin of the frame of the sensor that captured the cloud. Points with fewer than
+// three neighbors are copied without a normal.
+func EstimateNormals(pc PointCloud, k int, viewpoint r3.Vector) (PointCloud, error) {
+	if k < 3 {
+		return nil, errors.Errorf("argument k must be at least 3 to fit a plane, got %d", k)
+	}
+	kd := ToKDTree(pc)
+	withNormals := New()
+	var err error
+	kd.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		data := copyData(d)
+		neighbors := kd.KNearestNeighbors(p, k, true)
+		if len(neighbors) >= 3 {
+			positions := make([]r3.Vector, 0, len(neighbors))
+			for _, n := range neighbors {
+				positions = append(positions, n.P)
+			}
+			normal := estimatePlaneNormalFromPoints(positions)
+			if normal.Dot(viewpoint.Sub(p)) < 0 {
+				normal = normal.Mul(-1)
+			}
+			data.SetNormal(normal)
+		}
+		err = withNormals.Set(p, data)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withNormals, nil
+}
+
+// VoxelDownsample returns a point cloud with one point for each cube of side voxelSize which holds
+// points of the given cloud. The point is at the centroid of the points in its voxel and has the data
+// of the point closest to the centroid.
+func VoxelDownsample(pc PointCloud, voxelSize float64) (PointCloud, error) {
+	if voxelSize <= 0 {
+		return nil, errors.Errorf("argument voxelSize must be a positive float, got %.2f", voxelSize)
+	}
+	type voxel struct {
+		points []PointAndData
+		sum    r3.Vector
+	}
+	voxels := map[VoxelCoords]*voxel{}
+	// keys keeps the order in which voxels are first seen so the output does not depend on map order
+	keys := []VoxelCoords{}
+	pc.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		key := VoxelCoords{
+			I: int64(math.Floor(p.X / voxelSize)),
+			J: int64(math.Floor(p.Y / voxelSize)),
+			K: int64(math.Floor(p.Z / voxelSize)),
+		}
+		v, ok := voxels[key]
+		if !ok {
+			v = &voxel{}
+			voxels[key] = v
+			keys = append(keys, key)
+		}
+		v.points = append(v.points, PointAndData{P: p, D: d})
+		v.sum = v.sum.Add(p)
+		return true
+	})
+
+	downsampled := NewWithPrealloc(len(keys))
+	for _, key := range keys {
+		v := voxels[key]
+		centroid := v.sum.Mul(1 / float64(len(v.points)))
+		closest := v.points[0]
+		for _, pd := range v.points[1:] {
+			if pd.P.Distance(centroid) < closest.P.Distance(centroid) {
+				closest = pd
+			}
+		}
+		if err := downsampled.Set(centroid, copyData(closest.D)); err != nil {
+			return nil, err
+		}
+	}
+	return downsampled, nil
+}
+
+// CropBox returns the points of the cloud within the axis aligned box between min and max, inclusive.
+func CropBox(pc PointCloud, min, max r3.Vector) (PointCloud, error) {
+	if min.X > max.X || min.Y > max.Y || min.Z > max.Z {
+		return nil, errors.Errorf("minimum corner %v of the crop box is above its maximum corner %v", min, max)
+	}
+	return filterPoints(pc, func(p r3.Vector) (bool, error) {
+		return p.X >= min.X && p.X <= max.X &&
+			p.Y >= min.Y && p.Y <= max.Y &&
+			p.Z >= min.Z && p.Z <= max.Z, nil
+	})
+}
+
+// CropByGeometry returns the points of the cloud inside of the geometry, which can be any geometry
+// points can be checked for collision against, such as a box, sphere or capsule.
+func CropByGeometry(pc PointCloud, geometry spatialmath.Geometry) (PointCloud, error) {
+	if geometry == nil {
+		return nil, errors.New("cannot crop a point cloud by a nil geometry")
+	}
+	return filterPoints(pc, func(p r3.Vector) (bool, error) {
+		return spatialmath.NewPoint(p, "").CollidesWith(geometry)
+	})
+}
+
+// filterPoints returns the points of the cloud for which keep returns true.
+func filterPoints(pc PointCloud, keep func(p r3.Vector) (bool, error)) (PointCloud, error) {
+	filtered := New()
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		var ok bool
+		ok, err = keep(p)
+		if err != nil || !ok {
+			return err == nil
+		}
+		err = filtered.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// copyData returns a copy of the data so that setting fields on it leaves the original untouched.
+func copyData(d Data) Data {
+	if d == nil {
+		return NewBasicData()
+	}
+	if bd, ok := d.(*basicData); ok {
+		c := *bd
+		return &c
+	}
+	c := &basicData{}
+	if d.HasColor() {
+		r, g, b := d.RGB255()
+		c.SetColor(color.NRGBA{r, g, b, 255})
+	}
+	if d.HasValue() {
+		c.SetValue(d.Value())
+	}
+	if d.HasIntensity() {
+		c.SetIntensity(d.Intensity())
+	}
+	if d.HasNormal() {
+		c.SetNormal(d.Normal())
+	}
+	if d.HasLabel() {
+		c.SetLabel(d.Label())
+	}
+	if d.HasTimestamp() {
+		c.SetTimestamp(d.Timestamp())
+	}
+	return c
+}
@@ -0,0 +1,118 @@
+package pointcloud
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+func TestRadiusOutlierFilter(t *testing.T) {
+	_, err := RadiusOutlierFilter(0, 2)
+	test.That(t, err, test.ShouldBeError, errors.New("argument radius must be a positive float, got 0.00"))
+	_, err = RadiusOutlierFilter(1.5, 0)
+	test.That(t, err, test.ShouldBeError, errors.New("argument minNeighbors must be a positive int, got 0"))
+
+	filter, err := RadiusOutlierFilter(2, 1)
+	test.That(t, err, test.ShouldBeNil)
+	filtered, err := filter(makePointCloud(t))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 7)
+	test.That(t, CloudContains(filtered, 0, 0, 0), test.ShouldBeTrue)
+	test.That(t, CloudContains(filtered, -3.2, -3.2, -3.2), test.ShouldBeTrue)
+	test.That(t, CloudContains(filtered, 2000, 2000, 2000), test.ShouldBeFalse)
+
+	// the points at the ends of the line only have one neighbor within the radius
+	filter, err = RadiusOutlierFilter(2, 2)
+	test.That(t, err, test.ShouldBeNil)
+	filtered, err = filter(makePointCloud(t))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 5)
+	test.That(t, CloudContains(filtered, 3, 3, 3), test.ShouldBeFalse)
+	test.That(t, CloudContains(filtered, -3.2, -3.2, -3.2), test.ShouldBeFalse)
+}
+
+func TestEstimateNormals(t *testing.T) {
+	_, err := EstimateNormals(New(), 2, r3.Vector{})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// a floor seen from above
+	cloud := New()
+	for x := -5.; x <= 5; x++ {
+		for y := -5.; y <= 5; y++ {
+			test.That(t, cloud.Set(r3.Vector{X: x, Y: y, Z: -10}, NewColoredData(color.NRGBA{255, 0, 0, 255})), test.ShouldBeNil)
+		}
+	}
+	withNormals, err := EstimateNormals(cloud, 8, r3.Vector{})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, withNormals.Size(), test.ShouldEqual, cloud.Size())
+	test.That(t, withNormals.MetaData().HasNormal, test.ShouldBeTrue)
+	withNormals.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		test.That(t, d.HasColor(), test.ShouldBeTrue)
+		test.That(t, d.Normal().X, test.ShouldAlmostEqual, 0)
+		test.That(t, d.Normal().Y, test.ShouldAlmostEqual, 0)
+		test.That(t, d.Normal().Z, test.ShouldAlmostEqual, 1)
+		return true
+	})
+	// the input is left untouched
+	test.That(t, cloud.MetaData().HasNormal, test.ShouldBeFalse)
+
+	// seen from below, the normals face down
+	withNormals, err = EstimateNormals(cloud, 8, r3.Vector{Z: -20})
+	test.That(t, err, test.ShouldBeNil)
+	d, ok := withNormals.At(0, 0, -10)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Normal().Z, test.ShouldAlmostEqual, -1)
+}
+
+func TestVoxelDownsample(t *testing.T) {
+	_, err := VoxelDownsample(New(), 0)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cloud := New()
+	test.That(t, cloud.Set(r3.Vector{X: 1, Y: 1, Z: 1}, NewValueData(1)), test.ShouldBeNil)
+	test.That(t, cloud.Set(r3.Vector{X: 3, Y: 1, Z: 1}, NewValueData(2)), test.ShouldBeNil)
+	test.That(t, cloud.Set(r3.Vector{X: 2.5, Y: 1, Z: 1}, NewValueData(3)), test.ShouldBeNil)
+	test.That(t, cloud.Set(r3.Vector{X: 12, Y: 1, Z: 1}, NewValueData(4)), test.ShouldBeNil)
+	test.That(t, cloud.Set(r3.Vector{X: -1, Y: 1, Z: 1}, NewValueData(5)), test.ShouldBeNil)
+
+	downsampled, err := VoxelDownsample(cloud, 10)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, downsampled.Size(), test.ShouldEqual, 3)
+	d, ok := downsampled.At(6.5/3, 1, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 3)
+	d, ok = downsampled.At(12, 1, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 4)
+	d, ok = downsampled.At(-1, 1, 1)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 5)
+}
+
+func TestCrop(t *testing.T) {
+	cloud := makePointCloud(t)
+
+	_, err := CropBox(cloud, r3.Vector{X: 1}, r3.Vector{})
+	test.That(t, err, test.ShouldNotBeNil)
+	cropped, err := CropBox(cloud, r3.Vector{X: -1.5, Y: -1.5, Z: -1.5}, r3.Vector{X: 2, Y: 2, Z: 2})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cropped.Size(), test.ShouldEqual, 4)
+	test.That(t, CloudContains(cropped, 2, 2, 2), test.ShouldBeTrue)
+	test.That(t, CloudContains(cropped, -2.2, -2.2, -2.2), test.ShouldBeFalse)
+
+	_, err = CropByGeometry(cloud, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	sphere, err := spatialmath.NewSphere(spatialmath.NewPoseFromPoint(r3.Vector{X: 2, Y: 2, Z: 2}), 2, "")
+	test.That(t, err, test.ShouldBeNil)
+	cropped, err = CropByGeometry(cloud, sphere)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cropped.Size(), test.ShouldEqual, 3)
+	test.That(t, CloudContains(cropped, 1, 1, 1), test.ShouldBeTrue)
+	test.That(t, CloudContains(cropped, 3, 3, 3), test.ShouldBeTrue)
+	test.That(t, CloudContains(cropped, 0, 0, 0), test.ShouldBeFalse)
+}
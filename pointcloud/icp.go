@@ -17,6 +17,8 @@ import (
 type IcpMergeResultInfo struct {
 	X0        []float64
 	OptResult optimize.Result
+	// Pose is the transform from the frame of the source pointcloud to that of the target.
+	Pose spatialmath.Pose
 }
 
 // RegisterPointCloudICP registers a source pointcloud to a target pointcloud, starting from an initial guess using ICP.
@@ -159,5 +161,5 @@ func RegisterPointCloudICP(pcSrc PointCloud, target *KDTree, guess spatialmath.P
 		return err == nil
 	})
 
-	return registeredPointCloud, IcpMergeResultInfo{X0: x0, OptResult: *res}, nil
+	return registeredPointCloud, IcpMergeResultInfo{X0: x0, OptResult: *res, Pose: pose}, nil
 }
@@ -25,6 +25,28 @@ func pointCloudFromArtifact(t *testing.T, artifactPath string) (PointCloud, erro
 	return pc, nil
 }
 
+func TestICPRegistrationOfCorner(t *testing.T) {
+	// the floor and two walls of a corner, which pin down all six degrees of freedom
+	target := NewKDTree()
+	source := New()
+	offset := spatialmath.NewPose(r3.Vector{X: 30, Y: -20, Z: 10}, &spatialmath.EulerAngles{Yaw: 0.05})
+	inverse := spatialmath.PoseInverse(offset)
+	for i := 0.; i <= 1000; i += 50 {
+		for j := 0.; j <= 1000; j += 50 {
+			for _, p := range []r3.Vector{{X: i, Y: j}, {X: 1000, Y: i, Z: j}, {X: i, Y: 1000, Z: j}} {
+				test.That(t, target.Set(p, nil), test.ShouldBeNil)
+				test.That(t, source.Set(spatialmath.Compose(inverse, spatialmath.NewPoseFromPoint(p)).Point(), nil), test.ShouldBeNil)
+			}
+		}
+	}
+
+	registered, info, err := RegisterPointCloudICP(source, target, spatialmath.NewZeroPose(), false, numThreadsPointCloud)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, registered.Size(), test.ShouldEqual, source.Size())
+	test.That(t, info.OptResult.F, test.ShouldBeLessThan, 1.)
+	test.That(t, spatialmath.PoseAlmostCoincidentEps(info.Pose, offset, 1), test.ShouldBeTrue)
+}
+
 func TestICPRegistration(t *testing.T) {
 	if os.Getenv("VIAM_DEBUG") == "" {
 		t.Skip("Test is too large for now.")
@@ -39,12 +39,14 @@ func (v treeComparableR3Vector) Dims() int {
 	return 3
 }
 
+// Distance returns the squared distance between the points, which is what the kd tree prunes its
+// searches by.
 func (v treeComparableR3Vector) Distance(c kdtree.Comparable) float64 {
 	v2, ok := c.(treeComparableR3Vector)
 	if !ok {
 		panic("treeComparableR3Vector Distance got wrong data")
 	}
-	return v.vec.Distance(v2.vec)
+	return v.vec.Sub(v2.vec).Norm2()
 }
 
 type kdValues []treeComparableR3Vector
@@ -182,7 +184,7 @@ func (kd *KDTree) NearestNeighbor(p r3.Vector) (r3.Vector, Data, float64, bool)
 	if !ok {
 		panic("Mismatch between tree and point storage.")
 	}
-	return p2.vec, d, dist, true
+	return p2.vec, d, math.Sqrt(dist), true
 }
 
 func keeperToArray(heap kdtree.Heap, points storage, p r3.Vector, includeSelf bool, max int) []*PointAndData {
@@ -227,7 +229,8 @@ func (kd *KDTree) KNearestNeighbors(p r3.Vector, k int, includeSelf bool) []*Poi
 // If includeSelf is true and if the point p is in the point cloud, point p will also be returned in the slice
 // as the first element with distance 0.
 func (kd *KDTree) RadiusNearestNeighbors(p r3.Vector, r float64, includeSelf bool) []*PointAndData {
-	keep := kdtree.NewDistKeeper(r)
+	// the tree keeps squared distances, round the square of r up so points at r stay included
+	keep := kdtree.NewDistKeeper(math.Nextafter(r*r, math.Inf(1)))
 	kd.tree.NearestSet(keep, &treeComparableR3Vector{p})
 	return keeperToArray(keep.Heap, kd.points, p, includeSelf, math.MaxInt)
 }
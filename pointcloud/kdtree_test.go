@@ -3,6 +3,8 @@ package pointcloud
 import (
 	"errors"
 	"math"
+	"math/rand"
+	"sort"
 	"testing"
 
 	"github.com/golang/geo/r3"
@@ -48,6 +50,17 @@ func TestNearestNeighor(t *testing.T) {
 	nn, _, dist, _ = kd.NearestNeighbor(testPt)
 	test.That(t, nn, test.ShouldResemble, r3.Vector{0, 0, 0})
 	test.That(t, dist, test.ShouldEqual, 0.5)
+
+	// the nearest neighbor of points far from the origin is found in a tree grown by Set
+	grid := NewKDTree()
+	for i := 0.; i <= 1000; i += 100 {
+		for j := 0.; j <= 1000; j += 100 {
+			test.That(t, grid.Set(r3.Vector{i, j, 0}, nil), test.ShouldBeNil)
+		}
+	}
+	nn, _, dist, _ = grid.NearestNeighbor(r3.Vector{740, 260, 30})
+	test.That(t, nn, test.ShouldResemble, r3.Vector{700, 300, 0})
+	test.That(t, dist, test.ShouldAlmostEqual, math.Sqrt(40*40+40*40+30*30))
 }
 
 func TestKNearestNeighor(t *testing.T) {
@@ -147,6 +160,53 @@ func TestRadiusNearestNeighor(t *testing.T) {
 	test.That(t, nns, test.ShouldHaveLength, 0)
 }
 
+func TestNeighborsMatchBruteForce(t *testing.T) {
+	// trees grown by Set and built at once are searched with different splits, both must prune by the
+	// same distances they report
+	rnd := rand.New(rand.NewSource(1))
+	points := make([]r3.Vector, 0, 500)
+	grown := NewKDTree()
+	for i := 0; i < 500; i++ {
+		p := r3.Vector{X: rnd.Float64() * 1000, Y: rnd.Float64() * 1000, Z: rnd.Float64() * 100}
+		points = append(points, p)
+		test.That(t, grown.Set(p, nil), test.ShouldBeNil)
+	}
+	cloud := New()
+	for _, p := range points {
+		test.That(t, cloud.Set(p, nil), test.ShouldBeNil)
+	}
+	built := ToKDTree(cloud)
+
+	for i := 0; i < 100; i++ {
+		query := r3.Vector{X: rnd.Float64()*1200 - 100, Y: rnd.Float64()*1200 - 100, Z: rnd.Float64()*300 - 100}
+		byDistance := append([]r3.Vector{}, points...)
+		sort.Slice(byDistance, func(i, j int) bool {
+			return query.Distance(byDistance[i]) < query.Distance(byDistance[j])
+		})
+		// a radius reaching exactly the fifth nearest point includes it
+		radius := query.Distance(byDistance[4])
+
+		for _, kd := range []*KDTree{grown, built} {
+			nn, _, dist, ok := kd.NearestNeighbor(query)
+			test.That(t, ok, test.ShouldBeTrue)
+			test.That(t, nn, test.ShouldResemble, byDistance[0])
+			test.That(t, dist, test.ShouldAlmostEqual, query.Distance(byDistance[0]))
+
+			nns := kd.KNearestNeighbors(query, 5, true)
+			test.That(t, nns, test.ShouldHaveLength, 5)
+			for j, n := range nns {
+				test.That(t, n.P, test.ShouldResemble, byDistance[j])
+			}
+
+			nns = kd.RadiusNearestNeighbors(query, radius, true)
+			test.That(t, nns, test.ShouldHaveLength, 5)
+			for _, n := range nns {
+				test.That(t, query.Distance(n.P), test.ShouldBeLessThanOrEqualTo, radius)
+			}
+		}
+	}
+}
+
 func TestNewEmptyKDtree(t *testing.T) {
 	pt0 := r3.Vector{0, 0, 0}
 	pt1 := r3.Vector{0, 0, 1}
@@ -0,0 +1,124 @@
+package pointcloud
+
+import (
+	"github.com/pkg/errors"
+)
+
+// LZF is the compression used by binary_compressed PCD files. A compressed stream is a sequence of
+// chunks each starting with a control byte: a control byte below 32 is followed by that many plus one
+// literal bytes, otherwise its top three bits are the length of a back reference, extended by the next
+// byte if they are all set, and its bottom five bits with the following byte are the offset of the
+// back reference.
+const (
+	lzfMaxLiteral = 1 << 5
+	lzfMaxOffset  = 1 << 13
+	lzfMaxRef     = (1 << 8) + (1 << 3)
+	lzfHashLog    = 14
+	// lzfMaxExpansion is the most a compressed stream can grow by when decompressed, reached by a
+	// stream of the longest back references, which take three bytes each.
+	lzfMaxExpansion = lzfMaxRef / 3
+)
+
+// lzfCompress compresses in using LZF.
+func lzfCompress(in []byte) []byte {
+	out := make([]byte, 0, len(in)+len(in)/lzfMaxLiteral+1)
+	// positions of the last occurrence of every hashed three bytes, offset by one so zero is unset
+	var table [1 << lzfHashLog]int
+
+	writeLiterals := func(literals []byte) {
+		for len(literals) > 0 {
+			n := len(literals)
+			if n > lzfMaxLiteral {
+				n = lzfMaxLiteral
+			}
+			out = append(out, byte(n-1))
+			out = append(out, literals[:n]...)
+			literals = literals[n:]
+		}
+	}
+
+	literalStart := 0
+	i := 0
+	for i+2 < len(in) {
+		h := ((uint32(in[i]) << 16) | (uint32(in[i+1]) << 8) | uint32(in[i+2])) * 2654435761 >> (32 - lzfHashLog)
+		ref := table[h] - 1
+		table[h] = i + 1
+		off := i - ref - 1
+		if ref < 0 || off >= lzfMaxOffset || in[ref] != in[i] || in[ref+1] != in[i+1] || in[ref+2] != in[i+2] {
+			i++
+			continue
+		}
+
+		maxLen := len(in) - i
+		if maxLen > lzfMaxRef {
+			maxLen = lzfMaxRef
+		}
+		length := 3
+		for length < maxLen && in[ref+length] == in[i+length] {
+			length++
+		}
+
+		writeLiterals(in[literalStart:i])
+		n := length - 2
+		if n < 7 {
+			out = append(out, byte(n<<5|off>>8))
+		} else {
+			out = append(out, byte(7<<5|off>>8), byte(n-7))
+		}
+		out = append(out, byte(off))
+		i += length
+		literalStart = i
+	}
+	writeLiterals(in[literalStart:])
+	return out
+}
+
+// lzfDecompress decompresses in, which must decompress to exactly outLen bytes.
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < lzfMaxLiteral {
+			n := ctrl + 1
+			if i+n > len(in) {
+				return nil, errors.New("lzf literal run is past the end of the compressed data")
+			}
+			if len(out)+n > outLen {
+				return nil, errors.Errorf("lzf data decompresses to more than %d bytes", outLen)
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return nil, errors.New("lzf back reference is past the end of the compressed data")
+			}
+			n += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errors.New("lzf back reference is past the end of the compressed data")
+		}
+		ref := len(out) - ((ctrl & 0x1f) << 8) - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, errors.New("lzf back reference is before the start of the data")
+		}
+		n += 2
+		if len(out)+n > outLen {
+			return nil, errors.Errorf("lzf data decompresses to more than %d bytes", outLen)
+		}
+		// the reference may overlap the bytes being written so it is copied one byte at a time
+		for j := 0; j < n; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != outLen {
+		return nil, errors.Errorf("lzf data decompressed to %d bytes, expected %d", len(out), outLen)
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package pointcloud
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestLZFDecompress(t *testing.T) {
+	// a literal run of "abc" followed by a back reference of length 3 to its start
+	out, err := lzfDecompress([]byte{2, 'a', 'b', 'c', 1 << 5, 2}, 6)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(out), test.ShouldEqual, "abcabc")
+
+	_, err = lzfDecompress([]byte{2, 'a', 'b', 'c', 1 << 5, 2}, 5)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = lzfDecompress([]byte{2, 'a', 'b', 'c', 1 << 5, 2}, 7)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = lzfDecompress([]byte{2, 'a', 'b'}, 3)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = lzfDecompress([]byte{2, 'a', 'b', 'c', 1 << 5, 5}, 6)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestLZFRoundTrip(t *testing.T) {
+	random := make([]byte, 10000)
+	rand.New(rand.NewSource(1)).Read(random)
+	for _, in := range [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("ab"),
+		bytes.Repeat([]byte("a"), 1000),
+		bytes.Repeat([]byte("point cloud "), 1000),
+		random,
+	} {
+		compressed := lzfCompress(in)
+		out, err := lzfDecompress(compressed, len(in))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, out, test.ShouldResemble, in)
+	}
+	test.That(t, len(lzfCompress(bytes.Repeat([]byte("a"), 1000))), test.ShouldBeLessThan, 20)
+}
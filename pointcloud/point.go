@@ -62,11 +62,43 @@ type Data interface {
 	// Note(erd): we should try to remove this in favor of immutability.
 	SetValue(v int) Data
 
+	// HasIntensity returns whether or not this point has an intensity.
+	HasIntensity() bool
+
 	// Intensity returns the intensity value, or 0 if it doesn't exist
 	Intensity() uint16
 
 	// SetIntensity sets the intensity on the point.
 	SetIntensity(v uint16) Data
+
+	// HasNormal returns whether or not this point has a surface normal.
+	HasNormal() bool
+
+	// Normal returns the surface normal of the point, if it exists.
+	Normal() r3.Vector
+
+	// SetNormal sets the surface normal of the point.
+	SetNormal(n r3.Vector) Data
+
+	// HasLabel returns whether or not this point has a label, such as the id of the segment or class
+	// it belongs to.
+	HasLabel() bool
+
+	// Label returns the label of the point, if it exists.
+	Label() uint32
+
+	// SetLabel sets the label of the point.
+	SetLabel(l uint32) Data
+
+	// HasTimestamp returns whether or not this point has a timestamp.
+	HasTimestamp() bool
+
+	// Timestamp returns the time the point was captured in seconds, if it exists. What the time is
+	// relative to, e.g. the Unix epoch or the start of a scan, depends on the source of the point.
+	Timestamp() float64
+
+	// SetTimestamp sets the time the point was captured in seconds.
+	SetTimestamp(t float64) Data
 }
 
 type basicData struct {
@@ -76,7 +108,17 @@ type basicData struct {
 	hasValue bool
 	value    int
 
-	intensity uint16
+	hasIntensity bool
+	intensity    uint16
+
+	hasNormal bool
+	normal    r3.Vector
+
+	hasLabel bool
+	label    uint32
+
+	hasTimestamp bool
+	timestamp    float64
 }
 
 // NewBasicData returns a point that is solely positionally based.
@@ -127,10 +169,57 @@ func (bp *basicData) Value() int {
 }
 
 func (bp *basicData) SetIntensity(v uint16) Data {
+	bp.hasIntensity = true
 	bp.intensity = v
 	return bp
 }
 
+func (bp *basicData) HasIntensity() bool {
+	return bp.hasIntensity
+}
+
 func (bp *basicData) Intensity() uint16 {
 	return bp.intensity
 }
+
+func (bp *basicData) HasNormal() bool {
+	return bp.hasNormal
+}
+
+func (bp *basicData) Normal() r3.Vector {
+	return bp.normal
+}
+
+func (bp *basicData) SetNormal(n r3.Vector) Data {
+	bp.hasNormal = true
+	bp.normal = n
+	return bp
+}
+
+func (bp *basicData) HasLabel() bool {
+	return bp.hasLabel
+}
+
+func (bp *basicData) Label() uint32 {
+	return bp.label
+}
+
+func (bp *basicData) SetLabel(l uint32) Data {
+	bp.hasLabel = true
+	bp.label = l
+	return bp
+}
+
+func (bp *basicData) HasTimestamp() bool {
+	return bp.hasTimestamp
+}
+
+func (bp *basicData) Timestamp() float64 {
+	return bp.timestamp
+}
+
+func (bp *basicData) SetTimestamp(t float64) Data {
+	bp.hasTimestamp = true
+	bp.timestamp = t
+	return bp
+}
@@ -18,8 +18,12 @@ const numThreadsPointCloud = 8
 
 // MetaData is data about what's stored in the point cloud.
 type MetaData struct {
-	HasColor bool
-	HasValue bool
+	HasColor     bool
+	HasValue     bool
+	HasIntensity bool
+	HasNormal    bool
+	HasLabel     bool
+	HasTimestamp bool
 
 	MinX, MaxX             float64
 	MinY, MaxY             float64
@@ -91,6 +95,18 @@ func (meta *MetaData) Merge(v r3.Vector, data Data) {
 		if data.HasValue() {
 			meta.HasValue = true
 		}
+		if data.HasIntensity() {
+			meta.HasIntensity = true
+		}
+		if data.HasNormal() {
+			meta.HasNormal = true
+		}
+		if data.HasLabel() {
+			meta.HasLabel = true
+		}
+		if data.HasTimestamp() {
+			meta.HasTimestamp = true
+		}
 	}
 
 	if v.X > meta.MaxX {
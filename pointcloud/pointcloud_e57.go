@@ -0,0 +1,722 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"image/color"
+	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/num/quat"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// An E57 file is split in pages whose last four bytes are a CRC-32C checksum of the rest of the page.
+// Offsets into the file are either physical, counting the checksums, or logical, skipping them. The
+// file starts with a header locating an XML section which describes the scans in the file, the points
+// of every scan are stored in a binary section of data packets with one bytestream per field.
+const (
+	e57PageSize          = 1024
+	e57HeaderSize        = 48
+	e57SectionHeaderSize = 32
+	e57MaxPacketSize     = 1 << 16
+
+	e57CompressedVectorSection = 1
+	e57IndexPacket             = 0
+	e57DataPacket              = 1
+	e57EmptyPacket             = 2
+
+	e57Namespace        = "http://www.astm.org/COMMIT/E57/2010-e57-v1.0"
+	e57NormalsNamespace = "http://www.libe57.org/E57_NOR_surface_normals.txt"
+)
+
+var (
+	e57Signature = []byte("ASTM-E57")
+	e57CRCTable  = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// e57Node is an element of the XML section of an E57 file.
+type e57Node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []*e57Node `xml:",any"`
+}
+
+func (n *e57Node) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (n *e57Node) child(name string) *e57Node {
+	if n == nil {
+		return nil
+	}
+	for _, child := range n.Children {
+		if child.XMLName.Local == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// float returns the value of the named child, or def if there is no such child.
+func (n *e57Node) float(name string, def float64) (float64, error) {
+	child := n.child(name)
+	if child == nil || strings.TrimSpace(child.Content) == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(child.Content), 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid e57 value %q for %s", child.Content, name)
+	}
+	return v, nil
+}
+
+// floatAttr returns the value of the named attribute, or def if there is no such attribute.
+func (n *e57Node) floatAttr(name string, def float64) (float64, error) {
+	value := n.attr(name)
+	if value == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid e57 attribute %s=%q of %s", name, value, n.XMLName.Local)
+	}
+	return v, nil
+}
+
+// e57File is the logical content of an E57 file.
+type e57File struct {
+	data     []byte
+	pageSize int
+}
+
+func (f *e57File) logicalOffset(physical uint64) (int, error) {
+	page, offset := physical/uint64(f.pageSize), physical%uint64(f.pageSize)
+	if offset >= uint64(f.pageSize-4) {
+		return 0, errors.Errorf("e57 offset %d is inside a page checksum", physical)
+	}
+	logical := page*uint64(f.pageSize-4) + offset
+	if logical >= uint64(len(f.data)) {
+		return 0, errors.Errorf("e57 offset %d is past the end of the file", physical)
+	}
+	return int(logical), nil
+}
+
+func (f *e57File) bytes(offset, length int) ([]byte, error) {
+	if offset < 0 || length < 0 || offset+length > len(f.data) {
+		return nil, errors.New("e57 data is past the end of the file")
+	}
+	return f.data[offset : offset+length], nil
+}
+
+// e57Field is a field of the records of a compressed vector.
+type e57Field struct {
+	name string
+	// size is the number of bits of a value in the bytestream of the field.
+	size  int
+	float bool
+	// min and max are the limits of the values of the field, rawMin is the minimum of an integer field
+	// before it is scaled.
+	min, max      float64
+	rawMin        float64
+	scale, offset float64
+}
+
+func newE57Field(node *e57Node) (*e57Field, error) {
+	field := &e57Field{name: node.XMLName.Local, scale: 1}
+	var err error
+	switch node.attr("type") {
+	case "Float":
+		field.float = true
+		field.size = 64
+		if node.attr("precision") == "single" {
+			field.size = 32
+		}
+		if field.min, err = node.floatAttr("minimum", math.Inf(-1)); err != nil {
+			return nil, err
+		}
+		if field.max, err = node.floatAttr("maximum", math.Inf(1)); err != nil {
+			return nil, err
+		}
+		return field, nil
+	case "Integer", "ScaledInteger":
+		minimum, maximum := int64(math.MinInt64), int64(math.MaxInt64)
+		if value := node.attr("minimum"); value != "" {
+			if minimum, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, errors.Errorf("invalid e57 minimum %q of %s", value, field.name)
+			}
+		}
+		if value := node.attr("maximum"); value != "" {
+			if maximum, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, errors.Errorf("invalid e57 maximum %q of %s", value, field.name)
+			}
+		}
+		if maximum < minimum {
+			return nil, errors.Errorf("e57 maximum of %s is less than its minimum", field.name)
+		}
+		field.size = bits.Len64(uint64(maximum - minimum))
+		if node.attr("type") == "ScaledInteger" {
+			if field.scale, err = node.floatAttr("scale", 1); err != nil {
+				return nil, err
+			}
+			if field.offset, err = node.floatAttr("offset", 0); err != nil {
+				return nil, err
+			}
+		}
+		field.rawMin = float64(minimum)
+		field.min = float64(minimum)*field.scale + field.offset
+		field.max = float64(maximum)*field.scale + field.offset
+		if field.scale < 0 {
+			field.min, field.max = field.max, field.min
+		}
+		return field, nil
+	default:
+		return nil, errors.Errorf("unsupported e57 field %s of type %q", field.name, node.attr("type"))
+	}
+}
+
+// decode returns the count values of the field packed in stream.
+func (field *e57Field) decode(stream []byte, count int) ([]float64, error) {
+	if len(stream)*8 < count*field.size {
+		return nil, errors.Errorf("e57 bytestream of %s is too short for %d records", field.name, count)
+	}
+	values := make([]float64, count)
+	if field.float {
+		for i := range values {
+			if field.size == 32 {
+				values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(stream[4*i:])))
+			} else {
+				values[i] = math.Float64frombits(binary.LittleEndian.Uint64(stream[8*i:]))
+			}
+		}
+		return values, nil
+	}
+
+	// integers are the difference to the minimum packed in as few bits as possible, least significant
+	// bits first
+	for i := range values {
+		var raw uint64
+		bitPos := i * field.size
+		for got := 0; got < field.size; {
+			take := 8 - bitPos%8
+			if take > field.size-got {
+				take = field.size - got
+			}
+			raw |= uint64(stream[bitPos/8]>>(bitPos%8)&(1<<take-1)) << got
+			got += take
+			bitPos += take
+		}
+		values[i] = (field.rawMin+float64(raw))*field.scale + field.offset
+	}
+	return values, nil
+}
+
+// ReadE57 reads every scan of an E57 file into a single pointcloud, each in the coordinates of the file
+// according to the pose of the scan. The cartesian or spherical positions of the points are read along
+// with their colors, intensities and the surface normals of the libE57 extension.
+func ReadE57(inRaw io.Reader) (PointCloud, error) {
+	raw, err := io.ReadAll(inRaw)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < e57HeaderSize || !bytes.Equal(raw[:len(e57Signature)], e57Signature) {
+		return nil, errors.New("not an e57 file")
+	}
+	if major := binary.LittleEndian.Uint32(raw[8:]); major != 1 {
+		return nil, errors.Errorf("unsupported e57 version %d", major)
+	}
+	xmlPhysicalOffset := binary.LittleEndian.Uint64(raw[24:])
+	xmlLength := binary.LittleEndian.Uint64(raw[32:])
+	pageSize := int(binary.LittleEndian.Uint64(raw[40:]))
+	if pageSize <= 4 || len(raw)%pageSize != 0 {
+		return nil, errors.Errorf("e57 file length %d is not a multiple of its page size %d", len(raw), pageSize)
+	}
+
+	f := &e57File{data: make([]byte, 0, len(raw)/pageSize*(pageSize-4)), pageSize: pageSize}
+	for start := 0; start < len(raw); start += pageSize {
+		page := raw[start : start+pageSize-4]
+		sum := crc32.Checksum(page, e57CRCTable)
+		stored := raw[start+pageSize-4 : start+pageSize]
+		// the checksum should be big endian but some writers store it little endian
+		if binary.BigEndian.Uint32(stored) != sum && binary.LittleEndian.Uint32(stored) != sum {
+			return nil, errors.Errorf("e57 checksum mismatch on page %d", start/pageSize)
+		}
+		f.data = append(f.data, page...)
+	}
+
+	xmlOffset, err := f.logicalOffset(xmlPhysicalOffset)
+	if err != nil {
+		return nil, err
+	}
+	xmlData, err := f.bytes(xmlOffset, int(xmlLength))
+	if err != nil {
+		return nil, err
+	}
+	var root e57Node
+	if err := xml.Unmarshal(xmlData, &root); err != nil {
+		return nil, errors.Wrap(err, "error parsing e57 xml")
+	}
+
+	pc := New()
+	data3D := root.child("data3D")
+	if data3D == nil {
+		return pc, nil
+	}
+	for i, scan := range data3D.Children {
+		if err := readE57Scan(f, scan, pc); err != nil {
+			return nil, errors.Wrapf(err, "error reading e57 scan %d", i)
+		}
+	}
+	return pc, nil
+}
+
+// e57Limits returns the minimum and maximum of a field from the named limits of the scan, or from the
+// field itself if the scan does not have them. The limits of a float field default to [0, 1].
+func e57Limits(scan *e57Node, limits, minName, maxName string, field *e57Field) (float64, float64, error) {
+	minimum, maximum := field.min, field.max
+	if math.IsInf(minimum, 0) || math.IsInf(maximum, 0) {
+		minimum, maximum = 0, 1
+	}
+	node := scan.child(limits)
+	if node == nil {
+		return minimum, maximum, nil
+	}
+	minimum, err := node.float(minName, minimum)
+	if err != nil {
+		return 0, 0, err
+	}
+	maximum, err = node.float(maxName, maximum)
+	if err != nil {
+		return 0, 0, err
+	}
+	return minimum, maximum, nil
+}
+
+// e57Scale maps v from [minimum, maximum] to [0, max].
+func e57Scale(v, minimum, maximum, max float64) float64 {
+	if maximum <= minimum {
+		return 0
+	}
+	return math.Max(0, math.Min(max, math.Round((v-minimum)/(maximum-minimum)*max)))
+}
+
+func readE57Scan(f *e57File, scan *e57Node, pc PointCloud) error {
+	points := scan.child("points")
+	if points == nil {
+		return nil
+	}
+	if points.attr("type") != "CompressedVector" {
+		return errors.Errorf("unsupported e57 points of type %q", points.attr("type"))
+	}
+	fileOffset, err := strconv.ParseUint(points.attr("fileOffset"), 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid e57 fileOffset %q", points.attr("fileOffset"))
+	}
+	recordCount, err := strconv.Atoi(points.attr("recordCount"))
+	if err != nil || recordCount < 0 {
+		return errors.Errorf("invalid e57 recordCount %q", points.attr("recordCount"))
+	}
+	prototype := points.child("prototype")
+	if prototype == nil {
+		return errors.New("e57 points have no prototype")
+	}
+	fields := make([]*e57Field, len(prototype.Children))
+	for i, node := range prototype.Children {
+		if fields[i], err = newE57Field(node); err != nil {
+			return err
+		}
+	}
+
+	streams, err := readE57Streams(f, fileOffset, len(fields))
+	if err != nil {
+		return err
+	}
+	columns := map[string][]float64{}
+	fieldsByName := map[string]*e57Field{}
+	for i, field := range fields {
+		values, err := field.decode(streams[i], recordCount)
+		if err != nil {
+			return err
+		}
+		columns[field.name] = values
+		fieldsByName[field.name] = field
+	}
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := columns[name]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	var xs, ys, zs []float64
+	invalid := columns["cartesianInvalidState"]
+	switch {
+	case has("cartesianX", "cartesianY", "cartesianZ"):
+		xs, ys, zs = columns["cartesianX"], columns["cartesianY"], columns["cartesianZ"]
+	case has("sphericalRange", "sphericalAzimuth", "sphericalElevation"):
+		invalid = columns["sphericalInvalidState"]
+		xs, ys, zs = make([]float64, recordCount), make([]float64, recordCount), make([]float64, recordCount)
+		for i := range xs {
+			r, az, el := columns["sphericalRange"][i], columns["sphericalAzimuth"][i], columns["sphericalElevation"][i]
+			xs[i] = r * math.Cos(el) * math.Cos(az)
+			ys[i] = r * math.Cos(el) * math.Sin(az)
+			zs[i] = r * math.Sin(el)
+		}
+	default:
+		return errors.New("e57 points have neither cartesian nor spherical coordinates")
+	}
+
+	pose := spatialmath.NewZeroPose()
+	if node := scan.child("pose"); node != nil {
+		var values [7]float64
+		defaults := [7]float64{1, 0, 0, 0, 0, 0, 0}
+		for i, name := range []string{"w", "x", "y", "z"} {
+			if values[i], err = node.child("rotation").float(name, defaults[i]); err != nil {
+				return err
+			}
+		}
+		for i, name := range []string{"x", "y", "z"} {
+			if values[4+i], err = node.child("translation").float(name, 0); err != nil {
+				return err
+			}
+		}
+		pose = spatialmath.NewPose(
+			r3.Vector{X: values[4], Y: values[5], Z: values[6]},
+			spatialmath.QuatToOV(quat.Number{Real: values[0], Imag: values[1], Jmag: values[2], Kmag: values[3]}),
+		)
+	}
+	rotation := spatialmath.NewPoseFromOrientation(pose.Orientation())
+
+	hasColor := has("colorRed", "colorGreen", "colorBlue")
+	var colorLimits [3][2]float64
+	if hasColor {
+		for i, channel := range []string{"Red", "Green", "Blue"} {
+			colorLimits[i][0], colorLimits[i][1], err = e57Limits(scan, "colorLimits",
+				"color"+channel+"Minimum", "color"+channel+"Maximum", fieldsByName["color"+channel])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	hasIntensity := has("intensity")
+	var intensityLimits [2]float64
+	if hasIntensity {
+		intensityLimits[0], intensityLimits[1], err = e57Limits(scan, "intensityLimits",
+			"intensityMinimum", "intensityMaximum", fieldsByName["intensity"])
+		if err != nil {
+			return err
+		}
+	}
+	hasNormal := has("normalX", "normalY", "normalZ")
+
+	for i := 0; i < recordCount; i++ {
+		if invalid != nil && invalid[i] != 0 {
+			continue
+		}
+		p := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(r3.Vector{X: xs[i], Y: ys[i], Z: zs[i]})).Point()
+		d := NewBasicData()
+		if hasColor {
+			var c [3]uint8
+			for j, channel := range []string{"colorRed", "colorGreen", "colorBlue"} {
+				c[j] = uint8(e57Scale(columns[channel][i], colorLimits[j][0], colorLimits[j][1], 255))
+			}
+			d.SetColor(color.NRGBA{c[0], c[1], c[2], 255})
+		}
+		if hasIntensity {
+			d.SetIntensity(uint16(e57Scale(columns["intensity"][i], intensityLimits[0], intensityLimits[1], math.MaxUint16)))
+		}
+		if hasNormal {
+			n := r3.Vector{X: columns["normalX"][i], Y: columns["normalY"][i], Z: columns["normalZ"][i]}
+			d.SetNormal(spatialmath.Compose(rotation, spatialmath.NewPoseFromPoint(n)).Point())
+		}
+		// Converts E57 units (meters) to millimeters for RDK
+		if err := pc.Set(p.Mul(1000), d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readE57Streams returns the bytestreams of every field of the compressed vector section at offset.
+func readE57Streams(f *e57File, offset uint64, numFields int) ([][]byte, error) {
+	start, err := f.logicalOffset(offset)
+	if err != nil {
+		return nil, err
+	}
+	header, err := f.bytes(start, e57SectionHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != e57CompressedVectorSection {
+		return nil, errors.Errorf("expected an e57 compressed vector section, got section %d", header[0])
+	}
+	end := start + int(binary.LittleEndian.Uint64(header[8:]))
+	streams := make([][]byte, numFields)
+	if binary.LittleEndian.Uint64(header[16:]) == 0 {
+		// a section with no data packets
+		return streams, nil
+	}
+	pos, err := f.logicalOffset(binary.LittleEndian.Uint64(header[16:]))
+	if err != nil {
+		return nil, err
+	}
+
+	for pos < end {
+		packetHeader, err := f.bytes(pos, 4)
+		if err != nil {
+			return nil, err
+		}
+		length := int(binary.LittleEndian.Uint16(packetHeader[2:])) + 1
+		packet, err := f.bytes(pos, length)
+		if err != nil {
+			return nil, err
+		}
+		switch packet[0] {
+		case e57DataPacket:
+			if length < 6 {
+				return nil, errors.New("e57 data packet is too short")
+			}
+			count := int(binary.LittleEndian.Uint16(packet[4:]))
+			if count != numFields {
+				return nil, errors.Errorf("e57 data packet has %d bytestreams but there are %d fields", count, numFields)
+			}
+			bufStart := 6 + 2*count
+			if bufStart > length {
+				return nil, errors.New("e57 data packet is too short")
+			}
+			for i := range streams {
+				bufLen := int(binary.LittleEndian.Uint16(packet[6+2*i:]))
+				if bufStart+bufLen > length {
+					return nil, errors.New("e57 bytestream is past the end of its data packet")
+				}
+				streams[i] = append(streams[i], packet[bufStart:bufStart+bufLen]...)
+				bufStart += bufLen
+			}
+		case e57IndexPacket, e57EmptyPacket:
+		default:
+			return nil, errors.Errorf("unknown e57 packet type %d", packet[0])
+		}
+		pos += length
+	}
+	return streams, nil
+}
+
+// e57Column is a field written by ToE57 along with the little endian bytes of its values.
+type e57Column struct {
+	name      string
+	prototype string
+	size      int
+	data      []byte
+}
+
+// ToE57 writes out a point cloud to an E57 file as a single scan. Positions are written in meters along
+// with the colors, intensities and surface normals of the points when the cloud has them.
+func ToE57(cloud PointCloud, out io.Writer) error {
+	meta := cloud.MetaData()
+	size := cloud.Size()
+
+	columns := []*e57Column{
+		{name: "cartesianX", prototype: `<cartesianX type="Float"/>`, size: 8},
+		{name: "cartesianY", prototype: `<cartesianY type="Float"/>`, size: 8},
+		{name: "cartesianZ", prototype: `<cartesianZ type="Float"/>`, size: 8},
+	}
+	if meta.HasColor {
+		for _, name := range []string{"colorRed", "colorGreen", "colorBlue"} {
+			columns = append(columns, &e57Column{
+				name: name, prototype: fmt.Sprintf(`<%s type="Integer" minimum="0" maximum="255"/>`, name), size: 1,
+			})
+		}
+	}
+	if meta.HasNormal {
+		for _, name := range []string{"normalX", "normalY", "normalZ"} {
+			columns = append(columns, &e57Column{
+				name: name, prototype: fmt.Sprintf(`<nor:%s type="Float" precision="single" minimum="-1" maximum="1"/>`, name), size: 4,
+			})
+		}
+	}
+	if meta.HasIntensity {
+		columns = append(columns, &e57Column{
+			name: "intensity", prototype: `<intensity type="Integer" minimum="0" maximum="65535"/>`, size: 2,
+		})
+	}
+	bytesPerRecord := 0
+	for _, column := range columns {
+		column.data = make([]byte, 0, column.size*size)
+		bytesPerRecord += column.size
+	}
+
+	cloud.Iterate(0, 0, func(pos r3.Vector, d Data) bool {
+		if d == nil {
+			d = NewBasicData()
+		}
+		// Converts RDK units (millimeters) to meters for E57
+		i := 0
+		for _, v := range []float64{pos.X / 1000., pos.Y / 1000., pos.Z / 1000.} {
+			columns[i].data = binary.LittleEndian.AppendUint64(columns[i].data, math.Float64bits(v))
+			i++
+		}
+		if meta.HasColor {
+			r, g, b := d.RGB255()
+			for _, c := range []uint8{r, g, b} {
+				columns[i].data = append(columns[i].data, c)
+				i++
+			}
+		}
+		if meta.HasNormal {
+			n := d.Normal()
+			for _, v := range []float64{n.X, n.Y, n.Z} {
+				columns[i].data = binary.LittleEndian.AppendUint32(columns[i].data, math.Float32bits(float32(v)))
+				i++
+			}
+		}
+		if meta.HasIntensity {
+			columns[i].data = binary.LittleEndian.AppendUint16(columns[i].data, d.Intensity())
+		}
+		return true
+	})
+
+	var packets []byte
+	recordsPerPacket := (e57MaxPacketSize - 3 - 6 - 2*len(columns)) / bytesPerRecord
+	for start := 0; start < size; start += recordsPerPacket {
+		end := start + recordsPerPacket
+		if end > size {
+			end = size
+		}
+		streams := make([][]byte, len(columns))
+		for i, column := range columns {
+			streams[i] = column.data[start*column.size : end*column.size]
+		}
+		packets = append(packets, e57Packet(streams)...)
+	}
+
+	prototypes := make([]string, len(columns))
+	for i, column := range columns {
+		prototypes[i] = column.prototype
+	}
+	var limits string
+	if meta.HasColor {
+		limits += `<colorLimits type="Structure">`
+		for _, channel := range []string{"Red", "Green", "Blue"} {
+			limits += fmt.Sprintf(`<color%[1]sMinimum type="Integer">0</color%[1]sMinimum>`+
+				`<color%[1]sMaximum type="Integer">255</color%[1]sMaximum>`, channel)
+		}
+		limits += `</colorLimits>`
+	}
+	if meta.HasIntensity {
+		limits += `<intensityLimits type="Structure"><intensityMinimum type="Integer">0</intensityMinimum>` +
+			`<intensityMaximum type="Integer">65535</intensityMaximum></intensityLimits>`
+	}
+	if size > 0 {
+		limits += fmt.Sprintf(`<cartesianBounds type="Structure">`+
+			`<xMinimum type="Float">%[1]g</xMinimum><xMaximum type="Float">%[2]g</xMaximum>`+
+			`<yMinimum type="Float">%[3]g</yMinimum><yMaximum type="Float">%[4]g</yMaximum>`+
+			`<zMinimum type="Float">%[5]g</zMinimum><zMaximum type="Float">%[6]g</zMaximum></cartesianBounds>`,
+			meta.MinX/1000., meta.MaxX/1000., meta.MinY/1000., meta.MaxY/1000., meta.MinZ/1000., meta.MaxZ/1000.)
+	}
+	return writeE57(out, packets, func(fileOffset uint64) string {
+		return fmt.Sprintf(`<guid type="String"><![CDATA[{%s}]]></guid>
+%s
+<points type="CompressedVector" fileOffset="%d" recordCount="%d">
+<prototype type="Structure">%s</prototype>
+<codecs type="Vector" allowHeterogeneousChildren="1"></codecs>
+</points>`, uuid.NewString(), limits, fileOffset, size, strings.Join(prototypes, ""))
+	})
+}
+
+// e57Packet returns a data packet holding a buffer of each bytestream.
+func e57Packet(streams [][]byte) []byte {
+	packet := []byte{e57DataPacket, 0, 0, 0}
+	packet = binary.LittleEndian.AppendUint16(packet, uint16(len(streams)))
+	for _, stream := range streams {
+		packet = binary.LittleEndian.AppendUint16(packet, uint16(len(stream)))
+	}
+	for _, stream := range streams {
+		packet = append(packet, stream...)
+	}
+	// packets are padded to a multiple of four bytes
+	for len(packet)%4 != 0 {
+		packet = append(packet, 0)
+	}
+	binary.LittleEndian.PutUint16(packet[2:], uint16(len(packet)-1))
+	return packet
+}
+
+// writeE57 writes out an E57 file with a single scan whose points are in the given data packets. scan
+// returns the xml of the scan given the physical offset of the section of its points.
+func writeE57(out io.Writer, packets []byte, scan func(fileOffset uint64) string) error {
+	physical := func(logical int) uint64 {
+		return uint64(logical/(e57PageSize-4)*e57PageSize + logical%(e57PageSize-4))
+	}
+
+	// the logical content of the file is the header, the binary section and then the xml section
+	var logical bytes.Buffer
+	logical.Write(make([]byte, e57HeaderSize+e57SectionHeaderSize))
+	logical.Write(packets)
+	sectionStart := e57HeaderSize
+	xmlStart := logical.Len()
+	data := logical.Bytes()
+	data[sectionStart] = e57CompressedVectorSection
+	binary.LittleEndian.PutUint64(data[sectionStart+8:], uint64(xmlStart-sectionStart))
+	if len(packets) > 0 {
+		binary.LittleEndian.PutUint64(data[sectionStart+16:], physical(sectionStart+e57SectionHeaderSize))
+	}
+
+	xmlSection := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<e57Root type="Structure" xmlns="%s" xmlns:nor="%s">
+<formatName type="String"><![CDATA[ASTM E57 3D Imaging Data File]]></formatName>
+<guid type="String"><![CDATA[{%s}]]></guid>
+<versionMajor type="Integer">1</versionMajor>
+<versionMinor type="Integer">0</versionMinor>
+<data3D type="Vector" allowHeterogeneousChildren="1">
+<vectorChild type="Structure">
+%s
+</vectorChild>
+</data3D>
+<images2D type="Vector" allowHeterogeneousChildren="1"></images2D>
+</e57Root>
+`, e57Namespace, e57NormalsNamespace, uuid.NewString(), scan(physical(sectionStart)))
+	logical.WriteString(xmlSection)
+
+	// the last page is padded with zeros
+	numPages := (logical.Len() + e57PageSize - 5) / (e57PageSize - 4)
+	logical.Write(make([]byte, numPages*(e57PageSize-4)-logical.Len()))
+	data = logical.Bytes()
+	copy(data, e57Signature)
+	binary.LittleEndian.PutUint32(data[8:], 1)
+	binary.LittleEndian.PutUint32(data[12:], 0)
+	binary.LittleEndian.PutUint64(data[16:], uint64(numPages*e57PageSize))
+	binary.LittleEndian.PutUint64(data[24:], physical(xmlStart))
+	binary.LittleEndian.PutUint64(data[32:], uint64(len(xmlSection)))
+	binary.LittleEndian.PutUint64(data[40:], e57PageSize)
+
+	checksum := make([]byte, 4)
+	for start := 0; start < len(data); start += e57PageSize - 4 {
+		page := data[start : start+e57PageSize-4]
+		if _, err := out.Write(page); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint32(checksum, crc32.Checksum(page, e57CRCTable))
+		if _, err := out.Write(checksum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+// packBits packs values of size bits least significant bits first like an e57 integer bytestream.
+func packBits(values []uint64, size int) []byte {
+	out := make([]byte, (len(values)*size+7)/8)
+	for i, v := range values {
+		for bit := 0; bit < size; bit++ {
+			if v&(1<<bit) != 0 {
+				pos := i*size + bit
+				out[pos/8] |= 1 << (pos % 8)
+			}
+		}
+	}
+	return out
+}
+
+func TestE57RoundTrip(t *testing.T) {
+	cloud := newAttributedCloud(t)
+	var buf bytes.Buffer
+	test.That(t, ToE57(cloud, &buf), test.ShouldBeNil)
+	test.That(t, buf.Len()%e57PageSize, test.ShouldEqual, 0)
+	test.That(t, buf.String(), test.ShouldStartWith, "ASTM-E57")
+
+	cloud2, err := ReadE57(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	testAttributedCloud(t, cloud2)
+
+	// a corrupted page fails its checksum
+	corrupted := bytes.Clone(buf.Bytes())
+	corrupted[100]++
+	_, err = ReadE57(bytes.NewReader(corrupted))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "checksum")
+
+	// clouds spanning several pages and data packets
+	big := newBigPC()
+	buf.Reset()
+	test.That(t, ToE57(big, &buf), test.ShouldBeNil)
+	cloud2, err = ReadE57(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud2.Size(), test.ShouldEqual, big.Size())
+	test.That(t, CloudContains(cloud2, 10, 20, 50), test.ShouldBeTrue)
+
+	buf.Reset()
+	test.That(t, ToE57(New(), &buf), test.ShouldBeNil)
+	cloud2, err = ReadE57(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud2.Size(), test.ShouldEqual, 0)
+}
+
+func TestReadE57(t *testing.T) {
+	// three spherical points with bit packed ranges and invalid states split over two data packets, the
+	// last point is invalid
+	ranges := packBits([]uint64{1000, 2000, 4095}, 12)
+	invalid := packBits([]uint64{0, 0, 2}, 2)
+	float32s := func(values ...float32) []byte {
+		var buf bytes.Buffer
+		test.That(t, binary.Write(&buf, binary.LittleEndian, values), test.ShouldBeNil)
+		return buf.Bytes()
+	}
+	azimuths := float32s(0, math.Pi/2, 0)
+	intensities := float32s(1, 2, 0)
+	var packets []byte
+	packets = append(packets, e57Packet([][]byte{ranges[:2], azimuths[:4], {}, invalid, intensities[:8]})...)
+	packets = append(packets, e57EmptyPacket, 0, 3, 0)
+	packets = append(packets, e57Packet([][]byte{ranges[2:], azimuths[4:], float32s(0, 0, 0), {}, intensities[8:]})...)
+
+	var buf bytes.Buffer
+	err := writeE57(&buf, packets, func(fileOffset uint64) string {
+		return fmt.Sprintf(`<pose type="Structure">
+<rotation type="Structure"><w type="Float">%[1]v</w><x type="Float">0</x><y type="Float">0</y><z type="Float">%[1]v</z></rotation>
+<translation type="Structure"><x type="Float">1</x><y type="Float">0</y><z type="Float">0</z></translation>
+</pose>
+<intensityLimits type="Structure"><intensityMinimum type="Float">0</intensityMinimum><intensityMaximum type="Float">2</intensityMaximum></intensityLimits>
+<points type="CompressedVector" fileOffset="%[2]d" recordCount="3"><prototype type="Structure">
+<sphericalRange type="ScaledInteger" minimum="0" maximum="4095" scale="0.001"/>
+<sphericalAzimuth type="Float" precision="single"/>
+<sphericalElevation type="Float" precision="single"/>
+<sphericalInvalidState type="Integer" minimum="0" maximum="2"/>
+<intensity type="Float" precision="single"/>
+</prototype><codecs type="Vector"/></points>`, math.Sqrt2/2, fileOffset)
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	cloud, err := ReadE57(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud.Size(), test.ShouldEqual, 2)
+	var points []r3.Vector
+	var intensities16 []uint16
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		points = append(points, p)
+		intensities16 = append(intensities16, d.Intensity())
+		return true
+	})
+	if points[0].X < points[1].X {
+		points[0], points[1] = points[1], points[0]
+		intensities16[0], intensities16[1] = intensities16[1], intensities16[0]
+	}
+	// rotated a quarter turn about z and moved a meter along x
+	test.That(t, points[0].X, test.ShouldAlmostEqual, 1000, 1e-3)
+	test.That(t, points[0].Y, test.ShouldAlmostEqual, 1000, 1e-3)
+	test.That(t, points[0].Z, test.ShouldAlmostEqual, 0, 1e-3)
+	test.That(t, intensities16[0], test.ShouldEqual, 32768)
+	test.That(t, points[1].X, test.ShouldAlmostEqual, -1000, 1e-3)
+	test.That(t, points[1].Y, test.ShouldAlmostEqual, 0, 1e-3)
+	test.That(t, intensities16[1], test.ShouldEqual, math.MaxUint16)
+
+	_, err = ReadE57(bytes.NewReader([]byte("not an e57 file at all, not even close to one")))
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestE57FieldDecode(t *testing.T) {
+	field := &e57Field{name: "f", size: 3, rawMin: -2, scale: 0.5, offset: 1}
+	values, err := field.decode(packBits([]uint64{0, 7, 3}, 3), 3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, values, test.ShouldResemble, []float64{0, 3.5, 1.5})
+
+	_, err = field.decode(packBits([]uint64{0, 7, 3}, 3), 6)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// fields with a single value take no bits
+	field = &e57Field{name: "f", rawMin: 4, scale: 1}
+	values, err = field.decode(nil, 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, values, test.ShouldResemble, []float64{4, 4})
+}
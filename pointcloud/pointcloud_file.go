@@ -8,6 +8,7 @@ import (
 	"image/color"
 	"io"
 	"math"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -21,6 +22,7 @@ import (
 	"gonum.org/v1/gonum/num/quat"
 
 	"go.viam.com/rdk/spatialmath"
+	rutils "go.viam.com/rdk/utils"
 )
 
 // PCDType is the format of a pcd file.
@@ -37,20 +39,122 @@ const (
 
 // NewFromFile returns a pointcloud read in from the given file.
 func NewFromFile(fn string, logger golog.Logger) (PointCloud, error) {
-	switch filepath.Ext(fn) {
+	var read func(io.Reader) (PointCloud, error)
+	switch strings.ToLower(filepath.Ext(fn)) {
 	case ".las":
 		return NewFromLASFile(fn, logger)
+	case ".pcd":
+		read = ReadPCD
+	case ".ply":
+		read = ReadPLY
+	case ".xyz", ".txt", ".csv":
+		read = ReadXYZ
+	case ".e57":
+		read = ReadE57
 	default:
 		return nil, errors.Errorf("do not know how to read file %q", fn)
 	}
+	//nolint:gosec
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.UncheckedErrorFunc(f.Close)
+	return read(f)
+}
+
+// WriteToFile writes the point cloud out to the given file in the format of its extension.
+func WriteToFile(cloud PointCloud, fn string) (err error) {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".las":
+		return WriteToLASFile(cloud, fn)
+	case ".pcd":
+		write = func(out io.Writer) error { return ToPCD(cloud, out, PCDBinary) }
+	case ".ply":
+		write = func(out io.Writer) error { return ToPLY(cloud, out, PLYBinary) }
+	case ".xyz", ".txt":
+		write = func(out io.Writer) error { return ToXYZ(cloud, out, ' ') }
+	case ".csv":
+		write = func(out io.Writer) error { return ToXYZ(cloud, out, ',') }
+	case ".e57":
+		write = func(out io.Writer) error { return ToE57(cloud, out) }
+	default:
+		return errors.Errorf("do not know how to write file %q", fn)
+	}
+	//nolint:gosec
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Combine(err, f.Close())
+	}()
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ReadWithMIMEType reads a pointcloud encoded in the format of the given MIME type.
+func ReadWithMIMEType(in io.Reader, mimeType string) (PointCloud, error) {
+	switch mimeType {
+	case rutils.MimeTypePCD:
+		return ReadPCD(in)
+	case rutils.MimeTypePLY:
+		return ReadPLY(in)
+	case rutils.MimeTypeXYZ:
+		return ReadXYZ(in)
+	case rutils.MimeTypeE57:
+		return ReadE57(in)
+	default:
+		return nil, errors.Errorf("unsupported pointcloud mime type %q", mimeType)
+	}
+}
+
+// WriteWithMIMEType writes out a pointcloud in the format of the given MIME type, using the binary
+// variant of formats that have one. PCDs are written with the attributes of the points, so that
+// they are kept when the pointcloud is sent to a client.
+func WriteWithMIMEType(cloud PointCloud, out io.Writer, mimeType string) error {
+	switch mimeType {
+	case rutils.MimeTypePCD:
+		return ToPCDWithAttributes(cloud, out, PCDBinary)
+	case rutils.MimeTypePLY:
+		return ToPLY(cloud, out, PLYBinary)
+	case rutils.MimeTypeXYZ:
+		return ToXYZ(cloud, out, ' ')
+	case rutils.MimeTypeE57:
+		return ToE57(cloud, out)
+	default:
+		return errors.Errorf("unsupported pointcloud mime type %q", mimeType)
+	}
 }
 
 // pointValueDataTag encodes if the point has value data.
 const pointValueDataTag = "rc|pv"
 
+// pointLabelDataTag encodes if the point has label data.
+const pointLabelDataTag = "rc|pl"
+
+// pointNormalDataTag encodes if the point has normal data.
+const pointNormalDataTag = "rc|pn"
+
+// lasVLRData returns the data of the variable length record of the LAS file with the given
+// description, if it has one.
+func lasVLRData(lf *lidario.LasFile, description string) ([]byte, bool) {
+	for _, d := range lf.VlrData {
+		if d.Description == description {
+			return d.BinaryData, true
+		}
+	}
+	return nil, false
+}
+
 // NewFromLASFile returns a point cloud from reading a LAS file. If any
 // lossiness of points could occur from reading it in, it's reported but is not
-// an error.
+// an error. Nonzero intensities are read into the points and the GPS times of
+// point formats that have them are read as timestamps.
 func NewFromLASFile(fn string, logger golog.Logger) (PointCloud, error) {
 	lf, err := lidario.NewLasFile(fn, "r")
 	if err != nil {
@@ -58,15 +162,15 @@ func NewFromLASFile(fn string, logger golog.Logger) (PointCloud, error) {
 	}
 	defer utils.UncheckedErrorFunc(lf.Close)
 
-	var hasValue bool
-	var valueData []byte
-	for _, d := range lf.VlrData {
-		if d.Description == pointValueDataTag {
-			hasValue = true
-			valueData = d.BinaryData
-			break
-		}
+	valueData, hasValue := lasVLRData(lf, pointValueDataTag)
+	labelData, hasLabel := lasVLRData(lf, pointLabelDataTag)
+	normalData, hasNormal := lasVLRData(lf, pointNormalDataTag)
+	if (hasValue && len(valueData) < 8*lf.Header.NumberPoints) ||
+		(hasLabel && len(labelData) < 4*lf.Header.NumberPoints) ||
+		(hasNormal && len(normalData) < 24*lf.Header.NumberPoints) {
+		return nil, errors.Errorf("LAS file %q has fewer point attributes than its %d points", fn, lf.Header.NumberPoints)
 	}
+	formatID := lf.Header.PointFormatID
 
 	pc := New()
 	for i := 0; i < lf.Header.NumberPoints; i++ {
@@ -85,20 +189,33 @@ func NewFromLASFile(fn string, logger golog.Logger) (PointCloud, error) {
 		}
 
 		v := r3.Vector{X: x, Y: y, Z: z}
-		var dd Data
-		if lf.Header.PointFormatID == 2 && p.RgbData() != nil {
+		dd := NewBasicData()
+		if (formatID == 2 || formatID == 3) && p.RgbData() != nil {
 			r := uint8(p.RgbData().Red / 256)
 			g := uint8(p.RgbData().Green / 256)
 			b := uint8(p.RgbData().Blue / 256)
-			dd = NewColoredData(color.NRGBA{r, g, b, 255})
+			dd.SetColor(color.NRGBA{r, g, b, 255})
+		}
+		if data.Intensity != 0 {
+			dd.SetIntensity(data.Intensity)
+		}
+		if formatID == 1 || formatID == 3 {
+			dd.SetTimestamp(p.GpsTimeData())
 		}
 
 		if hasValue {
-			value := int(binary.LittleEndian.Uint64(valueData[i*8 : (i*8)+8]))
-			if dd == nil {
-				dd = NewBasicData()
-			}
-			dd.SetValue(value)
+			dd.SetValue(int(binary.LittleEndian.Uint64(valueData[i*8 : (i*8)+8])))
+		}
+		if hasLabel {
+			dd.SetLabel(binary.LittleEndian.Uint32(labelData[i*4 : (i*4)+4]))
+		}
+		if hasNormal {
+			n := normalData[i*24 : (i*24)+24]
+			dd.SetNormal(r3.Vector{
+				X: math.Float64frombits(binary.LittleEndian.Uint64(n)),
+				Y: math.Float64frombits(binary.LittleEndian.Uint64(n[8:])),
+				Z: math.Float64frombits(binary.LittleEndian.Uint64(n[16:])),
+			})
 		}
 
 		if err := pc.Set(v, dd); err != nil {
@@ -108,7 +225,9 @@ func NewFromLASFile(fn string, logger golog.Logger) (PointCloud, error) {
 	return pc, nil
 }
 
-// WriteToLASFile writes the point cloud out to a LAS file.
+// WriteToLASFile writes the point cloud out to a LAS file. Timestamps are
+// written as GPS times, while values, labels and normals are written to
+// variable length records.
 func WriteToLASFile(cloud PointCloud, fn string) (err error) {
 	lf, err := lidario.NewLasFile(fn, "w")
 	if err != nil {
@@ -122,8 +241,13 @@ func WriteToLASFile(cloud PointCloud, fn string) (err error) {
 	meta := cloud.MetaData()
 
 	pointFormatID := 0
-	if meta.HasColor {
+	switch {
+	case meta.HasColor && meta.HasTimestamp:
+		pointFormatID = 3
+	case meta.HasColor:
 		pointFormatID = 2
+	case meta.HasTimestamp:
+		pointFormatID = 1
 	}
 	if err = lf.AddHeader(lidario.LasHeader{
 		PointFormatID: byte(pointFormatID),
@@ -131,18 +255,19 @@ func WriteToLASFile(cloud PointCloud, fn string) (err error) {
 		return
 	}
 
-	var pVals []int
-	if meta.HasValue {
-		pVals = make([]int, 0, cloud.Size())
-	}
+	var valueData, labelData, normalData bytes.Buffer
 	var lastErr error
 	cloud.Iterate(0, 0, func(pos r3.Vector, d Data) bool {
+		if d == nil {
+			d = NewBasicData()
+		}
 		var lp lidario.LasPointer
 		pr0 := &lidario.PointRecord0{
 			// floating point lossiness validated/warned from set/load
-			X: pos.X,
-			Y: pos.Y,
-			Z: pos.Z,
+			X:         pos.X,
+			Y:         pos.Y,
+			Z:         pos.Z,
+			Intensity: d.Intensity(),
 			BitField: lidario.PointBitField{
 				Value: (1) | (1 << 3) | (0 << 6) | (0 << 7),
 			},
@@ -155,30 +280,42 @@ func WriteToLASFile(cloud PointCloud, fn string) (err error) {
 		}
 		lp = pr0
 
-		if d != nil {
-			pr0.Intensity = d.Intensity()
-		}
-
+		var rgb *lidario.RgbData
 		if meta.HasColor {
 			red, green, blue := 255, 255, 255
-			if d != nil && d.HasColor() {
+			if d.HasColor() {
 				r, g, b := d.RGB255()
 				red, green, blue = int(r), int(g), int(b)
 			}
-			lp = &lidario.PointRecord2{
-				PointRecord0: pr0,
-				RGB: &lidario.RgbData{
-					Red:   uint16(red * 256),
-					Green: uint16(green * 256),
-					Blue:  uint16(blue * 256),
-				},
+			rgb = &lidario.RgbData{
+				Red:   uint16(red * 256),
+				Green: uint16(green * 256),
+				Blue:  uint16(blue * 256),
 			}
 		}
+		switch pointFormatID {
+		case 1:
+			lp = &lidario.PointRecord1{PointRecord0: pr0, GPSTime: d.Timestamp()}
+		case 2:
+			lp = &lidario.PointRecord2{PointRecord0: pr0, RGB: rgb}
+		case 3:
+			lp = &lidario.PointRecord3{PointRecord0: pr0, GPSTime: d.Timestamp(), RGB: rgb}
+		}
+
+		buf := make([]byte, 8)
 		if meta.HasValue {
-			if d != nil && d.HasValue() {
-				pVals = append(pVals, d.Value())
-			} else {
-				pVals = append(pVals, 0)
+			binary.LittleEndian.PutUint64(buf, uint64(d.Value()))
+			valueData.Write(buf)
+		}
+		if meta.HasLabel {
+			binary.LittleEndian.PutUint32(buf, d.Label())
+			labelData.Write(buf[:4])
+		}
+		if meta.HasNormal {
+			n := d.Normal()
+			for _, v := range []float64{n.X, n.Y, n.Z} {
+				binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+				normalData.Write(buf)
 			}
 		}
 		if lerr := lf.AddLasPoint(lp); lerr != nil {
@@ -187,18 +324,23 @@ func WriteToLASFile(cloud PointCloud, fn string) (err error) {
 		}
 		return true
 	})
-	if meta.HasValue {
-		var buf bytes.Buffer
-		for _, v := range pVals {
-			bytes := make([]byte, 8)
-			binary.LittleEndian.PutUint64(bytes, uint64(v))
-			buf.Write(bytes)
+	for _, vlr := range []struct {
+		has         bool
+		description string
+		data        []byte
+	}{
+		{meta.HasValue, pointValueDataTag, valueData.Bytes()},
+		{meta.HasLabel, pointLabelDataTag, labelData.Bytes()},
+		{meta.HasNormal, pointNormalDataTag, normalData.Bytes()},
+	} {
+		if !vlr.has {
+			continue
 		}
 		if err = lf.AddVLR(lidario.VLR{
 			UserID:                  "",
-			Description:             pointValueDataTag,
-			BinaryData:              buf.Bytes(),
-			RecordLengthAfterHeader: buf.Len(),
+			Description:             vlr.description,
+			BinaryData:              vlr.data,
+			RecordLengthAfterHeader: len(vlr.data),
 		}); err != nil {
 			return
 		}
@@ -233,31 +375,96 @@ func _pcdIntToColor(c int) color.NRGBA {
 	return color.NRGBA{r, g, b, 255}
 }
 
-// ToPCD writes out a point cloud to a PCD file of the specified type.
+// pcdField is a field of the points written out to a PCD file.
+type pcdField struct {
+	name    string
+	size    int
+	valType string
+	value   func(pos r3.Vector, d Data) float64
+}
+
+// pcdFieldsOf returns the fields written out for the points of a cloud with the given metadata, the
+// position and color followed, if withAttributes is set, by each other attribute the points have.
+func pcdFieldsOf(meta MetaData, withAttributes bool) []pcdField {
+	// Converts RDK units (millimeters) to meters for PCD
+	fields := []pcdField{
+		{"x", 4, "F", func(pos r3.Vector, d Data) float64 { return pos.X / 1000. }},
+		{"y", 4, "F", func(pos r3.Vector, d Data) float64 { return pos.Y / 1000. }},
+		{"z", 4, "F", func(pos r3.Vector, d Data) float64 { return pos.Z / 1000. }},
+	}
+	if meta.HasColor {
+		fields = append(fields, pcdField{"rgb", 4, "I", func(pos r3.Vector, d Data) float64 {
+			return float64(_colorToPCDInt(d))
+		}})
+	}
+	if !withAttributes {
+		return fields
+	}
+	if meta.HasIntensity {
+		fields = append(fields, pcdField{"intensity", 2, "U", func(pos r3.Vector, d Data) float64 {
+			return float64(d.Intensity())
+		}})
+	}
+	if meta.HasNormal {
+		fields = append(fields,
+			pcdField{"normal_x", 4, "F", func(pos r3.Vector, d Data) float64 { return d.Normal().X }},
+			pcdField{"normal_y", 4, "F", func(pos r3.Vector, d Data) float64 { return d.Normal().Y }},
+			pcdField{"normal_z", 4, "F", func(pos r3.Vector, d Data) float64 { return d.Normal().Z }},
+		)
+	}
+	if meta.HasLabel {
+		fields = append(fields, pcdField{"label", 4, "U", func(pos r3.Vector, d Data) float64 {
+			return float64(d.Label())
+		}})
+	}
+	if meta.HasTimestamp {
+		fields = append(fields, pcdField{"timestamp", 8, "F", func(pos r3.Vector, d Data) float64 {
+			return d.Timestamp()
+		}})
+	}
+	return fields
+}
+
+// ToPCD writes out a point cloud to a PCD file of the specified type, with the positions and colors
+// of its points.
 func ToPCD(cloud PointCloud, out io.Writer, outputType PCDType) error {
+	return toPCD(cloud, out, outputType, false)
+}
+
+// ToPCDWithAttributes writes out a point cloud like ToPCD, along with the intensities, normals,
+// labels and timestamps of its points as the intensity, normal_x, normal_y, normal_z, label and
+// timestamp fields when the cloud has them. Readers only expecting x, y, z and rgb fields may
+// reject such files.
+func ToPCDWithAttributes(cloud PointCloud, out io.Writer, outputType PCDType) error {
+	return toPCD(cloud, out, outputType, true)
+}
+
+func toPCD(cloud PointCloud, out io.Writer, outputType PCDType, withAttributes bool) error {
 	var err error
 
 	_, err = fmt.Fprintf(out, "VERSION .7\n")
 	if err != nil {
 		return err
 	}
-	if cloud.MetaData().HasColor {
-		_, err = fmt.Fprintf(out, "FIELDS x y z rgb\n"+
-
-			"SIZE 4 4 4 4\n"+
-			//nolint:dupword
-			"TYPE F F F I\n"+
-
-			"COUNT 1 1 1 1\n")
-	} else {
-		_, err = fmt.Fprintf(out, "FIELDS x y z\n"+
-
-			"SIZE 4 4 4\n"+
-			//nolint:dupword
-			"TYPE F F F\n"+
-
-			"COUNT 1 1 1\n")
+	fields := pcdFieldsOf(cloud.MetaData(), withAttributes)
+	names := make([]string, len(fields))
+	sizes := make([]string, len(fields))
+	types := make([]string, len(fields))
+	counts := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.name
+		sizes[i] = strconv.Itoa(field.size)
+		types[i] = field.valType
+		counts[i] = "1"
 	}
+	_, err = fmt.Fprintf(out, "FIELDS %s\n"+
+		"SIZE %s\n"+
+		"TYPE %s\n"+
+		"COUNT %s\n",
+		strings.Join(names, " "),
+		strings.Join(sizes, " "),
+		strings.Join(types, " "),
+		strings.Join(counts, " "))
 	if err != nil {
 		return err
 	}
@@ -285,90 +492,171 @@ func ToPCD(cloud PointCloud, out io.Writer, outputType PCDType) error {
 			return err
 		}
 	case PCDCompressed:
-		// _, err = fmt.Fprintf(out, "DATA binary_compressed\n")
-		// if err != nil {
-		// 	return err
-		// }
-		return errors.New("compressed PCD not yet implemented")
+		_, err = fmt.Fprintf(out, "DATA binary_compressed\n")
+		if err != nil {
+			return err
+		}
+		return writePCDCompressed(cloud, out, fields)
 	}
-	err = writePCDData(cloud, out, outputType)
+	err = writePCDData(cloud, out, outputType, fields)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func writePCDData(cloud PointCloud, out io.Writer, pcdtype PCDType) error {
+func writePCDData(cloud PointCloud, out io.Writer, pcdtype PCDType, fields []pcdField) error {
+	rowSize := 0
+	for _, field := range fields {
+		rowSize += field.size
+	}
+	buf := make([]byte, rowSize)
+	tokens := make([]string, len(fields))
+	var err error
 	cloud.Iterate(0, 0, func(pos r3.Vector, d Data) bool {
-		var err error
-		// Converts RDK units (millimeters) to meters for PCD
-		x := pos.X / 1000.
-		y := pos.Y / 1000.
-		z := pos.Z / 1000.
-		if cloud.MetaData().HasColor {
-			c := _colorToPCDInt(d)
-			switch pcdtype {
-			case PCDBinary:
-				buf := make([]byte, 16)
-				binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(x)))
-				binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(float32(y)))
-				binary.LittleEndian.PutUint32(buf[8:], math.Float32bits(float32(z)))
-				binary.LittleEndian.PutUint32(buf[12:], uint32(c))
-				_, err = out.Write(buf)
-			case PCDAscii:
-				_, err = fmt.Fprintf(out, "%f %f %f %d\n", x, y, z, c)
-			case PCDCompressed:
-				return false // TODO(aidanglickman): Implement compressed PCD
-			default:
-				return false
+		if d == nil {
+			d = NewBasicData()
+		}
+		switch pcdtype {
+		case PCDBinary:
+			offset := 0
+			for _, field := range fields {
+				putPCDValue(buf[offset:], field.valType, field.size, field.value(pos, d))
+				offset += field.size
 			}
-		} else {
-			switch pcdtype {
-			case PCDBinary:
-				buf := make([]byte, 12)
-				binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(x)))
-				binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(float32(y)))
-				binary.LittleEndian.PutUint32(buf[8:], math.Float32bits(float32(z)))
-				_, err = out.Write(buf)
-			case PCDAscii:
-				_, err = fmt.Fprintf(out, "%f %f %f\n", x, y, z)
-			case PCDCompressed:
-				return false // TODO(aidanglickman): Implement compressed PCD
-			default:
-				return false
+			_, err = out.Write(buf)
+		case PCDAscii:
+			for i, field := range fields {
+				tokens[i] = formatPCDValue(field.valType, field.size, field.value(pos, d))
 			}
+			_, err = fmt.Fprintln(out, strings.Join(tokens, " "))
+		default:
+			return false
 		}
 		return err == nil
 	})
-	return nil
+	return err
 }
 
-func readFloat(n uint32) float64 {
-	f := float64(math.Float32frombits(n))
-	return math.Round(f*10000) / 10000
+// putPCDValue writes v into buf as a little endian value of the given PCD type and size.
+func putPCDValue(buf []byte, valType string, size int, v float64) {
+	var bits uint64
+	switch valType {
+	case "F":
+		if size == 8 {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+		} else {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+		}
+		return
+	case "I":
+		bits = uint64(int64(v))
+	default:
+		bits = uint64(v)
+	}
+	switch size {
+	case 1:
+		buf[0] = byte(bits)
+	case 2:
+		binary.LittleEndian.PutUint16(buf, uint16(bits))
+	case 4:
+		binary.LittleEndian.PutUint32(buf, uint32(bits))
+	default:
+		binary.LittleEndian.PutUint64(buf, bits)
+	}
+}
+
+// formatPCDValue formats v as an ascii value of the given PCD type and size.
+func formatPCDValue(valType string, size int, v float64) string {
+	switch {
+	case valType == "F" && size == 8:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case valType == "F":
+		return strconv.FormatFloat(v, 'f', 6, 64)
+	default:
+		return strconv.FormatInt(int64(v), 10)
+	}
+}
+
+// pcdValue reads a little endian value of the given PCD type and size from buf.
+func pcdValue(buf []byte, valType string, size uint64) float64 {
+	switch valType {
+	case "F":
+		if size == 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(buf))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(buf)))
+	case "I":
+		switch size {
+		case 1:
+			return float64(int8(buf[0]))
+		case 2:
+			return float64(int16(binary.LittleEndian.Uint16(buf)))
+		case 4:
+			return float64(int32(binary.LittleEndian.Uint32(buf)))
+		default:
+			return float64(int64(binary.LittleEndian.Uint64(buf)))
+		}
+	default:
+		switch size {
+		case 1:
+			return float64(buf[0])
+		case 2:
+			return float64(binary.LittleEndian.Uint16(buf))
+		case 4:
+			return float64(binary.LittleEndian.Uint32(buf))
+		default:
+			return float64(binary.LittleEndian.Uint64(buf))
+		}
+	}
+}
+
+// validPCDValue returns whether values of the given PCD type and size can be read.
+func validPCDValue(valType string, size uint64) bool {
+	switch valType {
+	case "F":
+		return size == 4 || size == 8
+	case "I", "U":
+		return size == 1 || size == 2 || size == 4 || size == 8
+	default:
+		return false
+	}
 }
 
 type pcdFieldType int
 
 const (
-	pcdPointOnly  pcdFieldType = 3
-	pcdPointColor pcdFieldType = 4
+	// pcdExtraFields are fields other than exactly x y z or x y z rgb, such as the attributes of the
+	// points.
+	pcdExtraFields pcdFieldType = 0
+	pcdPointOnly   pcdFieldType = 3
+	pcdPointColor  pcdFieldType = 4
 )
 
 type pcdHeader struct {
-	fields    pcdFieldType
-	size      []uint64
-	valTypes  []string
-	count     []uint64
-	width     uint64
-	height    uint64
-	viewpoint spatialmath.Pose
-	points    uint64
-	data      PCDType
+	fields     pcdFieldType
+	fieldNames []string
+	size       []uint64
+	valTypes   []string
+	count      []uint64
+	width      uint64
+	height     uint64
+	viewpoint  spatialmath.Pose
+	points     uint64
+	data       PCDType
+
+	// offsets are the indices of the first value of each field among all the values of a point
+	offsets   []int
+	numValues int
+	columns   pcdColumns
 }
 
 const pcdCommentChar = "#"
 
+// maxPCDPointSize is the largest size in bytes of the values of a point read from a PCD file, which
+// keeps a malformed header from making the reader allocate more than the file could hold.
+const maxPCDPointSize = 1 << 16
+
 var pcdHeaderFields = []string{"VERSION", "FIELDS", "SIZE", "TYPE", "COUNT", "WIDTH", "HEIGHT", "VIEWPOINT", "POINTS", "DATA"}
 
 func parsePCDHeaderLine(line string, index int, pcdHeader *pcdHeader) error {
@@ -386,16 +674,20 @@ func parsePCDHeaderLine(line string, index int, pcdHeader *pcdHeader) error {
 			return fmt.Errorf("unsupported pcd version %s", value)
 		}
 	case "FIELDS":
+		pcdHeader.fieldNames = tokens
 		switch value {
 		case "x y z":
 			pcdHeader.fields = pcdPointOnly
 		case "x y z rgb":
 			pcdHeader.fields = pcdPointColor
 		default:
-			return fmt.Errorf("unsupported pcd fields %s", value)
+			if pcdHeader.fieldIndex("x") < 0 || pcdHeader.fieldIndex("y") < 0 || pcdHeader.fieldIndex("z") < 0 {
+				return fmt.Errorf("unsupported pcd fields %s", value)
+			}
+			pcdHeader.fields = pcdExtraFields
 		}
 	case "SIZE":
-		if len(tokens) != int(pcdHeader.fields) {
+		if len(tokens) != len(pcdHeader.fieldNames) {
 			return fmt.Errorf("unexpected number of fields %d in SIZE line", len(tokens))
 		}
 		pcdHeader.size = make([]uint64, len(tokens))
@@ -406,13 +698,13 @@ func parsePCDHeaderLine(line string, index int, pcdHeader *pcdHeader) error {
 			}
 		}
 	case "TYPE":
-		if len(tokens) != int(pcdHeader.fields) {
+		if len(tokens) != len(pcdHeader.fieldNames) {
 			return fmt.Errorf("unexpected number of fields %d in TYPE line", len(tokens))
 		}
-		copy(pcdHeader.valTypes, tokens)
+		pcdHeader.valTypes = tokens
 
 	case "COUNT":
-		if len(tokens) != int(pcdHeader.fields) {
+		if len(tokens) != len(pcdHeader.fieldNames) {
 			return fmt.Errorf("unexpected number of fields %d in COUNT line", len(tokens))
 		}
 		pcdHeader.count = make([]uint64, len(tokens))
@@ -421,6 +713,15 @@ func parsePCDHeaderLine(line string, index int, pcdHeader *pcdHeader) error {
 			if err != nil {
 				return fmt.Errorf("invalid COUNT field %s: %w", token, err)
 			}
+			if pcdHeader.count[i] < 1 || pcdHeader.count[i] > maxPCDPointSize {
+				return fmt.Errorf("invalid COUNT field %s: must be between 1 and %d", token, maxPCDPointSize)
+			}
+		}
+		pcdHeader.offsets = make([]int, len(tokens))
+		pcdHeader.numValues = 0
+		for i, count := range pcdHeader.count {
+			pcdHeader.offsets[i] = pcdHeader.numValues
+			pcdHeader.numValues += int(count)
 		}
 	case "WIDTH":
 		pcdHeader.width, err = strconv.ParseUint(value, 10, 64)
@@ -492,9 +793,121 @@ func parsePCDHeader(in *bufio.Reader) (*pcdHeader, error) {
 		}
 		headerLineCount++
 	}
+	pointSize := uint64(0)
+	for i, name := range header.fieldNames {
+		if !validPCDValue(header.valTypes[i], header.size[i]) {
+			return nil, fmt.Errorf("unsupported pcd field %s of type %s and size %d", name, header.valTypes[i], header.size[i])
+		}
+		pointSize += header.size[i] * header.count[i]
+	}
+	if pointSize > maxPCDPointSize {
+		return nil, fmt.Errorf("pcd points of %d bytes are larger than the %d bytes supported", pointSize, maxPCDPointSize)
+	}
+	for _, name := range []string{"x", "y", "z"} {
+		if header.valTypes[header.fieldIndex(name)] != "F" {
+			return nil, fmt.Errorf("unsupported pcd field %s of type %s", name, header.valTypes[header.fieldIndex(name)])
+		}
+	}
+	header.columns = newPCDColumns(header)
+	if header.columns.colorField >= 0 && header.size[header.columns.colorField] != 4 {
+		return nil, fmt.Errorf("unsupported pcd color field of size %d", header.size[header.columns.colorField])
+	}
 	return header, nil
 }
 
+// pcdColumns are the indices among all the values of a point of the values RDK reads, -1 if the pcd
+// does not have them.
+type pcdColumns struct {
+	x, y, z    int
+	color      int
+	colorField int
+	intensity  int
+	nx, ny, nz int
+	label      int
+	timestamp  int
+}
+
+func newPCDColumns(header *pcdHeader) pcdColumns {
+	column := func(names ...string) int {
+		for _, name := range names {
+			if i := header.fieldIndex(name); i >= 0 {
+				return header.offsets[i]
+			}
+		}
+		return -1
+	}
+	columns := pcdColumns{
+		x:          column("x"),
+		y:          column("y"),
+		z:          column("z"),
+		color:      column("rgb", "rgba"),
+		colorField: header.fieldIndex("rgb"),
+		intensity:  column("intensity"),
+		nx:         column("normal_x"),
+		ny:         column("normal_y"),
+		nz:         column("normal_z"),
+		label:      column("label"),
+		timestamp:  column("timestamp"),
+	}
+	if columns.colorField < 0 {
+		columns.colorField = header.fieldIndex("rgba")
+	}
+	// a normal may also be a single field with a COUNT of 3
+	if i := header.fieldIndex("normal"); i >= 0 && header.count[i] >= 3 {
+		columns.nx, columns.ny, columns.nz = header.offsets[i], header.offsets[i]+1, header.offsets[i]+2
+	}
+	if columns.nx < 0 || columns.ny < 0 || columns.nz < 0 {
+		columns.nx, columns.ny, columns.nz = -1, -1, -1
+	}
+	return columns
+}
+
+// pcdPoint returns the point with the given values of all the fields of a pcd.
+func pcdPoint(values []float64, columns pcdColumns) PointAndData {
+	// Converts PCD units (meters) to millimeters for RDK
+	pos := r3.Vector{X: 1000. * values[columns.x], Y: 1000. * values[columns.y], Z: 1000. * values[columns.z]}
+	d := NewBasicData()
+	if columns.color >= 0 {
+		d.SetColor(_pcdIntToColor(int(values[columns.color])))
+	}
+	if columns.intensity >= 0 {
+		d.SetIntensity(uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(values[columns.intensity])))))
+	}
+	if columns.nx >= 0 {
+		d.SetNormal(r3.Vector{X: values[columns.nx], Y: values[columns.ny], Z: values[columns.nz]})
+	}
+	if columns.label >= 0 {
+		d.SetLabel(uint32(math.Max(0, math.Min(math.MaxUint32, values[columns.label]))))
+	}
+	if columns.timestamp >= 0 {
+		d.SetTimestamp(values[columns.timestamp])
+	}
+	return PointAndData{P: pos, D: d}
+}
+
+// binaryValue reads the value of the given field from buf. Colors are read as the bits of their
+// packed channels whatever their type and positions are rounded to a tenth of a millimeter.
+func (h *pcdHeader) binaryValue(field int, buf []byte) float64 {
+	if field == h.columns.colorField {
+		return float64(binary.LittleEndian.Uint32(buf))
+	}
+	v := pcdValue(buf, h.valTypes[field], h.size[field])
+	if offset := h.offsets[field]; offset == h.columns.x || offset == h.columns.y || offset == h.columns.z {
+		return math.Round(v*10000) / 10000
+	}
+	return v
+}
+
+// fieldIndex returns the index of the named field or -1 if the pcd does not have it.
+func (h *pcdHeader) fieldIndex(name string) int {
+	for i, field := range h.fieldNames {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // PCType is the type of point cloud to read the PCD file into.
 type PCType int
 
@@ -577,8 +990,7 @@ func readPCDHelper(inRaw io.Reader, pctype PCType) (PointCloud, error) {
 	case PCDBinary:
 		return readPCDBinary(in, *header, pc)
 	case PCDCompressed:
-		// return readPCDCompressed(in, header)
-		return nil, errors.New("compressed pcd not yet supported")
+		return readPCDCompressed(in, *header, pc)
 	default:
 		return nil, fmt.Errorf("unsupported pcd data type %v", header.data)
 	}
@@ -589,24 +1001,18 @@ func extractPCDPointASCII(in *bufio.Reader, header pcdHeader, i int) (PointAndDa
 	if err != nil {
 		return PointAndData{}, err
 	}
-	line = strings.TrimSpace(line)
-	tokens := strings.Split(line, " ")
-	if len(tokens) != int(header.fields) {
+	tokens := strings.Fields(line)
+	if len(tokens) != header.numValues {
 		return PointAndData{}, fmt.Errorf("unexpected number of fields in point %d", i)
 	}
-	point := make([]float64, len(tokens))
+	values := make([]float64, len(tokens))
 	for j, token := range tokens {
-		point[j], err = strconv.ParseFloat(token, 64)
+		values[j], err = strconv.ParseFloat(token, 64)
 		if err != nil {
 			return PointAndData{}, fmt.Errorf("invalid point %d field %s: %w", i, token, err)
 		}
 	}
-	pcPoint, data, err := readSliceToPoint(point, header)
-	if err != nil {
-		return PointAndData{}, err
-	}
-
-	return PointAndData{P: pcPoint, D: data}, nil
+	return pcdPoint(values, header.columns), nil
 }
 
 func readPCDASCII(in *bufio.Reader, header pcdHeader, pc PointCloud) (PointCloud, error) {
@@ -624,33 +1030,23 @@ func readPCDASCII(in *bufio.Reader, header pcdHeader, pc PointCloud) (PointCloud
 }
 
 func extractPCDPointBinary(in *bufio.Reader, header pcdHeader) (PointAndData, error) {
-	var err error
-	pointBuf := make([]float64, 3)
-	colorData := NewBasicData()
-	for j := 0; j < 3; j++ {
-		buf, err := readBuffer(in, header, j)
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return PointAndData{}, err
-		}
-		pointBuf[j] = readFloat(binary.LittleEndian.Uint32(buf))
+	rowSize := 0
+	for i := range header.fieldNames {
+		rowSize += int(header.size[i] * header.count[i])
 	}
-
-	// Converts PCD units (meters) to millimeters for RDK
-	point := r3.Vector{X: 1000. * pointBuf[0], Y: 1000. * pointBuf[1], Z: 1000. * pointBuf[2]}
-
-	if header.fields == pcdPointColor && !errors.Is(err, io.EOF) {
-		buf, err := readBuffer(in, header, 3)
-		if err != nil {
-			return PointAndData{}, err
+	buf := make([]byte, rowSize)
+	if _, err := io.ReadFull(in, buf); err != nil {
+		return PointAndData{}, err
+	}
+	values := make([]float64, header.numValues)
+	offset := 0
+	for i := range header.fieldNames {
+		for j := 0; j < int(header.count[i]); j++ {
+			values[header.offsets[i]+j] = header.binaryValue(i, buf[offset:])
+			offset += int(header.size[i])
 		}
-		colorBuf := int(binary.LittleEndian.Uint32(buf))
-		colorData = NewColoredData(_pcdIntToColor(colorBuf))
 	}
-
-	return PointAndData{P: point, D: colorData}, nil
+	return pcdPoint(values, header.columns), nil
 }
 
 func readPCDBinary(in *bufio.Reader, header pcdHeader, pc PointCloud) (PointCloud, error) {
@@ -670,6 +1066,125 @@ func readPCDBinary(in *bufio.Reader, header pcdHeader, pc PointCloud) (PointClou
 	return pc, nil
 }
 
+// binary_compressed data is the size of the compressed data and the size of the uncompressed data as
+// two little endian uint32s followed by the LZF compressed data. The uncompressed data is stored by
+// column, all the values of the first field followed by all the values of the second and so on.
+
+func extractPCDPointsCompressed(in *bufio.Reader, header pcdHeader) ([]PointAndData, error) {
+	if len(header.size) != len(header.fieldNames) || len(header.count) != len(header.fieldNames) ||
+		len(header.offsets) != len(header.fieldNames) {
+		return nil, errors.New("pcd header SIZE and COUNT do not match its FIELDS")
+	}
+	// the sizes in the file are not trusted, the data must be exactly what the header describes
+	pointSize := uint64(0)
+	for i := range header.fieldNames {
+		pointSize += header.size[i] * header.count[i]
+	}
+	if pointSize != 0 && header.points > math.MaxUint32/pointSize {
+		return nil, fmt.Errorf("compressed pcd of %d points of %d bytes is too large", header.points, pointSize)
+	}
+	dataSize := int(header.points * pointSize)
+
+	sizes := make([]byte, 8)
+	if _, err := io.ReadFull(in, sizes); err != nil {
+		return nil, fmt.Errorf("error reading compressed pcd sizes: %w", err)
+	}
+	compressedSize := int64(binary.LittleEndian.Uint32(sizes))
+	uncompressedSize := int(binary.LittleEndian.Uint32(sizes[4:]))
+	if uncompressedSize != dataSize {
+		return nil, fmt.Errorf("compressed pcd data has %d bytes but its fields need %d", uncompressedSize, dataSize)
+	}
+	if compressedSize > int64(dataSize+dataSize/lzfMaxLiteral+1) || int64(dataSize) > compressedSize*lzfMaxExpansion {
+		return nil, fmt.Errorf("compressed pcd data of %d bytes cannot decompress to %d bytes", compressedSize, dataSize)
+	}
+	// read rather than allocated up front, so that a truncated file does not allocate its whole size
+	compressed, err := io.ReadAll(io.LimitReader(in, compressedSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading compressed pcd data: %w", err)
+	}
+	if int64(len(compressed)) != compressedSize {
+		return nil, fmt.Errorf("error reading compressed pcd data: %w", io.ErrUnexpectedEOF)
+	}
+	data, err := lzfDecompress(compressed, dataSize)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]int, len(header.fieldNames))
+	offset := 0
+	for i := range header.fieldNames {
+		columns[i] = offset
+		offset += int(header.size[i]*header.count[i]) * int(header.points)
+	}
+
+	values := make([]float64, int(header.points)*header.numValues)
+	for field := range header.fieldNames {
+		size := int(header.size[field])
+		count := int(header.count[field])
+		for i := 0; i < int(header.points); i++ {
+			for j := 0; j < count; j++ {
+				start := columns[field] + (i*count+j)*size
+				values[i*header.numValues+header.offsets[field]+j] = header.binaryValue(field, data[start:start+size])
+			}
+		}
+	}
+
+	points := make([]PointAndData, header.points)
+	for i := range points {
+		points[i] = pcdPoint(values[i*header.numValues:(i+1)*header.numValues], header.columns)
+	}
+	return points, nil
+}
+
+func readPCDCompressed(in *bufio.Reader, header pcdHeader, pc PointCloud) (PointCloud, error) {
+	points, err := extractPCDPointsCompressed(in, header)
+	if err != nil {
+		return nil, err
+	}
+	for _, pd := range points {
+		if err := pc.Set(pd.P, pd.D); err != nil {
+			return nil, err
+		}
+	}
+	return pc, nil
+}
+
+func writePCDCompressed(cloud PointCloud, out io.Writer, fields []pcdField) error {
+	size := cloud.Size()
+	// the start of the column of each field
+	columns := make([]int, len(fields))
+	rowSize := 0
+	for i, field := range fields {
+		columns[i] = rowSize * size
+		rowSize += field.size
+	}
+	data := make([]byte, rowSize*size)
+	i := 0
+	cloud.Iterate(0, 0, func(pos r3.Vector, d Data) bool {
+		if i >= size {
+			return false
+		}
+		if d == nil {
+			d = NewBasicData()
+		}
+		for j, field := range fields {
+			putPCDValue(data[columns[j]+i*field.size:], field.valType, field.size, field.value(pos, d))
+		}
+		i++
+		return true
+	})
+
+	compressed := lzfCompress(data)
+	sizes := make([]byte, 8)
+	binary.LittleEndian.PutUint32(sizes, uint32(len(compressed)))
+	binary.LittleEndian.PutUint32(sizes[4:], uint32(len(data)))
+	if _, err := out.Write(sizes); err != nil {
+		return err
+	}
+	_, err := out.Write(compressed)
+	return err
+}
+
 func parsePCDMetaData(in bufio.Reader, header pcdHeader) (MetaData, error) {
 	meta := NewMetaData()
 	switch header.data {
@@ -691,8 +1206,13 @@ func parsePCDMetaData(in bufio.Reader, header pcdHeader) (MetaData, error) {
 			meta.Merge(pd.P, pd.D)
 		}
 	case PCDCompressed:
-		// return readPCDCompressed(in, header)
-		return MetaData{}, errors.New("compressed pcd not yet supported")
+		points, err := extractPCDPointsCompressed(&in, header)
+		if err != nil {
+			return MetaData{}, err
+		}
+		for _, pd := range points {
+			meta.Merge(pd.P, pd.D)
+		}
 	default:
 		return MetaData{}, fmt.Errorf("unsupported pcd data type %v", header.data)
 	}
@@ -709,32 +1229,3 @@ func GetPCDMetaData(inRaw io.Reader) (MetaData, error) {
 	}
 	return parsePCDMetaData(*in, *header)
 }
-
-// reads a specified amount of bytes from a buffer. The number of bytes specified is defined from the pcd.
-func readBuffer(in *bufio.Reader, header pcdHeader, index int) ([]byte, error) {
-	buf := make([]byte, header.size[index])
-	read, err := io.ReadFull(in, buf)
-	if err != nil {
-		return nil, err
-	}
-	if read != int(header.size[index]) {
-		return nil, fmt.Errorf("unexpected number of bytes read %d", read)
-	}
-	return buf, nil
-}
-
-func readSliceToPoint(slice []float64, header pcdHeader) (r3.Vector, Data, error) {
-	// multiply by 1000 as RDK uses millimeters and PCD expects meters
-	pos := r3.Vector{X: 1000. * slice[0], Y: 1000. * slice[1], Z: 1000. * slice[2]}
-	switch header.fields {
-	// This can be expanded to support more field types if needed.
-	case pcdPointOnly:
-		return pos, NewBasicData(), nil
-
-	case pcdPointColor:
-		color := NewColoredData(_pcdIntToColor(int(slice[3])))
-		return pos, color, nil
-	default:
-		return r3.Vector{}, nil, fmt.Errorf("unsupported pcd field type %d", header.fields)
-	}
-}
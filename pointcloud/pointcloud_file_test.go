@@ -1,17 +1,24 @@
 package pointcloud
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"image/color"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
 	"go.viam.com/test"
 	"go.viam.com/utils/artifact"
+
+	"go.viam.com/rdk/utils"
 )
 
 func BenchmarkNewFromFile(b *testing.B) {
@@ -41,6 +48,34 @@ func TestNewFromFile(t *testing.T) {
 	test.That(t, nextCloud, test.ShouldResemble, cloud)
 }
 
+func TestFileFormats(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	cloud := newAttributedCloud(t)
+	for _, ext := range []string{".pcd", ".ply", ".xyz", ".txt", ".csv", ".e57", ".PLY"} {
+		fn := filepath.Join(t.TempDir(), "cloud"+ext)
+		test.That(t, WriteToFile(cloud, fn), test.ShouldBeNil)
+		cloud2, err := NewFromFile(fn, logger)
+		test.That(t, err, test.ShouldBeNil)
+		testPCDOutput(t, cloud2)
+	}
+	test.That(t, WriteToFile(cloud, filepath.Join(t.TempDir(), "cloud.obj")), test.ShouldNotBeNil)
+	_, err := NewFromFile(filepath.Join(t.TempDir(), "cloud.obj"), logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewFromFile(filepath.Join(t.TempDir(), "missing.ply"), logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	for _, mimeType := range []string{utils.MimeTypePCD, utils.MimeTypePLY, utils.MimeTypeXYZ, utils.MimeTypeE57} {
+		var buf bytes.Buffer
+		test.That(t, WriteWithMIMEType(cloud, &buf, mimeType), test.ShouldBeNil)
+		cloud2, err := ReadWithMIMEType(&buf, mimeType)
+		test.That(t, err, test.ShouldBeNil)
+		testPCDOutput(t, cloud2)
+	}
+	test.That(t, WriteWithMIMEType(cloud, &bytes.Buffer{}, utils.MimeTypeJPEG), test.ShouldNotBeNil)
+	_, err = ReadWithMIMEType(&bytes.Buffer{}, utils.MimeTypeJPEG)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
 func TestPCD(t *testing.T) {
 	cloud := New()
 	test.That(t, cloud.Set(NewVector(-1, -2, 5), NewColoredData(color.NRGBA{255, 1, 2, 255}).SetValue(5)), test.ShouldBeNil)
@@ -66,6 +101,7 @@ func TestPCD(t *testing.T) {
 	testPCDHeaders(t)
 	testASCIIRoundTrip(t, cloud)
 	testBinaryRoundTrip(t, cloud)
+	testCompressedRoundTrip(t, cloud)
 }
 
 func testPCDHeaders(t *testing.T) {
@@ -159,6 +195,23 @@ func TestPCDNoColor(t *testing.T) {
 	testNoColorASCIIRoundTrip(t, cloud)
 	testNoColorBinaryRoundTrip(t, cloud)
 	testLargeBinaryNoError(t)
+
+	var buf bytes.Buffer
+	test.That(t, ToPCD(cloud, &buf, PCDCompressed), test.ShouldBeNil)
+	test.That(t, buf.String(), test.ShouldContainSubstring, "FIELDS x y z\n")
+	cloud2, err := ReadPCD(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	testPCDOutput(t, cloud2)
+	data, dataFlag := cloud2.At(-1, -2, 5)
+	test.That(t, dataFlag, test.ShouldBeTrue)
+	test.That(t, data.HasColor(), test.ShouldBeFalse)
+
+	buf.Reset()
+	largeCloud := newBigPC()
+	test.That(t, ToPCD(largeCloud, &buf, PCDCompressed), test.ShouldBeNil)
+	cloud2, err = ReadPCD(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud2.Size(), test.ShouldEqual, largeCloud.Size())
 }
 
 func testNoColorASCIIRoundTrip(t *testing.T, cloud PointCloud) {
@@ -254,6 +307,266 @@ func testBinaryRoundTrip(t *testing.T, cloud PointCloud) {
 	test.That(t, b, test.ShouldEqual, 2)
 }
 
+func testCompressedRoundTrip(t *testing.T, cloud PointCloud) {
+	t.Helper()
+	// write to .pcd
+	var buf bytes.Buffer
+	err := ToPCD(cloud, &buf, PCDCompressed)
+	test.That(t, err, test.ShouldBeNil)
+	gotPCD := buf.String()
+	test.That(t, gotPCD, test.ShouldContainSubstring, "POINTS 3\n")
+	test.That(t, gotPCD, test.ShouldContainSubstring, "DATA binary_compressed\n")
+
+	cloud2, err := ReadPCD(strings.NewReader(gotPCD))
+	test.That(t, err, test.ShouldBeNil)
+	testPCDOutput(t, cloud2)
+	data, dataFlag := cloud2.At(-1, -2, 5)
+	test.That(t, dataFlag, test.ShouldBeTrue)
+	test.That(t, data.HasColor(), test.ShouldBeTrue)
+	r, g, b := data.RGB255()
+	test.That(t, r, test.ShouldEqual, 255)
+	test.That(t, g, test.ShouldEqual, 1)
+	test.That(t, b, test.ShouldEqual, 2)
+
+	kd, err := ReadPCDToKDTree(strings.NewReader(gotPCD))
+	test.That(t, err, test.ShouldBeNil)
+	testPCDOutput(t, kd)
+
+	basicOct, err := ReadPCDToBasicOctree(strings.NewReader(gotPCD))
+	test.That(t, err, test.ShouldBeNil)
+	testPCDOutput(t, basicOct)
+
+	meta, err := GetPCDMetaData(strings.NewReader(gotPCD))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, meta.HasColor, test.ShouldBeTrue)
+	test.That(t, meta.MaxX, test.ShouldEqual, 582)
+}
+
+func TestPCDCompressedExtraFields(t *testing.T) {
+	// columns of two points with fields x y z as doubles, intensity, a normal with a COUNT of 3 and rgb
+	// stored as a float like PCL does
+	var data bytes.Buffer
+	for _, v := range []float64{0.001, 0.582, -0.002, 0.012, 0.005, 0} {
+		test.That(t, binary.Write(&data, binary.LittleEndian, v), test.ShouldBeNil)
+	}
+	for _, v := range []float32{0.5, 0.7, 1, 0, 0, 0, 1, 0} {
+		test.That(t, binary.Write(&data, binary.LittleEndian, v), test.ShouldBeNil)
+	}
+	for _, c := range []uint32{0x0a0b0c, 0x010203} {
+		test.That(t, binary.Write(&data, binary.LittleEndian, c), test.ShouldBeNil)
+	}
+	compressed := lzfCompress(data.Bytes())
+
+	var buf bytes.Buffer
+	buf.WriteString("# .PCD v0.7 - Point Cloud Data file format\n" +
+		"VERSION 0.7\n" +
+		"FIELDS x y z intensity normal rgb\n" +
+		"SIZE 8 8 8 4 4 4\n" +
+		//nolint:dupword
+		"TYPE F F F F F F\n" +
+		"COUNT 1 1 1 1 3 1\n" +
+		"WIDTH 2\n" +
+		"HEIGHT 1\n" +
+		"VIEWPOINT 0 0 0 1 0 0 0\n" +
+		"POINTS 2\n" +
+		"DATA binary_compressed\n")
+	test.That(t, binary.Write(&buf, binary.LittleEndian, uint32(len(compressed))), test.ShouldBeNil)
+	test.That(t, binary.Write(&buf, binary.LittleEndian, uint32(data.Len())), test.ShouldBeNil)
+	buf.Write(compressed)
+
+	cloud, err := ReadPCD(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud.Size(), test.ShouldEqual, 2)
+	d, ok := cloud.At(1, -2, 5)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Color(), test.ShouldResemble, &color.NRGBA{10, 11, 12, 255})
+	test.That(t, d.Intensity(), test.ShouldEqual, 1)
+	test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{X: 1, Y: 0, Z: 0})
+	d, ok = cloud.At(582, 12, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Color(), test.ShouldResemble, &color.NRGBA{1, 2, 3, 255})
+	test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{X: 0, Y: 1, Z: 0})
+
+	// truncated data
+	_, err = ReadPCD(bytes.NewReader(buf.Bytes()[:buf.Len()-4]))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// sizes in the file are checked against the header before anything is allocated, points with a
+	// normal COUNT of 3 have 44 bytes
+	hostileHeader := func(points, normalCount int) string {
+		return fmt.Sprintf("VERSION 0.7\nFIELDS x y z intensity normal rgb\nSIZE 8 8 8 4 4 4\nTYPE F F F F F F\n"+
+			"COUNT 1 1 1 1 %d 1\nWIDTH %d\nHEIGHT 1\nVIEWPOINT 0 0 0 1 0 0 0\nPOINTS %d\nDATA binary_compressed\n",
+			normalCount, points, points)
+	}
+	for _, tc := range []struct {
+		points      int
+		normalCount int
+		sizes       [2]uint32
+		err         string
+	}{
+		{2, 3, [2]uint32{math.MaxUint32, math.MaxUint32}, "compressed pcd data has 4294967295 bytes but its fields need 88"},
+		{2, 1 << 30, [2]uint32{1, 1}, "invalid COUNT field 1073741824"},
+		{2, 1 << 14, [2]uint32{1, 1}, "pcd points of 65568 bytes are larger than the 65536 bytes supported"},
+		{20000, 3, [2]uint32{1, 880000}, "compressed pcd data of 1 bytes cannot decompress to 880000 bytes"},
+		{20000, 3, [2]uint32{20000, 880000}, "unexpected EOF"},
+	} {
+		var hostile bytes.Buffer
+		hostile.WriteString(hostileHeader(tc.points, tc.normalCount))
+		test.That(t, binary.Write(&hostile, binary.LittleEndian, tc.sizes), test.ShouldBeNil)
+		_, err = ReadPCD(bytes.NewReader(hostile.Bytes()))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, tc.err)
+	}
+
+	header := pcdHeader{fieldNames: []string{"x", "y", "z"}, size: []uint64{4, 4}}
+	_, err = extractPCDPointsCompressed(bufio.NewReader(bytes.NewReader(nil)), header)
+	test.That(t, err, test.ShouldBeError, errors.New("pcd header SIZE and COUNT do not match its FIELDS"))
+}
+
+func TestPCDMalformedCount(t *testing.T) {
+	header := func(count, data string) string {
+		return "VERSION 0.7\nFIELDS x y z\nSIZE 4 4 4\nTYPE F F F\nCOUNT " + count +
+			"\nWIDTH 1\nHEIGHT 1\nVIEWPOINT 0 0 0 1 0 0 0\nPOINTS 1\nDATA " + data + "\n"
+	}
+
+	_, err := ReadPCD(strings.NewReader(header("1 1 0", "ascii") + "0 0\n"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid COUNT field 0")
+
+	_, err = ReadPCD(strings.NewReader(header("1 1 18446744073709551615", "binary") + "0000"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid COUNT field 18446744073709551615")
+
+	_, err = ReadPCD(strings.NewReader(header("1 1 20000", "binary") + "0000"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "larger than the 65536 bytes supported")
+}
+
+func TestPCDAttributes(t *testing.T) {
+	cloud := New()
+	test.That(t, cloud.Set(NewVector(-1, -2, 5),
+		NewColoredData(color.NRGBA{255, 1, 2, 255}).SetIntensity(100).SetNormal(r3.Vector{Z: 1}).
+			SetLabel(3).SetTimestamp(1689000000.123456)), test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(582, 12, 0),
+		NewColoredData(color.NRGBA{10, 20, 30, 255}).SetIntensity(math.MaxUint16).SetNormal(r3.Vector{X: 0.5, Y: -0.25}).
+			SetLabel(math.MaxUint32).SetTimestamp(1689000000.5)), test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(7, 6, 1),
+		NewBasicData().SetIntensity(0).SetNormal(r3.Vector{Y: -1}).SetLabel(0).SetTimestamp(0)), test.ShouldBeNil)
+
+	for _, pcdType := range []PCDType{PCDAscii, PCDBinary, PCDCompressed} {
+		// only the positions and colors are written out unless asked
+		var buf bytes.Buffer
+		test.That(t, ToPCD(cloud, &buf, pcdType), test.ShouldBeNil)
+		test.That(t, buf.String(), test.ShouldContainSubstring, "FIELDS x y z rgb\nSIZE 4 4 4 4\nTYPE F F F I\n")
+		cloud2, err := ReadPCD(bytes.NewReader(buf.Bytes()))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cloud2.MetaData().HasIntensity, test.ShouldBeFalse)
+
+		buf.Reset()
+		test.That(t, ToPCDWithAttributes(cloud, &buf, pcdType), test.ShouldBeNil)
+		test.That(t, buf.String(), test.ShouldContainSubstring,
+			"FIELDS x y z rgb intensity normal_x normal_y normal_z label timestamp\n"+
+				"SIZE 4 4 4 4 2 4 4 4 4 8\n"+
+				"TYPE F F F I U F F F U F\n")
+
+		cloud2, err = ReadPCD(bytes.NewReader(buf.Bytes()))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cloud2.Size(), test.ShouldEqual, 3)
+		meta := cloud2.MetaData()
+		test.That(t, meta.HasColor, test.ShouldBeTrue)
+		test.That(t, meta.HasIntensity, test.ShouldBeTrue)
+		test.That(t, meta.HasNormal, test.ShouldBeTrue)
+		test.That(t, meta.HasLabel, test.ShouldBeTrue)
+		test.That(t, meta.HasTimestamp, test.ShouldBeTrue)
+
+		d, ok := cloud2.At(-1, -2, 5)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Color(), test.ShouldResemble, &color.NRGBA{255, 1, 2, 255})
+		test.That(t, d.Intensity(), test.ShouldEqual, 100)
+		test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Z: 1})
+		test.That(t, d.Label(), test.ShouldEqual, 3)
+		test.That(t, d.Timestamp(), test.ShouldEqual, 1689000000.123456)
+
+		d, ok = cloud2.At(582, 12, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, math.MaxUint16)
+		test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{X: 0.5, Y: -0.25})
+		test.That(t, d.Label(), test.ShouldEqual, math.MaxUint32)
+		test.That(t, d.Timestamp(), test.ShouldEqual, 1689000000.5)
+
+		d, ok = cloud2.At(7, 6, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasIntensity(), test.ShouldBeTrue)
+		test.That(t, d.HasLabel(), test.ShouldBeTrue)
+		test.That(t, d.HasTimestamp(), test.ShouldBeTrue)
+		test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Y: -1})
+	}
+
+	// fields are read whatever their type and PCL's float intensities are rounded
+	cloud2, err := ReadPCD(strings.NewReader("VERSION .7\n" +
+		"FIELDS x y z intensity label timestamp\n" +
+		"SIZE 8 8 8 4 2 4\n" +
+		"TYPE F F F F I F\n" +
+		"COUNT 1 1 1 1 1 1\n" +
+		"WIDTH 1\n" +
+		"HEIGHT 1\n" +
+		"VIEWPOINT 0 0 0 1 0 0 0\n" +
+		"POINTS 1\n" +
+		"DATA ascii\n" +
+		"0.001 0.002 0.003 12.7 -1 0.25\n"))
+	test.That(t, err, test.ShouldBeNil)
+	d, ok := cloud2.At(1, 2, 3)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Intensity(), test.ShouldEqual, 13)
+	test.That(t, d.Label(), test.ShouldEqual, 0)
+	test.That(t, d.HasNormal(), test.ShouldBeFalse)
+	test.That(t, d.Timestamp(), test.ShouldEqual, 0.25)
+
+	// unsupported field types
+	_, err = ReadPCD(strings.NewReader("VERSION .7\nFIELDS x y z label\nSIZE 4 4 4 3\nTYPE F F F U\nCOUNT 1 1 1 1\n" +
+		"WIDTH 1\nHEIGHT 1\nVIEWPOINT 0 0 0 1 0 0 0\nPOINTS 1\nDATA ascii\n0 0 0 1\n"))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "unsupported pcd field label")
+}
+
+func TestLASAttributes(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	for _, withColor := range []bool{false, true} {
+		cloud := New()
+		d := NewBasicData().SetIntensity(100).SetNormal(r3.Vector{X: 0.5, Y: -0.25}).SetLabel(7).SetTimestamp(1689000000.123456)
+		if withColor {
+			d.SetColor(color.NRGBA{255, 1, 2, 255})
+		}
+		test.That(t, cloud.Set(NewVector(-1, -2, 5), d), test.ShouldBeNil)
+		test.That(t, cloud.Set(NewVector(582, 12, 0),
+			NewBasicData().SetIntensity(7).SetNormal(r3.Vector{Z: 1}).SetLabel(math.MaxUint32).SetTimestamp(2)), test.ShouldBeNil)
+
+		fn := filepath.Join(t.TempDir(), "attributes.las")
+		test.That(t, WriteToLASFile(cloud, fn), test.ShouldBeNil)
+		cloud2, err := NewFromFile(fn, logger)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cloud2.Size(), test.ShouldEqual, 2)
+		test.That(t, cloud2.MetaData().HasColor, test.ShouldEqual, withColor)
+
+		got, ok := cloud2.At(-1, -2, 5)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, got.Intensity(), test.ShouldEqual, 100)
+		test.That(t, got.Normal(), test.ShouldResemble, r3.Vector{X: 0.5, Y: -0.25})
+		test.That(t, got.Label(), test.ShouldEqual, 7)
+		test.That(t, got.Timestamp(), test.ShouldEqual, 1689000000.123456)
+		if withColor {
+			test.That(t, got.Color(), test.ShouldResemble, &color.NRGBA{255, 1, 2, 255})
+		}
+
+		got, ok = cloud2.At(582, 12, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, got.Intensity(), test.ShouldEqual, 7)
+		test.That(t, got.Normal(), test.ShouldResemble, r3.Vector{Z: 1})
+		test.That(t, got.Label(), test.ShouldEqual, math.MaxUint32)
+		test.That(t, got.Timestamp(), test.ShouldEqual, 2)
+	}
+}
+
 func testLargeBinaryNoError(t *testing.T) {
 	// This tests whether large pointclouds that exceed the usual buffered page size for a file error on reads
 	t.Helper()
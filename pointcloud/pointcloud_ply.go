@@ -0,0 +1,449 @@
+package pointcloud
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// PLYType is the format of a ply file.
+type PLYType int
+
+const (
+	// PLYAscii ascii format for ply.
+	PLYAscii PLYType = 0
+	// PLYBinary little endian binary format for ply.
+	PLYBinary PLYType = 1
+)
+
+// plyTypes maps the names of ply property types to their sizes in bytes, both the original and the
+// sized names are allowed.
+var plyTypes = map[string]int{
+	"char": 1, "int8": 1,
+	"uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2,
+	"ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4,
+	"uint": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+type plyProperty struct {
+	name    string
+	valType string
+	// countType is the type of the count of a list property, empty for other properties.
+	countType string
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+type plyHeader struct {
+	format   string
+	elements []plyElement
+}
+
+func parsePLYHeader(in *bufio.Reader) (*plyHeader, error) {
+	line, err := in.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading ply magic number")
+	}
+	if strings.TrimSpace(line) != "ply" {
+		return nil, errors.New("ply file must start with ply")
+	}
+
+	header := &plyHeader{}
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading ply header")
+		}
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		switch tokens[0] {
+		case "comment", "obj_info":
+		case "format":
+			if len(tokens) != 3 {
+				return nil, errors.Errorf("invalid ply format line %q", strings.TrimSpace(line))
+			}
+			switch tokens[1] {
+			case "ascii", "binary_little_endian", "binary_big_endian":
+				header.format = tokens[1]
+			default:
+				return nil, errors.Errorf("unsupported ply format %s", tokens[1])
+			}
+		case "element":
+			if len(tokens) != 3 {
+				return nil, errors.Errorf("invalid ply element line %q", strings.TrimSpace(line))
+			}
+			count, err := strconv.Atoi(tokens[2])
+			if err != nil || count < 0 {
+				return nil, errors.Errorf("invalid ply element count %s", tokens[2])
+			}
+			header.elements = append(header.elements, plyElement{name: tokens[1], count: count})
+		case "property":
+			if len(header.elements) == 0 {
+				return nil, errors.New("ply property is not part of an element")
+			}
+			var prop plyProperty
+			switch {
+			case len(tokens) == 5 && tokens[1] == "list":
+				prop = plyProperty{name: tokens[4], valType: tokens[3], countType: tokens[2]}
+				if _, ok := plyTypes[prop.countType]; !ok {
+					return nil, errors.Errorf("unsupported ply property type %s", prop.countType)
+				}
+			case len(tokens) == 3:
+				prop = plyProperty{name: tokens[2], valType: tokens[1]}
+			default:
+				return nil, errors.Errorf("invalid ply property line %q", strings.TrimSpace(line))
+			}
+			if _, ok := plyTypes[prop.valType]; !ok {
+				return nil, errors.Errorf("unsupported ply property type %s", prop.valType)
+			}
+			element := &header.elements[len(header.elements)-1]
+			element.properties = append(element.properties, prop)
+		case "end_header":
+			if header.format == "" {
+				return nil, errors.New("ply header is missing its format")
+			}
+			return header, nil
+		default:
+			return nil, errors.Errorf("unexpected ply header line %q", strings.TrimSpace(line))
+		}
+	}
+}
+
+// plyElementReader reads the values of every property of an element one element at a time.
+type plyElementReader interface {
+	next(element plyElement) ([]float64, error)
+}
+
+type plyASCIIReader struct {
+	in *bufio.Reader
+}
+
+func (r *plyASCIIReader) next(element plyElement) ([]float64, error) {
+	line, err := r.in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return nil, err
+	}
+	tokens := strings.Fields(line)
+	values := make([]float64, 0, len(element.properties))
+	for _, prop := range element.properties {
+		if len(tokens) == 0 {
+			return nil, errors.Errorf("too few values for ply element %s", element.name)
+		}
+		if prop.countType != "" {
+			count, err := strconv.Atoi(tokens[0])
+			if err != nil || count < 0 || count >= len(tokens) {
+				return nil, errors.Errorf("invalid ply list count %s", tokens[0])
+			}
+			// lists are skipped, only their count is kept
+			values = append(values, float64(count))
+			tokens = tokens[count+1:]
+			continue
+		}
+		v, err := strconv.ParseFloat(tokens[0], 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid ply value %s", tokens[0])
+		}
+		values = append(values, v)
+		tokens = tokens[1:]
+	}
+	return values, nil
+}
+
+type plyBinaryReader struct {
+	in    *bufio.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (r *plyBinaryReader) read(valType string) (float64, error) {
+	buf := r.buf[:plyTypes[valType]]
+	if _, err := io.ReadFull(r.in, buf); err != nil {
+		return 0, err
+	}
+	switch valType {
+	case "char", "int8":
+		return float64(int8(buf[0])), nil
+	case "uchar", "uint8":
+		return float64(buf[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(buf))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(buf)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(buf))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(buf)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(buf))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(buf)), nil
+	}
+}
+
+func (r *plyBinaryReader) next(element plyElement) ([]float64, error) {
+	values := make([]float64, 0, len(element.properties))
+	for _, prop := range element.properties {
+		if prop.countType != "" {
+			count, err := r.read(prop.countType)
+			if err != nil {
+				return nil, err
+			}
+			// lists are skipped, only their count is kept
+			if _, err := r.in.Discard(int(count) * plyTypes[prop.valType]); err != nil {
+				return nil, err
+			}
+			values = append(values, count)
+			continue
+		}
+		v, err := r.read(prop.valType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// plyVertexFields are the indices of the vertex properties that are read into a point, -1 if the
+// vertex does not have the property.
+type plyVertexFields struct {
+	x, y, z             int
+	red, green, blue    int
+	nx, ny, nz          int
+	intensity           int
+	coordType           string
+	colorType, intType  string
+	hasColor, hasNormal bool
+}
+
+func newPLYVertexFields(element plyElement) (*plyVertexFields, error) {
+	index := func(names ...string) int {
+		for i, prop := range element.properties {
+			for _, name := range names {
+				if strings.EqualFold(prop.name, name) && prop.countType == "" {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	fields := &plyVertexFields{
+		x:         index("x"),
+		y:         index("y"),
+		z:         index("z"),
+		red:       index("red", "r", "diffuse_red"),
+		green:     index("green", "g", "diffuse_green"),
+		blue:      index("blue", "b", "diffuse_blue"),
+		nx:        index("nx", "normal_x"),
+		ny:        index("ny", "normal_y"),
+		nz:        index("nz", "normal_z"),
+		intensity: index("intensity", "scalar_intensity", "i"),
+	}
+	if fields.x < 0 || fields.y < 0 || fields.z < 0 {
+		return nil, errors.New("ply vertex element must have x, y and z properties")
+	}
+	fields.coordType = element.properties[fields.x].valType
+	fields.hasColor = fields.red >= 0 && fields.green >= 0 && fields.blue >= 0
+	if fields.hasColor {
+		fields.colorType = element.properties[fields.red].valType
+	}
+	fields.hasNormal = fields.nx >= 0 && fields.ny >= 0 && fields.nz >= 0
+	if fields.intensity >= 0 {
+		fields.intType = element.properties[fields.intensity].valType
+	}
+	return fields, nil
+}
+
+// plyColor converts a color channel of the given type to 8 bits, floats are expected in [0, 1].
+func plyColor(v float64, valType string) uint8 {
+	switch valType {
+	case "float", "float32", "double", "float64":
+		v *= 255
+	case "ushort", "uint16":
+		v /= 257
+	}
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
+
+// plyIntensity converts an intensity of the given type to 16 bits, floats are expected in [0, 1].
+func plyIntensity(v float64, valType string) uint16 {
+	switch valType {
+	case "float", "float32", "double", "float64":
+		v *= math.MaxUint16
+	case "uchar", "uint8":
+		v *= 257
+	}
+	return uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(v))))
+}
+
+func (fields *plyVertexFields) point(values []float64) (r3.Vector, Data) {
+	coords := []float64{values[fields.x], values[fields.y], values[fields.z]}
+	if plyTypes[fields.coordType] == 4 {
+		// single precision coordinates are rounded like those of a PCD
+		for i, v := range coords {
+			coords[i] = math.Round(v*10000) / 10000
+		}
+	}
+	// Converts PLY units (meters) to millimeters for RDK
+	pos := r3.Vector{X: 1000. * coords[0], Y: 1000. * coords[1], Z: 1000. * coords[2]}
+	d := NewBasicData()
+	if fields.hasColor {
+		d.SetColor(color.NRGBA{
+			R: plyColor(values[fields.red], fields.colorType),
+			G: plyColor(values[fields.green], fields.colorType),
+			B: plyColor(values[fields.blue], fields.colorType),
+			A: 255,
+		})
+	}
+	if fields.hasNormal {
+		d.SetNormal(r3.Vector{X: values[fields.nx], Y: values[fields.ny], Z: values[fields.nz]})
+	}
+	if fields.intensity >= 0 {
+		d.SetIntensity(plyIntensity(values[fields.intensity], fields.intType))
+	}
+	return pos, d
+}
+
+// ReadPLY reads the vertices of a PLY file into a pointcloud. Positions are expected in meters and
+// the colors, normals and intensities of the vertices are kept. Faces and any other elements are
+// ignored.
+func ReadPLY(inRaw io.Reader) (PointCloud, error) {
+	in := bufio.NewReader(inRaw)
+	header, err := parsePLYHeader(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader plyElementReader
+	switch header.format {
+	case "ascii":
+		reader = &plyASCIIReader{in: in}
+	case "binary_little_endian":
+		reader = &plyBinaryReader{in: in, order: binary.LittleEndian}
+	default:
+		reader = &plyBinaryReader{in: in, order: binary.BigEndian}
+	}
+
+	for _, element := range header.elements {
+		if element.name != "vertex" {
+			// elements before the vertices have to be read past
+			for i := 0; i < element.count; i++ {
+				if _, err := reader.next(element); err != nil {
+					return nil, errors.Wrapf(err, "error reading ply element %s", element.name)
+				}
+			}
+			continue
+		}
+		fields, err := newPLYVertexFields(element)
+		if err != nil {
+			return nil, err
+		}
+		pc := NewWithPrealloc(element.count)
+		for i := 0; i < element.count; i++ {
+			values, err := reader.next(element)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error reading ply vertex %d", i)
+			}
+			if err := pc.Set(fields.point(values)); err != nil {
+				return nil, err
+			}
+		}
+		return pc, nil
+	}
+	return nil, errors.New("ply file has no vertex element")
+}
+
+// ToPLY writes out a point cloud to a PLY file of the specified type. Positions are written in meters
+// along with the colors, normals and intensities of the points when the cloud has them.
+func ToPLY(cloud PointCloud, out io.Writer, outputType PLYType) error {
+	meta := cloud.MetaData()
+
+	var format string
+	switch outputType {
+	case PLYAscii:
+		format = "ascii"
+	case PLYBinary:
+		format = "binary_little_endian"
+	default:
+		return errors.Errorf("unsupported ply type %d", outputType)
+	}
+
+	header := fmt.Sprintf("ply\nformat %s 1.0\nelement vertex %d\nproperty float x\nproperty float y\nproperty float z\n",
+		format, cloud.Size())
+	if meta.HasColor {
+		header += "property uchar red\nproperty uchar green\nproperty uchar blue\n"
+	}
+	if meta.HasNormal {
+		header += "property float nx\nproperty float ny\nproperty float nz\n"
+	}
+	if meta.HasIntensity {
+		header += "property ushort intensity\n"
+	}
+	header += "end_header\n"
+	if _, err := io.WriteString(out, header); err != nil {
+		return err
+	}
+
+	var err error
+	cloud.Iterate(0, 0, func(pos r3.Vector, d Data) bool {
+		if d == nil {
+			d = NewBasicData()
+		}
+		// Converts RDK units (millimeters) to meters for PLY
+		coords := []float64{pos.X / 1000., pos.Y / 1000., pos.Z / 1000.}
+		r, g, b := d.RGB255()
+		normal := d.Normal()
+
+		if outputType == PLYAscii {
+			line := fmt.Sprintf("%f %f %f", coords[0], coords[1], coords[2])
+			if meta.HasColor {
+				line += fmt.Sprintf(" %d %d %d", r, g, b)
+			}
+			if meta.HasNormal {
+				line += fmt.Sprintf(" %f %f %f", normal.X, normal.Y, normal.Z)
+			}
+			if meta.HasIntensity {
+				line += fmt.Sprintf(" %d", d.Intensity())
+			}
+			_, err = io.WriteString(out, line+"\n")
+			return err == nil
+		}
+
+		buf := make([]byte, 0, 29)
+		for _, v := range coords {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+		}
+		if meta.HasColor {
+			buf = append(buf, r, g, b)
+		}
+		if meta.HasNormal {
+			for _, v := range []float64{normal.X, normal.Y, normal.Z} {
+				buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+			}
+		}
+		if meta.HasIntensity {
+			buf = binary.LittleEndian.AppendUint16(buf, d.Intensity())
+		}
+		_, err = out.Write(buf)
+		return err == nil
+	})
+	return err
+}
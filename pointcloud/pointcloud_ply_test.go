@@ -0,0 +1,123 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+// newAttributedCloud returns a cloud whose points have colors, normals and intensities.
+func newAttributedCloud(t *testing.T) PointCloud {
+	t.Helper()
+	cloud := New()
+	test.That(t, cloud.Set(NewVector(-1, -2, 5),
+		NewColoredData(color.NRGBA{255, 1, 2, 255}).SetNormal(r3.Vector{Z: 1}).SetIntensity(100)), test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(582, 12, 0),
+		NewColoredData(color.NRGBA{10, 20, 30, 255}).SetNormal(r3.Vector{X: 1}).SetIntensity(math.MaxUint16)), test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(7, 6, 1),
+		NewColoredData(color.NRGBA{0, 0, 0, 255}).SetNormal(r3.Vector{Y: -1}).SetIntensity(0)), test.ShouldBeNil)
+	return cloud
+}
+
+func testAttributedCloud(t *testing.T, cloud PointCloud) {
+	t.Helper()
+	test.That(t, cloud.Size(), test.ShouldEqual, 3)
+	meta := cloud.MetaData()
+	test.That(t, meta.HasColor, test.ShouldBeTrue)
+	test.That(t, meta.HasNormal, test.ShouldBeTrue)
+
+	d, ok := cloud.At(-1, -2, 5)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{255, 1, 2})
+	test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Z: 1})
+	test.That(t, d.Intensity(), test.ShouldEqual, 100)
+
+	d, ok = cloud.At(582, 12, 0)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b = d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{10, 20, 30})
+	test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{X: 1})
+	test.That(t, d.Intensity(), test.ShouldEqual, math.MaxUint16)
+}
+
+func TestPLYRoundTrip(t *testing.T) {
+	cloud := newAttributedCloud(t)
+	for _, plyType := range []PLYType{PLYAscii, PLYBinary} {
+		var buf bytes.Buffer
+		test.That(t, ToPLY(cloud, &buf, plyType), test.ShouldBeNil)
+		test.That(t, buf.String(), test.ShouldContainSubstring, "element vertex 3\n")
+		test.That(t, buf.String(), test.ShouldContainSubstring, "property uchar red\n")
+		test.That(t, buf.String(), test.ShouldContainSubstring, "property float nx\n")
+		test.That(t, buf.String(), test.ShouldContainSubstring, "property ushort intensity\n")
+
+		cloud2, err := ReadPLY(bytes.NewReader(buf.Bytes()))
+		test.That(t, err, test.ShouldBeNil)
+		testAttributedCloud(t, cloud2)
+	}
+
+	// points without colors, normals or intensities only have positions
+	plain := New()
+	test.That(t, plain.Set(NewVector(1, 2, 3), NewBasicData()), test.ShouldBeNil)
+	var buf bytes.Buffer
+	test.That(t, ToPLY(plain, &buf, PLYAscii), test.ShouldBeNil)
+	test.That(t, buf.String(), test.ShouldEqual, "ply\nformat ascii 1.0\nelement vertex 1\n"+
+		"property float x\nproperty float y\nproperty float z\nend_header\n0.001000 0.002000 0.003000\n")
+}
+
+func TestReadPLY(t *testing.T) {
+	// big endian vertices with double coordinates and float colors after a list element
+	var buf bytes.Buffer
+	buf.WriteString("ply\nformat binary_big_endian 1.0\ncomment made by hand\n" +
+		"element camera 1\nproperty list uchar int ids\n" +
+		"element vertex 2\nproperty double x\nproperty double y\nproperty double z\n" +
+		"property float red\nproperty float green\nproperty float blue\nproperty float intensity\n" +
+		"element face 1\nproperty list uchar int vertex_indices\nend_header\n")
+	test.That(t, binary.Write(&buf, binary.BigEndian, []uint8{2}), test.ShouldBeNil)
+	test.That(t, binary.Write(&buf, binary.BigEndian, []int32{7, 8}), test.ShouldBeNil)
+	test.That(t, binary.Write(&buf, binary.BigEndian, []float64{0.001, 0.002, 0.003}), test.ShouldBeNil)
+	test.That(t, binary.Write(&buf, binary.BigEndian, []float32{1, 0, 0.5, 0.5}), test.ShouldBeNil)
+	test.That(t, binary.Write(&buf, binary.BigEndian, []float64{-1, 0, 1}), test.ShouldBeNil)
+	test.That(t, binary.Write(&buf, binary.BigEndian, []float32{0, 0, 0, 1}), test.ShouldBeNil)
+
+	cloud, err := ReadPLY(bytes.NewReader(buf.Bytes()))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud.Size(), test.ShouldEqual, 2)
+	d, ok := cloud.At(1, 2, 3)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{255, 0, 128})
+	test.That(t, d.Intensity(), test.ShouldEqual, 32768)
+	test.That(t, d.HasNormal(), test.ShouldBeFalse)
+	d, ok = cloud.At(-1000, 0, 1000)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Intensity(), test.ShouldEqual, math.MaxUint16)
+
+	// ascii lists are skipped
+	cloud, err = ReadPLY(strings.NewReader("ply\nformat ascii 1.0\nelement face 1\nproperty list uchar int vertex_indices\n" +
+		"element vertex 1\nproperty float x\nproperty float y\nproperty float z\nend_header\n3 0 1 2\n1 2 3\n"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, CloudContains(cloud, 1000, 2000, 3000), test.ShouldBeTrue)
+
+	for _, bad := range []string{
+		"",
+		"plyx\nformat ascii 1.0\nend_header\n",
+		"ply\nformat ascii 2.0 extra\nend_header\n",
+		"ply\nformat binary_middle_endian 1.0\nend_header\n",
+		"ply\nformat ascii 1.0\nproperty float x\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty quad x\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nend_header\n1 2\n",
+		"ply\nformat ascii 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n1 2 3\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\nend_header\n1 2\n",
+		"ply\nformat ascii 1.0\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n0\n",
+	} {
+		_, err := ReadPLY(strings.NewReader(bad))
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/stat"
 
 	"go.viam.com/rdk/spatialmath"
@@ -40,6 +41,68 @@ func BoundingBoxFromPointCloudWithLabel(cloud PointCloud, label string) (spatial
 	return spatialmath.NewBox(spatialmath.NewPoseFromPoint(mean), dims, label)
 }
 
+// OrientedBoundingBoxFromPointCloud returns a box encompassing all the points in the given point cloud
+// whose axes are the principal components of the points, which fits elongated or tilted clouds much
+// tighter than an axis aligned box. The box's x axis is along the direction in which the points
+// spread the most and its z axis along the one in which they spread the least.
+func OrientedBoundingBoxFromPointCloud(cloud PointCloud, label string) (spatialmath.Geometry, error) {
+	if cloud.Size() == 0 {
+		return nil, nil
+	}
+	points := make([]r3.Vector, 0, cloud.Size())
+	mean := r3.Vector{}
+	cloud.Iterate(0, 0, func(v r3.Vector, d Data) bool {
+		points = append(points, v)
+		mean = mean.Add(v)
+		return true
+	})
+	mean = mean.Mul(1 / float64(len(points)))
+
+	axes := [3]r3.Vector{{X: 1}, {Y: 1}, {Z: 1}}
+	if len(points) > 1 {
+		m := mat.NewDense(len(points), 3, nil)
+		for i, v := range points {
+			m.SetRow(i, []float64{v.X, v.Y, v.Z})
+		}
+		var pc stat.PC
+		if !pc.PrincipalComponents(m, nil) {
+			return nil, errors.New("could not compute the principal components of the point cloud")
+		}
+		var vecs mat.Dense
+		pc.VectorsTo(&vecs)
+		for i := range axes {
+			axes[i] = r3.Vector{X: vecs.At(0, i), Y: vecs.At(1, i), Z: vecs.At(2, i)}
+		}
+		// make the axes right handed so they form a rotation
+		if axes[0].Cross(axes[1]).Dot(axes[2]) < 0 {
+			axes[2] = axes[2].Mul(-1)
+		}
+	}
+
+	// the extents of the points along each axis
+	lo := r3.Vector{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)}
+	hi := r3.Vector{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)}
+	for _, v := range points {
+		centered := v.Sub(mean)
+		local := r3.Vector{X: centered.Dot(axes[0]), Y: centered.Dot(axes[1]), Z: centered.Dot(axes[2])}
+		lo = r3.Vector{X: math.Min(lo.X, local.X), Y: math.Min(lo.Y, local.Y), Z: math.Min(lo.Z, local.Z)}
+		hi = r3.Vector{X: math.Max(hi.X, local.X), Y: math.Max(hi.Y, local.Y), Z: math.Max(hi.Z, local.Z)}
+	}
+	localCenter := lo.Add(hi).Mul(0.5)
+	center := mean.Add(axes[0].Mul(localCenter.X)).Add(axes[1].Mul(localCenter.Y)).Add(axes[2].Mul(localCenter.Z))
+
+	// the rows of the rotation matrices of spatialmath are the axes of the rotated frame
+	rotation, err := spatialmath.NewRotationMatrix([]float64{
+		axes[0].X, axes[0].Y, axes[0].Z,
+		axes[1].X, axes[1].Y, axes[1].Z,
+		axes[2].X, axes[2].Y, axes[2].Z,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spatialmath.NewBox(spatialmath.NewPose(center, rotation), hi.Sub(lo), label)
+}
+
 // PrunePointClouds removes point clouds from a slice if the point cloud has less than nMin points.
 func PrunePointClouds(clouds []PointCloud, nMin int) []PointCloud {
 	pruned := make([]PointCloud, 0, len(clouds))
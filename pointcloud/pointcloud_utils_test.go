@@ -1,6 +1,7 @@
 package pointcloud
 
 import (
+	"math"
 	"testing"
 
 	"github.com/golang/geo/r3"
@@ -59,6 +60,45 @@ func TestBoundingBoxFromPointCloud(t *testing.T) {
 	}
 }
 
+func TestOrientedBoundingBoxFromPointCloud(t *testing.T) {
+	box, err := OrientedBoundingBoxFromPointCloud(New(), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, box, test.ShouldBeNil)
+
+	// a 200x40x10 block turned 30 degrees about z and tilted about x
+	pose := spatialmath.NewPose(r3.Vector{X: 500, Y: -200, Z: 50}, &spatialmath.EulerAngles{Roll: 0.3, Yaw: math.Pi / 6})
+	cloud := New()
+	for x := -100.; x <= 100; x += 10 {
+		for y := -20.; y <= 20; y += 5 {
+			for z := -5.; z <= 5; z += 5 {
+				p := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(r3.Vector{X: x, Y: y, Z: z})).Point()
+				test.That(t, cloud.Set(p, nil), test.ShouldBeNil)
+			}
+		}
+	}
+	box, err = OrientedBoundingBoxFromPointCloud(cloud, "block")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, box.Label(), test.ShouldEqual, "block")
+	test.That(t, spatialmath.R3VectorAlmostEqual(box.Pose().Point(), pose.Point(), 1e-6), test.ShouldBeTrue)
+	dims := box.ToProtobuf().GetBox().GetDimsMm()
+	test.That(t, dims.X, test.ShouldAlmostEqual, 200)
+	test.That(t, dims.Y, test.ShouldAlmostEqual, 40)
+	test.That(t, dims.Z, test.ShouldAlmostEqual, 10)
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		// points on the faces of the box are off of them by rounding errors
+		distance, err := spatialmath.NewPoint(p, "").DistanceFrom(box)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, distance, test.ShouldBeLessThan, 1e-6)
+		return true
+	})
+
+	// the axis aligned box is much looser
+	aligned, err := BoundingBoxFromPointCloud(cloud)
+	test.That(t, err, test.ShouldBeNil)
+	alignedDims := aligned.ToProtobuf().GetBox().GetDimsMm()
+	test.That(t, alignedDims.X*alignedDims.Y*alignedDims.Z, test.ShouldBeGreaterThan, 5*dims.X*dims.Y*dims.Z)
+}
+
 func TestPrune(t *testing.T) {
 	clouds := makeClouds(t)
 	// before prune
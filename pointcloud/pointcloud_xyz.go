@@ -0,0 +1,217 @@
+package pointcloud
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// xyzColumns are the indices of the columns of an XYZ file that are read into a point, -1 if the file
+// does not have the column.
+type xyzColumns struct {
+	x, y, z          int
+	red, green, blue int
+	nx, ny, nz       int
+	intensity        int
+	count            int
+}
+
+// xyzColumnsByCount are the columns assumed for files without a header line, by the number of values
+// on a line.
+var xyzColumnsByCount = map[int]xyzColumns{
+	3: {x: 0, y: 1, z: 2, red: -1, green: -1, blue: -1, nx: -1, ny: -1, nz: -1, intensity: -1, count: 3},
+	4: {x: 0, y: 1, z: 2, red: -1, green: -1, blue: -1, nx: -1, ny: -1, nz: -1, intensity: 3, count: 4},
+	6: {x: 0, y: 1, z: 2, red: 3, green: 4, blue: 5, nx: -1, ny: -1, nz: -1, intensity: -1, count: 6},
+	7: {x: 0, y: 1, z: 2, red: 4, green: 5, blue: 6, nx: -1, ny: -1, nz: -1, intensity: 3, count: 7},
+}
+
+func newXYZColumnsFromHeader(names []string) (xyzColumns, error) {
+	index := func(aliases ...string) int {
+		for i, name := range names {
+			for _, alias := range aliases {
+				if strings.EqualFold(name, alias) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	columns := xyzColumns{
+		x:         index("x"),
+		y:         index("y"),
+		z:         index("z"),
+		red:       index("red", "r"),
+		green:     index("green", "g"),
+		blue:      index("blue", "b"),
+		nx:        index("nx", "normal_x"),
+		ny:        index("ny", "normal_y"),
+		nz:        index("nz", "normal_z"),
+		intensity: index("intensity", "i", "scalar_intensity"),
+		count:     len(names),
+	}
+	if columns.x < 0 || columns.y < 0 || columns.z < 0 {
+		return xyzColumns{}, errors.Errorf("xyz header %q must name x, y and z columns", strings.Join(names, " "))
+	}
+	if columns.red < 0 || columns.green < 0 || columns.blue < 0 {
+		columns.red, columns.green, columns.blue = -1, -1, -1
+	}
+	if columns.nx < 0 || columns.ny < 0 || columns.nz < 0 {
+		columns.nx, columns.ny, columns.nz = -1, -1, -1
+	}
+	return columns, nil
+}
+
+// splitXYZLine splits a line on commas, semicolons or whitespace.
+func splitXYZLine(line string) []string {
+	var tokens []string
+	switch {
+	case strings.Contains(line, ","):
+		tokens = strings.Split(line, ",")
+	case strings.Contains(line, ";"):
+		tokens = strings.Split(line, ";")
+	default:
+		return strings.Fields(line)
+	}
+	for i, token := range tokens {
+		tokens[i] = strings.TrimSpace(token)
+	}
+	return tokens
+}
+
+// ReadXYZ reads a point cloud from lines of delimited values, positions in meters followed by the
+// optional colors, normals and intensities of the points. Values may be separated by commas, semicolons
+// or whitespace and lines starting with # or // are ignored. A header line naming the columns may be
+// given, otherwise three values are x y z, four x y z intensity, six x y z red green blue and seven
+// x y z intensity red green blue. Colors are 0 to 255.
+func ReadXYZ(inRaw io.Reader) (PointCloud, error) {
+	in := bufio.NewScanner(inRaw)
+	pc := New()
+	var columns *xyzColumns
+	lineNum := 0
+	for in.Scan() {
+		lineNum++
+		line := strings.TrimSpace(in.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		tokens := splitXYZLine(line)
+
+		values := make([]float64, len(tokens))
+		isHeader := false
+		for i, token := range tokens {
+			v, err := strconv.ParseFloat(token, 64)
+			if err != nil {
+				isHeader = true
+				break
+			}
+			values[i] = v
+		}
+		if isHeader {
+			if columns != nil {
+				return nil, errors.Errorf("invalid value on xyz line %d: %q", lineNum, line)
+			}
+			fromHeader, err := newXYZColumnsFromHeader(tokens)
+			if err != nil {
+				return nil, err
+			}
+			columns = &fromHeader
+			continue
+		}
+		if columns == nil {
+			byCount, ok := xyzColumnsByCount[len(values)]
+			if !ok {
+				return nil, errors.Errorf("cannot tell the columns of xyz lines with %d values without a header", len(values))
+			}
+			columns = &byCount
+		}
+		if len(values) != columns.count {
+			return nil, errors.Errorf("expected %d values on xyz line %d, got %d", columns.count, lineNum, len(values))
+		}
+
+		// Converts XYZ units (meters) to millimeters for RDK
+		pos := r3.Vector{X: 1000. * values[columns.x], Y: 1000. * values[columns.y], Z: 1000. * values[columns.z]}
+		d := NewBasicData()
+		if columns.red >= 0 {
+			channel := func(v float64) uint8 {
+				return uint8(math.Max(0, math.Min(255, math.Round(v))))
+			}
+			d.SetColor(color.NRGBA{channel(values[columns.red]), channel(values[columns.green]), channel(values[columns.blue]), 255})
+		}
+		if columns.nx >= 0 {
+			d.SetNormal(r3.Vector{X: values[columns.nx], Y: values[columns.ny], Z: values[columns.nz]})
+		}
+		if columns.intensity >= 0 {
+			d.SetIntensity(uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(values[columns.intensity])))))
+		}
+		if err := pc.Set(pos, d); err != nil {
+			return nil, err
+		}
+	}
+	if err := in.Err(); err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// ToXYZ writes out a point cloud as lines of values separated by delimiter, preceded by a header line
+// naming the columns. Positions are written in meters along with the colors, normals and intensities
+// of the points when the cloud has them.
+func ToXYZ(cloud PointCloud, out io.Writer, delimiter rune) error {
+	meta := cloud.MetaData()
+	sep := string(delimiter)
+
+	names := []string{"x", "y", "z"}
+	if meta.HasColor {
+		names = append(names, "red", "green", "blue")
+	}
+	if meta.HasNormal {
+		names = append(names, "nx", "ny", "nz")
+	}
+	if meta.HasIntensity {
+		names = append(names, "intensity")
+	}
+	if _, err := io.WriteString(out, strings.Join(names, sep)+"\n"); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(out)
+	var err error
+	cloud.Iterate(0, 0, func(pos r3.Vector, d Data) bool {
+		if d == nil {
+			d = NewBasicData()
+		}
+		// Converts RDK units (millimeters) to meters for XYZ
+		values := []string{
+			strconv.FormatFloat(pos.X/1000., 'f', 6, 64),
+			strconv.FormatFloat(pos.Y/1000., 'f', 6, 64),
+			strconv.FormatFloat(pos.Z/1000., 'f', 6, 64),
+		}
+		if meta.HasColor {
+			r, g, b := d.RGB255()
+			values = append(values, fmt.Sprint(r), fmt.Sprint(g), fmt.Sprint(b))
+		}
+		if meta.HasNormal {
+			n := d.Normal()
+			values = append(values,
+				strconv.FormatFloat(n.X, 'f', 6, 64),
+				strconv.FormatFloat(n.Y, 'f', 6, 64),
+				strconv.FormatFloat(n.Z, 'f', 6, 64))
+		}
+		if meta.HasIntensity {
+			values = append(values, fmt.Sprint(d.Intensity()))
+		}
+		_, err = w.WriteString(strings.Join(values, sep) + "\n")
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
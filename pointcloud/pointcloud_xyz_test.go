@@ -0,0 +1,68 @@
+package pointcloud
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestXYZRoundTrip(t *testing.T) {
+	cloud := newAttributedCloud(t)
+	for _, delimiter := range []rune{' ', ','} {
+		var buf bytes.Buffer
+		test.That(t, ToXYZ(cloud, &buf, delimiter), test.ShouldBeNil)
+		header := strings.Join([]string{"x", "y", "z", "red", "green", "blue", "nx", "ny", "nz", "intensity"}, string(delimiter))
+		test.That(t, buf.String(), test.ShouldStartWith, header+"\n")
+
+		cloud2, err := ReadXYZ(bytes.NewReader(buf.Bytes()))
+		test.That(t, err, test.ShouldBeNil)
+		testAttributedCloud(t, cloud2)
+	}
+}
+
+func TestReadXYZ(t *testing.T) {
+	// without a header the columns are told by their count
+	cloud, err := ReadXYZ(strings.NewReader("# scan\n// exported\n\n0.001 0.002 0.003 10 20 30\n-1 0 1 1 2 3\n"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud.Size(), test.ShouldEqual, 2)
+	d, ok := cloud.At(1, 2, 3)
+	test.That(t, ok, test.ShouldBeTrue)
+	r, g, b := d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{10, 20, 30})
+
+	cloud, err = ReadXYZ(strings.NewReader("1;2;3;40\n"))
+	test.That(t, err, test.ShouldBeNil)
+	d, ok = cloud.At(1000, 2000, 3000)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Intensity(), test.ShouldEqual, 40)
+	test.That(t, d.HasColor(), test.ShouldBeFalse)
+
+	cloud, err = ReadXYZ(strings.NewReader("1 2 3 40 1 2 3\n"))
+	test.That(t, err, test.ShouldBeNil)
+	d, ok = cloud.At(1000, 2000, 3000)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Intensity(), test.ShouldEqual, 40)
+	r, g, b = d.RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{1, 2, 3})
+
+	// a header names the columns in any order and unknown columns are ignored
+	cloud, err = ReadXYZ(strings.NewReader("time, Z, nx, ny, nz, X, Y\n5, 3, 0, 0, 1, 1, 2\n"))
+	test.That(t, err, test.ShouldBeNil)
+	d, ok = cloud.At(1000, 2000, 3000)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Z: 1})
+
+	for _, bad := range []string{
+		"1 2\n",
+		"1 2 3 4 5\n",
+		"1 2 3\n1 2\n",
+		"a b c\n1 2 3\n",
+		"x y z\n1 2 3\nx y z\n",
+	} {
+		_, err := ReadXYZ(strings.NewReader(bad))
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
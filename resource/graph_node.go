@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -28,6 +29,47 @@ type GraphNode struct {
 	markedForRemoval          bool
 	unresolvedDependencies    []string
 	needsDependencyResolution bool
+	stateChangedAt            time.Time
+}
+
+// NodeState is the state of the resource of a GraphNode.
+type NodeState uint8
+
+// The states the resource of a GraphNode can be in.
+const (
+	// NodeStateConfiguring means the resource is waiting to be built, or rebuilt with a new config.
+	NodeStateConfiguring NodeState = iota
+	// NodeStateReady means the resource is built and available.
+	NodeStateReady
+	// NodeStateFailed means the resource failed to build or was made unavailable by an error.
+	NodeStateFailed
+	// NodeStateRemoving means the resource is pending removal.
+	NodeStateRemoving
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case NodeStateConfiguring:
+		return "configuring"
+	case NodeStateReady:
+		return "ready"
+	case NodeStateFailed:
+		return "failed"
+	case NodeStateRemoving:
+		return "removing"
+	default:
+		return "unknown"
+	}
+}
+
+// NodeStatus is the state of the resource of a GraphNode and when it last changed.
+type NodeStatus struct {
+	State NodeState
+	// Err is the error the resource failed with, if it is in NodeStateFailed.
+	Err error
+	// LastUpdated is when the state or error last changed. It is the zero time for a node that
+	// has never changed.
+	LastUpdated time.Time
 }
 
 var (
@@ -112,6 +154,34 @@ func (w *GraphNode) HasResource() bool {
 	return !w.markedForRemoval && w.lastErr == nil && w.current != nil
 }
 
+// Status returns the state the resource of this node is in.
+func (w *GraphNode) Status() NodeStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return NodeStatus{State: w.state(), Err: w.lastErr, LastUpdated: w.stateChangedAt}
+}
+
+func (w *GraphNode) state() NodeState {
+	switch {
+	case w.markedForRemoval:
+		return NodeStateRemoving
+	case w.lastErr != nil:
+		return NodeStateFailed
+	case w.current == nil || w.needsReconfigure:
+		return NodeStateConfiguring
+	default:
+		return NodeStateReady
+	}
+}
+
+// stateChanged records when the state of the node last changed. It is called with the state and
+// error the node had before a change.
+func (w *GraphNode) stateChanged(prevState NodeState, prevErr error) {
+	if w.state() != prevState || w.lastErr != prevErr {
+		w.stateChangedAt = time.Now()
+	}
+}
+
 // IsUninitialized returns if this resource is in an uninitialized state.
 func (w *GraphNode) IsUninitialized() bool {
 	w.mu.RLock()
@@ -126,6 +196,7 @@ func (w *GraphNode) IsUninitialized() bool {
 func (w *GraphNode) SwapResource(newRes Resource, newModel Model) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer w.stateChanged(w.state(), w.lastErr)
 	w.current = newRes
 	w.currentModel = newModel
 	w.lastErr = nil
@@ -144,6 +215,7 @@ func (w *GraphNode) SwapResource(newRes Resource, newModel Model) {
 func (w *GraphNode) MarkForRemoval() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer w.stateChanged(w.state(), w.lastErr)
 	w.markedForRemoval = true
 }
 
@@ -161,6 +233,7 @@ func (w *GraphNode) MarkedForRemoval() bool {
 func (w *GraphNode) SetLastError(err error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer w.stateChanged(w.state(), w.lastErr)
 	w.lastErr = err
 }
 
@@ -192,6 +265,7 @@ func (w *GraphNode) hasUnresolvedDependencies() bool {
 func (w *GraphNode) setNeedsReconfigure(newConfig Config, mustReconfigure bool, dependencies []string) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer w.stateChanged(w.state(), w.lastErr)
 	if !mustReconfigure && w.markedForRemoval {
 		// This is the case where the node is being asked to update
 		// with no new config but it was marked for removal otherwise.
@@ -266,7 +340,9 @@ func (w *GraphNode) Close(ctx context.Context) error {
 		return nil
 	}
 	current := w.current
+	prevState := w.state()
 	w.current = nil
+	w.stateChanged(prevState, w.lastErr)
 	return current.Close(ctx)
 }
 
@@ -293,6 +369,7 @@ func (w *GraphNode) replace(other *GraphNode) error {
 	w.markedForRemoval = other.markedForRemoval
 	w.unresolvedDependencies = other.unresolvedDependencies
 	w.needsDependencyResolution = other.needsDependencyResolution
+	w.stateChangedAt = other.stateChangedAt
 
 	// other is now owned by the graph/node and is invalidated
 	other.updatedAt = 0
@@ -305,6 +382,7 @@ func (w *GraphNode) replace(other *GraphNode) error {
 	other.markedForRemoval = false
 	other.unresolvedDependencies = nil
 	other.needsDependencyResolution = false
+	other.stateChangedAt = time.Time{}
 	other.mu.Unlock()
 	return nil
 }
@@ -223,3 +223,32 @@ func (s *someResource) Close(ctx context.Context) error {
 	}
 	return nil
 }
+
+func TestGraphNodeStatus(t *testing.T) {
+	node := resource.NewUnconfiguredGraphNode(resource.Config{}, nil)
+	status := node.Status()
+	test.That(t, status.State, test.ShouldEqual, resource.NodeStateConfiguring)
+	test.That(t, status.LastUpdated.IsZero(), test.ShouldBeTrue)
+
+	buildErr := errors.New("whoops")
+	node.SetLastError(buildErr)
+	status = node.Status()
+	test.That(t, status.State, test.ShouldEqual, resource.NodeStateFailed)
+	test.That(t, status.Err, test.ShouldEqual, buildErr)
+	test.That(t, status.LastUpdated.IsZero(), test.ShouldBeFalse)
+	failedAt := status.LastUpdated
+
+	// the same error does not change the state
+	node.SetLastError(buildErr)
+	test.That(t, node.Status().LastUpdated, test.ShouldEqual, failedAt)
+
+	ourRes := &someResource{Resource: testutils.NewUnimplementedResource(generic.Named("some"))}
+	node.SwapResource(ourRes, resource.DefaultModelFamily.WithModel("bar"))
+	status = node.Status()
+	test.That(t, status.State, test.ShouldEqual, resource.NodeStateReady)
+	test.That(t, status.Err, test.ShouldBeNil)
+	test.That(t, status.State.String(), test.ShouldEqual, "ready")
+
+	node.MarkForRemoval()
+	test.That(t, node.Status().State, test.ShouldEqual, resource.NodeStateRemoving)
+}
@@ -0,0 +1,184 @@
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependencyKind is the kind of dependency an edge of a GraphSnapshot stands for.
+type DependencyKind string
+
+// The kinds of dependencies between resources.
+const (
+	// DependencyKindHard is a dependency that must be built before the resource depending on it,
+	// whether it is configured or implied by the attributes of the resource.
+	DependencyKindHard DependencyKind = "hard"
+	// DependencyKindWeak is a dependency that is passed to the resource depending on it when it
+	// is available, without holding up the building of the resource.
+	DependencyKindWeak DependencyKind = "weak"
+	// DependencyKindFrame is a dependency of a resource on the parent of its frame in the frame
+	// system.
+	DependencyKindFrame DependencyKind = "frame"
+)
+
+// A GraphSnapshot describes the nodes and edges of a resource graph at some point in time, for
+// inspecting it.
+type GraphSnapshot struct {
+	Nodes []GraphSnapshotNode `json:"nodes"`
+	Edges []GraphSnapshotEdge `json:"edges"`
+}
+
+// A GraphSnapshotNode describes a node of a resource graph.
+type GraphSnapshotNode struct {
+	Name  string `json:"name"`
+	Model string `json:"model,omitempty"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	// Remote is the remote the resource comes from, if any.
+	Remote string `json:"remote,omitempty"`
+	// Module is the module serving the resource, if any.
+	Module string `json:"module,omitempty"`
+}
+
+// A GraphSnapshotEdge is a dependency of the resource named From on the resource named To.
+type GraphSnapshotEdge struct {
+	From string         `json:"from"`
+	To   string         `json:"to"`
+	Kind DependencyKind `json:"kind"`
+}
+
+// Snapshot returns the nodes of the graph along with their hard dependencies, sorted by name.
+func (g *Graph) Snapshot() *GraphSnapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	snapshot := &GraphSnapshot{
+		Nodes: make([]GraphSnapshotNode, 0, len(g.nodes)),
+		Edges: []GraphSnapshotEdge{},
+	}
+	for name, node := range g.nodes {
+		status := node.Status()
+		model := node.Config().Model
+		if model == (Model{}) {
+			model = node.ResourceModel()
+		}
+		snapshotNode := GraphSnapshotNode{
+			Name:   name.String(),
+			State:  status.State.String(),
+			Remote: name.Remote,
+		}
+		if model != (Model{}) {
+			snapshotNode.Model = model.String()
+		}
+		if status.Err != nil {
+			snapshotNode.Error = status.Err.Error()
+		}
+		snapshot.Nodes = append(snapshot.Nodes, snapshotNode)
+		for parent := range g.getAllParentOf(name) {
+			snapshot.Edges = append(snapshot.Edges, GraphSnapshotEdge{
+				From: name.String(),
+				To:   parent.String(),
+				Kind: DependencyKindHard,
+			})
+		}
+	}
+	snapshot.Sort()
+	return snapshot
+}
+
+// AddEdge adds a dependency between two nodes of the snapshot, unless they already have one.
+func (s *GraphSnapshot) AddEdge(from, to Name, kind DependencyKind) {
+	for _, edge := range s.Edges {
+		if edge.From == from.String() && edge.To == to.String() {
+			return
+		}
+	}
+	s.Edges = append(s.Edges, GraphSnapshotEdge{From: from.String(), To: to.String(), Kind: kind})
+}
+
+// Sort sorts the nodes of the snapshot by name and its edges by the names of their ends.
+func (s *GraphSnapshot) Sort() {
+	sort.Slice(s.Nodes, func(i, j int) bool {
+		return s.Nodes[i].Name < s.Nodes[j].Name
+	})
+	sort.Slice(s.Edges, func(i, j int) bool {
+		if s.Edges[i].From != s.Edges[j].From {
+			return s.Edges[i].From < s.Edges[j].From
+		}
+		return s.Edges[i].To < s.Edges[j].To
+	})
+}
+
+var (
+	dotStateColors = map[string]string{
+		NodeStateConfiguring.String(): "orange",
+		NodeStateReady.String():       "darkgreen",
+		NodeStateFailed.String():      "red",
+		NodeStateRemoving.String():    "gray",
+	}
+	dotEdgeStyles = map[DependencyKind]string{
+		DependencyKindHard:  "solid",
+		DependencyKindWeak:  "dashed",
+		DependencyKindFrame: "dotted",
+	}
+)
+
+// DOT renders the snapshot in the Graphviz DOT language. Edges point from a resource to its
+// dependencies; hard dependencies are solid, weak ones dashed and frame ones dotted. Nodes are
+// colored by state and grouped by the remote they come from.
+func (s *GraphSnapshot) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph resources {\n")
+	sb.WriteString("\tnode [shape=box];\n")
+
+	writeNode := func(indent string, node GraphSnapshotNode) {
+		label := node.Name
+		if node.Model != "" {
+			label += "\n" + node.Model
+		}
+		if node.Module != "" {
+			label += "\nmodule " + node.Module
+		}
+		label += "\n" + node.State
+		if node.Error != "" {
+			label += ": " + node.Error
+		}
+		color, ok := dotStateColors[node.State]
+		if !ok {
+			color = "black"
+		}
+		fmt.Fprintf(&sb, "%s%q [label=%q, color=%q];\n", indent, node.Name, label, color)
+	}
+
+	byRemote := map[string][]GraphSnapshotNode{}
+	var remotes []string
+	for _, node := range s.Nodes {
+		if node.Remote == "" {
+			writeNode("\t", node)
+			continue
+		}
+		if _, ok := byRemote[node.Remote]; !ok {
+			remotes = append(remotes, node.Remote)
+		}
+		byRemote[node.Remote] = append(byRemote[node.Remote], node)
+	}
+	sort.Strings(remotes)
+	for idx, remote := range remotes {
+		fmt.Fprintf(&sb, "\tsubgraph cluster_%d {\n", idx)
+		fmt.Fprintf(&sb, "\t\tlabel=%q;\n", "remote "+remote)
+		for _, node := range byRemote[remote] {
+			writeNode("\t\t", node)
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	for _, edge := range s.Edges {
+		style, ok := dotEdgeStyles[edge.Kind]
+		if !ok {
+			style = "solid"
+		}
+		fmt.Fprintf(&sb, "\t%q -> %q [style=%s];\n", edge.From, edge.To, style)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -1,6 +1,8 @@
 package resource
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -262,6 +264,38 @@ func (g *Graph) GetAllParentsOf(node Name) []Name {
 	return names
 }
 
+// BlockingDependencies returns why the dependencies of a node keep its resource from being built:
+// dependencies that cannot be found in the graph and dependencies whose resources are not ready.
+func (g *Graph) BlockingDependencies(node Name) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	nodeVal, ok := g.nodes[node]
+	if !ok {
+		return nil
+	}
+	var reasons []string
+	for _, dep := range nodeVal.UnresolvedDependencies() {
+		reasons = append(reasons, fmt.Sprintf("dependency %q cannot be found", dep))
+	}
+	parents := make([]Name, 0, len(g.getAllParentOf(node)))
+	for parent := range g.getAllParentOf(node) {
+		parents = append(parents, parent)
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		return parents[i].String() < parents[j].String()
+	})
+	for _, parent := range parents {
+		parentVal, ok := g.nodes[parent]
+		if !ok {
+			continue
+		}
+		if state := parentVal.Status().State; state != NodeStateReady {
+			reasons = append(reasons, fmt.Sprintf("dependency %q is %s", parent, state))
+		}
+	}
+	return reasons
+}
+
 func (g *Graph) addNode(node Name, nodeVal *GraphNode) error {
 	if nodeVal == nil {
 		golog.Global().Errorw("addNode called with a nil value; setting to uninitialized", "name", node)
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
 	"go.viam.com/test"
 )
 
@@ -1049,3 +1050,73 @@ type someResource struct {
 	TriviallyReconfigurable
 	TriviallyCloseable
 }
+
+func TestResourceGraphBlockingDependencies(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	g := NewGraph()
+
+	boardName := NewName(APINamespaceRDK.WithComponentType("board"), "board")
+	boardNode := NewUnconfiguredGraphNode(Config{}, nil)
+	test.That(t, g.AddNode(boardName, boardNode), test.ShouldBeNil)
+	motorName := NewName(APINamespaceRDK.WithComponentType("motor"), "motor")
+	motorNode := NewUnconfiguredGraphNode(Config{}, []string{"board", "encoder"})
+	test.That(t, g.AddNode(motorName, motorNode), test.ShouldBeNil)
+	test.That(t, g.ResolveDependencies(logger), test.ShouldBeNil)
+
+	test.That(t, g.BlockingDependencies(boardName), test.ShouldBeEmpty)
+	test.That(t, g.BlockingDependencies(motorName), test.ShouldResemble, []string{
+		`dependency "encoder" cannot be found`,
+		`dependency "rdk:component:board/board" is configuring`,
+	})
+
+	boardNode.SetLastError(errors.New("no such pin"))
+	test.That(t, g.BlockingDependencies(motorName), test.ShouldResemble, []string{
+		`dependency "encoder" cannot be found`,
+		`dependency "rdk:component:board/board" is failed`,
+	})
+	test.That(t, g.BlockingDependencies(NewName(APINamespaceRDK.WithComponentType("arm"), "arm")), test.ShouldBeNil)
+}
+
+func TestResourceGraphSnapshot(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	g := NewGraph()
+
+	boardName := NewName(APINamespaceRDK.WithComponentType("board"), "board")
+	boardNode := NewUnconfiguredGraphNode(Config{Model: DefaultModelFamily.WithModel("fake")}, nil)
+	test.That(t, g.AddNode(boardName, boardNode), test.ShouldBeNil)
+	motorName := NewName(APINamespaceRDK.WithComponentType("motor"), "motor")
+	motorNode := NewUnconfiguredGraphNode(Config{}, []string{"board"})
+	test.That(t, g.AddNode(motorName, motorNode), test.ShouldBeNil)
+	gripperName := NewName(APINamespaceRDK.WithComponentType("gripper"), "remote1:gripper")
+	test.That(t, g.AddNode(gripperName, NewUnconfiguredGraphNode(Config{}, nil)), test.ShouldBeNil)
+	test.That(t, g.ResolveDependencies(logger), test.ShouldBeNil)
+	motorNode.SetLastError(errors.New("no such pin"))
+
+	snapshot := g.Snapshot()
+	test.That(t, snapshot.Nodes, test.ShouldResemble, []GraphSnapshotNode{
+		{Name: boardName.String(), Model: "rdk:builtin:fake", State: "configuring"},
+		{Name: gripperName.String(), State: "configuring", Remote: "remote1"},
+		{Name: motorName.String(), State: "failed", Error: "no such pin"},
+	})
+	test.That(t, snapshot.Edges, test.ShouldResemble, []GraphSnapshotEdge{
+		{From: motorName.String(), To: boardName.String(), Kind: DependencyKindHard},
+	})
+
+	// an edge between resources that already depend on one another is not added again
+	snapshot.AddEdge(motorName, boardName, DependencyKindFrame)
+	snapshot.AddEdge(motorName, gripperName, DependencyKindWeak)
+	snapshot.Sort()
+	test.That(t, snapshot.Edges, test.ShouldResemble, []GraphSnapshotEdge{
+		{From: motorName.String(), To: boardName.String(), Kind: DependencyKindHard},
+		{From: motorName.String(), To: gripperName.String(), Kind: DependencyKindWeak},
+	})
+
+	dot := snapshot.DOT()
+	test.That(t, dot, test.ShouldStartWith, "digraph resources {\n")
+	test.That(t, dot, test.ShouldContainSubstring,
+		`"rdk:component:motor/motor" [label="rdk:component:motor/motor\nfailed: no such pin", color="red"];`)
+	test.That(t, dot, test.ShouldContainSubstring, "subgraph cluster_0 {\n\t\tlabel=\"remote remote1\";\n")
+	test.That(t, dot, test.ShouldContainSubstring, `"rdk:component:motor/motor" -> "rdk:component:board/board" [style=solid];`)
+	test.That(t, dot, test.ShouldContainSubstring,
+		`"rdk:component:motor/motor" -> "rdk:component:gripper/remote1:gripper" [style=dashed];`)
+}
@@ -26,7 +26,9 @@ import (
 	"google.golang.org/grpc/codes"
 	reflectpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"go.viam.com/rdk/config"
 	"go.viam.com/rdk/grpc"
 	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/pointcloud"
@@ -36,6 +38,7 @@ import (
 	"go.viam.com/rdk/robot"
 	"go.viam.com/rdk/robot/framesystem"
 	"go.viam.com/rdk/robot/packages"
+	robotserver "go.viam.com/rdk/robot/server"
 	"go.viam.com/rdk/session"
 	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/utils/contextutils"
@@ -887,6 +890,33 @@ func (rc *RobotClient) Status(ctx context.Context, resourceNames []resource.Name
 	return statuses, nil
 }
 
+// ResourceStatuses returns the state of every resource the robot is configured with or connected to.
+func (rc *RobotClient) ResourceStatuses(ctx context.Context) ([]robot.ResourceStatus, error) {
+	resp := &structpb.Struct{}
+	if err := rc.conn.Invoke(ctx, robotserver.GetResourceStatusesMethod, &structpb.Struct{}, resp); err != nil {
+		return nil, err
+	}
+	return robotserver.ResourceStatusesFromProto(resp)
+}
+
+// ResourceGraph returns a snapshot of the resources of the robot and the dependencies between them.
+func (rc *RobotClient) ResourceGraph(ctx context.Context) (*resource.GraphSnapshot, error) {
+	resp := &structpb.Struct{}
+	if err := rc.conn.Invoke(ctx, robotserver.GetResourceGraphMethod, &structpb.Struct{}, resp); err != nil {
+		return nil, err
+	}
+	return robotserver.ResourceGraphFromProto(resp)
+}
+
+// Config returns the config the robot is running.
+func (rc *RobotClient) Config(ctx context.Context) (*config.Config, error) {
+	resp := &structpb.Struct{}
+	if err := rc.conn.Invoke(ctx, robotserver.GetConfigMethod, &structpb.Struct{}, resp); err != nil {
+		return nil, err
+	}
+	return robotserver.ConfigFromProto(resp)
+}
+
 // StopAll cancels all current and outstanding operations for the robot and stops all actuators and movement.
 func (rc *RobotClient) StopAll(ctx context.Context, extra map[resource.Name]map[string]interface{}) error {
 	e := []*pb.StopExtraParameters{}
@@ -32,6 +32,9 @@ var exemptFromSession = map[string]bool{
 	"/viam.robot.v1.RobotService/ResourceRPCSubtypes":                true,
 	"/viam.robot.v1.RobotService/StartSession":                       true,
 	"/viam.robot.v1.RobotService/SendSessionHeartbeat":               true,
+	"/rdk.resourcestatus.ResourceStatusService/GetResourceStatuses":  true,
+	"/rdk.resourcestatus.ResourceStatusService/GetResourceGraph":     true,
+	"/rdk.resourcestatus.ResourceStatusService/GetConfig":            true,
 }
 
 func (rc *RobotClient) sessionReset() {
@@ -6,6 +6,7 @@ package robotimpl
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -43,7 +44,9 @@ var _ = robot.LocalRobot(&localRobot{})
 type localRobot struct {
 	mu      sync.Mutex
 	manager *resourceManager
-	config  *config.Config
+	// configMu guards config, which Reconfigure changes while it is read by other goroutines.
+	configMu sync.RWMutex
+	config   *config.Config
 
 	operations                 *operation.Manager
 	sessionManager             session.Manager
@@ -181,12 +184,37 @@ func (r *localRobot) StopAll(ctx context.Context, extra map[resource.Name]map[st
 // Config returns the config used to construct the robot. Only local resources are returned.
 // This is allowed to be partial or empty.
 func (r *localRobot) Config(ctx context.Context) (*config.Config, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
 	cfgCpy := *r.config
 	cfgCpy.Components = append([]resource.Config{}, cfgCpy.Components...)
 
 	return &cfgCpy, nil
 }
 
+// FailedResources returns the names of the configured components and services that are not built
+// with their current config.
+func (r *localRobot) FailedResources() []resource.Name {
+	var failed []resource.Name
+	for _, conf := range r.configuredResources() {
+		name := conf.ResourceName()
+		node, ok := r.manager.resources.Node(name)
+		if !ok || !node.HasResource() || node.NeedsReconfigure() {
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+// configuredResources returns a copy of the component and service configs of the robot.
+func (r *localRobot) configuredResources() []resource.Config {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+	confs := make([]resource.Config, 0, len(r.config.Components)+len(r.config.Services))
+	confs = append(confs, r.config.Components...)
+	return append(confs, r.config.Services...)
+}
+
 // Logger returns the logger the robot is using.
 func (r *localRobot) Logger() golog.Logger {
 	return r.logger
@@ -314,6 +342,7 @@ func (r *localRobot) Status(ctx context.Context, resourceNames []resource.Name)
 					return nil, errors.Wrapf(err, "failed to get status from %q", name)
 				}
 			}
+			status = r.withModuleHealth(name, status)
 			resourceStatus = robot.Status{Name: name, Status: status}
 		}
 		statuses = append(statuses, resourceStatus)
@@ -321,6 +350,154 @@ func (r *localRobot) Status(ctx context.Context, resourceNames []resource.Name)
 	return statuses, nil
 }
 
+// ResourceStatuses returns the state of every component, service and remote of the robot,
+// including the ones that failed to build or were orphaned by their module.
+func (r *localRobot) ResourceStatuses(ctx context.Context) ([]robot.ResourceStatus, error) {
+	var statuses []robot.ResourceStatus
+	for _, name := range r.manager.resources.Names() {
+		if name.API.Type.Namespace == resource.APINamespaceRDKInternal {
+			continue
+		}
+		gNode, ok := r.manager.resources.Node(name)
+		if !ok {
+			continue
+		}
+		nodeStatus := gNode.Status()
+		status := robot.ResourceStatus{
+			Name:        name,
+			Error:       nodeStatus.Err,
+			LastUpdated: nodeStatus.LastUpdated,
+		}
+		switch nodeStatus.State {
+		case resource.NodeStateReady:
+			status.State = robot.ResourceStateReady
+		case resource.NodeStateFailed:
+			status.State = robot.ResourceStateFailed
+		case resource.NodeStateRemoving:
+			status.State = robot.ResourceStateRemoving
+		case resource.NodeStateConfiguring:
+			status.State = robot.ResourceStateConfiguring
+		}
+		if status.State != robot.ResourceStateReady {
+			status.BlockedBy = r.manager.resources.BlockingDependencies(name)
+		}
+		if r.manager.moduleManager != nil && r.manager.moduleManager.IsModularResource(name) {
+			if health, ok := r.manager.moduleManager.ModuleHealth(name); ok {
+				status.ModuleHealth = health
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	// resources orphaned by their module are no longer in the graph
+	for _, conf := range r.configuredResources() {
+		name := conf.ResourceName()
+		if _, ok := r.manager.resources.Node(name); ok {
+			continue
+		}
+		if orphaned, ok := r.manager.orphanedResource(name); ok {
+			statuses = append(statuses, robot.ResourceStatus{
+				Name:        name,
+				State:       robot.ResourceStateOrphaned,
+				Error:       orphaned.err,
+				LastUpdated: orphaned.at,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name.String() < statuses[j].Name.String()
+	})
+	return statuses, nil
+}
+
+// ResourceGraph returns a snapshot of the resource graph of the robot, including the weak
+// dependencies of resources and the dependencies of their frames on their parent frames.
+func (r *localRobot) ResourceGraph(ctx context.Context) (*resource.GraphSnapshot, error) {
+	snapshot := r.manager.resources.Snapshot()
+	names := r.manager.resources.Names()
+	if r.manager.moduleManager != nil {
+		modules := map[string]string{}
+		for _, name := range names {
+			if module, ok := r.manager.moduleManager.ModuleName(name); ok {
+				modules[name.String()] = module
+			}
+		}
+		for idx := range snapshot.Nodes {
+			snapshot.Nodes[idx].Module = modules[snapshot.Nodes[idx].Name]
+		}
+	}
+
+	for _, name := range names {
+		gNode, ok := r.manager.resources.Node(name)
+		if !ok {
+			continue
+		}
+		conf := gNode.Config()
+		for _, dep := range r.weakDependencyNames(name, conf.API, conf.Model, names) {
+			snapshot.AddEdge(name, dep, resource.DependencyKindWeak)
+		}
+		if conf.Frame == nil || conf.Frame.Parent == "" || conf.Frame.Parent == referenceframe.World {
+			continue
+		}
+		for _, parent := range names {
+			if parent != name && parent.API.IsComponent() && parent.ShortName() == conf.Frame.Parent {
+				snapshot.AddEdge(name, parent, resource.DependencyKindFrame)
+			}
+		}
+	}
+	snapshot.Sort()
+	return snapshot, nil
+}
+
+// weakDependencyNames returns the names of the resources among the given ones that a resource
+// weakly depends on, whether or not they are available.
+func (r *localRobot) weakDependencyNames(
+	resName resource.Name,
+	api resource.API,
+	model resource.Model,
+	names []resource.Name,
+) []resource.Name {
+	var deps []resource.Name
+	for _, matcher := range r.getWeakDependencyMatchers(api, model) {
+		for _, name := range names {
+			if name == resName {
+				continue
+			}
+			switch matcher {
+			case internal.ComponentDependencyWildcardMatcher:
+				if name.API.IsComponent() {
+					deps = append(deps, name)
+				}
+			case internal.SLAMDependencyWildcardMatcher:
+				if name.API.IsService() && name.API.SubtypeName == slam.API.SubtypeName {
+					deps = append(deps, name)
+				}
+			default:
+			}
+		}
+	}
+	return deps
+}
+
+// withModuleHealth adds the health of the module serving a modular resource to its status, when
+// the status is a map.
+func (r *localRobot) withModuleHealth(name resource.Name, status interface{}) interface{} {
+	fields, ok := status.(map[string]interface{})
+	if !ok || r.manager.moduleManager == nil || !r.manager.moduleManager.IsModularResource(name) {
+		return status
+	}
+	health, ok := r.manager.moduleManager.ModuleHealth(name)
+	if !ok {
+		return status
+	}
+	withHealth := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		withHealth[k] = v
+	}
+	withHealth["module_health"] = health
+	return withHealth
+}
+
 func newWithResources(
 	ctx context.Context,
 	cfg *config.Config,
@@ -386,6 +563,13 @@ func newWithResources(
 			r.logger.Debug("Using no-op PackageManager when internet not available")
 			r.packageManager = packages.NewNoopManager()
 		}
+	} else if cfg.PackagePath != "" {
+		// without the cloud, only packages with a source of their own are synced, the others are
+		// left as they are like the no-op PackageManager does
+		r.packageManager, err = packages.NewCloudManager(nil, cfg.PackagePath, logger)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		r.logger.Debug("Using no-op PackageManager when Cloud config is not available")
 		r.packageManager = packages.NewNoopManager()
@@ -430,7 +614,7 @@ func newWithResources(
 
 	// Once web service is started, start module manager and add initially
 	// specified modules.
-	r.manager.startModuleManager(r.webSvc.ModuleAddress(), cfg.UntrustedEnv, logger)
+	r.manager.startModuleManager(r.webSvc.ModuleAddress(), cfg.UntrustedEnv, cfg.PackagePath, logger)
 	for _, mod := range cfg.Modules {
 		if err := r.manager.moduleManager.Add(ctx, mod); err != nil {
 			r.logger.Errorw("error adding module", "module", mod.Name, "error", err)
@@ -1057,7 +1241,9 @@ func (r *localRobot) Reconfigure(ctx context.Context, newConfig *config.Config)
 
 	// Second we update the resource graph.
 	allErrs = multierr.Combine(allErrs, r.manager.updateResources(ctx, diff))
+	r.configMu.Lock()
 	r.config = newConfig
+	r.configMu.Unlock()
 
 	allErrs = multierr.Combine(allErrs, processesToClose.Stop())
 
@@ -1082,6 +1268,7 @@ func (r *localRobot) Reconfigure(ctx context.Context, newConfig *config.Config)
 		//
 		// TODO(RSDK-2876): remove this code when we start referring to a config
 		// generated from resource graph instead of r.config.
+		r.configMu.Lock()
 		for i, c := range r.config.Components {
 			if c.ResourceName() == removedName {
 				r.config.Components[i] = r.config.Components[len(r.config.Components)-1]
@@ -1094,6 +1281,7 @@ func (r *localRobot) Reconfigure(ctx context.Context, newConfig *config.Config)
 				r.config.Services = r.config.Services[:len(r.config.Services)-1]
 			}
 		}
+		r.configMu.Unlock()
 	}
 
 	// cleanup unused packages after all old resources have been closed above. This ensures
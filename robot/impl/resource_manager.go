@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/edaniels/golog"
 	"github.com/jhump/protoreflect/desc"
@@ -47,6 +48,16 @@ type resourceManager struct {
 	opts           resourceManagerOptions
 	logger         golog.Logger
 	configLock     sync.Mutex
+
+	orphanedMu sync.Mutex
+	orphaned   map[resource.Name]orphanedResource
+}
+
+// orphanedResource is a resource that its module stopped serving. It stays out of the graph
+// until it is configured again.
+type orphanedResource struct {
+	err error
+	at  time.Time
 }
 
 type resourceManagerOptions struct {
@@ -88,10 +99,12 @@ func fromRemoteNameToRemoteNodeName(name string) resource.Name {
 func (manager *resourceManager) startModuleManager(
 	parentAddr string,
 	untrustedEnv bool,
+	packagesDir string,
 	logger golog.Logger,
 ) {
 	mmOpts := modmanageroptions.Options{
 		UntrustedEnv:            untrustedEnv,
+		PackagesDir:             packagesDir,
 		RemoveOrphanedResources: manager.removeOrphanedResources,
 	}
 	manager.moduleManager = modmanager.NewManager(parentAddr, logger, mmOpts)
@@ -784,6 +797,9 @@ func (manager *resourceManager) markResourceForUpdate(name resource.Name, conf r
 		}
 		return nil
 	}
+	manager.orphanedMu.Lock()
+	delete(manager.orphaned, name)
+	manager.orphanedMu.Unlock()
 	gNode = resource.NewUnconfiguredGraphNode(conf, deps)
 	if err := manager.resources.AddNode(name, gNode); err != nil {
 		return errors.Errorf("failed to add new node for unconfigured resource %q: %v", name, err)
@@ -887,6 +903,8 @@ func (manager *resourceManager) updateResources(
 		if err != nil {
 			manager.logger.Errorw("error reconfiguring module", "module", mod.Name, "error", err)
 		}
+		manager.markOrphaned(orphanedResourceNames,
+			errors.Errorf("module %q no longer serves the resource after being reconfigured", mod.Name))
 		for _, resToClose := range manager.markResourcesRemoved(orphanedResourceNames, nil) {
 			if err := resToClose.Close(ctx); err != nil {
 				manager.logger.Errorw("error closing now orphaned resource", "resource",
@@ -964,6 +982,7 @@ func (manager *resourceManager) markRemoved(
 		if err != nil {
 			manager.logger.Errorw("error removing module", "module", conf.Name, "error", err)
 		}
+		manager.markOrphaned(orphanedResourceNames, errors.Errorf("module %q serving the resource was removed", conf.Name))
 		resourcesToMark = append(resourcesToMark, orphanedResourceNames...)
 	}
 
@@ -1023,6 +1042,7 @@ func (manager *resourceManager) markResourcesRemoved(
 func (manager *resourceManager) removeOrphanedResources(ctx context.Context,
 	rNames []resource.Name,
 ) {
+	manager.markOrphaned(rNames, errors.New("the module serving the resource exited and could not serve it again"))
 	// Ignore returned resources to close, as removeMarkedAndClose will handle.
 	manager.markResourcesRemoved(rNames, nil)
 	if _, err := manager.removeMarkedAndClose(ctx, nil); err != nil {
@@ -1031,6 +1051,31 @@ func (manager *resourceManager) removeOrphanedResources(ctx context.Context,
 	}
 }
 
+// markOrphaned records that the given resources were orphaned by their module.
+func (manager *resourceManager) markOrphaned(rNames []resource.Name, err error) {
+	if len(rNames) == 0 {
+		return
+	}
+	manager.orphanedMu.Lock()
+	defer manager.orphanedMu.Unlock()
+	if manager.orphaned == nil {
+		manager.orphaned = map[resource.Name]orphanedResource{}
+	}
+	now := time.Now()
+	for _, name := range rNames {
+		manager.orphaned[name] = orphanedResource{err: err, at: now}
+	}
+}
+
+// orphanedResource returns how the given resource was orphaned, if it is no longer in the graph
+// because its module stopped serving it.
+func (manager *resourceManager) orphanedResource(name resource.Name) (orphanedResource, bool) {
+	manager.orphanedMu.Lock()
+	defer manager.orphanedMu.Unlock()
+	orphaned, ok := manager.orphaned[name]
+	return orphaned, ok
+}
+
 func remoteDialOptions(config config.Remote, opts resourceManagerOptions) []rpc.DialOption {
 	var dialOpts []rpc.DialOption
 	if opts.debug {
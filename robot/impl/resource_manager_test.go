@@ -1824,6 +1824,14 @@ func (rr *dummyRobot) Status(ctx context.Context, resourceNames []resource.Name)
 	panic("change to return nil")
 }
 
+func (rr *dummyRobot) ResourceStatuses(ctx context.Context) ([]robot.ResourceStatus, error) {
+	panic("change to return nil")
+}
+
+func (rr *dummyRobot) ResourceGraph(ctx context.Context) (*resource.GraphSnapshot, error) {
+	panic("change to return nil")
+}
+
 func (rr *dummyRobot) ProcessManager() pexec.ProcessManager {
 	panic("change to return nil")
 }
@@ -1863,7 +1871,7 @@ func managerForDummyRobot(robot robot.Robot) *resourceManager {
 
 	// start a dummy module manager so calls to moduleManager.Provides() do not
 	// panic.
-	manager.startModuleManager("", false, robot.Logger())
+	manager.startModuleManager("", false, "", robot.Logger())
 
 	for _, name := range robot.ResourceNames() {
 		res, err := robot.ResourceByName(name)
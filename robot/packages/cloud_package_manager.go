@@ -4,7 +4,9 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"hash"
 	"hash/crc32"
@@ -52,7 +54,10 @@ type cloudManager struct {
 	packagesDir     string
 
 	managedPackages map[PackageName]*managedPackage
-	mu              sync.RWMutex
+	// sourced holds the names of the packages with a source. Without a client, the other packages
+	// are left as they are, like the noop manager does.
+	sourced map[PackageName]bool
+	mu      sync.RWMutex
 
 	logger golog.Logger
 }
@@ -67,6 +72,8 @@ var API = resource.APINamespaceRDKInternal.WithServiceType(SubtypeName)
 var InternalServiceName = resource.NewName(API, "builtin")
 
 // NewCloudManager creates a new manager with the given package service client and directory to sync to.
+// Packages with a source are downloaded from it rather than the package service, so the client can be
+// nil for robots without a cloud connection, which can then only sync such packages.
 func NewCloudManager(client pb.PackageServiceClient, packagesDir string, logger golog.Logger) (ManagerSyncer, error) {
 	packagesDataDir := filepath.Join(packagesDir, ".data")
 
@@ -96,6 +103,9 @@ func (m *cloudManager) PackagePath(name PackageName) (string, error) {
 
 	p, ok := m.managedPackages[name]
 	if !ok {
+		if m.client == nil && !m.sourced[name] {
+			return string(name), nil
+		}
 		return "", ErrPackageMissing
 	}
 
@@ -137,6 +147,12 @@ func (m *cloudManager) Sync(ctx context.Context, packages []config.PackageConfig
 	defer m.mu.Unlock()
 
 	newManagedPackages := make(map[PackageName]*managedPackage, len(packages))
+	m.sourced = make(map[PackageName]bool, len(packages))
+	for _, p := range packages {
+		if p.Source != "" {
+			m.sourced[PackageName(p.Name)] = true
+		}
+	}
 
 	for idx, p := range packages {
 		select {
@@ -151,7 +167,7 @@ func (m *cloudManager) Sync(ctx context.Context, packages []config.PackageConfig
 		// Package exists in known cache.
 		existing, ok := m.managedPackages[PackageName(p.Name)]
 		if ok {
-			if existing.thePackage.Package == p.Package && existing.thePackage.Version == p.Version {
+			if existing.thePackage == p {
 				m.logger.Debug("  Package already managed, skipping")
 
 				newManagedPackages[PackageName(p.Name)] = existing
@@ -161,23 +177,55 @@ func (m *cloudManager) Sync(ctx context.Context, packages []config.PackageConfig
 			// anything left over in the m.managedPackages will be cleaned up later.
 		}
 
-		// Lookup the packages http url
-		includeURL := true
-		resp, err := m.client.GetPackage(ctx, &pb.GetPackageRequest{Id: p.Package, Version: p.Version, IncludeUrl: &includeURL})
-		if err != nil {
-			m.logger.Errorf("Failed fetching package details for package %s:%s, %s", p.Package, p.Version, err)
-			outErr = multierr.Append(outErr, errors.Wrapf(err, "failed loading package url for %s:%s", p.Package, p.Version))
-			continue
+		var packageURL string
+		var download func(ctx context.Context, downloadPath string) error
+		if p.Source != "" {
+			source, err := lookupSource(p)
+			if err != nil {
+				m.logger.Errorf("Failed finding source of package %s:%s, %s", p.Package, p.Version, err)
+				outErr = multierr.Append(outErr, errors.Wrapf(err, "failed loading package %s:%s from %s",
+					p.Package, p.Version, sanitizeURLForLogs(p.Source)))
+				continue
+			}
+			packageURL = p.Source
+			download = func(ctx context.Context, downloadPath string) error {
+				return downloadFromSource(ctx, source, p, downloadPath)
+			}
+		} else {
+			if m.client == nil {
+				m.logger.Debugf("  Package has no source and there is no cloud connection to fetch it from, skipping")
+				continue
+			}
+
+			// Lookup the packages http url
+			includeURL := true
+			resp, err := m.client.GetPackage(ctx, &pb.GetPackageRequest{Id: p.Package, Version: p.Version, IncludeUrl: &includeURL})
+			if err != nil {
+				m.logger.Errorf("Failed fetching package details for package %s:%s, %s", p.Package, p.Version, err)
+				outErr = multierr.Append(outErr, errors.Wrapf(err, "failed loading package url for %s:%s", p.Package, p.Version))
+				continue
+			}
+			packageURL = resp.Package.Url
+			download = func(ctx context.Context, downloadPath string) error {
+				_, contentType, err := m.downloadFileFromGCSURL(ctx, packageURL, downloadPath)
+				if err != nil {
+					return err
+				}
+				if contentType != allowedContentType {
+					return fmt.Errorf("unknown content-type for package %s", contentType)
+				}
+				return nil
+			}
 		}
 
-		m.logger.Debugf("  Downloading from %s", sanitizeURLForLogs(resp.Package.Url))
+		m.logger.Debugf("  Downloading from %s", sanitizeURLForLogs(packageURL))
 
-		// load package from a http endpoint
-		err = m.loadFile(ctx, resp.Package.Url, p)
+		// load package from a http endpoint or source
+		err := m.loadFile(ctx, p, download)
 		if err != nil {
-			m.logger.Errorf("Failed downloading package %s:%s from %s, %s", p.Package, p.Version, sanitizeURLForLogs(resp.Package.Url), err)
+			m.logger.Errorf("Failed downloading package %s:%s from %s, %s", p.Package, p.Version, sanitizeURLForLogs(packageURL), err)
 			outErr = multierr.Append(outErr, errors.Wrapf(err, "failed downloading package %s:%s from %s",
-				p.Package, p.Version, sanitizeURLForLogs(resp.Package.Url)))
+				p.Package, p.Version, sanitizeURLForLogs(packageURL)))
 			continue
 		}
 
@@ -209,6 +257,11 @@ func (m *cloudManager) Cleanup(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// without a client nothing was synced unless some package has a source
+	if m.client == nil && len(m.sourced) == 0 {
+		return nil
+	}
+
 	var allErrors error
 
 	// packageDir will contain either symlinks to the packages or the .data directory.
@@ -272,7 +325,11 @@ func sanitizeURLForLogs(u string) string {
 	return parsed.String()
 }
 
-func (m *cloudManager) loadFile(ctx context.Context, url string, p config.PackageConfig) error {
+func (m *cloudManager) loadFile(
+	ctx context.Context,
+	p config.PackageConfig,
+	download func(ctx context.Context, downloadPath string) error,
+) error {
 	// TODO(): validate integrity of directory.
 	if dirExists(m.localDataPath(p)) {
 		m.logger.Debug("  Package already downloaded, skipping.")
@@ -287,15 +344,10 @@ func (m *cloudManager) loadFile(ctx context.Context, url string, p config.Packag
 		m.logger.Debug(err)
 	}
 
-	// Download from GCS
-	_, contentType, err := m.downloadFileFromGCSURL(ctx, url, p)
-	if err != nil {
-		return err
-	}
-
-	if contentType != allowedContentType {
+	// Download from GCS or the source of the package
+	if err := download(ctx, m.localDownloadPath(p)); err != nil {
 		utils.UncheckedError(m.cleanup(p))
-		return fmt.Errorf("unknown content-type for package %s", contentType)
+		return err
 	}
 
 	// unpack to temp directory to ensure we do an atomic rename once finished.
@@ -315,7 +367,7 @@ func (m *cloudManager) loadFile(ctx context.Context, url string, p config.Packag
 	}()
 
 	// unzip archive.
-	err = m.unpackFile(ctx, m.localDownloadPath(p), tmpDataPath)
+	err = unpackFile(ctx, m.localDownloadPath(p), tmpDataPath)
 	if err != nil {
 		utils.UncheckedError(m.cleanup(p))
 		return err
@@ -337,9 +389,7 @@ func (m *cloudManager) cleanup(p config.PackageConfig) error {
 	)
 }
 
-func (m *cloudManager) downloadFileFromGCSURL(ctx context.Context, url string, p config.PackageConfig) (string, string, error) {
-	downloadPath := m.localDownloadPath(p)
-
+func (m *cloudManager) downloadFileFromGCSURL(ctx context.Context, url, downloadPath string) (string, string, error) {
 	getReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", "", err
@@ -384,7 +434,7 @@ func (m *cloudManager) downloadFileFromGCSURL(ctx context.Context, url string, p
 	return checksum, contentType, nil
 }
 
-func (m *cloudManager) unpackFile(ctx context.Context, fromFile, toDir string) error {
+func unpackFile(ctx context.Context, fromFile, toDir string) error {
 	if err := os.MkdirAll(toDir, 0o700); err != nil {
 		return err
 	}
@@ -441,11 +491,15 @@ func (m *cloudManager) unpackFile(ctx context.Context, fromFile, toDir string) e
 
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.Mkdir(path, info.Mode()); err != nil {
+			if err := os.Mkdir(path, info.Mode()); err != nil && !os.IsExist(err) {
 				return errors.Wrapf(err, "failed to create directory %s", path)
 			}
 
 		case tar.TypeReg:
+			// not every tarball has entries for the directories of its files
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return errors.Wrapf(err, "failed to create directory of %s", path)
+			}
 			//nolint:gosec // path sanitized with safeJoin
 			outFile, err := os.Create(path)
 			if err != nil {
@@ -529,7 +583,13 @@ func crc32Hash() hash.Hash32 {
 
 func hashName(f config.PackageConfig) string {
 	// replace / to avoid a directory path in the name. This will happen with `org/package` format.
-	return fmt.Sprintf("%s-%s", strings.ReplaceAll(f.Package, "/", "-"), f.Version)
+	name := fmt.Sprintf("%s-%s", strings.ReplaceAll(f.Package, "/", "-"), f.Version)
+	if f.Source == "" {
+		return name
+	}
+	// a package from a source is told apart from those of the same name from other sources
+	sum := sha256.Sum256([]byte(f.Source + "\n" + f.Checksum))
+	return name + "-" + hex.EncodeToString(sum[:8])
 }
 
 // safeJoin performs a filepath.Join of 'parent' and 'subdir' but returns an error
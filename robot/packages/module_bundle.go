@@ -0,0 +1,106 @@
+package packages
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/config"
+)
+
+// moduleBundlesDir is the directory, under the packages directory, module bundles are installed to.
+const moduleBundlesDir = ".modules"
+
+// InstalledModule is a module bundle unpacked to the local file system.
+type InstalledModule struct {
+	// Dir is the directory the bundle was unpacked to.
+	Dir string
+	// ExePath is the path of the entrypoint of the module.
+	ExePath  string
+	Manifest *config.ModuleManifest
+}
+
+// InstallModuleBundle unpacks the bundle of the module to the packages directory, unless it already
+// is, and removes the other versions of the module installed there. Each version is unpacked to a
+// directory named after the hash of its bundle, so a changed bundle is installed even if its name
+// is not.
+func InstallModuleBundle(ctx context.Context, packagesDir string, mod config.Module) (*InstalledModule, error) {
+	if packagesDir == "" {
+		return nil, errors.New("no packages directory to install module bundles to")
+	}
+	bundlePath, err := mod.BundlePath()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find bundle of module %s", mod.Name)
+	}
+	manifest, err := config.ReadModuleBundleManifest(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := fileHash(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	moduleDir := filepath.Join(packagesDir, moduleBundlesDir, mod.Name)
+	installed := &InstalledModule{Dir: filepath.Join(moduleDir, hash), Manifest: manifest}
+	installed.ExePath, err = safeJoin(installed.Dir, manifest.Entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dirExists(installed.Dir) {
+		if err := os.MkdirAll(moduleDir, 0o700); err != nil {
+			return nil, err
+		}
+		// unpack next to the final directory so that an interrupted install is never used
+		tmpDir, err := os.MkdirTemp(moduleDir, ".tmp-")
+		if err != nil {
+			return nil, err
+		}
+		defer utils.UncheckedErrorFunc(func() error { return os.RemoveAll(tmpDir) })
+		if err := unpackFile(ctx, bundlePath, tmpDir); err != nil {
+			return nil, errors.Wrapf(err, "cannot unpack bundle of module %s", mod.Name)
+		}
+		// tarballs do not always keep the executable bit
+		entrypoint := filepath.Join(tmpDir, filepath.FromSlash(manifest.Entrypoint))
+		if info, err := os.Stat(entrypoint); err == nil && info.Mode().IsRegular() {
+			if err := os.Chmod(entrypoint, info.Mode().Perm()|0o700); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.Rename(tmpDir, installed.Dir); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := os.ReadDir(moduleDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Name() != hash {
+			utils.UncheckedError(os.RemoveAll(filepath.Join(moduleDir, entry.Name())))
+		}
+	}
+	return installed, nil
+}
+
+func fileHash(path string) (string, error) {
+	//nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer utils.UncheckedErrorFunc(f.Close)
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
@@ -0,0 +1,82 @@
+package packages
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/config"
+)
+
+func writeModuleBundle(t *testing.T, path string, manifest config.ModuleManifest, files map[string]string) {
+	t.Helper()
+	//nolint:gosec
+	f, err := os.Create(path)
+	test.That(t, err, test.ShouldBeNil)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	data, err := json.Marshal(manifest)
+	test.That(t, err, test.ShouldBeNil)
+	files[config.ModuleManifestFileName] = string(data)
+	for name, content := range files {
+		// no file is executable, as with bundles made on some systems
+		test.That(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}), test.ShouldBeNil)
+		_, err := tw.Write([]byte(content))
+		test.That(t, err, test.ShouldBeNil)
+	}
+	test.That(t, tw.Close(), test.ShouldBeNil)
+	test.That(t, gz.Close(), test.ShouldBeNil)
+}
+
+func TestInstallModuleBundle(t *testing.T) {
+	ctx := context.Background()
+	usb := t.TempDir()
+	packagesDir := t.TempDir()
+	manifest := config.ModuleManifest{Entrypoint: "bin/module"}
+	writeModuleBundle(t, filepath.Join(usb, "my-module.tar.gz"), manifest, map[string]string{"bin/module": "#!/bin/sh\necho 1\n"})
+	mod := config.Module{Name: "my-module", Bundle: usb}
+
+	_, err := InstallModuleBundle(ctx, "", mod)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	installed, err := InstallModuleBundle(ctx, packagesDir, mod)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, installed.Manifest.Entrypoint, test.ShouldEqual, "bin/module")
+	test.That(t, installed.ExePath, test.ShouldEqual, filepath.Join(installed.Dir, "bin", "module"))
+	test.That(t, filepath.Dir(installed.Dir), test.ShouldEqual, filepath.Join(packagesDir, moduleBundlesDir, "my-module"))
+	info, err := os.Stat(installed.ExePath)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, info.Mode().Perm()&0o100, test.ShouldNotBeZeroValue)
+
+	// the same bundle is not unpacked again
+	test.That(t, os.WriteFile(filepath.Join(installed.Dir, "marker"), nil, 0o600), test.ShouldBeNil)
+	again, err := InstallModuleBundle(ctx, packagesDir, mod)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, again.Dir, test.ShouldEqual, installed.Dir)
+	_, err = os.Stat(filepath.Join(again.Dir, "marker"))
+	test.That(t, err, test.ShouldBeNil)
+
+	// a new bundle under the same name replaces the old version
+	writeModuleBundle(t, filepath.Join(usb, "my-module.tar.gz"), manifest, map[string]string{"bin/module": "#!/bin/sh\necho 2\n"})
+	updated, err := InstallModuleBundle(ctx, packagesDir, mod)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, updated.Dir, test.ShouldNotEqual, installed.Dir)
+	_, err = os.Stat(installed.Dir)
+	test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+	entries, err := os.ReadDir(filepath.Dir(updated.Dir))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, entries, test.ShouldHaveLength, 1)
+	content, err := os.ReadFile(updated.ExePath)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(content), test.ShouldEqual, "#!/bin/sh\necho 2\n")
+
+	_, err = InstallModuleBundle(ctx, packagesDir, config.Module{Name: "missing", Bundle: usb})
+	test.That(t, err, test.ShouldNotBeNil)
+}
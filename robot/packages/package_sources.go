@@ -0,0 +1,371 @@
+package packages
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/config"
+)
+
+// A Source downloads the archives of packages from the kind of location it is registered for.
+type Source interface {
+	// Download writes the gzipped tarball of the package at the source of its config to w.
+	Download(ctx context.Context, p config.PackageConfig, w io.Writer) error
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = map[string]Source{}
+)
+
+// RegisterSource makes the packages whose source is a URL of the scheme downloaded by the source,
+// replacing any source registered for it before.
+func RegisterSource(scheme string, source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[scheme] = source
+}
+
+func init() {
+	RegisterSource("file", localSource{})
+	httpSrc := &httpSource{client: http.Client{Timeout: time.Minute * 30}}
+	RegisterSource("http", httpSrc)
+	RegisterSource("https", httpSrc)
+	ociSrc := &ociSource{client: http.Client{Timeout: time.Minute * 30}}
+	RegisterSource("oci", ociSrc)
+	RegisterSource("oci+http", ociSrc)
+}
+
+// sourceScheme returns the scheme of the source of the package, "file" for a local path.
+func sourceScheme(source string) (string, error) {
+	if filepath.IsAbs(source) || !strings.Contains(source, "://") {
+		return "file", nil
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid package source %q", source)
+	}
+	return u.Scheme, nil
+}
+
+// lookupSource returns the source registered for the source URL of the package.
+func lookupSource(p config.PackageConfig) (Source, error) {
+	scheme, err := sourceScheme(p.Source)
+	if err != nil {
+		return nil, err
+	}
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	source, ok := sources[scheme]
+	if !ok {
+		return nil, errors.Errorf("no package source for %q URLs", scheme)
+	}
+	return source, nil
+}
+
+// downloadFromSource downloads the archive of the package from its source to the file, and checks it
+// against the checksum of the package if it has one.
+func downloadFromSource(ctx context.Context, source Source, p config.PackageConfig, downloadPath string) error {
+	//nolint:gosec // safe
+	out, err := os.Create(downloadPath)
+	if err != nil {
+		return err
+	}
+	defer utils.UncheckedErrorFunc(out.Close)
+
+	hash := sha256.New()
+	if err := source.Download(ctx, p, io.MultiWriter(out, hash)); err != nil {
+		utils.UncheckedError(os.Remove(downloadPath))
+		return err
+	}
+	if p.Checksum == "" {
+		return nil
+	}
+	if outHash := "sha256:" + hex.EncodeToString(hash.Sum(nil)); outHash != p.Checksum {
+		utils.UncheckedError(os.Remove(downloadPath))
+		return errors.Errorf("download did not match expected hash %s != %s", p.Checksum, outHash)
+	}
+	return nil
+}
+
+// copyLimited copies the archive of a package, failing rather than truncating it if it is larger
+// than the limit.
+func copyLimited(w io.Writer, r io.Reader, limit int64) error {
+	n, err := io.CopyN(w, r, limit+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if n > limit {
+		return errors.Errorf("package is larger than the limit of %d bytes", limit)
+	}
+	return nil
+}
+
+// localSource copies archives from the local file system, e.g. a USB stick.
+type localSource struct{}
+
+func (localSource) Download(ctx context.Context, p config.PackageConfig, w io.Writer) error {
+	path := p.Source
+	if strings.HasPrefix(path, "file:") {
+		u, err := url.Parse(path)
+		if err != nil {
+			return err
+		}
+		path = filepath.FromSlash(u.Path)
+	}
+	//nolint:gosec // safe
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer utils.UncheckedErrorFunc(f.Close)
+	return copyLimited(w, f, maxPackageSize)
+}
+
+// httpSource downloads archives from plain HTTP(S) servers, such as an artifact server. Packages from
+// it must have a checksum, as nothing else vouches for what was downloaded.
+type httpSource struct {
+	client http.Client
+}
+
+func (s *httpSource) Download(ctx context.Context, p config.PackageConfig, w io.Writer) error {
+	if p.Checksum == "" {
+		return errors.Errorf("package %s from %s has no checksum", p.Name, sanitizeURLForLogs(p.Source))
+	}
+	getReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Source, nil)
+	if err != nil {
+		return err
+	}
+	//nolint:bodyclose /// closed in UncheckedErrorFunc
+	resp, err := s.client.Do(getReq)
+	if err != nil {
+		return err
+	}
+	defer utils.UncheckedErrorFunc(resp.Body.Close)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code %d", resp.StatusCode)
+	}
+	return copyLimited(w, resp.Body, maxPackageSize)
+}
+
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	maxOCIManifestSize      = 4 << 20
+	ociDigestPrefix         = "sha256:"
+)
+
+// ociSource downloads archives stored as a layer of an artifact in an OCI registry, e.g. pushed with
+// oras. Anonymous access with bearer tokens is supported, for registries that ask for it.
+type ociSource struct {
+	client http.Client
+}
+
+type ociReference struct {
+	baseURL    string
+	repository string
+	reference  string
+}
+
+func parseOCIReference(source string) (ociReference, error) {
+	var ref ociReference
+	u, err := url.Parse(source)
+	if err != nil {
+		return ref, err
+	}
+	scheme := "https"
+	if u.Scheme == "oci+http" {
+		scheme = "http"
+	}
+	repo := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || repo == "" {
+		return ref, errors.Errorf("OCI source %q must be of the form oci://registry/repository[:tag|@digest]", source)
+	}
+	ref.baseURL = scheme + "://" + u.Host
+	switch {
+	case strings.Contains(repo, "@"):
+		i := strings.Index(repo, "@")
+		ref.repository, ref.reference = repo[:i], repo[i+1:]
+	case strings.LastIndex(repo, ":") > strings.LastIndex(repo, "/"):
+		i := strings.LastIndex(repo, ":")
+		ref.repository, ref.reference = repo[:i], repo[i+1:]
+	default:
+		ref.repository, ref.reference = repo, "latest"
+	}
+	return ref, nil
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+func (s *ociSource) Download(ctx context.Context, p config.PackageConfig, w io.Writer) error {
+	ref, err := parseOCIReference(p.Source)
+	if err != nil {
+		return err
+	}
+	var token string
+
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", ref.baseURL, ref.repository, ref.reference)
+	body, err := s.get(ctx, manifestURL, ociManifestMediaType+", "+dockerManifestMediaType, &token)
+	if err != nil {
+		return errors.Wrap(err, "cannot get OCI manifest")
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxOCIManifestSize))
+	utils.UncheckedError(body.Close())
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(ref.reference, ociDigestPrefix) {
+		if err := checkDigest(ref.reference, data); err != nil {
+			return errors.Wrap(err, "OCI manifest")
+		}
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return errors.Wrap(err, "cannot decode OCI manifest")
+	}
+	layer, err := archiveLayer(manifest.Layers)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(layer.Digest, ociDigestPrefix) {
+		return errors.Errorf("unsupported digest %q of OCI layer", layer.Digest)
+	}
+
+	blobURL := fmt.Sprintf("%s/v2/%s/blobs/%s", ref.baseURL, ref.repository, layer.Digest)
+	body, err = s.get(ctx, blobURL, "", &token)
+	if err != nil {
+		return errors.Wrap(err, "cannot get OCI layer")
+	}
+	defer utils.UncheckedErrorFunc(body.Close)
+	hash := sha256.New()
+	if err := copyLimited(io.MultiWriter(w, hash), body, maxPackageSize); err != nil {
+		return err
+	}
+	if got := ociDigestPrefix + hex.EncodeToString(hash.Sum(nil)); got != layer.Digest {
+		return errors.Errorf("OCI layer did not match its digest %s != %s", layer.Digest, got)
+	}
+	return nil
+}
+
+// archiveLayer returns the layer of an artifact holding the archive of the package: its gzipped
+// layer, or its only layer.
+func archiveLayer(layers []ociDescriptor) (ociDescriptor, error) {
+	for _, layer := range layers {
+		if strings.Contains(layer.MediaType, "gzip") {
+			return layer, nil
+		}
+	}
+	if len(layers) == 1 {
+		return layers[0], nil
+	}
+	return ociDescriptor{}, errors.Errorf("OCI artifact has %d layers and none is gzipped", len(layers))
+}
+
+func checkDigest(digest string, data []byte) error {
+	sum := sha256.Sum256(data)
+	if got := ociDigestPrefix + hex.EncodeToString(sum[:]); got != digest {
+		return errors.Errorf("did not match its digest %s != %s", digest, got)
+	}
+	return nil
+}
+
+// get gets the URL from the registry, fetching an anonymous bearer token first if the registry asks
+// for one. The token is kept for the following requests.
+func (s *ociSource) get(ctx context.Context, u, accept string, token *string) (io.ReadCloser, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if *token != "" {
+			req.Header.Set("Authorization", "Bearer "+*token)
+		}
+		//nolint:bodyclose // returned, or closed below
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp.Body, nil
+		}
+		utils.UncheckedError(resp.Body.Close())
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 || !strings.HasPrefix(challenge, "Bearer ") {
+			return nil, fmt.Errorf("invalid status code %d", resp.StatusCode)
+		}
+		if *token, err = s.fetchToken(ctx, challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchToken fetches an anonymous token as asked for by a Bearer WWW-Authenticate challenge.
+func (s *ociSource) fetchToken(ctx context.Context, challenge string) (string, error) {
+	params := map[string]string{}
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", errors.Errorf("invalid OCI authentication realm %q", params["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value, ok := params[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	//nolint:bodyclose /// closed in UncheckedErrorFunc
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer utils.UncheckedErrorFunc(resp.Body.Close)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("invalid status code %d fetching OCI token", resp.StatusCode)
+	}
+	var tokens map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOCIManifestSize)).Decode(&tokens); err != nil {
+		return "", errors.Wrap(err, "cannot decode OCI token")
+	}
+	for _, field := range []string{"token", "access_token"} {
+		if token, ok := tokens[field].(string); ok && token != "" {
+			return token, nil
+		}
+	}
+	return "", errors.New("OCI token response has no token")
+}
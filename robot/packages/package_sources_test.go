@@ -0,0 +1,229 @@
+package packages
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/config"
+)
+
+func packageArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		test.That(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}), test.ShouldBeNil)
+		_, err := tw.Write([]byte(content))
+		test.That(t, err, test.ShouldBeNil)
+	}
+	test.That(t, tw.Close(), test.ShouldBeNil)
+	test.That(t, gz.Close(), test.ShouldBeNil)
+	return buf.Bytes()
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// fakeRegistry serves one artifact with an archive layer, asking for an anonymous token first.
+func fakeRegistry(t *testing.T, archive []byte) (*httptest.Server, string) {
+	t.Helper()
+	layerDigest := sha256Digest(archive)
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     ociManifestMediaType,
+		"layers": []map[string]interface{}{
+			{"mediaType": "application/vnd.acme.labels.v1+json", "digest": sha256Digest([]byte("{}")), "size": 2},
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": layerDigest, "size": len(archive)},
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:models/detector:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:models/detector:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/models/detector/manifests/v2", "/v2/models/detector/manifests/" + sha256Digest(manifest):
+			test.That(t, r.Header.Get("Accept"), test.ShouldContainSubstring, ociManifestMediaType)
+			_, err := w.Write(manifest)
+			test.That(t, err, test.ShouldBeNil)
+		case "/v2/models/detector/blobs/" + layerDigest:
+			_, err := w.Write(archive)
+			test.That(t, err, test.ShouldBeNil)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, sha256Digest(manifest)
+}
+
+func TestPackageSources(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	archive := packageArchive(t, map[string]string{"labels.txt": "bolt\nnut\n"})
+	checksum := sha256Digest(archive)
+
+	usb := t.TempDir()
+	archivePath := filepath.Join(usb, "labels.tar.gz")
+	test.That(t, os.WriteFile(archivePath, archive, 0o600), test.ShouldBeNil)
+
+	artifacts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/labels.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write(archive)
+		test.That(t, err, test.ShouldBeNil)
+	}))
+	defer artifacts.Close()
+	registry, manifestDigest := fakeRegistry(t, archive)
+	defer registry.Close()
+	registryHost := strings.TrimPrefix(registry.URL, "http://")
+
+	packagesDir := t.TempDir()
+	pm, err := NewCloudManager(nil, packagesDir, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer pm.Close(ctx)
+
+	good := []config.PackageConfig{
+		{Name: "local", Package: "labels", Source: archivePath},
+		{Name: "file-url", Package: "labels", Source: "file://" + filepath.ToSlash(archivePath), Checksum: checksum},
+		{Name: "http", Package: "labels", Source: artifacts.URL + "/labels.tar.gz", Checksum: checksum},
+		{Name: "oci", Package: "labels", Source: "oci+http://" + registryHost + "/models/detector:v2"},
+		{Name: "oci-digest", Package: "labels", Source: "oci+http://" + registryHost + "/models/detector@" + manifestDigest},
+	}
+	for _, p := range good {
+		test.That(t, p.Validate("packages.0"), test.ShouldBeNil)
+	}
+	bad := []config.PackageConfig{
+		{Name: "bad-checksum", Package: "labels", Source: archivePath, Checksum: sha256Digest([]byte("other"))},
+		{Name: "no-checksum", Package: "labels", Source: artifacts.URL + "/labels.tar.gz"},
+		{Name: "missing", Package: "labels", Source: artifacts.URL + "/missing.tar.gz", Checksum: checksum},
+		{Name: "oci-missing", Package: "labels", Source: "oci+http://" + registryHost + "/models/detector:v1"},
+		{Name: "unknown-scheme", Package: "labels", Source: "s3://bucket/labels.tar.gz"},
+	}
+	// without a cloud connection, packages without a source are left to the user like the noop manager does
+	cloud := config.PackageConfig{Name: "cloud", Package: "org/labels", Version: "1"}
+	test.That(t, bad[1].Validate("packages.0").Error(), test.ShouldContainSubstring, "need a checksum")
+	test.That(t, (&config.PackageConfig{Name: "p", Package: "p", Checksum: "md5:abc"}).Validate("packages.0"), test.ShouldNotBeNil)
+	err = pm.Sync(ctx, append(append(good, bad...), cloud))
+	test.That(t, err, test.ShouldNotBeNil)
+	for _, p := range bad {
+		test.That(t, err.Error(), test.ShouldContainSubstring, p.Source)
+		_, err := pm.PackagePath(PackageName(p.Name))
+		test.That(t, err, test.ShouldEqual, ErrPackageMissing)
+	}
+	test.That(t, err.Error(), test.ShouldContainSubstring, "did not match expected hash")
+	test.That(t, err.Error(), test.ShouldNotContainSubstring, "org/labels")
+	cloudPath, err := pm.PackagePath(PackageName(cloud.Name))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloudPath, test.ShouldEqual, cloud.Name)
+	for _, p := range good {
+		labels, err := pm.RefPath("${packages." + p.Name + "}/labels.txt")
+		test.That(t, err, test.ShouldBeNil)
+		data, err := os.ReadFile(labels)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(data), test.ShouldEqual, "bolt\nnut\n")
+	}
+
+	// packages from different sources do not share a download, and stay after a cleanup
+	test.That(t, hashName(good[0]), test.ShouldNotEqual, hashName(good[1]))
+	test.That(t, pm.Sync(ctx, good), test.ShouldBeNil)
+	test.That(t, pm.Cleanup(ctx), test.ShouldBeNil)
+	entries, err := os.ReadDir(filepath.Join(packagesDir, ".data"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, entries, test.ShouldHaveLength, len(good))
+
+	// a changed source is downloaded again
+	RegisterSource("test", sourceFunc(func(ctx context.Context, p config.PackageConfig, w io.Writer) error {
+		_, err := w.Write(packageArchive(t, map[string]string{"labels.txt": "washer\n"}))
+		return err
+	}))
+	changed := good[0]
+	changed.Source = "test://labels"
+	test.That(t, pm.Sync(ctx, []config.PackageConfig{changed}), test.ShouldBeNil)
+	labels, err := pm.RefPath("${packages.local}/labels.txt")
+	test.That(t, err, test.ShouldBeNil)
+	data, err := os.ReadFile(labels)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(data), test.ShouldEqual, "washer\n")
+}
+
+func TestPackagesWithoutSources(t *testing.T) {
+	ctx := context.Background()
+	packagesDir := t.TempDir()
+	pm, err := NewCloudManager(nil, packagesDir, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer pm.Close(ctx)
+
+	// packages fetched from the cloud in an earlier run are kept when there is no cloud connection
+	leftover := filepath.Join(packagesDir, ".data", "org-labels-1")
+	test.That(t, os.MkdirAll(leftover, 0o700), test.ShouldBeNil)
+	test.That(t, pm.Sync(ctx, []config.PackageConfig{{Name: "labels", Package: "org/labels", Version: "1"}}), test.ShouldBeNil)
+	test.That(t, pm.Cleanup(ctx), test.ShouldBeNil)
+	_, err = os.Stat(leftover)
+	test.That(t, err, test.ShouldBeNil)
+	path, err := pm.RefPath("${packages.labels}/labels.txt")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, path, test.ShouldEqual, "labels/labels.txt")
+}
+
+func TestCopyLimited(t *testing.T) {
+	var buf bytes.Buffer
+	test.That(t, copyLimited(&buf, strings.NewReader("bolt"), 4), test.ShouldBeNil)
+	test.That(t, buf.String(), test.ShouldEqual, "bolt")
+	buf.Reset()
+	err := copyLimited(&buf, strings.NewReader("bolts"), 4)
+	test.That(t, err, test.ShouldBeError, "package is larger than the limit of 4 bytes")
+}
+
+type sourceFunc func(ctx context.Context, p config.PackageConfig, w io.Writer) error
+
+func (f sourceFunc) Download(ctx context.Context, p config.PackageConfig, w io.Writer) error {
+	return f(ctx, p, w)
+}
+
+func TestParseOCIReference(t *testing.T) {
+	for source, expected := range map[string]ociReference{
+		"oci://registry.local:5000/models/detector:v2": {"https://registry.local:5000", "models/detector", "v2"},
+		"oci+http://localhost:5000/detector":           {"http://localhost:5000", "detector", "latest"},
+		"oci://ghcr.io/acme/detector@sha256:abc":       {"https://ghcr.io", "acme/detector", "sha256:abc"},
+	} {
+		ref, err := parseOCIReference(source)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ref, test.ShouldResemble, expected)
+	}
+	_, err := parseOCIReference("oci://registry.local")
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/edaniels/golog"
 	"github.com/jhump/protoreflect/desc"
@@ -79,6 +80,14 @@ type Robot interface {
 	// Status takes a list of resource names and returns their corresponding statuses. If no names are passed in, return all statuses.
 	Status(ctx context.Context, resourceNames []resource.Name) ([]Status, error)
 
+	// ResourceStatuses returns the state of every resource the robot is configured with or
+	// connected to, including the ones that are not available.
+	ResourceStatuses(ctx context.Context) ([]ResourceStatus, error)
+
+	// ResourceGraph returns a snapshot of the resources of the robot and the dependencies
+	// between them.
+	ResourceGraph(ctx context.Context) (*resource.GraphSnapshot, error)
+
 	// Close attempts to cleanly close down all constituent parts of the robot.
 	Close(ctx context.Context) error
 
@@ -98,6 +107,11 @@ type LocalRobot interface {
 	// on the given new config.
 	Reconfigure(ctx context.Context, newConfig *config.Config)
 
+	// FailedResources returns the names of the components and services of the
+	// config the robot is using that are not built with that config, such as
+	// because they failed to build.
+	FailedResources() []resource.Name
+
 	// StartWeb starts the web server, will return an error if server is already up.
 	StartWeb(ctx context.Context, o weboptions.Options) error
 
@@ -127,6 +141,38 @@ type Status struct {
 	Status interface{}
 }
 
+// ResourceState is the state of a resource of a robot.
+type ResourceState string
+
+// The states a resource of a robot can be in.
+const (
+	// ResourceStateConfiguring means the resource is waiting to be built, or rebuilt with a new config.
+	ResourceStateConfiguring ResourceState = "configuring"
+	// ResourceStateReady means the resource is built and available.
+	ResourceStateReady ResourceState = "ready"
+	// ResourceStateFailed means the resource failed to build or was made unavailable by an error.
+	ResourceStateFailed ResourceState = "failed"
+	// ResourceStateRemoving means the resource is being removed.
+	ResourceStateRemoving ResourceState = "removing"
+	// ResourceStateOrphaned means the module serving the resource stopped serving it, such as by
+	// crashing, and the resource will not be available until the robot is reconfigured.
+	ResourceStateOrphaned ResourceState = "orphaned"
+)
+
+// ResourceStatus describes the state of a resource of a robot.
+type ResourceStatus struct {
+	Name  resource.Name
+	State ResourceState
+	// Error is why the resource failed or was orphaned, if it was.
+	Error error
+	// LastUpdated is when the state last changed, if known.
+	LastUpdated time.Time
+	// BlockedBy explains which dependencies keep the resource from being built, if any.
+	BlockedBy []string
+	// ModuleHealth is the health of the module serving the resource, if it is modular.
+	ModuleHealth map[string]interface{}
+}
+
 // AllResourcesByName returns an array of all resources that have this short name.
 // NOTE: this function queries by the shortname rather than the fully qualified resource name which is not recommended practice
 // and may become deprecated in the future.
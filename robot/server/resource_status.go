@@ -0,0 +1,321 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	commonpb "go.viam.com/api/common/v1"
+	vprotoutils "go.viam.com/utils/protoutils"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/protoutils"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+)
+
+// ResourceStatusServiceName is the name of the gRPC service reporting the state of every resource
+// of a robot and the dependencies between them. The service is specific to the RDK and has no
+// proto definition, so it is kept out of the packages of the robot API protos; its requests and
+// responses are google.protobuf.Struct messages, whose fields are described with each method.
+const ResourceStatusServiceName = "rdk.resourcestatus.ResourceStatusService"
+
+// GetResourceStatusesMethod is the full method name of the call returning the state of every
+// resource of a robot. The request is an empty struct. The response is a struct with the field
+//
+//	resources: list of structs, one per resource, with the fields
+//	  name: struct with the string fields namespace, type, subtype and name of the resource
+//	  state: string, one of "configuring", "ready", "failed", "removing" or "orphaned"
+//	  error: string, why the resource failed or was orphaned, if it was
+//	  last_updated: string, when the state last changed in RFC 3339 format, if known
+//	  blocked_by: list of strings, which dependencies keep the resource from being built, if any
+//	  module_health: struct, the health of the module serving the resource, if it is modular
+const GetResourceStatusesMethod = "/" + ResourceStatusServiceName + "/GetResourceStatuses"
+
+// GetResourceGraphMethod is the full method name of the call returning a snapshot of the resource
+// graph of a robot. The request is an empty struct. The response is a struct with the fields
+//
+//	nodes: list of structs, one per resource sorted by name, with the fields
+//	  name: string, the full name of the resource
+//	  model: string, the model of the resource, if known
+//	  state: string, one of "configuring", "ready", "failed" or "removing"
+//	  error: string, why the resource failed, if it did
+//	  remote: string, the remote the resource comes from, if any
+//	  module: string, the module serving the resource, if any
+//	edges: list of structs, one per dependency, with the fields
+//	  from: string, the name of the dependent resource
+//	  to: string, the name of the resource depended on
+//	  kind: string, one of "hard", "weak" or "frame"
+const GetResourceGraphMethod = "/" + ResourceStatusServiceName + "/GetResourceGraph"
+
+// GetConfigMethod is the full method name of the call returning the config a robot is running.
+// The request is an empty struct. The response is the config in the JSON form of a robot config
+// file, secrets included; it is only served by robots that can be reconfigured.
+const GetConfigMethod = "/" + ResourceStatusServiceName + "/GetConfig"
+
+// ResourceStatusServiceDesc describes the resource status service for registering it with an
+// rpc.Server.
+var ResourceStatusServiceDesc = grpc.ServiceDesc{
+	ServiceName: ResourceStatusServiceName,
+	HandlerType: (*ResourceStatusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetResourceStatuses",
+			Handler:    getResourceStatusesHandler,
+		},
+		{
+			MethodName: "GetResourceGraph",
+			Handler:    getResourceGraphHandler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    getConfigHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// A ResourceStatusServer serves the state of every resource of a robot and the dependencies
+// between them.
+type ResourceStatusServer interface {
+	GetResourceStatuses(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	GetResourceGraph(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	GetConfig(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+type resourceStatusServer struct {
+	r robot.Robot
+}
+
+// NewResourceStatusServer constructs a resource status service server for a Robot.
+func NewResourceStatusServer(r robot.Robot) ResourceStatusServer {
+	return &resourceStatusServer{r: r}
+}
+
+// GetResourceStatuses returns the state of every resource of the robot.
+func (s *resourceStatusServer) GetResourceStatuses(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	statuses, err := s.r.ResourceStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ResourceStatusesToProto(statuses)
+}
+
+// GetResourceGraph returns a snapshot of the resource graph of the robot.
+func (s *resourceStatusServer) GetResourceGraph(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	snapshot, err := s.r.ResourceGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ResourceGraphToProto(snapshot)
+}
+
+// GetConfig returns the config the robot is running.
+func (s *resourceStatusServer) GetConfig(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	lr, ok := s.r.(robot.LocalRobot)
+	if !ok {
+		return nil, errors.New("robot does not serve the config it is running")
+	}
+	cfg, err := lr.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ConfigToProto(cfg)
+}
+
+func getResourceStatusesHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceStatusServer).GetResourceStatuses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetResourceStatusesMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceStatusServer).GetResourceStatuses(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getResourceGraphHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceStatusServer).GetResourceGraph(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetResourceGraphMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceStatusServer).GetResourceGraph(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getConfigHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceStatusServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GetConfigMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceStatusServer).GetConfig(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ResourceStatusesToProto converts resource statuses into the response of GetResourceStatuses.
+func ResourceStatusesToProto(statuses []robot.ResourceStatus) (*structpb.Struct, error) {
+	resources := make([]interface{}, 0, len(statuses))
+	for _, status := range statuses {
+		name := protoutils.ResourceNameToProto(status.Name)
+		encoded := map[string]interface{}{
+			"name": map[string]interface{}{
+				"namespace": name.Namespace,
+				"type":      name.Type,
+				"subtype":   name.Subtype,
+				"name":      name.Name,
+			},
+			"state": string(status.State),
+		}
+		if status.Error != nil {
+			encoded["error"] = status.Error.Error()
+		}
+		if !status.LastUpdated.IsZero() {
+			encoded["last_updated"] = status.LastUpdated.UTC().Format(time.RFC3339Nano)
+		}
+		if len(status.BlockedBy) > 0 {
+			blockedBy := make([]interface{}, 0, len(status.BlockedBy))
+			for _, reason := range status.BlockedBy {
+				blockedBy = append(blockedBy, reason)
+			}
+			encoded["blocked_by"] = blockedBy
+		}
+		if status.ModuleHealth != nil {
+			encoded["module_health"] = status.ModuleHealth
+		}
+		resources = append(resources, encoded)
+	}
+	return vprotoutils.StructToStructPb(map[string]interface{}{"resources": resources})
+}
+
+// ResourceStatusesFromProto converts the response of GetResourceStatuses into resource statuses.
+func ResourceStatusesFromProto(resp *structpb.Struct) ([]robot.ResourceStatus, error) {
+	resources := resp.GetFields()["resources"].GetListValue().GetValues()
+	statuses := make([]robot.ResourceStatus, 0, len(resources))
+	for _, res := range resources {
+		fields := res.GetStructValue().GetFields()
+		name := fields["name"].GetStructValue().GetFields()
+		status := robot.ResourceStatus{
+			Name: protoutils.ResourceNameFromProto(&commonpb.ResourceName{
+				Namespace: name["namespace"].GetStringValue(),
+				Type:      name["type"].GetStringValue(),
+				Subtype:   name["subtype"].GetStringValue(),
+				Name:      name["name"].GetStringValue(),
+			}),
+			State: robot.ResourceState(fields["state"].GetStringValue()),
+		}
+		if errMsg := fields["error"].GetStringValue(); errMsg != "" {
+			status.Error = errors.New(errMsg)
+		}
+		if lastUpdated := fields["last_updated"].GetStringValue(); lastUpdated != "" {
+			t, err := time.Parse(time.RFC3339Nano, lastUpdated)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid last update time of %q", status.Name)
+			}
+			status.LastUpdated = t
+		}
+		for _, reason := range fields["blocked_by"].GetListValue().GetValues() {
+			status.BlockedBy = append(status.BlockedBy, reason.GetStringValue())
+		}
+		if health := fields["module_health"].GetStructValue(); health != nil {
+			status.ModuleHealth = health.AsMap()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ResourceGraphToProto converts a resource graph snapshot into the response of GetResourceGraph.
+func ResourceGraphToProto(snapshot *resource.GraphSnapshot) (*structpb.Struct, error) {
+	md, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	resp := &structpb.Struct{}
+	if err := protojson.Unmarshal(md, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ResourceGraphFromProto converts the response of GetResourceGraph into a resource graph snapshot.
+func ResourceGraphFromProto(resp *structpb.Struct) (*resource.GraphSnapshot, error) {
+	md, err := protojson.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot resource.GraphSnapshot
+	if err := json.Unmarshal(md, &snapshot); err != nil {
+		return nil, errors.Wrap(err, "invalid resource graph")
+	}
+	return &snapshot, nil
+}
+
+// ConfigToProto converts a robot config into the response of GetConfig.
+func ConfigToProto(cfg *config.Config) (*structpb.Struct, error) {
+	md, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	resp := &structpb.Struct{}
+	if err := protojson.Unmarshal(md, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ConfigFromProto converts the response of GetConfig into a robot config.
+func ConfigFromProto(resp *structpb.Struct) (*config.Config, error) {
+	md, err := protojson.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var cfg config.Config
+	if err := json.Unmarshal(md, &cfg); err != nil {
+		return nil, errors.Wrap(err, "invalid robot config")
+	}
+	return &cfg, nil
+}
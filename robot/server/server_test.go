@@ -22,9 +22,11 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"go.viam.com/rdk/components/arm"
 	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/config"
 	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/protoutils"
 	"go.viam.com/rdk/referenceframe"
@@ -36,6 +38,7 @@ import (
 	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/testutils"
 	"go.viam.com/rdk/testutils/inject"
+	rutils "go.viam.com/rdk/utils"
 )
 
 var emptyResources = &pb.ResourceNamesResponse{
@@ -649,3 +652,117 @@ func (mgr *sessionManager) Close() {
 func (mgr *sessionManager) ServerInterceptors() session.ServerInterceptors {
 	panic("unimplemented")
 }
+
+func TestServerResourceStatuses(t *testing.T) {
+	lastUpdated := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []robot.ResourceStatus{
+		{
+			Name:        arm.Named("arm1"),
+			State:       robot.ResourceStateReady,
+			LastUpdated: lastUpdated,
+			ModuleHealth: map[string]interface{}{
+				"module":   "arms",
+				"healthy":  true,
+				"restarts": []interface{}{map[string]interface{}{"reason": "crashed", "success": true}},
+			},
+		},
+		{
+			Name:        movementsensor.Named("remote1:imu"),
+			State:       robot.ResourceStateFailed,
+			Error:       errors.New("resource build error: no serial port"),
+			LastUpdated: lastUpdated,
+			BlockedBy:   []string{`dependency "board" cannot be found`},
+		},
+		{
+			Name:  arm.Named("arm2"),
+			State: robot.ResourceStateOrphaned,
+		},
+	}
+	injectRobot := &inject.Robot{}
+	injectRobot.ResourceStatusesFunc = func(ctx context.Context) ([]robot.ResourceStatus, error) {
+		return statuses, nil
+	}
+	resp, err := server.NewResourceStatusServer(injectRobot).GetResourceStatuses(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldBeNil)
+
+	decoded, err := server.ResourceStatusesFromProto(resp)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, decoded, test.ShouldHaveLength, 3)
+	test.That(t, decoded[0], test.ShouldResemble, statuses[0])
+	test.That(t, decoded[1].Name, test.ShouldResemble, statuses[1].Name)
+	test.That(t, decoded[1].Error.Error(), test.ShouldEqual, statuses[1].Error.Error())
+	test.That(t, decoded[1].BlockedBy, test.ShouldResemble, statuses[1].BlockedBy)
+	test.That(t, decoded[2], test.ShouldResemble, statuses[2])
+
+	injectRobot.ResourceStatusesFunc = func(ctx context.Context) ([]robot.ResourceStatus, error) {
+		return nil, errors.New("whoops")
+	}
+	_, err = server.NewResourceStatusServer(injectRobot).GetResourceStatuses(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestServerResourceGraph(t *testing.T) {
+	snapshot := &resource.GraphSnapshot{
+		Nodes: []resource.GraphSnapshotNode{
+			{Name: arm.Named("arm1").String(), Model: "rdk:builtin:fake", State: "ready", Module: "arms"},
+			{Name: movementsensor.Named("remote1:imu").String(), State: "failed", Error: "no serial port", Remote: "remote1"},
+		},
+		Edges: []resource.GraphSnapshotEdge{
+			{From: arm.Named("arm1").String(), To: movementsensor.Named("remote1:imu").String(), Kind: resource.DependencyKindFrame},
+		},
+	}
+	injectRobot := &inject.Robot{}
+	injectRobot.ResourceGraphFunc = func(ctx context.Context) (*resource.GraphSnapshot, error) {
+		return snapshot, nil
+	}
+	resp, err := server.NewResourceStatusServer(injectRobot).GetResourceGraph(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldBeNil)
+
+	decoded, err := server.ResourceGraphFromProto(resp)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, decoded, test.ShouldResemble, snapshot)
+
+	injectRobot.ResourceGraphFunc = func(ctx context.Context) (*resource.GraphSnapshot, error) {
+		return nil, errors.New("whoops")
+	}
+	_, err = server.NewResourceStatusServer(injectRobot).GetResourceGraph(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestServerConfig(t *testing.T) {
+	cfg := &config.Config{
+		Components: []resource.Config{
+			{
+				Name:  "arm1",
+				API:   arm.API,
+				Model: resource.DefaultModelFamily.WithModel("fake"),
+				Attributes: rutils.AttributeMap{
+					"model-path": "ur5e.json",
+				},
+			},
+		},
+		Modules: []config.Module{{Name: "arms", ExePath: "/tmp/arms"}},
+	}
+	injectRobot := &inject.Robot{}
+	injectRobot.ConfigFunc = func(ctx context.Context) (*config.Config, error) {
+		return cfg, nil
+	}
+	resp, err := server.NewResourceStatusServer(injectRobot).GetConfig(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldBeNil)
+
+	decoded, err := server.ConfigFromProto(resp)
+	test.That(t, err, test.ShouldBeNil)
+	diff, err := config.DiffConfigs(*cfg, *decoded, false)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, diff.ResourcesEqual, test.ShouldBeTrue)
+	test.That(t, diff.NetworkEqual, test.ShouldBeTrue)
+
+	injectRobot.ConfigFunc = func(ctx context.Context) (*config.Config, error) {
+		return nil, errors.New("whoops")
+	}
+	_, err = server.NewResourceStatusServer(injectRobot).GetConfig(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	_, err = server.NewResourceStatusServer(struct{ robot.Robot }{injectRobot}).GetConfig(context.Background(), &structpb.Struct{})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -416,6 +416,13 @@ func (svc *webService) StartModule(ctx context.Context) error {
 	if err := svc.modServer.RegisterServiceServer(ctx, &pb.RobotService_ServiceDesc, grpcserver.New(svc.r)); err != nil {
 		return err
 	}
+	if err := svc.modServer.RegisterServiceServer(
+		ctx,
+		&grpcserver.ResourceStatusServiceDesc,
+		grpcserver.NewResourceStatusServer(svc.r),
+	); err != nil {
+		return err
+	}
 	if err := svc.refreshResources(); err != nil {
 		return err
 	}
@@ -799,6 +806,13 @@ func (svc *webService) runWeb(ctx context.Context, options weboptions.Options) (
 	); err != nil {
 		return err
 	}
+	if err := svc.rpcServer.RegisterServiceServer(
+		ctx,
+		&grpcserver.ResourceStatusServiceDesc,
+		grpcserver.NewResourceStatusServer(svc.r),
+	); err != nil {
+		return err
+	}
 
 	if err := svc.refreshResources(); err != nil {
 		return err
@@ -268,6 +268,12 @@ func GetFileExt(dataType v1.DataType, methodName string, parameters map[string]s
 				return ".png"
 			case utils.MimeTypePCD:
 				return ".pcd"
+			case utils.MimeTypePLY:
+				return ".ply"
+			case utils.MimeTypeXYZ:
+				return ".xyz"
+			case utils.MimeTypeE57:
+				return ".e57"
 			default:
 				return defaultFileExt
 			}
@@ -0,0 +1,45 @@
+package mlmodel
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// BatchService is implemented by ML model services that can run several sets of inputs through
+// their model in one call, such as by stacking them along the batch dimension of the inputs.
+type BatchService interface {
+	Service
+	InferBatch(ctx context.Context, inputs []map[string]interface{}) ([]map[string]interface{}, error)
+}
+
+// InferBatch runs each of the input maps through the model and returns the outputs in the same
+// order. Services that implement BatchService run them together, others are called once per input.
+func InferBatch(ctx context.Context, svc Service, inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	if bs, ok := svc.(BatchService); ok {
+		return bs.InferBatch(ctx, inputs)
+	}
+	outputs := make([]map[string]interface{}, 0, len(inputs))
+	for i, input := range inputs {
+		out, err := svc.Infer(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "input %d of batch", i)
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
+
+type callerKey struct{}
+
+// ContextWithCaller returns a context that attributes the inference requests made with it to the
+// caller, e.g. the camera the images come from, so that services can report latency per caller.
+func ContextWithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller set by ContextWithCaller, if any.
+func CallerFromContext(ctx context.Context) (string, bool) {
+	caller, ok := ctx.Value(callerKey{}).(string)
+	return caller, ok
+}
@@ -2,8 +2,10 @@ package mlmodel
 
 import (
 	"context"
+	"sync"
 
 	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
 	pb "go.viam.com/api/service/mlmodel/v1"
 	"go.viam.com/utils/rpc"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -57,6 +59,28 @@ func (c *client) Infer(ctx context.Context, input map[string]interface{}) (map[s
 	return resp.OutputData.AsMap(), nil
 }
 
+// InferBatch sends the inputs as concurrent requests so that the remote service can batch them or
+// run them on several interpreters at once.
+func (c *client) InferBatch(ctx context.Context, inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	outputs := make([]map[string]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func(i int, input map[string]interface{}) {
+			defer wg.Done()
+			outputs[i], errs[i] = c.Infer(ctx, input)
+		}(i, input)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "input %d of batch", i)
+		}
+	}
+	return outputs, nil
+}
+
 func (c *client) Metadata(ctx context.Context) (MLMetadata, error) {
 	resp, err := c.client.Metadata(ctx, &pb.MetadataRequest{
 		Name: c.name,
@@ -82,6 +82,11 @@ func TestClient(t *testing.T) {
 		result, err = client.Infer(context.Background(), nil)
 		test.That(t, err, test.ShouldBeNil)
 		test.That(t, len(result), test.ShouldEqual, 4)
+		// batches are sent as concurrent requests
+		results, err := mlmodel.InferBatch(context.Background(), client, []map[string]interface{}{inputData, nil})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, results, test.ShouldHaveLength, 2)
+		test.That(t, len(results[1]), test.ShouldEqual, 4)
 		// Metadata Command
 		meta, err := client.Metadata(context.Background())
 		test.That(t, err, test.ShouldBeNil)
@@ -0,0 +1,342 @@
+// Package onnxcpu runs ONNX model files on the host's CPU, as an implementation the ML model service.
+package onnxcpu
+
+import (
+	"context"
+	fp "path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/ml/inference/onnx"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/mlmodel"
+	"go.viam.com/rdk/services/mlmodel/scheduler"
+	"go.viam.com/rdk/utils"
+)
+
+var sModel = resource.DefaultModelFamily.WithModel("onnx_cpu")
+
+const (
+	// LayoutNHWC means flat image inputs are given channels last, the way the vision service passes
+	// images, and are transposed for graphs that take them channels first.
+	LayoutNHWC = "nhwc"
+	// LayoutNCHW means flat image inputs are given in the layout of the graph and passed as they are.
+	LayoutNCHW = "nchw"
+)
+
+func init() {
+	resource.RegisterService(mlmodel.API, sModel, resource.Registration[mlmodel.Service, *ONNXConfig]{
+		Constructor: func(
+			ctx context.Context,
+			_ resource.Dependencies,
+			conf resource.Config,
+			logger golog.Logger,
+		) (mlmodel.Service, error) {
+			svcConf, err := resource.NativeConfig[*ONNXConfig](conf)
+			if err != nil {
+				return nil, err
+			}
+			return NewONNXCPUModel(ctx, svcConf, conf.ResourceName(), logger)
+		},
+	})
+}
+
+// ONNXConfig contains the parameters specific to an onnx_cpu implementation
+// of the MLMS (machine learning model service).
+type ONNXConfig struct {
+	resource.TriviallyValidateConfig
+	ModelPath string  `json:"model_path"`
+	LabelPath *string `json:"label_path"`
+	// InputLayout is the layout of flat 4D inputs, either "nhwc", the default, or "nchw".
+	InputLayout string `json:"input_layout,omitempty"`
+	// PoolSize is the number of requests run concurrently, 1 by default.
+	PoolSize int `json:"pool_size,omitempty"`
+	// MaxBatchSize is the largest number of samples batched into one run of the model for the
+	// models with a batch dimension of unknown size. Requests are not batched by default.
+	MaxBatchSize int `json:"max_batch_size,omitempty"`
+	// BatchTimeoutMs is how long requests wait for others to batch with when the model is idle.
+	BatchTimeoutMs int `json:"batch_timeout_ms,omitempty"`
+}
+
+// Walk implements the Walker interface and correctly replaces model and label paths.
+func (cfg *ONNXConfig) Walk(visitor utils.Visitor) (interface{}, error) {
+	modelPath, err := visitor.Visit(cfg.ModelPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ModelPath = modelPath.(string)
+
+	labelPath, err := visitor.Visit(cfg.LabelPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.LabelPath = labelPath.(*string)
+
+	return cfg, nil
+}
+
+// Model is a struct that implements the ONNX CPU implementation of the MLMS.
+// It includes the configured parameters, model struct, and associated metadata.
+type Model struct {
+	resource.Named
+	resource.AlwaysRebuild
+	conf      ONNXConfig
+	model     *onnx.Model
+	metadata  mlmodel.MLMetadata
+	scheduler *scheduler.Scheduler
+	logger    golog.Logger
+}
+
+// NewONNXCPUModel is a constructor that builds an onnx cpu implementation of the MLMS.
+func NewONNXCPUModel(ctx context.Context, params *ONNXConfig, name resource.Name, logger golog.Logger) (mlmodel.Service, error) {
+	_, span := trace.StartSpan(ctx, "service::mlmodel::NewONNXCPUModel")
+	defer span.End()
+	if params == nil {
+		return nil, errors.New("could not find parameters")
+	}
+	switch params.InputLayout {
+	case "", LayoutNHWC, LayoutNCHW:
+	default:
+		return nil, errors.Errorf("input_layout must be %q or %q, got %q", LayoutNHWC, LayoutNCHW, params.InputLayout)
+	}
+	if params.BatchTimeoutMs < 0 {
+		return nil, errors.New("batch_timeout_ms cannot be negative")
+	}
+	path := params.ModelPath
+	if fullpath, err := fp.Abs(path); err == nil {
+		path = fullpath
+	}
+	model, err := onnx.Load(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not add model from location %s", params.ModelPath)
+	}
+	m := &Model{
+		Named:  name.AsNamed(),
+		conf:   *params,
+		model:  model,
+		logger: logger,
+	}
+	m.metadata = m.fillMetadata()
+	// the model does not change while inferring, so every interpreter of the pool shares it
+	m.scheduler, err = scheduler.New(
+		scheduler.Config{
+			PoolSize:     params.PoolSize,
+			MaxBatchSize: params.MaxBatchSize,
+			BatchTimeout: time.Duration(params.BatchTimeoutMs) * time.Millisecond,
+		},
+		m.metadata.Inputs,
+		func() (scheduler.Interpreter, error) { return scheduler.InterpreterFunc(m.infer), nil },
+		m.logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fillMetadata describes the model from its graph, the type of the model is read from the
+// model_type metadata property that exporters can set.
+func (m *Model) fillMetadata() mlmodel.MLMetadata {
+	info := m.model.Info
+	out := mlmodel.MLMetadata{
+		ModelName:        info.GraphName,
+		ModelType:        info.MetadataProps["model_type"],
+		ModelDescription: info.Description,
+	}
+	if out.ModelName == "" {
+		out.ModelName = strings.TrimSuffix(fp.Base(m.conf.ModelPath), fp.Ext(m.conf.ModelPath))
+	}
+	for _, in := range info.Inputs {
+		out.Inputs = append(out.Inputs, getTensorInfo(in))
+	}
+	for i, o := range info.Outputs {
+		td := getTensorInfo(o)
+		if i == 0 && m.conf.LabelPath != nil {
+			td.Extra = map[string]interface{}{"labels": *m.conf.LabelPath}
+		}
+		out.Outputs = append(out.Outputs, td)
+	}
+	return out
+}
+
+func getTensorInfo(info onnx.TensorInfo) mlmodel.TensorInfo {
+	return mlmodel.TensorInfo{
+		Name:        info.Name,
+		Description: info.Description,
+		DataType:    info.DataType,
+		Shape:       info.Shape,
+	}
+}
+
+// Infer takes the input map, keyed by the names of the graph inputs, and returns the outputs of the
+// graph keyed by their names. Each output is also returned as output0, output1, etc. in the order of
+// the graph so that consumers looking for positional outputs find them.
+func (m *Model) Infer(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	ctx, span := trace.StartSpan(ctx, "service::mlmodel::onnx_cpu::Infer")
+	defer span.End()
+	return m.scheduler.Infer(ctx, input)
+}
+
+// InferBatch runs the input maps together, stacking them along the batch dimension of the graph
+// inputs when max_batch_size allows it, and returns their outputs in the same order.
+func (m *Model) InferBatch(ctx context.Context, inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	ctx, span := trace.StartSpan(ctx, "service::mlmodel::onnx_cpu::InferBatch")
+	defer span.End()
+	return m.scheduler.InferBatch(ctx, inputs)
+}
+
+// DoCommand returns the latency of the requests made to the model by caller for the
+// inference_stats command.
+func (m *Model) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if name, ok := cmd["command"]; ok && name == scheduler.StatsCommand {
+		return m.scheduler.StatsResponse(), nil
+	}
+	return m.Named.DoCommand(ctx, cmd)
+}
+
+// infer runs the graph on one input map.
+func (m *Model) infer(input map[string]interface{}) (map[string]interface{}, error) {
+	inputs := make(map[string]onnx.Tensor, len(m.metadata.Inputs))
+	for _, info := range m.metadata.Inputs {
+		data, ok := input[info.Name]
+		if !ok {
+			// If there's only one thing in the input map, use it.
+			if len(input) != 1 || len(m.metadata.Inputs) != 1 {
+				return nil, errors.Errorf("input map has no tensor named %q", info.Name)
+			}
+			for _, in := range input {
+				data = in
+			}
+		}
+		tensor, err := m.toTensor(info, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't infer from model %q", m.Name())
+		}
+		inputs[info.Name] = tensor
+	}
+
+	outputs, err := m.model.Infer(inputs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't infer from model %q", m.Name())
+	}
+	outMap := make(map[string]interface{}, 2*len(outputs))
+	for i, info := range m.metadata.Outputs {
+		data := outputs[info.Name].Data
+		outMap[info.Name] = data
+		outMap["output"+strconv.Itoa(i)] = data
+	}
+	return outMap, nil
+}
+
+// toTensor shapes the flat input for the graph input, inferring the size of the one dimension
+// whose size is not fixed, usually the batch size, from the length of the data.
+func (m *Model) toTensor(info mlmodel.TensorInfo, data interface{}) (onnx.Tensor, error) {
+	length, err := flatLength(data)
+	if err != nil {
+		return onnx.Tensor{}, errors.Wrapf(err, "input %q", info.Name)
+	}
+	shape := append([]int{}, info.Shape...)
+	unknown := -1
+	known := 1
+	for k, d := range shape {
+		if d >= 0 {
+			known *= d
+			continue
+		}
+		if unknown >= 0 {
+			return onnx.Tensor{}, errors.Errorf("input %q has more than one dimension of unknown size in shape %v", info.Name, info.Shape)
+		}
+		unknown = k
+	}
+	if unknown >= 0 {
+		if known == 0 || length%known != 0 {
+			return onnx.Tensor{}, errors.Errorf("input %q of %d elements does not fit shape %v", info.Name, length, info.Shape)
+		}
+		shape[unknown] = length / known
+	}
+	// images of 1 or 3 channels given channels last are transposed for graphs taking them channels first
+	if m.conf.InputLayout != LayoutNCHW && len(shape) == 4 && isChannels(shape[1]) && !isChannels(shape[3]) {
+		data = nhwcToNCHW(data, shape)
+	}
+	return onnx.Tensor{Shape: shape, Data: data}, nil
+}
+
+func isChannels(d int) bool {
+	return d == 1 || d == 3
+}
+
+func flatLength(data interface{}) (int, error) {
+	switch v := data.(type) {
+	case []float32:
+		return len(v), nil
+	case []float64:
+		return len(v), nil
+	case []uint8:
+		return len(v), nil
+	case []int8:
+		return len(v), nil
+	case []int32:
+		return len(v), nil
+	case []int64:
+		return len(v), nil
+	case []int:
+		return len(v), nil
+	case []bool:
+		return len(v), nil
+	default:
+		return 0, errors.Errorf("unsupported input of type %T, expected a flat slice of numbers", data)
+	}
+}
+
+// nhwcToNCHW transposes flat data of the NCHW shape given channels last to channels first.
+func nhwcToNCHW(data interface{}, shape []int) interface{} {
+	switch v := data.(type) {
+	case []float32:
+		return transposeChannels(v, shape)
+	case []float64:
+		return transposeChannels(v, shape)
+	case []uint8:
+		return transposeChannels(v, shape)
+	case []int8:
+		return transposeChannels(v, shape)
+	case []int32:
+		return transposeChannels(v, shape)
+	case []int64:
+		return transposeChannels(v, shape)
+	case []int:
+		return transposeChannels(v, shape)
+	default:
+		return data
+	}
+}
+
+func transposeChannels[T any](data []T, shape []int) []T {
+	n, c, h, w := shape[0], shape[1], shape[2], shape[3]
+	out := make([]T, len(data))
+	for b := 0; b < n; b++ {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				for ch := 0; ch < c; ch++ {
+					out[((b*c+ch)*h+y)*w+x] = data[((b*h+y)*w+x)*c+ch]
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Metadata returns the metadata of the model, read from its graph.
+func (m *Model) Metadata(ctx context.Context) (mlmodel.MLMetadata, error) {
+	return m.metadata, nil
+}
+
+// Close stops the scheduling of requests to the model.
+func (m *Model) Close(ctx context.Context) error {
+	m.scheduler.Close()
+	return nil
+}
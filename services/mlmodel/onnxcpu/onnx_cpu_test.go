@@ -0,0 +1,121 @@
+package onnxcpu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/services/mlmodel"
+)
+
+// the test model averages the red and blue channels of a 2x2 image into two logits.
+const testModelPath = "data/red_blue_classifier.onnx"
+
+func TestEmptyONNXConfig(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	got, err := NewONNXCPUModel(ctx, &ONNXConfig{}, mlmodel.Named("fakeModel"), logger)
+	test.That(t, got, test.ShouldBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "could not add model")
+
+	_, err = NewONNXCPUModel(ctx, nil, mlmodel.Named("fakeModel"), logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	_, err = NewONNXCPUModel(ctx, &ONNXConfig{ModelPath: testModelPath, InputLayout: "chw"}, mlmodel.Named("fakeModel"), logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "input_layout must be")
+}
+
+func TestONNXCPUClassifier(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	labels := "labels.txt"
+	svc, err := NewONNXCPUModel(ctx, &ONNXConfig{ModelPath: testModelPath, LabelPath: &labels}, mlmodel.Named("myClassifier"), logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+
+	md, err := svc.Metadata(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, md.ModelName, test.ShouldEqual, "test_graph")
+	test.That(t, md.ModelType, test.ShouldEqual, "classifier")
+	test.That(t, md.Inputs, test.ShouldHaveLength, 1)
+	test.That(t, md.Inputs[0].Name, test.ShouldEqual, "image")
+	test.That(t, md.Inputs[0].DataType, test.ShouldEqual, "float32")
+	test.That(t, md.Inputs[0].Shape, test.ShouldResemble, []int{-1, 3, 2, 2})
+	test.That(t, md.Outputs, test.ShouldHaveLength, 1)
+	test.That(t, md.Outputs[0].Name, test.ShouldEqual, "logits")
+	test.That(t, md.Outputs[0].Extra, test.ShouldResemble, map[string]interface{}{"labels": "labels.txt"})
+
+	// a red image given channels last like the vision service does, under any single name
+	red := []float32{1, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0}
+	out, err := svc.Infer(ctx, map[string]interface{}{"input": red})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["logits"], test.ShouldResemble, []float32{1, 0})
+	test.That(t, out["output0"], test.ShouldResemble, []float32{1, 0})
+
+	// a batch of a red and a half blue image, uint8 values are converted to floats
+	out, err = svc.Infer(ctx, map[string]interface{}{"image": []uint8{
+		1, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0,
+		0, 0, 2, 0, 0, 2, 0, 0, 0, 0, 0, 0,
+	}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["logits"], test.ShouldResemble, []float32{1, 0, 0, 1})
+
+	_, err = svc.Infer(ctx, map[string]interface{}{"image": red[:5]})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "does not fit shape")
+	_, err = svc.Infer(ctx, map[string]interface{}{"image": "red"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.Infer(ctx, map[string]interface{}{"a": red, "b": red})
+	test.That(t, err, test.ShouldBeError, `input map has no tensor named "image"`)
+
+	// images already channels first are passed as they are
+	nchw, err := NewONNXCPUModel(
+		ctx, &ONNXConfig{ModelPath: testModelPath, InputLayout: LayoutNCHW}, mlmodel.Named("myClassifier"), logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, nchw.Close(ctx), test.ShouldBeNil)
+	}()
+	out, err = nchw.Infer(ctx, map[string]interface{}{"image": []float32{1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["logits"], test.ShouldResemble, []float32{1, 0})
+}
+
+func TestONNXCPUBatching(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	svc, err := NewONNXCPUModel(ctx, &ONNXConfig{
+		ModelPath:      testModelPath,
+		PoolSize:       2,
+		MaxBatchSize:   4,
+		BatchTimeoutMs: 50,
+	}, mlmodel.Named("myClassifier"), logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+
+	red := []float32{1, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0}
+	blue := []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1}
+	outputs, err := mlmodel.InferBatch(mlmodel.ContextWithCaller(ctx, "front"), svc, []map[string]interface{}{
+		{"image": red},
+		{"image": blue},
+		{"image": append(append([]float32{}, blue...), red...)},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, outputs, test.ShouldHaveLength, 3)
+	test.That(t, outputs[0]["logits"], test.ShouldResemble, []float32{1, 0})
+	test.That(t, outputs[1]["logits"], test.ShouldResemble, []float32{0, 1})
+	test.That(t, outputs[2]["output0"], test.ShouldResemble, []float32{0, 1, 1, 0})
+
+	stats, err := svc.DoCommand(ctx, map[string]interface{}{"command": "inference_stats"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stats["batching"], test.ShouldBeTrue)
+	front := stats["callers"].(map[string]interface{})["front"].(map[string]interface{})
+	test.That(t, front["requests"], test.ShouldEqual, int64(3))
+	test.That(t, front["errors"], test.ShouldEqual, int64(0))
+}
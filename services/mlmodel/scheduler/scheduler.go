@@ -0,0 +1,453 @@
+// Package scheduler schedules the inference requests made to an ML model service across a pool of
+// interpreters of the model, batching the requests together when the inputs of the model allow it.
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/services/mlmodel"
+)
+
+// Interpreter runs a model on a map of inputs. Each interpreter of the pool is only used by one
+// batch of requests at a time.
+type Interpreter interface {
+	Infer(input map[string]interface{}) (map[string]interface{}, error)
+}
+
+// InterpreterFunc is an Interpreter made of a function, for models that can run concurrently.
+type InterpreterFunc func(input map[string]interface{}) (map[string]interface{}, error)
+
+// Infer calls the function.
+func (f InterpreterFunc) Infer(input map[string]interface{}) (map[string]interface{}, error) {
+	return f(input)
+}
+
+// Config configures a Scheduler.
+type Config struct {
+	// PoolSize is the number of interpreters running requests concurrently, 1 if not positive.
+	PoolSize int
+	// MaxBatchSize is the largest number of samples run in one batch. Requests are not batched if
+	// it is below 2 or the model has inputs with a fixed batch size.
+	MaxBatchSize int
+	// BatchTimeout is how long a request waits for others to batch with when an interpreter is free.
+	BatchTimeout time.Duration
+}
+
+// ErrClosed is returned for the requests made to or still waiting in a closed scheduler.
+var ErrClosed = errors.New("inference scheduler is closed")
+
+// Scheduler queues inference requests and runs them on its pool of interpreters. When the first
+// dimension of every input of the model is the batch dimension of unknown size, the requests that
+// arrive while the interpreters are busy or within the batch timeout are stacked along it and run
+// together, and the outputs are split back along their first dimension.
+type Scheduler struct {
+	conf      Config
+	inputs    []mlmodel.TensorInfo
+	batchable bool
+	logger    golog.Logger
+
+	requests chan *request
+	pool     chan Interpreter
+	stats    *stats
+
+	cancelCtx               context.Context
+	cancel                  func()
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+type request struct {
+	ctx    context.Context
+	caller string
+	input  map[string]interface{}
+	// samples is the size of the batch dimension of the inputs, 0 if the request can't be batched.
+	samples  int
+	enqueued time.Time
+	started  time.Time
+	result   chan result
+}
+
+type result struct {
+	output    map[string]interface{}
+	err       error
+	batchSize int
+}
+
+// New returns a scheduler running requests on PoolSize interpreters made by newInterpreter, for a
+// model with the given inputs.
+func New(
+	conf Config,
+	inputs []mlmodel.TensorInfo,
+	newInterpreter func() (Interpreter, error),
+	logger golog.Logger,
+) (*Scheduler, error) {
+	if conf.PoolSize <= 0 {
+		conf.PoolSize = 1
+	}
+	if conf.MaxBatchSize <= 0 {
+		conf.MaxBatchSize = 1
+	}
+	if conf.BatchTimeout < 0 {
+		return nil, errors.New("batch timeout cannot be negative")
+	}
+	pool := make(chan Interpreter, conf.PoolSize)
+	for i := 0; i < conf.PoolSize; i++ {
+		interpreter, err := newInterpreter()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create interpreter %d of the pool", i)
+		}
+		pool <- interpreter
+	}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		conf:      conf,
+		inputs:    inputs,
+		batchable: conf.MaxBatchSize > 1 && batchableInputs(inputs),
+		logger:    logger,
+		requests:  make(chan *request),
+		pool:      pool,
+		stats:     newStats(),
+		cancelCtx: cancelCtx,
+		cancel:    cancel,
+	}
+	s.activeBackgroundWorkers.Add(1)
+	utils.ManagedGo(s.dispatch, s.activeBackgroundWorkers.Done)
+	return s, nil
+}
+
+// batchableInputs returns whether every input has a first dimension of unknown size and no other.
+func batchableInputs(inputs []mlmodel.TensorInfo) bool {
+	if len(inputs) == 0 {
+		return false
+	}
+	for _, info := range inputs {
+		if len(info.Shape) == 0 || info.Shape[0] >= 0 {
+			return false
+		}
+		for _, d := range info.Shape[1:] {
+			if d < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Batchable returns whether the scheduler batches requests.
+func (s *Scheduler) Batchable() bool {
+	return s.batchable
+}
+
+// Infer queues the input and returns the output once an interpreter has run it. The latency of the
+// request is attributed to the caller set in the context with mlmodel.ContextWithCaller.
+func (s *Scheduler) Infer(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	r, err := s.enqueue(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return s.wait(r)
+}
+
+// InferBatch queues all the inputs at once, so that they are batched or spread over the pool, and
+// returns their outputs in the same order.
+func (s *Scheduler) InferBatch(ctx context.Context, inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	reqs := make([]*request, 0, len(inputs))
+	var err error
+	for _, input := range inputs {
+		var r *request
+		if r, err = s.enqueue(ctx, input); err != nil {
+			break
+		}
+		reqs = append(reqs, r)
+	}
+	// the queued requests are waited for even on error so that their latency is recorded
+	outputs := make([]map[string]interface{}, len(inputs))
+	for i, r := range reqs {
+		out, reqErr := s.wait(r)
+		if reqErr != nil && err == nil {
+			err = errors.Wrapf(reqErr, "input %d of batch", i)
+		}
+		outputs[i] = out
+	}
+	if err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+func (s *Scheduler) enqueue(ctx context.Context, input map[string]interface{}) (*request, error) {
+	caller, ok := mlmodel.CallerFromContext(ctx)
+	if !ok {
+		caller = UnknownCaller
+	}
+	r := &request{
+		ctx:      ctx,
+		caller:   caller,
+		input:    input,
+		enqueued: time.Now(),
+		result:   make(chan result, 1),
+	}
+	if s.batchable {
+		r.samples = s.samples(input)
+	}
+	select {
+	case s.requests <- r:
+		return r, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.cancelCtx.Done():
+		return nil, ErrClosed
+	}
+}
+
+func (s *Scheduler) wait(r *request) (map[string]interface{}, error) {
+	var res result
+	select {
+	case res = <-r.result:
+	case <-r.ctx.Done():
+		// the request is dropped when it comes up if it has not been run yet
+		res = result{err: r.ctx.Err()}
+	}
+	s.stats.record(r, res)
+	return res.output, res.err
+}
+
+// samples returns the size of the batch dimension of the input, or 0 if it can't be batched.
+func (s *Scheduler) samples(input map[string]interface{}) int {
+	samples := 0
+	for name, data := range input {
+		info, ok := s.inputInfo(name, len(input))
+		if !ok {
+			return 0
+		}
+		v := reflect.ValueOf(data)
+		if v.Kind() != reflect.Slice {
+			return 0
+		}
+		perSample := 1
+		for _, d := range info.Shape[1:] {
+			perSample *= d
+		}
+		if perSample == 0 || v.Len() == 0 || v.Len()%perSample != 0 {
+			return 0
+		}
+		n := v.Len() / perSample
+		if samples != 0 && n != samples {
+			return 0
+		}
+		samples = n
+	}
+	return samples
+}
+
+// inputInfo returns the model input the named input is for. A single input is for the single input
+// of a model whatever its name.
+func (s *Scheduler) inputInfo(name string, count int) (mlmodel.TensorInfo, bool) {
+	for _, info := range s.inputs {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	if count == 1 && len(s.inputs) == 1 {
+		return s.inputs[0], true
+	}
+	return mlmodel.TensorInfo{}, false
+}
+
+// dispatch forms the batches and hands them to free interpreters until the scheduler is closed.
+func (s *Scheduler) dispatch() {
+	var pending *request
+	for {
+		first := pending
+		pending = nil
+		if first == nil {
+			select {
+			case first = <-s.requests:
+			case <-s.cancelCtx.Done():
+				return
+			}
+		}
+		batch := []*request{first}
+		samples := first.samples
+		room := func() bool {
+			return s.batchable && first.samples > 0 && samples < s.conf.MaxBatchSize
+		}
+		add := func(r *request) {
+			if r.samples > 0 && samples+r.samples <= s.conf.MaxBatchSize && sameInputs(first, r) {
+				batch = append(batch, r)
+				samples += r.samples
+				return
+			}
+			// it starts the next batch instead
+			pending = r
+		}
+
+		// wait for the batch timeout for more requests to batch with
+		if room() && s.conf.BatchTimeout > 0 {
+			timer := time.NewTimer(s.conf.BatchTimeout)
+		collect:
+			for room() && pending == nil {
+				select {
+				case r := <-s.requests:
+					add(r)
+				case <-timer.C:
+					break collect
+				case <-s.cancelCtx.Done():
+					timer.Stop()
+					s.fail(append(batch, pending), ErrClosed)
+					return
+				}
+			}
+			timer.Stop()
+		}
+
+		// keep adding the requests arriving while the interpreters are busy
+		var interpreter Interpreter
+		for interpreter == nil {
+			requests := s.requests
+			if !room() || pending != nil {
+				requests = nil
+			}
+			select {
+			case interpreter = <-s.pool:
+			case r := <-requests:
+				add(r)
+			case <-s.cancelCtx.Done():
+				s.fail(append(batch, pending), ErrClosed)
+				return
+			}
+		}
+
+		s.activeBackgroundWorkers.Add(1)
+		utils.PanicCapturingGo(func() {
+			defer s.activeBackgroundWorkers.Done()
+			defer func() {
+				s.pool <- interpreter
+			}()
+			s.run(interpreter, batch)
+		})
+	}
+}
+
+// sameInputs returns whether two requests have the same input names and types and can be stacked.
+func sameInputs(a, b *request) bool {
+	if len(a.input) != len(b.input) {
+		return false
+	}
+	for name, data := range a.input {
+		other, ok := b.input[name]
+		if !ok || reflect.TypeOf(data) != reflect.TypeOf(other) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scheduler) fail(batch []*request, err error) {
+	for _, r := range batch {
+		if r != nil {
+			r.result <- result{err: err}
+		}
+	}
+}
+
+// run runs the batch on the interpreter, running its requests one by one if their outputs can't be
+// split by sample.
+func (s *Scheduler) run(interpreter Interpreter, batch []*request) {
+	live := batch[:0]
+	for _, r := range batch {
+		if r.ctx.Err() != nil {
+			r.result <- result{err: r.ctx.Err()}
+			continue
+		}
+		r.started = time.Now()
+		live = append(live, r)
+	}
+	if len(live) == 0 {
+		return
+	}
+	if len(live) == 1 {
+		out, err := infer(interpreter, live[0].input)
+		live[0].result <- result{output: out, err: err, batchSize: 1}
+		return
+	}
+
+	out, err := infer(interpreter, stack(live))
+	if err == nil {
+		var outputs []map[string]interface{}
+		if outputs, err = split(out, live); err == nil {
+			for i, r := range live {
+				r.result <- result{output: outputs[i], batchSize: len(live)}
+			}
+			return
+		}
+	}
+	s.logger.Debugw("could not run requests as a batch, running them one by one", "error", err)
+	for _, r := range live {
+		out, err := infer(interpreter, r.input)
+		r.result <- result{output: out, err: err, batchSize: 1}
+	}
+}
+
+// infer runs the input on the interpreter, failing the request rather than the scheduler if it panics.
+func infer(interpreter Interpreter, input map[string]interface{}) (out map[string]interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = errors.Errorf("interpreter panicked: %v", p)
+		}
+	}()
+	return interpreter.Infer(input)
+}
+
+// stack concatenates the inputs of the requests, which sameInputs holds for.
+func stack(batch []*request) map[string]interface{} {
+	out := make(map[string]interface{}, len(batch[0].input))
+	for name := range batch[0].input {
+		stacked := reflect.ValueOf(batch[0].input[name])
+		stacked = reflect.AppendSlice(reflect.MakeSlice(stacked.Type(), 0, stacked.Len()*len(batch)), stacked)
+		for _, r := range batch[1:] {
+			stacked = reflect.AppendSlice(stacked, reflect.ValueOf(r.input[name]))
+		}
+		out[name] = stacked.Interface()
+	}
+	return out
+}
+
+// split splits every output of a batch along its first dimension into the outputs of its requests.
+func split(out map[string]interface{}, batch []*request) ([]map[string]interface{}, error) {
+	total := 0
+	for _, r := range batch {
+		total += r.samples
+	}
+	outputs := make([]map[string]interface{}, len(batch))
+	for i := range outputs {
+		outputs[i] = make(map[string]interface{}, len(out))
+	}
+	for name, data := range out {
+		v := reflect.ValueOf(data)
+		if v.Kind() != reflect.Slice || v.Len()%total != 0 {
+			return nil, errors.Errorf("output %q can't be split into %d samples", name, total)
+		}
+		perSample := v.Len() / total
+		offset := 0
+		for i, r := range batch {
+			end := offset + r.samples*perSample
+			// the capacity is capped so that appending to an output doesn't overwrite the next one
+			outputs[i][name] = v.Slice3(offset, end, end).Interface()
+			offset = end
+		}
+	}
+	return outputs, nil
+}
+
+// Close fails the queued requests and waits for the running ones to finish.
+func (s *Scheduler) Close() {
+	s.cancel()
+	s.activeBackgroundWorkers.Wait()
+}
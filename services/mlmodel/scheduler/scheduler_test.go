@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/services/mlmodel"
+)
+
+// doubler doubles its input and sums each sample of it, recording the inputs it is called with.
+type doubler struct {
+	mu    sync.Mutex
+	calls [][]float32
+}
+
+func (d *doubler) Infer(input map[string]interface{}) (map[string]interface{}, error) {
+	in, ok := input["x"].([]float32)
+	if !ok {
+		return nil, errors.New("no input x")
+	}
+	d.mu.Lock()
+	d.calls = append(d.calls, in)
+	d.mu.Unlock()
+	doubled := make([]float32, len(in))
+	sums := make([]float32, (len(in)+1)/2)
+	for i, v := range in {
+		doubled[i] = 2 * v
+		sums[i/2] += v
+	}
+	return map[string]interface{}{"doubled": doubled, "sum": sums}, nil
+}
+
+var batchInputs = []mlmodel.TensorInfo{{Name: "x", DataType: "float32", Shape: []int{-1, 2}}}
+
+func TestBatching(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+	d := &doubler{}
+	s, err := New(
+		Config{MaxBatchSize: 4, BatchTimeout: 200 * time.Millisecond},
+		batchInputs,
+		func() (Interpreter, error) { return d, nil },
+		logger,
+	)
+	test.That(t, err, test.ShouldBeNil)
+	defer s.Close()
+	test.That(t, s.Batchable(), test.ShouldBeTrue)
+
+	// the first three inputs fill the batch and run together, the last one on its own
+	outputs, err := s.InferBatch(ctx, []map[string]interface{}{
+		{"x": []float32{1, 2}},
+		{"x": []float32{3, 4, 5, 6}},
+		{"x": []float32{7, 8}},
+		{"x": []float32{9, 10}},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, outputs, test.ShouldHaveLength, 4)
+	test.That(t, outputs[0]["doubled"], test.ShouldResemble, []float32{2, 4})
+	test.That(t, outputs[0]["sum"], test.ShouldResemble, []float32{3})
+	test.That(t, outputs[1]["doubled"], test.ShouldResemble, []float32{6, 8, 10, 12})
+	test.That(t, outputs[1]["sum"], test.ShouldResemble, []float32{7, 11})
+	test.That(t, outputs[2]["sum"], test.ShouldResemble, []float32{15})
+	test.That(t, outputs[3]["sum"], test.ShouldResemble, []float32{19})
+	test.That(t, d.calls, test.ShouldHaveLength, 2)
+	test.That(t, d.calls[0], test.ShouldResemble, []float32{1, 2, 3, 4, 5, 6, 7, 8})
+
+	// appending to an output doesn't change the output of the next request of the batch
+	_ = append(outputs[0]["doubled"].([]float32), 100)
+	test.That(t, outputs[1]["doubled"], test.ShouldResemble, []float32{6, 8, 10, 12})
+
+	// a request of a different shape is run on its own
+	d.calls = nil
+	_, err = s.Infer(ctx, map[string]interface{}{"x": []float32{1, 2, 3}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, d.calls, test.ShouldResemble, [][]float32{{1, 2, 3}})
+
+	stats := s.Stats()
+	test.That(t, stats, test.ShouldHaveLength, 1)
+	test.That(t, stats[UnknownCaller].Requests, test.ShouldEqual, 5)
+	test.That(t, stats[UnknownCaller].Errors, test.ShouldEqual, 0)
+	test.That(t, stats[UnknownCaller].MeanBatchSize, test.ShouldAlmostEqual, 11./5)
+}
+
+func TestBatchFallback(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	ctx := context.Background()
+	calls := 0
+	var mu sync.Mutex
+	// the output has one value whatever the batch size, so the batch can't be split
+	interpreter := InterpreterFunc(func(input map[string]interface{}) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return map[string]interface{}{"count": []int{len(input["x"].([]float32))}}, nil
+	})
+	s, err := New(
+		Config{MaxBatchSize: 8, BatchTimeout: 100 * time.Millisecond},
+		batchInputs,
+		func() (Interpreter, error) { return interpreter, nil },
+		logger,
+	)
+	test.That(t, err, test.ShouldBeNil)
+	defer s.Close()
+
+	outputs, err := s.InferBatch(ctx, []map[string]interface{}{
+		{"x": []float32{1, 2}},
+		{"x": []float32{3, 4, 5, 6}},
+		{"x": []float32{7, 8, 9, 10, 11, 12}},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, outputs[0]["count"], test.ShouldResemble, []int{2})
+	test.That(t, outputs[1]["count"], test.ShouldResemble, []int{4})
+	test.That(t, outputs[2]["count"], test.ShouldResemble, []int{6})
+	test.That(t, calls, test.ShouldEqual, 4)
+}
+
+func TestPool(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	// the fixed batch size keeps the requests from being batched
+	inputs := []mlmodel.TensorInfo{{Name: "x", Shape: []int{1, 2}}}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	made := 0
+	s, err := New(
+		Config{PoolSize: 3, MaxBatchSize: 4},
+		inputs,
+		func() (Interpreter, error) {
+			made++
+			return InterpreterFunc(func(input map[string]interface{}) (map[string]interface{}, error) {
+				started <- struct{}{}
+				<-release
+				return input, nil
+			}), nil
+		},
+		logger,
+	)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, made, test.ShouldEqual, 3)
+	test.That(t, s.Batchable(), test.ShouldBeFalse)
+
+	var wg sync.WaitGroup
+	for _, camera := range []string{"left", "right", "left"} {
+		wg.Add(1)
+		go func(camera string) {
+			defer wg.Done()
+			ctx := mlmodel.ContextWithCaller(context.Background(), camera)
+			out, err := s.Infer(ctx, map[string]interface{}{"x": []float32{1, 2}})
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, out["x"], test.ShouldResemble, []float32{1, 2})
+		}(camera)
+	}
+	// all three requests run at once
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	close(release)
+	wg.Wait()
+
+	stats := s.Stats()
+	test.That(t, stats, test.ShouldHaveLength, 2)
+	test.That(t, stats["left"].Requests, test.ShouldEqual, 2)
+	test.That(t, stats["right"].Requests, test.ShouldEqual, 1)
+	test.That(t, stats["right"].MeanBatchSize, test.ShouldEqual, 1)
+	test.That(t, stats["right"].MaxLatency, test.ShouldBeGreaterThanOrEqualTo, stats["right"].MeanQueueWait)
+
+	resp := s.StatsResponse()
+	test.That(t, resp["pool_size"], test.ShouldEqual, 3)
+	test.That(t, resp["batching"], test.ShouldBeFalse)
+	left := resp["callers"].(map[string]interface{})["left"].(map[string]interface{})
+	test.That(t, left["requests"], test.ShouldEqual, int64(2))
+
+	s.Close()
+	_, err = s.Infer(context.Background(), map[string]interface{}{"x": []float32{1, 2}})
+	test.That(t, err, test.ShouldBeError, ErrClosed)
+}
+
+func TestInterpreterPanic(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	s, err := New(Config{}, nil, func() (Interpreter, error) {
+		return InterpreterFunc(func(input map[string]interface{}) (map[string]interface{}, error) {
+			if _, ok := input["bad"]; ok {
+				panic("bad input")
+			}
+			return input, nil
+		}), nil
+	}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer s.Close()
+
+	// the panic fails the request and the interpreter keeps serving the next ones
+	_, err = s.Infer(context.Background(), map[string]interface{}{"bad": 1})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "bad input")
+	out, err := s.Infer(context.Background(), map[string]interface{}{"good": 1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out["good"], test.ShouldEqual, 1)
+	test.That(t, s.Stats()[UnknownCaller].Errors, test.ShouldEqual, 1)
+}
+
+func TestNewErrors(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	_, err := New(Config{BatchTimeout: -time.Second}, nil, nil, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	_, err = New(Config{PoolSize: 2}, nil, func() (Interpreter, error) {
+		return nil, errors.New("no memory")
+	}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "no memory")
+}
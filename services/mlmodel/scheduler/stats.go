@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// UnknownCaller is the caller the requests made without one set in their context are attributed to.
+const UnknownCaller = "unknown"
+
+// CallerStats summarizes the requests a caller has made to a scheduler.
+type CallerStats struct {
+	Requests int64
+	Errors   int64
+	// MeanLatency and MaxLatency are measured from when a request is queued until its output is returned.
+	MeanLatency time.Duration
+	MaxLatency  time.Duration
+	// MeanQueueWait is the part of the latency spent waiting for an interpreter.
+	MeanQueueWait time.Duration
+	// MeanBatchSize is the mean number of requests that ran in the same batch as a request.
+	MeanBatchSize float64
+}
+
+type callerTotals struct {
+	requests, errors, ran int64
+	latency, maxLatency   time.Duration
+	queueWait             time.Duration
+	batchSizes            int64
+}
+
+type stats struct {
+	mu      sync.Mutex
+	callers map[string]*callerTotals
+}
+
+func newStats() *stats {
+	return &stats{callers: map[string]*callerTotals{}}
+}
+
+func (st *stats) record(r *request, res result) {
+	latency := time.Since(r.enqueued)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	t, ok := st.callers[r.caller]
+	if !ok {
+		t = &callerTotals{}
+		st.callers[r.caller] = t
+	}
+	t.requests++
+	if res.err != nil {
+		t.errors++
+	}
+	t.latency += latency
+	if latency > t.maxLatency {
+		t.maxLatency = latency
+	}
+	if res.batchSize > 0 {
+		t.ran++
+		t.queueWait += r.started.Sub(r.enqueued)
+		t.batchSizes += int64(res.batchSize)
+	}
+}
+
+// Stats returns the statistics of the requests made to the scheduler so far, by caller.
+func (s *Scheduler) Stats() map[string]CallerStats {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+	out := make(map[string]CallerStats, len(s.stats.callers))
+	for caller, t := range s.stats.callers {
+		cs := CallerStats{
+			Requests:    t.requests,
+			Errors:      t.errors,
+			MeanLatency: t.latency / time.Duration(t.requests),
+			MaxLatency:  t.maxLatency,
+		}
+		if t.ran > 0 {
+			cs.MeanQueueWait = t.queueWait / time.Duration(t.ran)
+			cs.MeanBatchSize = float64(t.batchSizes) / float64(t.ran)
+		}
+		out[caller] = cs
+	}
+	return out
+}
+
+// StatsCommand is the DoCommand command of the ML model services using a scheduler that returns the
+// statistics of their requests, as returned by StatsResponse.
+const StatsCommand = "inference_stats"
+
+// StatsResponse returns the statistics of the scheduler by caller in a form that DoCommand can
+// return, with durations in milliseconds.
+func (s *Scheduler) StatsResponse() map[string]interface{} {
+	callers := map[string]interface{}{}
+	for caller, cs := range s.Stats() {
+		callers[caller] = map[string]interface{}{
+			"requests":           cs.Requests,
+			"errors":             cs.Errors,
+			"mean_latency_ms":    durationMs(cs.MeanLatency),
+			"max_latency_ms":     durationMs(cs.MaxLatency),
+			"mean_queue_wait_ms": durationMs(cs.MeanQueueWait),
+			"mean_batch_size":    cs.MeanBatchSize,
+		}
+	}
+	return map[string]interface{}{
+		"pool_size":      s.conf.PoolSize,
+		"max_batch_size": s.conf.MaxBatchSize,
+		"batching":       s.batchable,
+		"callers":        callers,
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -11,11 +11,13 @@ import (
 	"github.com/edaniels/golog"
 	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
+	"go.uber.org/multierr"
 
 	inf "go.viam.com/rdk/ml/inference"
 	"go.viam.com/rdk/ml/inference/tflite_metadata"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/services/mlmodel"
+	"go.viam.com/rdk/services/mlmodel/scheduler"
 	"go.viam.com/rdk/utils"
 )
 
@@ -46,6 +48,8 @@ type TFLiteConfig struct {
 	ModelPath  string  `json:"model_path"`
 	NumThreads int     `json:"num_threads"`
 	LabelPath  *string `json:"label_path"`
+	// PoolSize is the number of interpreters of the model, each running one request at a time.
+	PoolSize int `json:"pool_size,omitempty"`
 }
 
 // Walk implements the Walker interface and correctly replaces model and label paths.
@@ -70,11 +74,13 @@ func (cfg *TFLiteConfig) Walk(visitor utils.Visitor) (interface{}, error) {
 type Model struct {
 	resource.Named
 	resource.AlwaysRebuild
-	resource.TriviallyCloseable
 	conf     TFLiteConfig
 	model    *inf.TFLiteStruct
 	metadata *mlmodel.MLMetadata
 	logger   golog.Logger
+	// pool holds the interpreters the scheduler runs requests on, the first of them being model.
+	pool      []*inf.TFLiteStruct
+	scheduler *scheduler.Scheduler
 }
 
 // NewTFLiteCPUModel is a constructor that builds a tflite cpu implementation of the MLMS.
@@ -121,18 +127,78 @@ func NewTFLiteCPUModel(ctx context.Context, params *TFLiteConfig, name resource.
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not add model from location %s", params.ModelPath)
 	}
-	return &Model{Named: name.AsNamed(), conf: *params, model: model, logger: logger}, nil
+	m := &Model{Named: name.AsNamed(), conf: *params, model: model, logger: logger}
+
+	// the input shapes of tflite models are fixed, so requests are spread over the pool but not batched
+	m.scheduler, err = scheduler.New(
+		scheduler.Config{PoolSize: params.PoolSize},
+		nil,
+		func() (scheduler.Interpreter, error) {
+			interpreter := m.model
+			if len(m.pool) > 0 {
+				var err error
+				if interpreter, err = addModel(); err != nil {
+					return nil, err
+				}
+			}
+			m.pool = append(m.pool, interpreter)
+			return scheduler.InterpreterFunc(func(input map[string]interface{}) (map[string]interface{}, error) {
+				return m.inferWith(interpreter, input)
+			}), nil
+		},
+		logger,
+	)
+	if err != nil {
+		return nil, multierr.Combine(err, m.closePool())
+	}
+	return m, nil
 }
 
 // Infer takes the input map and uses the inference package to
 // return the result from the tflite cpu model as a map.
 func (m *Model) Infer(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
-	_, span := trace.StartSpan(ctx, "service::mlmodel::tflite_cpu::Infer")
+	ctx, span := trace.StartSpan(ctx, "service::mlmodel::tflite_cpu::Infer")
 	defer span.End()
+	return m.scheduler.Infer(ctx, input)
+}
+
+// InferBatch runs the input maps on the interpreters of the pool and returns their outputs in the
+// same order.
+func (m *Model) InferBatch(ctx context.Context, inputs []map[string]interface{}) ([]map[string]interface{}, error) {
+	ctx, span := trace.StartSpan(ctx, "service::mlmodel::tflite_cpu::InferBatch")
+	defer span.End()
+	return m.scheduler.InferBatch(ctx, inputs)
+}
+
+// DoCommand returns the latency of the requests made to the model by caller for the
+// inference_stats command.
+func (m *Model) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if name, ok := cmd["command"]; ok && name == scheduler.StatsCommand {
+		return m.scheduler.StatsResponse(), nil
+	}
+	return m.Named.DoCommand(ctx, cmd)
+}
+
+// Close stops the scheduler and deletes the interpreters of the pool.
+func (m *Model) Close(ctx context.Context) error {
+	m.scheduler.Close()
+	return m.closePool()
+}
+
+func (m *Model) closePool() error {
+	var err error
+	for _, interpreter := range m.pool {
+		err = multierr.Combine(err, interpreter.Close())
+	}
+	m.pool = nil
+	return err
+}
 
+// inferWith runs the input map on one interpreter of the pool.
+func (m *Model) inferWith(model *inf.TFLiteStruct, input map[string]interface{}) (map[string]interface{}, error) {
 	outMap := make(map[string]interface{})
 	doInfer := func(input interface{}) (map[string]interface{}, error) {
-		outTensors, err := m.model.Infer(input)
+		outTensors, err := model.Infer(input)
 		if err != nil {
 			return nil, errors.Wrapf(err, "couldn't infer from model %q", m.Name())
 		}
@@ -0,0 +1,266 @@
+// Package icpmapping implements a slam service which builds a map from the scans of a depth camera
+// or lidar, registering each scan against the map with ICP, optionally starting from the motion
+// measured by an odometry movement sensor.
+package icpmapping
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	geo "github.com/kellydunn/golang-geo"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/slam"
+	"go.viam.com/rdk/spatialmath"
+	rdkutils "go.viam.com/rdk/utils"
+)
+
+// Model is the model of the icp mapping slam service.
+var Model = resource.DefaultModelFamily.WithModel("icp_mapping")
+
+const (
+	defaultMapResolutionMM = 50.
+	defaultUpdateRateMs    = 500
+	chunkSizeBytes         = 1 * 1024 * 1024
+)
+
+func init() {
+	resource.RegisterService(slam.API, Model, resource.Registration[slam.Service, *Config]{
+		Constructor: func(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (slam.Service, error) {
+			return newICPMapping(deps, conf, clock.New(), logger)
+		},
+	})
+}
+
+// Config is used for converting config attributes.
+type Config struct {
+	// Camera is the depth camera or lidar whose point clouds are the scans.
+	Camera string `json:"camera"`
+	// MovementSensor is an optional odometry sensor whose motion between scans is the initial guess
+	// of their registration.
+	MovementSensor string `json:"movement_sensor,omitempty"`
+	// MapResolutionMM is the side of the voxels scans and the map are downsampled to.
+	MapResolutionMM float64 `json:"map_resolution_mm,omitempty"`
+	UpdateRateMs    int     `json:"update_rate_ms,omitempty"`
+	// MaxMatchErrorMM is the largest mean distance between the points of a registered scan and the map
+	// for the scan to be added to the map, it defaults to twice the map resolution.
+	MaxMatchErrorMM float64 `json:"max_match_error_mm,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Camera == "" {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "camera")
+	}
+	if cfg.MapResolutionMM < 0 {
+		return nil, utils.NewConfigValidationError(path, errors.New("map_resolution_mm cannot be negative"))
+	}
+	if cfg.UpdateRateMs < 0 {
+		return nil, utils.NewConfigValidationError(path, errors.New("update_rate_ms cannot be negative"))
+	}
+	if cfg.MaxMatchErrorMM < 0 {
+		return nil, utils.NewConfigValidationError(path, errors.New("max_match_error_mm cannot be negative"))
+	}
+	deps := []string{cfg.Camera}
+	if cfg.MovementSensor != "" {
+		deps = append(deps, cfg.MovementSensor)
+	}
+	return deps, nil
+}
+
+type icpMapping struct {
+	resource.Named
+	resource.AlwaysRebuild
+
+	cameraName     string
+	camera         camera.Camera
+	movementSensor movementsensor.MovementSensor
+	pointMap       *pointMap
+
+	// origin is the first position reported by the movement sensor, odometry is relative to it.
+	originMu sync.Mutex
+	origin   *geo.Point
+
+	clock                   clock.Clock
+	updateRate              time.Duration
+	cancelFunc              func()
+	activeBackgroundWorkers sync.WaitGroup
+	logger                  golog.Logger
+}
+
+func newICPMapping(deps resource.Dependencies, conf resource.Config, clk clock.Clock, logger golog.Logger) (*icpMapping, error) {
+	cfg, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	cam, err := camera.FromDependencies(deps, cfg.Camera)
+	if err != nil {
+		return nil, err
+	}
+	var ms movementsensor.MovementSensor
+	if cfg.MovementSensor != "" {
+		ms, err = movementsensor.FromDependencies(deps, cfg.MovementSensor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resolution := cfg.MapResolutionMM
+	if resolution == 0 {
+		resolution = defaultMapResolutionMM
+	}
+	maxMatchError := cfg.MaxMatchErrorMM
+	if maxMatchError == 0 {
+		maxMatchError = 2 * resolution
+	}
+	updateRateMs := cfg.UpdateRateMs
+	if updateRateMs == 0 {
+		updateRateMs = defaultUpdateRateMs
+	}
+	pm, err := newPointMap(resolution, maxMatchError, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelCtx, cancelFunc := context.WithCancel(context.Background())
+	svc := &icpMapping{
+		Named:          conf.ResourceName().AsNamed(),
+		cameraName:     cfg.Camera,
+		camera:         cam,
+		movementSensor: ms,
+		pointMap:       pm,
+		clock:          clk,
+		updateRate:     time.Duration(updateRateMs) * time.Millisecond,
+		cancelFunc:     cancelFunc,
+		logger:         logger,
+	}
+	svc.activeBackgroundWorkers.Add(1)
+	utils.ManagedGo(func() { svc.mapLoop(cancelCtx) }, svc.activeBackgroundWorkers.Done)
+	return svc, nil
+}
+
+// mapLoop adds a scan to the map at every update until ctx is done.
+func (svc *icpMapping) mapLoop(ctx context.Context) {
+	ticker := svc.clock.Ticker(svc.updateRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := svc.update(ctx); err != nil && ctx.Err() == nil {
+			svc.logger.Warnw("failed to add scan to the map", "error", err.Error())
+		}
+	}
+}
+
+// update adds the next scan of the camera to the map.
+func (svc *icpMapping) update(ctx context.Context) error {
+	var odometry spatialmath.Pose
+	if svc.movementSensor != nil {
+		var err error
+		odometry, err = svc.odometryPose(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	scan, err := svc.camera.NextPointCloud(ctx)
+	if err != nil {
+		return err
+	}
+	return svc.pointMap.addScan(ctx, scan, odometry)
+}
+
+// odometryPose returns the pose reported by the movement sensor in mm relative to the first position
+// it reported, with x east and y north.
+func (svc *icpMapping) odometryPose(ctx context.Context) (spatialmath.Pose, error) {
+	position, _, err := svc.movementSensor.Position(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	orientation, err := svc.movementSensor.Orientation(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	svc.originMu.Lock()
+	if svc.origin == nil {
+		svc.origin = position
+	}
+	origin := svc.origin
+	svc.originMu.Unlock()
+
+	// GreatCircleDistance is in km
+	distance := origin.GreatCircleDistance(position) * 1e6
+	bearing := rdkutils.DegToRad(origin.BearingTo(position))
+	if distance == 0 {
+		bearing = 0
+	}
+	return spatialmath.NewPose(r3.Vector{X: distance * math.Sin(bearing), Y: distance * math.Cos(bearing)}, orientation), nil
+}
+
+// GetPosition returns the pose of the camera in the map, the map's origin being where the camera took
+// its first scan.
+func (svc *icpMapping) GetPosition(ctx context.Context) (spatialmath.Pose, string, error) {
+	_, span := trace.StartSpan(ctx, "slam::icpmapping::GetPosition")
+	defer span.End()
+	return svc.pointMap.currentPose(), svc.cameraName, nil
+}
+
+// GetPointCloudMap returns a callback function which will return the next chunk of the map as a
+// binary PCD.
+func (svc *icpMapping) GetPointCloudMap(ctx context.Context) (func() ([]byte, error), error) {
+	_, span := trace.StartSpan(ctx, "slam::icpmapping::GetPointCloudMap")
+	defer span.End()
+	data, err := svc.pointMap.writePCD(pointcloud.PCDBinary)
+	if err != nil {
+		return nil, err
+	}
+	return chunks(data), nil
+}
+
+// GetInternalState returns a callback function which will return the next chunk of the map as a
+// binary_compressed PCD, which is the state the mapping resumes from besides the current pose.
+func (svc *icpMapping) GetInternalState(ctx context.Context) (func() ([]byte, error), error) {
+	_, span := trace.StartSpan(ctx, "slam::icpmapping::GetInternalState")
+	defer span.End()
+	data, err := svc.pointMap.writePCD(pointcloud.PCDCompressed)
+	if err != nil {
+		return nil, err
+	}
+	return chunks(data), nil
+}
+
+// chunks returns a callback which returns data chunkSizeBytes at a time and then io.EOF.
+func chunks(data []byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if len(data) == 0 {
+			return nil, io.EOF
+		}
+		n := chunkSizeBytes
+		if n > len(data) {
+			n = len(data)
+		}
+		chunk := data[:n]
+		data = data[n:]
+		return chunk, nil
+	}
+}
+
+func (svc *icpMapping) Close(ctx context.Context) error {
+	svc.cancelFunc()
+	svc.activeBackgroundWorkers.Wait()
+	return nil
+}
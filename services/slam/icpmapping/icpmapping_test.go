@@ -0,0 +1,206 @@
+package icpmapping
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	geo "github.com/kellydunn/golang-geo"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/slam"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+// corner returns a scan of the floor and two walls of the corner of a room, as seen from a sensor at
+// the given pose in the room.
+func corner(t *testing.T, sensor spatialmath.Pose) pointcloud.PointCloud {
+	t.Helper()
+	inverse := spatialmath.PoseInverse(sensor)
+	cloud := pointcloud.New()
+	for i := 0.; i <= 1000; i += 100 {
+		for j := 0.; j <= 1000; j += 100 {
+			for _, p := range []r3.Vector{{X: i, Y: j}, {X: 1000, Y: i, Z: j}, {X: i, Y: 1000, Z: j}} {
+				inScan := spatialmath.Compose(inverse, spatialmath.NewPoseFromPoint(p)).Point()
+				if _, ok := cloud.At(inScan.X, inScan.Y, inScan.Z); ok {
+					continue
+				}
+				test.That(t, cloud.Set(inScan, pointcloud.NewBasicData()), test.ShouldBeNil)
+			}
+		}
+	}
+	return cloud
+}
+
+func TestValidate(t *testing.T) {
+	deps, err := (&Config{Camera: "lidar"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"lidar"})
+
+	deps, err = (&Config{Camera: "lidar", MovementSensor: "odometry"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"lidar", "odometry"})
+
+	for _, cfg := range []*Config{
+		{},
+		{Camera: "lidar", MapResolutionMM: -1},
+		{Camera: "lidar", UpdateRateMs: -1},
+		{Camera: "lidar", MaxMatchErrorMM: -1},
+	} {
+		_, err := cfg.Validate("path")
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
+
+func TestPointMap(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	t.Run("registers scans with icp", func(t *testing.T) {
+		pm, err := newPointMap(50, 100, logger)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pm.addScan(ctx, corner(t, spatialmath.NewZeroPose()), nil), test.ShouldBeNil)
+		size := pm.octree.Size()
+		test.That(t, size, test.ShouldEqual, 331)
+
+		moved := spatialmath.NewPoseFromPoint(r3.Vector{X: 40, Y: -30})
+		test.That(t, pm.addScan(ctx, corner(t, moved), nil), test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostCoincidentEps(pm.currentPose(), moved, 5), test.ShouldBeTrue)
+		// the registered scan falls in the voxels of the first
+		test.That(t, pm.octree.Size(), test.ShouldBeLessThan, size*11/10)
+	})
+
+	t.Run("starts registration from odometry", func(t *testing.T) {
+		pm, err := newPointMap(50, 100, logger)
+		test.That(t, err, test.ShouldBeNil)
+		odometry := spatialmath.NewPoseFromPoint(r3.Vector{X: 1000, Y: 1000})
+		test.That(t, pm.addScan(ctx, corner(t, spatialmath.NewZeroPose()), odometry), test.ShouldBeNil)
+
+		moved := spatialmath.NewPoseFromPoint(r3.Vector{X: 300, Y: 200})
+		measured := spatialmath.NewPoseFromPoint(r3.Vector{X: 1290, Y: 1210})
+		test.That(t, pm.addScan(ctx, corner(t, moved), measured), test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostCoincidentEps(pm.currentPose(), moved, 5), test.ShouldBeTrue)
+	})
+
+	t.Run("rejects scans that do not match", func(t *testing.T) {
+		pm, err := newPointMap(50, 5, logger)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pm.addScan(ctx, corner(t, spatialmath.NewZeroPose()), nil), test.ShouldBeNil)
+		size := pm.octree.Size()
+
+		// no pose puts all of these points near the corner
+		other := pointcloud.New()
+		for _, p := range []r3.Vector{{X: -5000}, {X: 5000}, {Y: 5000}, {Z: -5000}} {
+			test.That(t, other.Set(p, nil), test.ShouldBeNil)
+		}
+		err = pm.addScan(ctx, other, nil)
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "does not match the map")
+		test.That(t, pm.octree.Size(), test.ShouldEqual, size)
+
+		test.That(t, pm.addScan(ctx, pointcloud.New(), nil), test.ShouldNotBeNil)
+	})
+
+	t.Run("grows to fit scans", func(t *testing.T) {
+		pm, err := newPointMap(50, 100, logger)
+		test.That(t, err, test.ShouldBeNil)
+		far := pointcloud.New()
+		test.That(t, far.Set(r3.Vector{X: 30000, Y: -12000, Z: 1}, nil), test.ShouldBeNil)
+		test.That(t, far.Set(r3.Vector{X: 1, Y: 2, Z: 3}, nil), test.ShouldBeNil)
+		test.That(t, far.Set(r3.Vector{X: 2, Y: 3, Z: 4}, nil), test.ShouldBeNil)
+		test.That(t, pm.addScan(ctx, far, nil), test.ShouldBeNil)
+		test.That(t, pm.octree.Size(), test.ShouldEqual, 2)
+		test.That(t, pm.side, test.ShouldEqual, 80000)
+		_, ok := pm.octree.At(30000, -12000, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+	})
+}
+
+func TestICPMapping(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	var mu sync.Mutex
+	sensor := spatialmath.NewZeroPose()
+	cam := inject.NewCamera("lidar")
+	cam.NextPointCloudFunc = func(ctx context.Context) (pointcloud.PointCloud, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return corner(t, sensor), nil
+	}
+	ms := inject.NewMovementSensor("odometry")
+	ms.PositionFunc = func(ctx context.Context, extra map[string]interface{}) (*geo.Point, float64, error) {
+		return geo.NewPoint(40.7, -74), 0, nil
+	}
+	ms.OrientationFunc = func(ctx context.Context, extra map[string]interface{}) (spatialmath.Orientation, error) {
+		return spatialmath.NewZeroOrientation(), nil
+	}
+	deps := resource.Dependencies{
+		camera.Named("lidar"):            cam,
+		movementsensor.Named("odometry"): ms,
+	}
+	conf := resource.Config{
+		Name:                "mapper",
+		API:                 slam.API,
+		Model:               Model,
+		ConvertedAttributes: &Config{Camera: "lidar", MovementSensor: "odometry", UpdateRateMs: 100},
+	}
+
+	clk := clock.NewMock()
+	svc, err := newICPMapping(deps, conf, clk, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+
+	mapSize := func() int {
+		data, err := slam.GetPointCloudMapFull(ctx, svc)
+		test.That(t, err, test.ShouldBeNil)
+		cloud, err := pointcloud.ReadPCD(bytes.NewReader(data))
+		test.That(t, err, test.ShouldBeNil)
+		return cloud.Size()
+	}
+	test.That(t, mapSize(), test.ShouldEqual, 0)
+
+	testutils.WaitForAssertionWithSleep(t, time.Millisecond, 1000, func(tb testing.TB) {
+		tb.Helper()
+		clk.Add(100 * time.Millisecond)
+		test.That(tb, mapSize(), test.ShouldBeGreaterThanOrEqualTo, 331)
+	})
+
+	mu.Lock()
+	sensor = spatialmath.NewPoseFromPoint(r3.Vector{X: -30, Y: 20})
+	mu.Unlock()
+	testutils.WaitForAssertionWithSleep(t, time.Millisecond, 1000, func(tb testing.TB) {
+		tb.Helper()
+		clk.Add(100 * time.Millisecond)
+		pose, component, err := svc.GetPosition(ctx)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, component, test.ShouldEqual, "lidar")
+		test.That(tb, spatialmath.PoseAlmostCoincidentEps(pose, sensor, 5), test.ShouldBeTrue)
+	})
+
+	state, err := slam.GetInternalStateFull(ctx, svc)
+	test.That(t, err, test.ShouldBeNil)
+	cloud, err := pointcloud.ReadPCD(bytes.NewReader(state))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud.Size(), test.ShouldBeGreaterThanOrEqualTo, 331)
+}
+
+func TestChunks(t *testing.T) {
+	data := make([]byte, chunkSizeBytes+10)
+	full, err := slam.HelperConcatenateChunksToFull(chunks(data))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, full, test.ShouldResemble, data)
+}
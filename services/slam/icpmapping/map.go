@@ -0,0 +1,232 @@
+package icpmapping
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sync"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// initialMapSideMM is the side length of the octree a map starts with, it doubles whenever a scan
+// does not fit.
+const initialMapSideMM = 10000.
+
+// numThreadsICP is the number of goroutines registering a scan against the map.
+const numThreadsICP = 8
+
+// pointMap accumulates registered scans into a map with at most one point per voxel, along with the
+// pose of the sensor in the map.
+type pointMap struct {
+	// scanMu serializes addScan, the only writer of the map, so that scans register against the map
+	// without holding mu while readers of the pose and the map only wait on the insertion of a scan.
+	scanMu        sync.Mutex
+	mu            sync.Mutex
+	resolution    float64
+	maxMatchError float64
+
+	// octree holds the map while kd holds the same points for the nearest neighbor lookups of ICP and
+	// voxels the voxels which have a point.
+	octree *pointcloud.BasicOctree
+	kd     *pointcloud.KDTree
+	voxels map[voxelKey]struct{}
+	side   float64
+
+	pose spatialmath.Pose
+	// odometry is the odometry reading of the last scan, nil without odometry.
+	odometry spatialmath.Pose
+	logger   golog.Logger
+}
+
+func newPointMap(resolution, maxMatchError float64, logger golog.Logger) (*pointMap, error) {
+	octree, err := pointcloud.NewBasicOctree(r3.Vector{}, initialMapSideMM)
+	if err != nil {
+		return nil, err
+	}
+	return &pointMap{
+		resolution:    resolution,
+		maxMatchError: maxMatchError,
+		octree:        octree,
+		kd:            pointcloud.NewKDTree(),
+		voxels:        map[voxelKey]struct{}{},
+		side:          initialMapSideMM,
+		pose:          spatialmath.NewZeroPose(),
+		logger:        logger,
+	}, nil
+}
+
+// addScan registers a scan, in the frame of the sensor, against the map and adds it to the map. The
+// odometry reading taken with the scan, if not nil, gives the initial guess of the registration.
+// Scans that do not match the map closely enough are not added, though the pose still follows the
+// odometry.
+func (m *pointMap) addScan(ctx context.Context, scan pointcloud.PointCloud, odometry spatialmath.Pose) error {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+
+	m.mu.Lock()
+	guess := m.pose
+	if odometry != nil && m.odometry != nil {
+		guess = spatialmath.Compose(m.pose, spatialmath.Compose(spatialmath.PoseInverse(m.odometry), odometry))
+	}
+	m.odometry = odometry
+	m.mu.Unlock()
+
+	downsampled, err := m.downsample(scan)
+	if err != nil {
+		return err
+	}
+	if downsampled.Size() == 0 {
+		m.setPose(guess)
+		return errors.New("scan has no points")
+	}
+
+	var registered pointcloud.PointCloud
+	if m.kd.Size() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var info pointcloud.IcpMergeResultInfo
+		// the kd tree only changes under scanMu, which is held here
+		registered, info, err = pointcloud.RegisterPointCloudICP(downsampled, m.kd, guess, false, numThreadsICP)
+		if err != nil {
+			return err
+		}
+		// the loss of the registration is the mean distance of the points to their nearest neighbors
+		if matchError := info.OptResult.F; matchError > m.maxMatchError {
+			m.setPose(guess)
+			return errors.Errorf("scan does not match the map, its points are %.1fmm from the map on average which is over %.1fmm",
+				matchError, m.maxMatchError)
+		}
+		guess = info.Pose
+	} else {
+		registered, err = pointcloud.ApplyOffset(ctx, downsampled, guess, m.logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pose = guess
+	registered.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = m.insert(p, d)
+		return err == nil
+	})
+	return err
+}
+
+func (m *pointMap) setPose(pose spatialmath.Pose) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pose = pose
+}
+
+// voxelKey identifies a voxel of the map by its indices along each axis.
+type voxelKey struct {
+	x, y, z int64
+}
+
+func (m *pointMap) voxelOf(p r3.Vector) voxelKey {
+	return voxelKey{
+		x: int64(math.Floor(p.X / m.resolution)),
+		y: int64(math.Floor(p.Y / m.resolution)),
+		z: int64(math.Floor(p.Z / m.resolution)),
+	}
+}
+
+// downsample returns the first point of the cloud in each voxel.
+func (m *pointMap) downsample(cloud pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+	downsampled := pointcloud.New()
+	voxels := map[voxelKey]struct{}{}
+	var err error
+	cloud.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		key := m.voxelOf(p)
+		if _, ok := voxels[key]; ok {
+			return true
+		}
+		voxels[key] = struct{}{}
+		err = downsampled.Set(p, d)
+		return err == nil
+	})
+	return downsampled, err
+}
+
+// insert adds a point to the map if its voxel is empty, growing the octree when the point is outside
+// of it.
+func (m *pointMap) insert(p r3.Vector, d pointcloud.Data) error {
+	// registration leaves points which coincide with the map off by rounding errors, which would
+	// otherwise land in separate voxels and split the octree down to its precision
+	p = r3.Vector{X: roundToMicrometers(p.X), Y: roundToMicrometers(p.Y), Z: roundToMicrometers(p.Z)}
+	key := m.voxelOf(p)
+	if _, ok := m.voxels[key]; ok {
+		return nil
+	}
+	if d == nil {
+		d = pointcloud.NewBasicData()
+	}
+	for !m.contains(p) {
+		if err := m.grow(); err != nil {
+			return err
+		}
+	}
+	if err := m.octree.Set(p, d); err != nil {
+		return err
+	}
+	if err := m.kd.Set(p, d); err != nil {
+		return err
+	}
+	m.voxels[key] = struct{}{}
+	return nil
+}
+
+func roundToMicrometers(mm float64) float64 {
+	return math.Round(mm*1000) / 1000
+}
+
+func (m *pointMap) contains(p r3.Vector) bool {
+	half := m.side / 2
+	return math.Abs(p.X) < half && math.Abs(p.Y) < half && math.Abs(p.Z) < half
+}
+
+// grow replaces the octree, centered on the origin of the map, with one of twice the side length
+// holding the same points.
+func (m *pointMap) grow() error {
+	octree, err := pointcloud.NewBasicOctree(r3.Vector{}, 2*m.side)
+	if err != nil {
+		return err
+	}
+	m.octree.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		err = octree.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	m.octree = octree
+	m.side *= 2
+	return nil
+}
+
+// currentPose returns the pose of the sensor in the map.
+func (m *pointMap) currentPose() spatialmath.Pose {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pose
+}
+
+// writePCD writes out the map as a PCD of the given type.
+func (m *pointMap) writePCD(pcdType pointcloud.PCDType) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var buf bytes.Buffer
+	if err := pointcloud.ToPCD(m.octree, &buf, pcdType); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+// Package euclideanclustering uses the 3D Euclidean cluster extraction as defined in the
+// RDK vision/segmentation package as vision model. Its objects have oriented bounding boxes
+// by default, which fit them tight enough to plan grasps against.
+package euclideanclustering
+
+import (
+	"context"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/segmentation"
+)
+
+var model = resource.DefaultModelFamily.WithModel("euclidean_clustering_segmenter")
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *segmentation.EuclideanClusteringConfig]{
+		DeprecatedRobotConstructor: func(ctx context.Context, r any, c resource.Config, logger golog.Logger) (vision.Service, error) {
+			attrs, err := resource.NativeConfig[*segmentation.EuclideanClusteringConfig](c)
+			if err != nil {
+				return nil, err
+			}
+			actualR, err := utils.AssertType[robot.Robot](r)
+			if err != nil {
+				return nil, err
+			}
+			return registerECSegmenter(ctx, c.ResourceName(), attrs, actualR)
+		},
+	})
+}
+
+// registerECSegmenter creates a new 3D Euclidean clustering segmenter from the config.
+func registerECSegmenter(
+	ctx context.Context,
+	name resource.Name,
+	conf *segmentation.EuclideanClusteringConfig,
+	r robot.Robot,
+) (vision.Service, error) {
+	_, span := trace.StartSpan(ctx, "service::vision::registerEuclideanClustering")
+	defer span.End()
+	if conf == nil {
+		return nil, errors.New("config for euclidean clustering segmenter cannot be nil")
+	}
+	err := conf.CheckValid()
+	if err != nil {
+		return nil, errors.Wrap(err, "euclidean clustering segmenter config error")
+	}
+	segmenter := segmentation.Segmenter(conf.EuclideanClustering)
+	return vision.NewService(name, r, nil, nil, nil, segmenter)
+}
@@ -0,0 +1,84 @@
+package euclideanclustering
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	pc "go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/vision/segmentation"
+)
+
+func TestEuclideanClusteringSegmentation(t *testing.T) {
+	r := &inject.Robot{}
+	cam := &inject.Camera{}
+	cam.NextPointCloudFunc = func(ctx context.Context) (pc.PointCloud, error) {
+		return nil, errors.New("no pointcloud")
+	}
+	r.ResourceNamesFunc = func() []resource.Name {
+		return []resource.Name{camera.Named("fakeCamera")}
+	}
+	r.ResourceByNameFunc = func(n resource.Name) (resource.Resource, error) {
+		switch n.Name {
+		case "fakeCamera":
+			return cam, nil
+		default:
+			return nil, resource.NewNotFoundError(n)
+		}
+	}
+	params := &segmentation.EuclideanClusteringConfig{
+		ClusterToleranceMm: -1,
+		MinClusterSize:     3,
+		MaxClusterSize:     10,
+	}
+	// bad registration, no parameters
+	name := vision.Named("test_ecs")
+	_, err := registerECSegmenter(context.Background(), name, nil, r)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be nil")
+	// bad registration, parameters out of bounds
+	_, err = registerECSegmenter(context.Background(), name, params, r)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "segmenter config error")
+	// successful registration
+	params.ClusterToleranceMm = 1.5
+	seg, err := registerECSegmenter(context.Background(), name, params, r)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, seg.Name(), test.ShouldResemble, name)
+
+	// fails since camera cannot generate point clouds
+	_, err = seg.GetObjectPointClouds(context.Background(), "fakeCamera", map[string]interface{}{})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "no pointcloud")
+
+	// a diagonal bar of four points, a cluster too large to be an object, and a lone point
+	cam.NextPointCloudFunc = func(ctx context.Context) (pc.PointCloud, error) {
+		cloud := pc.New()
+		for i := 0.; i < 4; i++ {
+			test.That(t, cloud.Set(pc.NewVector(i, i, 0), nil), test.ShouldBeNil)
+		}
+		for i := 0.; i < 20; i++ {
+			test.That(t, cloud.Set(pc.NewVector(100, 0, i), nil), test.ShouldBeNil)
+		}
+		test.That(t, cloud.Set(pc.NewVector(-100, 0, 0), nil), test.ShouldBeNil)
+		return cloud, nil
+	}
+	objects, err := seg.GetObjectPointClouds(context.Background(), "fakeCamera", map[string]interface{}{})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(objects), test.ShouldEqual, 1)
+	test.That(t, objects[0].Size(), test.ShouldEqual, 4)
+	// the box is along the bar rather than around it
+	dims := objects[0].Geometry.ToProtobuf().GetBox().GetDimsMm()
+	test.That(t, dims.X, test.ShouldAlmostEqual, 3*1.4142135623730951)
+	test.That(t, dims.Y, test.ShouldAlmostEqual, 0)
+	// does not implement detector
+	_, err = seg.Detections(context.Background(), nil, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "does not implement")
+}
@@ -2,6 +2,11 @@ package mlvision
 
 import (
 	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -11,8 +16,10 @@ import (
 
 	"go.viam.com/rdk/rimage"
 	"go.viam.com/rdk/services/mlmodel"
+	"go.viam.com/rdk/services/mlmodel/onnxcpu"
 	"go.viam.com/rdk/services/mlmodel/tflitecpu"
 	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
 	"go.viam.com/rdk/vision/classification"
 )
 
@@ -264,6 +271,44 @@ func TestNewMLClassifier(t *testing.T) {
 	test.That(t, topNL[1].Score(), test.ShouldBeLessThan, 0.01)
 }
 
+func TestONNXMLClassifier(t *testing.T) {
+	ctx := context.Background()
+	labelLoc := filepath.Join(t.TempDir(), "labels.txt")
+	test.That(t, os.WriteFile(labelLoc, []byte("red\nblue\n"), 0o600), test.ShouldBeNil)
+
+	// the model averages the red and blue channels of a 2x2 image channels first into two logits
+	out, err := onnxcpu.NewONNXCPUModel(ctx, &onnxcpu.ONNXConfig{
+		ModelPath: utils.ResolveFile("services/mlmodel/onnxcpu/data/red_blue_classifier.onnx"),
+		LabelPath: &labelLoc,
+	}, mlmodel.Named("myONNXClassif"), golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, out.Close(ctx), test.ShouldBeNil)
+	}()
+
+	gotClassifier, err := attemptToBuildClassifier(out)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, checkIfClassifierWorks(ctx, gotClassifier), test.ShouldBeNil)
+
+	for _, tc := range []struct {
+		c     color.Color
+		label string
+	}{
+		{color.RGBA{R: 255, A: 255}, "red"},
+		{color.RGBA{B: 255, A: 255}, "blue"},
+	} {
+		pic := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		draw.Draw(pic, pic.Bounds(), image.NewUniform(tc.c), image.Point{}, draw.Src)
+		gotClassifications, err := gotClassifier(ctx, pic)
+		test.That(t, err, test.ShouldBeNil)
+		gotTop, err := gotClassifications.TopN(2)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, gotTop[0].Label(), test.ShouldEqual, tc.label)
+		test.That(t, gotTop[0].Score(), test.ShouldBeGreaterThan, 0.5)
+		test.That(t, gotTop[1].Label(), test.ShouldNotEqual, tc.label)
+	}
+}
+
 func TestMoreMLDetectors(t *testing.T) {
 	// Test that a detector would give an expected output on the dog image
 	pic, err := rimage.NewImageFromFile(artifact.MustPath("vision/tflite/dogscute.jpeg"))
@@ -0,0 +1,251 @@
+// Package objecttracker implements a vision service that tracks the objects found by a detector
+// across frames, labelling its detections with persistent track IDs.
+package objecttracker
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	vutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/objectdetection"
+	"go.viam.com/rdk/vision/objecttracking"
+)
+
+var model = resource.DefaultModelFamily.WithModel("object_tracker")
+
+// DoCommand commands of the tracker, which take an optional camera_name argument selecting the
+// tracks of the detections of that camera rather than of the images passed to Detections.
+const (
+	// GetTracksCommand returns the confirmed tracks that are not lost yet.
+	GetTracksCommand = "get_tracks"
+	// ResetTracksCommand drops the tracks.
+	ResetTracksCommand = "reset_tracks"
+)
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		DeprecatedRobotConstructor: func(ctx context.Context, r any, c resource.Config, logger golog.Logger) (vision.Service, error) {
+			attrs, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			actualR, err := utils.AssertType[robot.Robot](r)
+			if err != nil {
+				return nil, err
+			}
+			return registerObjectTracker(ctx, c.ResourceName(), attrs, actualR)
+		},
+	})
+}
+
+// Config are the parameters of an object tracker.
+type Config struct {
+	DetectorName string `json:"detector_name"`
+	// ConfidenceThreshold drops the detections of a lower score before they are tracked.
+	ConfidenceThreshold float64 `json:"confidence_threshold,omitempty"`
+	// IoUThreshold is the smallest overlap of a detection with a track to continue it, 0.3 by default.
+	IoUThreshold float64 `json:"iou_threshold,omitempty"`
+	// MinHits is the number of frames an object is detected in before it is reported, 3 by default.
+	MinHits int `json:"min_hits,omitempty"`
+	// MaxAgeFrames is the number of frames a track is kept without detections, 10 by default.
+	MaxAgeFrames int `json:"max_age_frames,omitempty"`
+}
+
+// Validate ensures the tracker has a detector, which it depends on.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.DetectorName == "" {
+		return nil, vutils.NewConfigValidationFieldRequiredError(path, "detector_name")
+	}
+	if cfg.IoUThreshold < 0 || cfg.IoUThreshold > 1 {
+		return nil, errors.Errorf("iou_threshold must be between 0 and 1, got %v", cfg.IoUThreshold)
+	}
+	if cfg.MinHits < 0 || cfg.MaxAgeFrames < 0 {
+		return nil, errors.New("min_hits and max_age_frames cannot be negative")
+	}
+	return []string{cfg.DetectorName}, nil
+}
+
+// tracker is a vision service whose detections are those of its detector that belong to confirmed
+// tracks. Each camera has its own tracks, as do the images passed to Detections.
+type tracker struct {
+	vision.Service
+	detector   vision.Service
+	conf       objecttracking.Config
+	confThresh float64
+
+	mu       sync.Mutex
+	trackers map[string]*objecttracking.Tracker
+}
+
+func registerObjectTracker(
+	ctx context.Context,
+	name resource.Name,
+	conf *Config,
+	r robot.Robot,
+) (vision.Service, error) {
+	_, span := trace.StartSpan(ctx, "service::vision::registerObjectTracker")
+	defer span.End()
+	if conf == nil {
+		return nil, errors.New("config for object tracker cannot be nil")
+	}
+	if _, err := conf.Validate(""); err != nil {
+		return nil, err
+	}
+	detector, err := vision.FromRobot(r, conf.DetectorName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find necessary dependency, detector %q", conf.DetectorName)
+	}
+	t := &tracker{
+		detector: detector,
+		conf: objecttracking.Config{
+			IoUThreshold: conf.IoUThreshold,
+			MinHits:      conf.MinHits,
+			MaxAge:       conf.MaxAgeFrames,
+		},
+		confThresh: conf.ConfidenceThreshold,
+		trackers:   map[string]*objecttracking.Tracker{},
+	}
+	// the vision service of the tracking detector provides the methods the tracker doesn't override
+	t.Service, err = vision.NewService(name, r, nil, nil, func(ctx context.Context, img image.Image) ([]objectdetection.Detection, error) {
+		return t.Detections(ctx, img, nil)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Detections tracks the detections of the images passed to it, which are assumed to be consecutive
+// frames of the same camera.
+func (t *tracker) Detections(
+	ctx context.Context,
+	img image.Image,
+	extra map[string]interface{},
+) ([]objectdetection.Detection, error) {
+	ctx, span := trace.StartSpan(ctx, "service::vision::objecttracker::Detections")
+	defer span.End()
+	dets, err := t.detector.Detections(ctx, img, extra)
+	if err != nil {
+		return nil, err
+	}
+	return t.track("", dets)
+}
+
+// DetectionsFromCamera tracks the detections of the next image of the camera.
+func (t *tracker) DetectionsFromCamera(
+	ctx context.Context,
+	cameraName string,
+	extra map[string]interface{},
+) ([]objectdetection.Detection, error) {
+	ctx, span := trace.StartSpan(ctx, "service::vision::objecttracker::DetectionsFromCamera")
+	defer span.End()
+	dets, err := t.detector.DetectionsFromCamera(ctx, cameraName, extra)
+	if err != nil {
+		return nil, err
+	}
+	return t.track(cameraName, dets)
+}
+
+// track updates the tracks of the camera with the detections of a frame and returns a detection
+// for each confirmed track in it, labelled with the label of the track followed by its ID.
+func (t *tracker) track(cameraName string, dets []objectdetection.Detection) ([]objectdetection.Detection, error) {
+	tr, err := t.trackerFor(cameraName)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]objectdetection.Detection, 0, len(dets))
+	for _, d := range dets {
+		if d.Score() >= t.confThresh {
+			kept = append(kept, d)
+		}
+	}
+	tracks := tr.Update(kept, time.Now())
+	out := make([]objectdetection.Detection, 0, len(tracks))
+	for _, track := range tracks {
+		out = append(out, objectdetection.NewDetection(track.BoundingBox, track.Score, TrackLabel(track)))
+	}
+	return out, nil
+}
+
+func (t *tracker) trackerFor(cameraName string) (*objecttracking.Tracker, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tr, ok := t.trackers[cameraName]; ok {
+		return tr, nil
+	}
+	tr, err := objecttracking.NewTracker(t.conf)
+	if err != nil {
+		return nil, err
+	}
+	t.trackers[cameraName] = tr
+	return tr, nil
+}
+
+// TrackLabel returns the label of the detections of a track, as drawn by the detections overlay.
+func TrackLabel(track objecttracking.Track) string {
+	return fmt.Sprintf("%s #%d", track.Label, track.ID)
+}
+
+// DoCommand returns or resets the tracks with the get_tracks and reset_tracks commands.
+func (t *tracker) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"]
+	if !ok {
+		return nil, errors.New("missing 'command' value")
+	}
+	cameraName := ""
+	if c, ok := cmd["camera_name"]; ok {
+		if cameraName, ok = c.(string); !ok {
+			return nil, errors.Errorf("camera_name must be a string, got %T", c)
+		}
+	}
+	switch name {
+	case GetTracksCommand:
+		tr, err := t.trackerFor(cameraName)
+		if err != nil {
+			return nil, err
+		}
+		tracks := tr.Tracks()
+		out := make([]interface{}, 0, len(tracks))
+		for _, track := range tracks {
+			out = append(out, trackToMap(track))
+		}
+		return map[string]interface{}{"tracks": out}, nil
+	case ResetTracksCommand:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if tr, ok := t.trackers[cameraName]; ok {
+			tr.Reset()
+		}
+		return map[string]interface{}{}, nil
+	default:
+		return nil, errors.Errorf("no such command: %s", name)
+	}
+}
+
+func trackToMap(track objecttracking.Track) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         track.ID,
+		"label":      track.Label,
+		"score":      track.Score,
+		"x_min":      track.BoundingBox.Min.X,
+		"y_min":      track.BoundingBox.Min.Y,
+		"x_max":      track.BoundingBox.Max.X,
+		"y_max":      track.BoundingBox.Max.Y,
+		"velocity_x": track.VelocityX,
+		"velocity_y": track.VelocityY,
+		"hits":       track.Hits,
+		"missed":     track.Missed,
+		"age_ms":     track.Age().Milliseconds(),
+	}
+}
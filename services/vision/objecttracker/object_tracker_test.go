@@ -0,0 +1,109 @@
+package objecttracker
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+func TestObjectTracker(t *testing.T) {
+	ctx := context.Background()
+	frame := 0
+	detect := func() []objectdetection.Detection {
+		frame++
+		return []objectdetection.Detection{
+			objectdetection.NewDetection(image.Rect(10+2*frame, 10, 50+2*frame, 90), 0.9, "person"),
+			objectdetection.NewDetection(image.Rect(200, 10, 240, 90), 0.2, "person"),
+		}
+	}
+	detector := inject.NewVisionService("detector")
+	detector.DetectionsFunc = func(ctx context.Context, img image.Image, extra map[string]interface{}) ([]objectdetection.Detection, error) {
+		return detect(), nil
+	}
+	detector.DetectionsFromCameraFunc = func(
+		ctx context.Context,
+		cameraName string,
+		extra map[string]interface{},
+	) ([]objectdetection.Detection, error) {
+		return detect(), nil
+	}
+	r := &inject.Robot{}
+	r.ResourceNamesFunc = func() []resource.Name {
+		return []resource.Name{vision.Named("detector")}
+	}
+	r.ResourceByNameFunc = func(n resource.Name) (resource.Resource, error) {
+		if n.Name == "detector" {
+			return detector, nil
+		}
+		return nil, resource.NewNotFoundError(n)
+	}
+
+	name := vision.Named("tracker")
+	_, err := registerObjectTracker(ctx, name, nil, r)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be nil")
+	_, err = registerObjectTracker(ctx, name, &Config{}, r)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "detector_name")
+	_, err = registerObjectTracker(ctx, name, &Config{DetectorName: "missing"}, r)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "could not find necessary dependency")
+	_, err = registerObjectTracker(ctx, name, &Config{DetectorName: "detector", IoUThreshold: 2}, r)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "iou_threshold")
+
+	svc, err := registerObjectTracker(ctx, name, &Config{DetectorName: "detector", ConfidenceThreshold: 0.5, MinHits: 2}, r)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, svc.Name(), test.ShouldResemble, name)
+
+	// the object is reported from its second frame on, the low confidence detection never is
+	dets, err := svc.Detections(ctx, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldBeEmpty)
+	dets, err = svc.Detections(ctx, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldHaveLength, 1)
+	test.That(t, dets[0].Label(), test.ShouldEqual, "person #1")
+	test.That(t, *dets[0].BoundingBox(), test.ShouldResemble, image.Rect(14, 10, 54, 90))
+
+	// each camera has its own tracks, numbered from 1
+	dets, err = svc.DetectionsFromCamera(ctx, "front", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldBeEmpty)
+	dets, err = svc.DetectionsFromCamera(ctx, "front", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldHaveLength, 1)
+	test.That(t, dets[0].Label(), test.ShouldEqual, "person #1")
+
+	resp, err := svc.DoCommand(ctx, map[string]interface{}{"command": GetTracksCommand, "camera_name": "front"})
+	test.That(t, err, test.ShouldBeNil)
+	tracks := resp["tracks"].([]interface{})
+	test.That(t, tracks, test.ShouldHaveLength, 1)
+	track := tracks[0].(map[string]interface{})
+	test.That(t, track["id"], test.ShouldEqual, 1)
+	test.That(t, track["label"], test.ShouldEqual, "person")
+	test.That(t, track["hits"], test.ShouldEqual, 2)
+	test.That(t, track["x_min"], test.ShouldEqual, 18)
+
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": ResetTracksCommand, "camera_name": "front"})
+	test.That(t, err, test.ShouldBeNil)
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"command": GetTracksCommand, "camera_name": "front"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["tracks"], test.ShouldBeEmpty)
+	// the tracks of the images passed directly are untouched
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"command": GetTracksCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["tracks"], test.ShouldHaveLength, 1)
+
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": "follow"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": GetTracksCommand, "camera_name": 3})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// the tracker is not a classifier
+	_, err = svc.Classifications(ctx, image.NewRGBA(image.Rect(0, 0, 10, 10)), 1, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -5,6 +5,9 @@ import (
 	// for vision models.
 	_ "go.viam.com/rdk/services/vision/colordetector"
 	_ "go.viam.com/rdk/services/vision/detectionstosegments"
+	_ "go.viam.com/rdk/services/vision/euclideanclustering"
 	_ "go.viam.com/rdk/services/vision/mlvision"
+	_ "go.viam.com/rdk/services/vision/objecttracker"
 	_ "go.viam.com/rdk/services/vision/radiusclustering"
+	_ "go.viam.com/rdk/services/vision/trainableclassifier"
 )
@@ -0,0 +1,273 @@
+// Package trainableclassifier implements a vision service that classifies images with a small
+// classifier trained on the robot, from folders of labeled images, captured data or examples taught
+// from its cameras, and persisted to disk.
+package trainableclassifier
+
+import (
+	"context"
+	"image"
+	"os"
+	"sync"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	vutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/classification"
+	"go.viam.com/rdk/vision/imageclassifier"
+)
+
+var model = resource.DefaultModelFamily.WithModel("trainable_classifier")
+
+// DoCommand commands of the classifier.
+const (
+	// TrainCommand trains a new model on the images of the training_dir and capture_dir of the
+	// command, the configured ones by default, dropping the examples of the previous model.
+	TrainCommand = "train"
+	// AddExampleCommand teaches the model the next image of the camera camera_name, labeled label.
+	AddExampleCommand = "add_example"
+	// LabelsCommand returns the labels of the model and its number of examples.
+	LabelsCommand = "labels"
+)
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		DeprecatedRobotConstructor: func(ctx context.Context, r any, c resource.Config, logger golog.Logger) (vision.Service, error) {
+			attrs, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			actualR, err := utils.AssertType[robot.Robot](r)
+			if err != nil {
+				return nil, err
+			}
+			return registerTrainableClassifier(ctx, c.ResourceName(), attrs, actualR, logger)
+		},
+	})
+}
+
+// Config are the parameters of a trainable classifier.
+type Config struct {
+	// ModelPath is the file the model is saved to and loaded from.
+	ModelPath string `json:"model_path"`
+	// TrainingDir holds a folder of images for each label, named after it. The model is trained on
+	// it when there is no saved model yet, and again with the train command.
+	TrainingDir string `json:"training_dir,omitempty"`
+	// CaptureDir holds data capture files of images, labeled with the first tag of their capture,
+	// which are trained on like the images of TrainingDir.
+	CaptureDir string `json:"capture_dir,omitempty"`
+	// Features are color_histogram, the default, or orb.
+	Features string `json:"features,omitempty"`
+	// Classifier is knn, the default, or neural.
+	Classifier string `json:"classifier,omitempty"`
+	// K is the number of neighbors that vote on the label of an image, 3 by default.
+	K int `json:"k,omitempty"`
+}
+
+// Validate ensures the classifier has a model path and known features and classifier.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.ModelPath == "" {
+		return nil, vutils.NewConfigValidationFieldRequiredError(path, "model_path")
+	}
+	if _, err := imageclassifier.NewModel(cfg.Features, cfg.Classifier, cfg.K); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// trainer is a vision service whose classifier can be trained while it runs.
+type trainer struct {
+	vision.Service
+	r      robot.Robot
+	conf   Config
+	logger golog.Logger
+
+	mu    sync.RWMutex
+	model *imageclassifier.Model
+}
+
+// registerTrainableClassifier loads the saved model, so that the examples taught to it are kept, and
+// trains a model on the configured images when there is none yet. With neither the classifier
+// starts untrained, to be taught examples.
+func registerTrainableClassifier(
+	ctx context.Context,
+	name resource.Name,
+	conf *Config,
+	r robot.Robot,
+	logger golog.Logger,
+) (vision.Service, error) {
+	_, span := trace.StartSpan(ctx, "service::vision::registerTrainableClassifier")
+	defer span.End()
+	if conf == nil {
+		return nil, errors.New("config for trainable classifier cannot be nil")
+	}
+	if _, err := conf.Validate(""); err != nil {
+		return nil, err
+	}
+	t := &trainer{r: r, conf: *conf, logger: logger}
+	var err error
+	switch _, statErr := os.Stat(conf.ModelPath); {
+	case statErr == nil:
+		if t.model, err = imageclassifier.LoadModel(conf.ModelPath); err != nil {
+			return nil, err
+		}
+		if conf.Features != "" && conf.Features != t.model.Features {
+			logger.Warnf("model %q has %s features rather than the configured %s, retrain it to change them",
+				conf.ModelPath, t.model.Features, conf.Features)
+		}
+	case os.IsNotExist(statErr) && (conf.TrainingDir != "" || conf.CaptureDir != ""):
+		if t.model, err = t.train(conf.TrainingDir, conf.CaptureDir); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(statErr):
+		logger.Infof("no model at %q yet, classifications will fail until examples are added", conf.ModelPath)
+		if t.model, err = imageclassifier.NewModel(conf.Features, conf.Classifier, conf.K); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, statErr
+	}
+	t.Service, err = vision.NewService(name, r, nil, t.classify, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// train returns a model trained on the images of the directories, which it saves.
+func (t *trainer) train(trainingDir, captureDir string) (*imageclassifier.Model, error) {
+	m, err := imageclassifier.NewModel(t.conf.Features, t.conf.Classifier, t.conf.K)
+	if err != nil {
+		return nil, err
+	}
+	if trainingDir != "" {
+		n, err := m.AddFolder(trainingDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read training images of %q", trainingDir)
+		}
+		t.logger.Debugf("added %d images of %q", n, trainingDir)
+	}
+	if captureDir != "" {
+		n, err := m.AddCaptureDir(captureDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read captured images of %q", captureDir)
+		}
+		t.logger.Debugf("added %d images of %q", n, captureDir)
+	}
+	if err := m.Train(); err != nil {
+		return nil, err
+	}
+	if err := m.Save(t.conf.ModelPath); err != nil {
+		return nil, errors.Wrap(err, "cannot save model")
+	}
+	return m, nil
+}
+
+func (t *trainer) classify(ctx context.Context, img image.Image) (classification.Classifications, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.model.Classify(img)
+}
+
+// DoCommand trains the model and teaches it examples with the train and add_example commands.
+func (t *trainer) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"]
+	if !ok {
+		return nil, errors.New("missing 'command' value")
+	}
+	switch name {
+	case TrainCommand:
+		trainingDir, captureDir := t.conf.TrainingDir, t.conf.CaptureDir
+		var err error
+		if trainingDir, err = stringArg(cmd, "training_dir", trainingDir); err != nil {
+			return nil, err
+		}
+		if captureDir, err = stringArg(cmd, "capture_dir", captureDir); err != nil {
+			return nil, err
+		}
+		if trainingDir == "" && captureDir == "" {
+			return nil, errors.New("train needs a training_dir or capture_dir")
+		}
+		m, err := t.train(trainingDir, captureDir)
+		if err != nil {
+			return nil, err
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.model = m
+		return t.summary(), nil
+	case AddExampleCommand:
+		label, err := stringArg(cmd, "label", "")
+		if err != nil {
+			return nil, err
+		}
+		cameraName, err := stringArg(cmd, "camera_name", "")
+		if err != nil {
+			return nil, err
+		}
+		if label == "" || cameraName == "" {
+			return nil, errors.New("add_example needs a label and a camera_name")
+		}
+		if err := t.addExample(ctx, cameraName, label); err != nil {
+			return nil, err
+		}
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return t.summary(), nil
+	case LabelsCommand:
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return t.summary(), nil
+	default:
+		return nil, errors.Errorf("no such command: %s", name)
+	}
+}
+
+// addExample trains the model on the next image of the camera and saves it.
+func (t *trainer) addExample(ctx context.Context, cameraName, label string) error {
+	cam, err := camera.FromRobot(t.r, cameraName)
+	if err != nil {
+		return errors.Wrapf(err, "could not find camera named %s", cameraName)
+	}
+	img, release, err := camera.ReadImage(ctx, cam)
+	if err != nil {
+		return errors.Wrapf(err, "could not get image from %s", cameraName)
+	}
+	defer release()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.model.AddImage(img, label); err != nil {
+		return err
+	}
+	if err := t.model.Train(); err != nil {
+		t.model.Examples = t.model.Examples[:len(t.model.Examples)-1]
+		return err
+	}
+	return errors.Wrap(t.model.Save(t.conf.ModelPath), "cannot save model")
+}
+
+func (t *trainer) summary() map[string]interface{} {
+	labels := make([]interface{}, 0)
+	for _, label := range t.model.Labels() {
+		labels = append(labels, label)
+	}
+	return map[string]interface{}{"labels": labels, "examples": len(t.model.Examples)}
+}
+
+func stringArg(cmd map[string]interface{}, key, def string) (string, error) {
+	v, ok := cmd[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("%s must be a string, got %T", key, v)
+	}
+	return s, nil
+}
@@ -0,0 +1,140 @@
+package trainableclassifier
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/edaniels/gostream"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+func solid(c color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+var (
+	red   = color.RGBA{200, 30, 30, 255}
+	green = color.RGBA{30, 200, 30, 255}
+	blue  = color.RGBA{30, 30, 200, 255}
+)
+
+func TestTrainableClassifier(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	dir := t.TempDir()
+	trainingDir := filepath.Join(dir, "training")
+	for label, c := range map[string]color.RGBA{"red": red, "blue": blue} {
+		test.That(t, os.MkdirAll(filepath.Join(trainingDir, label), 0o700), test.ShouldBeNil)
+		test.That(t, rimage.WriteImageToFile(filepath.Join(trainingDir, label, "a.png"), solid(c)), test.ShouldBeNil)
+	}
+
+	seen := solid(green)
+	cam := &inject.Camera{}
+	cam.StreamFunc = func(ctx context.Context, errHandlers ...gostream.ErrorHandler) (gostream.VideoStream, error) {
+		return gostream.NewEmbeddedVideoStreamFromReader(gostream.VideoReaderFunc(func(ctx context.Context) (image.Image, func(), error) {
+			return seen, func() {}, nil
+		})), nil
+	}
+	r := &inject.Robot{}
+	r.ResourceNamesFunc = func() []resource.Name {
+		return []resource.Name{camera.Named("cam")}
+	}
+	r.ResourceByNameFunc = func(n resource.Name) (resource.Resource, error) {
+		if n.Name == "cam" {
+			return cam, nil
+		}
+		return nil, resource.NewNotFoundError(n)
+	}
+
+	name := vision.Named("parts")
+	_, err := registerTrainableClassifier(ctx, name, nil, r, logger)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be nil")
+	_, err = registerTrainableClassifier(ctx, name, &Config{}, r, logger)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "model_path")
+	modelPath := filepath.Join(dir, "model.json")
+	_, err = registerTrainableClassifier(ctx, name, &Config{ModelPath: modelPath, Features: "sift"}, r, logger)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "unknown features")
+
+	// with no model and nothing to train on the classifier starts untrained
+	svc, err := registerTrainableClassifier(ctx, name, &Config{ModelPath: modelPath}, r, logger)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = svc.Classifications(ctx, solid(red), 1, nil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "not trained")
+
+	// training at startup saves the model
+	svc, err = registerTrainableClassifier(ctx, name, &Config{ModelPath: modelPath, TrainingDir: trainingDir, K: 1}, r, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, svc.Name(), test.ShouldResemble, name)
+	classes, err := svc.Classifications(ctx, solid(blue), 1, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, classes[0].Label(), test.ShouldEqual, "blue")
+	_, err = os.Stat(modelPath)
+	test.That(t, err, test.ShouldBeNil)
+
+	// a part is taught from the camera
+	resp, err := svc.DoCommand(ctx, map[string]interface{}{"command": LabelsCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["labels"], test.ShouldResemble, []interface{}{"blue", "red"})
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": AddExampleCommand, "label": "green"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": AddExampleCommand, "label": "green", "camera_name": "missing"})
+	test.That(t, err, test.ShouldNotBeNil)
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"command": AddExampleCommand, "label": "green", "camera_name": "cam"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["labels"], test.ShouldResemble, []interface{}{"blue", "green", "red"})
+	test.That(t, resp["examples"], test.ShouldEqual, 3)
+	classes, err = svc.ClassificationsFromCamera(ctx, "cam", 1, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, classes[0].Label(), test.ShouldEqual, "green")
+	test.That(t, classes[0].Score(), test.ShouldEqual, 1)
+
+	// the taught example survives a restart, the saved model is not trained again on the images
+	svc, err = registerTrainableClassifier(ctx, name, &Config{ModelPath: modelPath, TrainingDir: trainingDir, K: 1}, r, logger)
+	test.That(t, err, test.ShouldBeNil)
+	classes, err = svc.Classifications(ctx, solid(green), 1, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, classes[0].Label(), test.ShouldEqual, "green")
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"command": LabelsCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["examples"], test.ShouldEqual, 3)
+
+	// training again starts over from the configured images
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"command": TrainCommand})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["labels"], test.ShouldResemble, []interface{}{"blue", "red"})
+	test.That(t, resp["examples"], test.ShouldEqual, 2)
+
+	// or from the images of the command
+	svc, err = registerTrainableClassifier(ctx, name, &Config{ModelPath: modelPath}, r, logger)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": TrainCommand})
+	test.That(t, err, test.ShouldNotBeNil)
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"command": TrainCommand, "training_dir": trainingDir})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["labels"], test.ShouldResemble, []interface{}{"blue", "red"})
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": TrainCommand, "training_dir": 3})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"command": "forget"})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// the classifier is neither a detector nor a segmenter
+	_, err = svc.Detections(ctx, solid(red), nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -13,6 +13,7 @@ import (
 	"go.viam.com/rdk/components/camera"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/services/mlmodel"
 	viz "go.viam.com/rdk/vision"
 	"go.viam.com/rdk/vision/classification"
 	"go.viam.com/rdk/vision/objectdetection"
@@ -139,7 +140,8 @@ func (vm *vizModel) DetectionsFromCamera(
 		return nil, errors.Wrapf(err, "could not get image from %s", cameraName)
 	}
 	defer release()
-	return vm.detectorFunc(ctx, img)
+	// attribute the inference latency to the camera when the detector runs an ML model
+	return vm.detectorFunc(mlmodel.ContextWithCaller(ctx, cameraName), img)
 }
 
 // Classifications returns the classifications of given image if the model implements classifications.Classifier.
@@ -182,7 +184,7 @@ func (vm *vizModel) ClassificationsFromCamera(
 		return nil, errors.Wrapf(err, "could not get image from %s", cameraName)
 	}
 	defer release()
-	fullClassifications, err := vm.classifierFunc(ctx, img)
+	fullClassifications, err := vm.classifierFunc(mlmodel.ContextWithCaller(ctx, cameraName), img)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get classifications from image")
 	}
@@ -0,0 +1,118 @@
+package sim
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Default acceleration limits of a simulated base.
+const (
+	DefaultLinearAccelMmPerSec2   = 500.
+	DefaultAngularAccelDegPerSec2 = 360.
+)
+
+// Base is a planar base which tracks commanded linear and angular velocities within acceleration
+// limits. It starts at the origin heading along +Y, positive angular velocities turn it counterclockwise.
+type Base struct {
+	mu              sync.Mutex
+	maxLinearAccel  float64 // mm/s^2
+	maxAngularAccel float64 // deg/s^2
+	targetLinear    float64 // mm/s
+	targetAngular   float64 // deg/s
+	linear          float64
+	angular         float64
+	x, y            float64 // mm
+	theta           float64 // degrees
+	odometer        float64 // mm
+	turned          float64 // degrees
+}
+
+// NewBase returns a base at the origin, zero accelerations use the defaults.
+func NewBase(maxLinearAccel, maxAngularAccel float64) *Base {
+	if maxLinearAccel <= 0 {
+		maxLinearAccel = DefaultLinearAccelMmPerSec2
+	}
+	if maxAngularAccel <= 0 {
+		maxAngularAccel = DefaultAngularAccelDegPerSec2
+	}
+	return &Base{maxLinearAccel: maxLinearAccel, maxAngularAccel: maxAngularAccel}
+}
+
+// approach moves cur towards target by at most maxDelta.
+func approach(cur, target, maxDelta float64) float64 {
+	if math.Abs(target-cur) <= maxDelta {
+		return target
+	}
+	if target > cur {
+		return cur + maxDelta
+	}
+	return cur - maxDelta
+}
+
+// Step advances the base by dt.
+func (b *Base) Step(dt time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := dt.Seconds()
+	nextLinear := approach(b.linear, b.targetLinear, b.maxLinearAccel*s)
+	nextAngular := approach(b.angular, b.targetAngular, b.maxAngularAccel*s)
+	dist := (b.linear + nextLinear) / 2 * s
+	dTheta := (b.angular + nextAngular) / 2 * s
+	heading := (b.theta + dTheta/2) * math.Pi / 180
+	b.x -= math.Sin(heading) * dist
+	b.y += math.Cos(heading) * dist
+	b.theta = math.Mod(b.theta+dTheta, 360)
+	b.odometer += math.Abs(dist)
+	b.turned += math.Abs(dTheta)
+	b.linear, b.angular = nextLinear, nextAngular
+}
+
+// SetVelocity sets the linear velocity in mm/s and angular velocity in deg/s the base tracks.
+func (b *Base) SetVelocity(linear, angular float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.targetLinear, b.targetAngular = linear, angular
+}
+
+// Velocity returns the current linear velocity in mm/s and angular velocity in deg/s of the base.
+func (b *Base) Velocity() (float64, float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.linear, b.angular
+}
+
+// IsMoving returns whether the base is moving or commanded to move.
+func (b *Base) IsMoving() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.linear != 0 || b.angular != 0 || b.targetLinear != 0 || b.targetAngular != 0
+}
+
+// Odometry returns the total distance travelled in mm and the total angle turned in degrees.
+func (b *Base) Odometry() (float64, float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.odometer, b.turned
+}
+
+// Heading returns the heading of the base in degrees, counterclockwise from +Y.
+func (b *Base) Heading() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.theta
+}
+
+// Pose returns the pose of the base in the world.
+func (b *Base) Pose() spatialmath.Pose {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return spatialmath.NewPose(
+		r3.Vector{X: b.x, Y: b.y},
+		&spatialmath.OrientationVectorDegrees{OZ: 1, Theta: b.theta},
+	)
+}
@@ -0,0 +1,85 @@
+package sim
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Joints is a set of independent axes, such as the joints of an arm or the axes of a gantry,
+// each moving towards its target no faster than its velocity limit.
+type Joints struct {
+	mu        sync.Mutex
+	maxVel    []float64 // units/s
+	positions []float64
+	targets   []float64
+}
+
+// NewJoints returns joints at 0 with the given velocity limits, one per joint.
+func NewJoints(maxVelocities []float64) *Joints {
+	return &Joints{
+		maxVel:    append([]float64{}, maxVelocities...),
+		positions: make([]float64, len(maxVelocities)),
+		targets:   make([]float64, len(maxVelocities)),
+	}
+}
+
+// Step advances the joints by dt.
+func (j *Joints) Step(dt time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range j.positions {
+		j.positions[i] = approach(j.positions[i], j.targets[i], j.maxVel[i]*dt.Seconds())
+	}
+}
+
+// SetTargets sets the positions the joints move towards.
+func (j *Joints) SetTargets(targets []float64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(targets) != len(j.targets) {
+		return errors.Errorf("expected %d joint targets got %d", len(j.targets), len(targets))
+	}
+	copy(j.targets, targets)
+	return nil
+}
+
+// SetPositions places the joints at positions without moving them.
+func (j *Joints) SetPositions(positions []float64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(positions) != len(j.positions) {
+		return errors.Errorf("expected %d joint positions got %d", len(j.positions), len(positions))
+	}
+	copy(j.positions, positions)
+	copy(j.targets, positions)
+	return nil
+}
+
+// Stop holds the joints where they are.
+func (j *Joints) Stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	copy(j.targets, j.positions)
+}
+
+// Positions returns the current positions of the joints.
+func (j *Joints) Positions() []float64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]float64{}, j.positions...)
+}
+
+// IsMoving returns whether any joint has not reached its target.
+func (j *Joints) IsMoving() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range j.positions {
+		if math.Abs(j.positions[i]-j.targets[i]) > 1e-9 {
+			return true
+		}
+	}
+	return false
+}
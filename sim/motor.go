@@ -0,0 +1,78 @@
+package sim
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultMotorTimeConstant is the time a simulated motor takes to reach 63% of a speed change.
+const DefaultMotorTimeConstant = 100 * time.Millisecond
+
+// Motor is a DC motor whose speed follows the commanded power with a first order lag,
+// which models the inertia of the motor and its load.
+type Motor struct {
+	mu           sync.Mutex
+	maxRPM       float64
+	timeConstant time.Duration
+	power        float64
+	rpm          float64
+	revolutions  float64
+}
+
+// NewMotor returns a motor spinning at maxRPM at full power. A zero timeConstant uses DefaultMotorTimeConstant.
+func NewMotor(maxRPM float64, timeConstant time.Duration) *Motor {
+	m := &Motor{}
+	m.Configure(maxRPM, timeConstant)
+	return m
+}
+
+// Configure changes the speed at full power and the time constant of the motor, which keeps its
+// speed and position. A zero timeConstant uses DefaultMotorTimeConstant.
+func (m *Motor) Configure(maxRPM float64, timeConstant time.Duration) {
+	if timeConstant <= 0 {
+		timeConstant = DefaultMotorTimeConstant
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxRPM, m.timeConstant = maxRPM, timeConstant
+}
+
+// Step advances the motor by dt.
+func (m *Motor) Step(dt time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	target := m.power * m.maxRPM
+	next := target + (m.rpm-target)*math.Exp(-dt.Seconds()/m.timeConstant.Seconds())
+	// integrate the average speed over the step
+	m.revolutions += (m.rpm + next) / 2 * dt.Minutes()
+	m.rpm = next
+}
+
+// SetPower sets the power applied to the motor, between -1 and 1.
+func (m *Motor) SetPower(powerPct float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.power = math.Max(-1, math.Min(1, powerPct))
+}
+
+// Power returns the power applied to the motor.
+func (m *Motor) Power() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.power
+}
+
+// RPM returns the current speed of the motor.
+func (m *Motor) RPM() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rpm
+}
+
+// Position returns the number of revolutions the motor has made.
+func (m *Motor) Position() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revolutions
+}
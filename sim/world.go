@@ -0,0 +1,194 @@
+// Package sim implements a small kinematic world stepped on a clock that fake components
+// can share so that their motion is consistent with each other.
+package sim
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+)
+
+// DefaultStep is the period at which shared worlds are stepped.
+const DefaultStep = 10 * time.Millisecond
+
+// A Body is anything a World advances in time.
+type Body interface {
+	// Step advances the body by dt.
+	Step(dt time.Duration)
+}
+
+// A World holds named bodies and steps all of them at a fixed period.
+type World struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	step   time.Duration
+	bodies map[string]Body
+	refs   int
+
+	cancelCtx               context.Context
+	cancel                  func()
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+// NewWorld returns a world stepped every step on clk once started. A nil clk uses the wall clock.
+func NewWorld(clk clock.Clock, step time.Duration) *World {
+	if clk == nil {
+		clk = clock.New()
+	}
+	if step <= 0 {
+		step = DefaultStep
+	}
+	return &World{clock: clk, step: step, bodies: map[string]Body{}}
+}
+
+// Start steps the world in the background until Close is called.
+func (w *World) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+	w.cancelCtx, w.cancel = context.WithCancel(context.Background())
+	ticker := w.clock.Ticker(w.step)
+	cancelCtx := w.cancelCtx
+	w.activeBackgroundWorkers.Add(1)
+	utils.ManagedGo(func() {
+		defer ticker.Stop()
+		last := w.clock.Now()
+		for {
+			select {
+			case <-cancelCtx.Done():
+				return
+			case now := <-ticker.C:
+				w.Step(now.Sub(last))
+				last = now
+			}
+		}
+	}, w.activeBackgroundWorkers.Done)
+}
+
+// Step advances every body of the world by dt.
+func (w *World) Step(dt time.Duration) {
+	w.mu.Lock()
+	bodies := make([]Body, 0, len(w.bodies))
+	for _, b := range w.bodies {
+		bodies = append(bodies, b)
+	}
+	w.mu.Unlock()
+	for _, b := range bodies {
+		b.Step(dt)
+	}
+}
+
+// Clock returns the clock the world is stepped on.
+func (w *World) Clock() clock.Clock {
+	return w.clock
+}
+
+// Add adds a body to the world under name, which must not already be in use.
+func (w *World) Add(name string, b Body) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.bodies[name]; ok {
+		return errors.Errorf("simulated body %q already exists", name)
+	}
+	w.bodies[name] = b
+	return nil
+}
+
+// Remove removes the body name from the world if it exists.
+func (w *World) Remove(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.bodies, name)
+}
+
+// Body returns the body name.
+func (w *World) Body(name string) (Body, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b, ok := w.bodies[name]
+	return b, ok
+}
+
+// BodyAs returns the body name as a T.
+func BodyAs[T Body](w *World, name string) (T, error) {
+	var zero T
+	b, ok := w.Body(name)
+	if !ok {
+		return zero, errors.Errorf("no simulated body %q", name)
+	}
+	typed, ok := b.(T)
+	if !ok {
+		return zero, errors.Errorf("simulated body %q is a %T and not a %T", name, b, zero)
+	}
+	return typed, nil
+}
+
+// Close stops stepping the world.
+func (w *World) Close() {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	w.activeBackgroundWorkers.Wait()
+}
+
+var (
+	sharedMu     sync.Mutex
+	sharedWorlds = map[string]*World{}
+)
+
+// Acquire returns the shared world name, creating and starting it on the wall clock if needed.
+// The returned function must be called once the world is no longer used, the world is closed
+// when its last user releases it.
+func Acquire(name string) (*World, func()) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	w, ok := sharedWorlds[name]
+	if !ok {
+		w = NewWorld(nil, DefaultStep)
+		w.Start()
+		sharedWorlds[name] = w
+	}
+	w.refs++
+	var once sync.Once
+	return w, func() {
+		once.Do(func() {
+			sharedMu.Lock()
+			defer sharedMu.Unlock()
+			w.refs--
+			if w.refs == 0 {
+				delete(sharedWorlds, name)
+				w.Close()
+			}
+		})
+	}
+}
+
+// SetShared registers w as the shared world name, replacing any world of that name. This lets tests
+// step a world on a mock clock while components acquire it through its name.
+func SetShared(name string, w *World) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	sharedWorlds[name] = w
+}
+
+// WaitUntil polls cond on the world's clock every step until it returns true or ctx is done.
+func (w *World) WaitUntil(ctx context.Context, cond func() bool) error {
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.clock.After(w.step):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package sim
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+)
+
+func TestWorld(t *testing.T) {
+	w := NewWorld(clock.NewMock(), 0)
+	m := NewMotor(60, 0)
+	test.That(t, w.Add("m", m), test.ShouldBeNil)
+	test.That(t, w.Add("m", m), test.ShouldNotBeNil)
+
+	_, err := BodyAs[*Base](w, "m")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = BodyAs[*Motor](w, "n")
+	test.That(t, err, test.ShouldNotBeNil)
+	got, err := BodyAs[*Motor](w, "m")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, got, test.ShouldEqual, m)
+
+	m.SetPower(1)
+	w.Step(time.Second)
+	test.That(t, m.RPM(), test.ShouldAlmostEqual, 60, 0.01)
+
+	w.Remove("m")
+	_, ok := w.Body("m")
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestWorldStart(t *testing.T) {
+	mock := clock.NewMock()
+	w := NewWorld(mock, 10*time.Millisecond)
+	m := NewMotor(60, 0)
+	test.That(t, w.Add("m", m), test.ShouldBeNil)
+	m.SetPower(1)
+	w.Start()
+	defer w.Close()
+
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		mock.Add(10 * time.Millisecond)
+		test.That(tb, m.RPM(), test.ShouldBeGreaterThan, 0)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	test.That(t, w.WaitUntil(ctx, func() bool { return false }), test.ShouldNotBeNil)
+}
+
+func TestAcquire(t *testing.T) {
+	w1, release1 := Acquire("world")
+	w2, release2 := Acquire("world")
+	test.That(t, w1, test.ShouldEqual, w2)
+	release1()
+	release1()
+	w3, release3 := Acquire("world")
+	test.That(t, w3, test.ShouldEqual, w1)
+	release2()
+	release3()
+	w4, release4 := Acquire("world")
+	defer release4()
+	test.That(t, w4, test.ShouldNotEqual, w1)
+}
+
+func TestMotor(t *testing.T) {
+	m := NewMotor(120, 100*time.Millisecond)
+	m.SetPower(2)
+	test.That(t, m.Power(), test.ShouldEqual, 1)
+	m.SetPower(0.5)
+	m.Step(100 * time.Millisecond)
+	// one time constant in, the motor reached 63% of its target speed
+	test.That(t, m.RPM(), test.ShouldAlmostEqual, 60*(1-math.Exp(-1)), 1e-6)
+	for i := 0; i < 100; i++ {
+		m.Step(100 * time.Millisecond)
+	}
+	test.That(t, m.RPM(), test.ShouldAlmostEqual, 60, 1e-3)
+	before := m.Position()
+	m.Step(time.Minute)
+	test.That(t, m.Position()-before, test.ShouldAlmostEqual, 60, 1e-3)
+}
+
+func TestBase(t *testing.T) {
+	b := NewBase(100, 90)
+	test.That(t, b.IsMoving(), test.ShouldBeFalse)
+	b.SetVelocity(100, 0)
+	test.That(t, b.IsMoving(), test.ShouldBeTrue)
+	b.Step(500 * time.Millisecond)
+	linear, _ := b.Velocity()
+	test.That(t, linear, test.ShouldAlmostEqual, 50)
+	for i := 0; i < 100; i++ {
+		b.Step(10 * time.Millisecond)
+	}
+	linear, _ = b.Velocity()
+	test.That(t, linear, test.ShouldAlmostEqual, 100)
+	pt := b.Pose().Point()
+	test.That(t, pt.X, test.ShouldAlmostEqual, 0)
+	test.That(t, pt.Y, test.ShouldBeGreaterThan, 0)
+
+	b.SetVelocity(0, 90)
+	for i := 0; i < 300; i++ {
+		b.Step(10 * time.Millisecond)
+	}
+	_, angular := b.Velocity()
+	test.That(t, angular, test.ShouldAlmostEqual, 90)
+	test.That(t, b.Heading(), test.ShouldBeGreaterThan, 0)
+	dist, turned := b.Odometry()
+	test.That(t, dist, test.ShouldBeGreaterThan, pt.Y)
+	test.That(t, turned, test.ShouldAlmostEqual, b.Heading())
+}
+
+func TestJoints(t *testing.T) {
+	j := NewJoints([]float64{10, 20})
+	test.That(t, j.SetTargets([]float64{1}), test.ShouldNotBeNil)
+	test.That(t, j.SetTargets([]float64{5, -5}), test.ShouldBeNil)
+	test.That(t, j.IsMoving(), test.ShouldBeTrue)
+	j.Step(100 * time.Millisecond)
+	test.That(t, j.Positions(), test.ShouldResemble, []float64{1, -2})
+	j.Stop()
+	test.That(t, j.IsMoving(), test.ShouldBeFalse)
+	test.That(t, j.SetTargets([]float64{5, -5}), test.ShouldBeNil)
+	j.Step(time.Second)
+	test.That(t, j.Positions(), test.ShouldResemble, []float64{5, -5})
+	test.That(t, j.IsMoving(), test.ShouldBeFalse)
+	test.That(t, j.SetPositions([]float64{1, 2}), test.ShouldBeNil)
+	test.That(t, j.Positions(), test.ShouldResemble, []float64{1, 2})
+	test.That(t, j.IsMoving(), test.ShouldBeFalse)
+}
@@ -0,0 +1,157 @@
+package spatialmath
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// rayIntersecter is implemented by the geometries a ray can hit.
+type rayIntersecter interface {
+	// rayIntersection returns the distance along the unit vector dir from origin to the first surface of the
+	// geometry, and whether the ray hits it at all.
+	rayIntersection(origin, dir r3.Vector) (float64, bool)
+}
+
+// RayIntersection returns the distance from origin along direction to the first surface of g that the ray hits,
+// and whether it hits g at all. A ray starting inside of g hits its surface on the way out. Points have no surface
+// and are never hit.
+func RayIntersection(g Geometry, origin, direction r3.Vector) (float64, bool) {
+	ri, ok := g.(rayIntersecter)
+	if !ok || direction.Norm2() == 0 {
+		return 0, false
+	}
+	return ri.rayIntersection(origin, direction.Normalize())
+}
+
+// firstNonNegative returns the smallest of the roots t0 <= t1 which is in front of the ray origin.
+func firstNonNegative(t0, t1 float64) (float64, bool) {
+	switch {
+	case t0 >= 0:
+		return t0, true
+	case t1 >= 0:
+		return t1, true
+	default:
+		return 0, false
+	}
+}
+
+// Reference: https://github.com/gszauer/GamePhysicsCookbook/blob/a0b8ee0c39fed6d4b90bb6d2195004dfcf5a1115/Code/Geometry3D.cpp#L478
+func (b *box) rayIntersection(origin, dir r3.Vector) (float64, bool) {
+	delta := b.pose.Point().Sub(origin)
+	rm := b.rotationMatrix()
+	tMin, tMax := math.Inf(-1), math.Inf(1)
+	for i := 0; i < 3; i++ {
+		axis := rm.Row(i)
+		e := axis.Dot(delta)
+		f := axis.Dot(dir)
+		if math.Abs(f) < floatEpsilon {
+			// the ray is parallel to this slab and misses it if it starts outside of it
+			if -e-b.halfSize[i] > 0 || -e+b.halfSize[i] < 0 {
+				return 0, false
+			}
+			continue
+		}
+		t0 := (e + b.halfSize[i]) / f
+		t1 := (e - b.halfSize[i]) / f
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tMin = math.Max(tMin, t0)
+		tMax = math.Min(tMax, t1)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return firstNonNegative(tMin, tMax)
+}
+
+func (s *sphere) rayIntersection(origin, dir r3.Vector) (float64, bool) {
+	return raySphereIntersection(origin, dir, s.pose.Point(), s.radius)
+}
+
+func raySphereIntersection(origin, dir, center r3.Vector, radius float64) (float64, bool) {
+	oc := origin.Sub(center)
+	b := oc.Dot(dir)
+	c := oc.Norm2() - radius*radius
+	disc := b*b - c
+	if disc < 0 {
+		return 0, false
+	}
+	sq := math.Sqrt(disc)
+	return firstNonNegative(-b-sq, -b+sq)
+}
+
+// A capsule is hit either on its cylindrical body or on one of the hemispheres capping it.
+func (c *capsule) rayIntersection(origin, dir r3.Vector) (float64, bool) {
+	best, hit := math.Inf(1), false
+	consider := func(t float64, ok bool) {
+		if ok && t < best {
+			best, hit = t, true
+		}
+	}
+	consider(raySphereIntersection(origin, dir, c.segA, c.radius))
+	consider(raySphereIntersection(origin, dir, c.segB, c.radius))
+
+	seg := c.segB.Sub(c.segA)
+	segLen := seg.Norm()
+	axis := seg.Mul(1 / segLen)
+	oa := origin.Sub(c.segA)
+	// project the ray and origin on the plane normal to the axis to intersect with the infinite cylinder
+	d := dir.Sub(axis.Mul(dir.Dot(axis)))
+	o := oa.Sub(axis.Mul(oa.Dot(axis)))
+	a := d.Norm2()
+	if a > floatEpsilon {
+		b := o.Dot(d)
+		cc := o.Norm2() - c.radius*c.radius
+		if disc := b*b - a*cc; disc >= 0 {
+			sq := math.Sqrt(disc)
+			for _, t := range []float64{(-b - sq) / a, (-b + sq) / a} {
+				if along := oa.Add(dir.Mul(t)).Dot(axis); t >= 0 && along >= 0 && along <= segLen {
+					consider(t, true)
+				}
+			}
+		}
+	}
+	if !hit {
+		return 0, false
+	}
+	return best, true
+}
+
+func (m *mesh) rayIntersection(origin, dir r3.Vector) (float64, bool) {
+	best, hit := math.Inf(1), false
+	for _, t := range m.triangles {
+		if d, ok := t.rayIntersection(origin, dir); ok && d < best {
+			best, hit = d, true
+		}
+	}
+	if !hit {
+		return 0, false
+	}
+	return best, true
+}
+
+// Reference: https://en.wikipedia.org/wiki/M%C3%B6ller%E2%80%93Trumbore_intersection_algorithm
+func (t *triangle) rayIntersection(origin, dir r3.Vector) (float64, bool) {
+	e1 := t.p1.Sub(t.p0)
+	e2 := t.p2.Sub(t.p0)
+	h := dir.Cross(e2)
+	a := e1.Dot(h)
+	if math.Abs(a) < floatEpsilon {
+		return 0, false
+	}
+	f := 1 / a
+	s := origin.Sub(t.p0)
+	u := f * s.Dot(h)
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(e1)
+	v := f * dir.Dot(q)
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	dist := f * e2.Dot(q)
+	return dist, dist >= 0
+}
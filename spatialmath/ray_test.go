@@ -0,0 +1,57 @@
+package spatialmath
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestRayIntersection(t *testing.T) {
+	boxGeom, err := NewBox(NewPose(r3.Vector{Y: 100}, &OrientationVectorDegrees{OZ: 1, Theta: 45}), r3.Vector{X: 20, Y: 20, Z: 20}, "")
+	test.That(t, err, test.ShouldBeNil)
+	sphere, err := NewSphere(NewPoseFromPoint(r3.Vector{Y: 100}), 10, "")
+	test.That(t, err, test.ShouldBeNil)
+	capsule, err := NewCapsule(NewPoseFromPoint(r3.Vector{Y: 100}), 10, 60, "")
+	test.That(t, err, test.ShouldBeNil)
+	point := NewPoint(r3.Vector{Y: 100}, "")
+
+	cases := []struct {
+		name      string
+		g         Geometry
+		origin    r3.Vector
+		direction r3.Vector
+		hit       bool
+		dist      float64
+	}{
+		{"box", boxGeom, r3.Vector{}, r3.Vector{Y: 2}, true, 100 - 10*1.4142135623730951},
+		{"box from inside", boxGeom, r3.Vector{Y: 100}, r3.Vector{Z: 1}, true, 10},
+		{"box miss", boxGeom, r3.Vector{}, r3.Vector{X: 1}, false, 0},
+		{"box behind", boxGeom, r3.Vector{}, r3.Vector{Y: -1}, false, 0},
+		{"sphere", sphere, r3.Vector{}, r3.Vector{Y: 1}, true, 90},
+		{"sphere from inside", sphere, r3.Vector{Y: 100}, r3.Vector{X: -1}, true, 10},
+		{"sphere miss", sphere, r3.Vector{X: 11}, r3.Vector{Y: 1}, false, 0},
+		{"capsule body", capsule, r3.Vector{Z: 15}, r3.Vector{Y: 1}, true, 90},
+		{"capsule cap", capsule, r3.Vector{Y: 100, Z: 100}, r3.Vector{Z: -1}, true, 70},
+		{"capsule miss", capsule, r3.Vector{Z: 35}, r3.Vector{Y: 1}, false, 0},
+		{"point", point, r3.Vector{}, r3.Vector{Y: 1}, false, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dist, hit := RayIntersection(c.g, c.origin, c.direction)
+			test.That(t, hit, test.ShouldEqual, c.hit)
+			if c.hit {
+				test.That(t, dist, test.ShouldAlmostEqual, c.dist, 1e-6)
+			}
+		})
+	}
+
+	// a box and its mesh agree
+	b := boxGeom.(*box)
+	for _, dir := range []r3.Vector{{Y: 1}, {X: 0.05, Y: 1, Z: 0.05}, {X: 1}} {
+		boxDist, boxHit := RayIntersection(boxGeom, r3.Vector{}, dir)
+		meshDist, meshHit := b.toMesh().rayIntersection(r3.Vector{}, dir.Normalize())
+		test.That(t, meshHit, test.ShouldEqual, boxHit)
+		test.That(t, meshDist, test.ShouldAlmostEqual, boxDist, 1e-6)
+	}
+}
@@ -21,6 +21,7 @@ type Base struct {
 	IsMovingFunc     func(context.Context) (bool, error)
 	CloseFunc        func(ctx context.Context) error
 	SetPowerFunc     func(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error
+	SetVelocityFunc  func(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error
 }
 
 // NewBase returns a new injected base.
@@ -99,3 +100,11 @@ func (b *Base) SetPower(ctx context.Context, linear, angular r3.Vector, extra ma
 	}
 	return b.SetPowerFunc(ctx, linear, angular, extra)
 }
+
+// SetVelocity calls the injected SetVelocity or the real version.
+func (b *Base) SetVelocity(ctx context.Context, linear, angular r3.Vector, extra map[string]interface{}) error {
+	if b.SetVelocityFunc == nil {
+		return b.LocalBase.SetVelocity(ctx, linear, angular, extra)
+	}
+	return b.SetVelocityFunc(ctx, linear, angular, extra)
+}
@@ -46,6 +46,8 @@ type Robot struct {
 	) (*referenceframe.PoseInFrame, error)
 	TransformPointCloudFunc func(ctx context.Context, srcpc pointcloud.PointCloud, srcName, dstName string) (pointcloud.PointCloud, error)
 	StatusFunc              func(ctx context.Context, resourceNames []resource.Name) ([]robot.Status, error)
+	ResourceStatusesFunc    func(ctx context.Context) ([]robot.ResourceStatus, error)
+	ResourceGraphFunc       func(ctx context.Context) (*resource.GraphSnapshot, error)
 	ModuleAddressFunc       func() (string, error)
 
 	ops        *operation.Manager
@@ -264,6 +266,26 @@ func (r *Robot) Status(ctx context.Context, resourceNames []resource.Name) ([]ro
 	return r.StatusFunc(ctx, resourceNames)
 }
 
+// ResourceStatuses calls the injected ResourceStatuses or the real one.
+func (r *Robot) ResourceStatuses(ctx context.Context) ([]robot.ResourceStatus, error) {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+	if r.ResourceStatusesFunc == nil {
+		return r.LocalRobot.ResourceStatuses(ctx)
+	}
+	return r.ResourceStatusesFunc(ctx)
+}
+
+// ResourceGraph calls the injected ResourceGraph or the real one.
+func (r *Robot) ResourceGraph(ctx context.Context) (*resource.GraphSnapshot, error) {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+	if r.ResourceGraphFunc == nil {
+		return r.LocalRobot.ResourceGraph(ctx)
+	}
+	return r.ResourceGraphFunc(ctx)
+}
+
 // ModuleAddress calls the injected ModuleAddress or the real one.
 func (r *Robot) ModuleAddress() (string, error) {
 	r.Mu.RLock()
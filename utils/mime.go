@@ -28,6 +28,15 @@ const (
 	// MimeTypePCD is for .pcd pountcloud files.
 	MimeTypePCD = "pointcloud/pcd"
 
+	// MimeTypePLY is for .ply pointcloud files.
+	MimeTypePLY = "pointcloud/ply"
+
+	// MimeTypeXYZ is for .xyz pointcloud files of delimited values.
+	MimeTypeXYZ = "pointcloud/xyz"
+
+	// MimeTypeE57 is for .e57 pointcloud files.
+	MimeTypeE57 = "pointcloud/e57"
+
 	// MimeTypeQOI is for .qoi "Quite OK Image" for lossless, fast encoding/decoding.
 	MimeTypeQOI = "image/qoi"
 
@@ -0,0 +1,111 @@
+package imageclassifier
+
+import (
+	"bytes"
+	"image"
+	// register the formats of the images of the datasets.
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+)
+
+var imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// AddFolder adds the images of the subdirectories of dir to the examples, each labeled with the name
+// of its subdirectory, and returns the number of images added.
+func (m *Model) AddFolder(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		label := entry.Name()
+		files, err := os.ReadDir(filepath.Join(dir, label))
+		if err != nil {
+			return added, err
+		}
+		for _, f := range files {
+			if f.IsDir() || !imageExtensions[strings.ToLower(filepath.Ext(f.Name()))] {
+				continue
+			}
+			path := filepath.Join(dir, label, f.Name())
+			img, err := rimage.NewImageFromFile(path)
+			if err != nil {
+				return added, errors.Wrapf(err, "cannot read image %q", path)
+			}
+			if err := m.AddImage(img, label); err != nil {
+				return added, errors.Wrapf(err, "cannot add image %q", path)
+			}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// AddCaptureDir adds the images captured by the data manager to the capture files under dir to the
+// examples, each labeled with the first tag of its capture, and returns the number of images added.
+// Captures with no tags or that are not images are skipped.
+func (m *Model) AddCaptureDir(dir string) (int, error) {
+	added := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != datacapture.FileExt {
+			return nil
+		}
+		n, err := m.addCaptureFile(path)
+		added += n
+		return err
+	})
+	return added, err
+}
+
+func (m *Model) addCaptureFile(path string) (int, error) {
+	//nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck
+	defer f.Close()
+	captures, err := datacapture.ReadFile(f)
+	if err != nil {
+		return 0, err
+	}
+	tags := captures.ReadMetadata().GetTags()
+	if len(tags) == 0 {
+		return 0, nil
+	}
+	readings, err := datacapture.SensorDataFromFile(captures)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot read capture file %q", path)
+	}
+	added := 0
+	for _, reading := range readings {
+		if reading.GetBinary() == nil {
+			continue
+		}
+		img, _, err := image.Decode(bytes.NewReader(reading.GetBinary()))
+		if err != nil {
+			continue
+		}
+		if err := m.AddImage(img, tags[0]); err != nil {
+			return added, errors.Wrapf(err, "cannot add image of %q", path)
+		}
+		added++
+	}
+	return added, nil
+}
@@ -0,0 +1,149 @@
+// Package imageclassifier trains small image classifiers on the robot from labeled example images.
+// Images are reduced to feature vectors, either a color histogram or a summary of their ORB
+// descriptors, which a lightweight classifier such as a nearest neighbor vote learns to label.
+package imageclassifier
+
+import (
+	"image"
+	"math"
+
+	"github.com/nfnt/resize"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/vision/keypoints"
+)
+
+// The features an image can be reduced to.
+const (
+	// ColorHistogramFeatures are the share of the pixels of the image in each bin of a histogram of
+	// their hue, saturation and value. It suits objects told apart by their colors.
+	ColorHistogramFeatures = "color_histogram"
+	// ORBFeatures are the share of the ORB descriptors of the image that have each bit set. It suits
+	// objects told apart by their texture and shape.
+	ORBFeatures = "orb"
+)
+
+const (
+	hueBins        = 8
+	saturationBins = 3
+	valueBins      = 3
+	// histogramSide is the number of pixels sampled along each side of the image for its histogram.
+	histogramSide = 128
+	// orbSide is the size of the square gray image the ORB descriptors are computed on.
+	orbSide = 128
+)
+
+// orbConfig is that of vision/keypoints/orbconfig.json, with fewer layers and bits to suit the
+// small images the descriptors are computed on. Its sampling is fixed so features are reproducible.
+var orbConfig = &keypoints.ORBConfig{
+	Layers:          2,
+	DownscaleFactor: 2,
+	FastConf: &keypoints.FASTConfig{
+		NMatchesCircle: 9,
+		NMSWinSize:     7,
+		Threshold:      20,
+		Oriented:       true,
+		Radius:         16,
+	},
+	BRIEFConf: &keypoints.BRIEFConfig{
+		N:              256,
+		Sampling:       2,
+		UseOrientation: true,
+		PatchSize:      48,
+	},
+}
+
+var orbSamplePairs = keypoints.GenerateSamplePairs(
+	orbConfig.BRIEFConf.Sampling, orbConfig.BRIEFConf.N, orbConfig.BRIEFConf.PatchSize)
+
+// ValidFeatures returns an error if the features are not known, the empty string being the default.
+func ValidFeatures(features string) error {
+	switch features {
+	case "", ColorHistogramFeatures, ORBFeatures:
+		return nil
+	default:
+		return errors.Errorf("unknown features %q, expected %q or %q", features, ColorHistogramFeatures, ORBFeatures)
+	}
+}
+
+// ExtractFeatures returns the feature vector of the image, color histogram features by default.
+func ExtractFeatures(features string, img image.Image) ([]float64, error) {
+	if img == nil || img.Bounds().Empty() {
+		return nil, errors.New("cannot extract features of an empty image")
+	}
+	switch features {
+	case "", ColorHistogramFeatures:
+		return colorHistogram(img), nil
+	case ORBFeatures:
+		return orbHistogram(img)
+	default:
+		return nil, ValidFeatures(features)
+	}
+}
+
+// colorHistogram samples the pixels of the image on a grid of at most histogramSide pixels a side.
+func colorHistogram(img image.Image) []float64 {
+	hist := make([]float64, hueBins*saturationBins*valueBins)
+	b := img.Bounds()
+	stepX := math.Max(1, float64(b.Dx())/histogramSide)
+	stepY := math.Max(1, float64(b.Dy())/histogramSide)
+	n := 0.
+	for fy := float64(b.Min.Y); fy < float64(b.Max.Y); fy += stepY {
+		for fx := float64(b.Min.X); fx < float64(b.Max.X); fx += stepX {
+			h, s, v := rimage.NewColorFromColor(img.At(int(fx), int(fy))).HsvNormal()
+			hBin := binOf(h/360, hueBins)
+			sBin := binOf(s, saturationBins)
+			vBin := binOf(v, valueBins)
+			hist[(hBin*saturationBins+sBin)*valueBins+vBin]++
+			n++
+		}
+	}
+	for i := range hist {
+		hist[i] /= n
+	}
+	return hist
+}
+
+// binOf returns the bin of a value between 0 and 1 among n bins of equal width.
+func binOf(v float64, n int) int {
+	bin := int(v * float64(n))
+	if bin >= n {
+		return n - 1
+	}
+	if bin < 0 {
+		return 0
+	}
+	return bin
+}
+
+// orbHistogram computes the ORB descriptors of the image scaled to orbSide pixels a side, all zeros
+// if it has no keypoints.
+func orbHistogram(img image.Image) ([]float64, error) {
+	small := resize.Resize(orbSide, orbSide, img, resize.Bilinear)
+	gray := image.NewGray(image.Rect(0, 0, orbSide, orbSide))
+	b := small.Bounds()
+	for y := 0; y < orbSide; y++ {
+		for x := 0; x < orbSide; x++ {
+			gray.Set(x, y, small.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	descs, _, err := keypoints.ComputeORBKeypoints(gray, orbSamplePairs, orbConfig)
+	if err != nil {
+		return nil, err
+	}
+	hist := make([]float64, orbConfig.BRIEFConf.N)
+	for _, d := range descs {
+		for i := range hist {
+			if i/64 < len(d) && d[i/64]&(1<<uint(i%64)) != 0 {
+				hist[i]++
+			}
+		}
+	}
+	if len(descs) > 0 {
+		for i := range hist {
+			hist[i] /= float64(len(descs))
+		}
+	}
+	return hist, nil
+}
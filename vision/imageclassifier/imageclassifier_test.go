@@ -0,0 +1,193 @@
+package imageclassifier
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+)
+
+// solid returns an image of the color with a little variation, shaded by i.
+func solid(c color.RGBA, i int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			d := uint8((x + y + i) % 8)
+			img.Set(x, y, color.RGBA{c.R - c.R/8 + d, c.G - c.G/8 + d, c.B - c.B/8 + d, 255})
+		}
+	}
+	return img
+}
+
+// pattern returns a gray image of separate squares of the given size, or stripes if stripes is set.
+func pattern(size int, stripes bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 160, 160))
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 160; x++ {
+			on := (x/size)%2 == 0
+			if !stripes {
+				on = on && (y/size)%2 == 0
+			}
+			if on {
+				img.SetGray(x, y, color.Gray{230})
+			} else {
+				img.SetGray(x, y, color.Gray{20})
+			}
+		}
+	}
+	return img
+}
+
+var (
+	red  = color.RGBA{200, 30, 30, 255}
+	blue = color.RGBA{30, 30, 200, 255}
+)
+
+func TestFeatures(t *testing.T) {
+	test.That(t, ValidFeatures("sift"), test.ShouldNotBeNil)
+	_, err := ExtractFeatures(ORBFeatures, image.NewRGBA(image.Rectangle{}))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	hist, err := ExtractFeatures("", solid(red, 0))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, hist, test.ShouldHaveLength, hueBins*saturationBins*valueBins)
+	sum := 0.
+	for _, v := range hist {
+		sum += v
+	}
+	test.That(t, sum, test.ShouldAlmostEqual, 1)
+
+	orb, err := ExtractFeatures(ORBFeatures, pattern(16, false))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, orb, test.ShouldHaveLength, 256)
+	again, err := ExtractFeatures(ORBFeatures, pattern(16, false))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, again, test.ShouldResemble, orb)
+	// an image with no corners has no keypoints
+	flat, err := ExtractFeatures(ORBFeatures, solid(red, 0))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, flat, test.ShouldResemble, make([]float64, 256))
+}
+
+func TestModel(t *testing.T) {
+	_, err := NewModel("", "svm", 0)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewModel("", "", -1)
+	test.That(t, err, test.ShouldNotBeNil)
+	m, err := NewModel("", "", 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.Features, test.ShouldEqual, ColorHistogramFeatures)
+	test.That(t, m.Classifier, test.ShouldEqual, KNNClassifier)
+	test.That(t, m.K, test.ShouldEqual, 3)
+
+	test.That(t, m.Train(), test.ShouldNotBeNil)
+	_, err = m.Classify(solid(red, 0))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, m.AddImage(solid(red, 0), ""), test.ShouldNotBeNil)
+
+	for i := 0; i < 3; i++ {
+		test.That(t, m.AddImage(solid(red, i), "red"), test.ShouldBeNil)
+		test.That(t, m.AddImage(solid(blue, i), "blue"), test.ShouldBeNil)
+	}
+	test.That(t, m.Train(), test.ShouldBeNil)
+	test.That(t, m.Labels(), test.ShouldResemble, []string{"blue", "red"})
+	classes, err := m.Classify(solid(red, 5))
+	test.That(t, err, test.ShouldBeNil)
+	top, err := classes.TopN(1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, top[0].Label(), test.ShouldEqual, "red")
+	test.That(t, top[0].Score(), test.ShouldAlmostEqual, 1)
+
+	path := filepath.Join(t.TempDir(), "models", "colors.json")
+	test.That(t, m.Save(path), test.ShouldBeNil)
+	loaded, err := LoadModel(path)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, loaded.Labels(), test.ShouldResemble, m.Labels())
+	classes, err = loaded.Classify(solid(blue, 5))
+	test.That(t, err, test.ShouldBeNil)
+	top, err = classes.TopN(1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, top[0].Label(), test.ShouldEqual, "blue")
+
+	_, err = LoadModel(filepath.Join(t.TempDir(), "missing.json"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestORBModel(t *testing.T) {
+	m, err := NewModel(ORBFeatures, KNNClassifier, 1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.AddImage(pattern(16, false), "squares"), test.ShouldBeNil)
+	test.That(t, m.AddImage(pattern(16, true), "stripes"), test.ShouldBeNil)
+	test.That(t, m.Train(), test.ShouldBeNil)
+	classes, err := m.Classify(pattern(20, false))
+	test.That(t, err, test.ShouldBeNil)
+	top, err := classes.TopN(1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, top[0].Label(), test.ShouldEqual, "squares")
+}
+
+func TestAddFolder(t *testing.T) {
+	dir := t.TempDir()
+	for label, c := range map[string]color.RGBA{"red": red, "blue": blue} {
+		test.That(t, os.Mkdir(filepath.Join(dir, label), 0o700), test.ShouldBeNil)
+		for i, name := range []string{"a.png", "b.jpg"} {
+			test.That(t, rimage.WriteImageToFile(filepath.Join(dir, label, name), solid(c, i)), test.ShouldBeNil)
+		}
+		test.That(t, os.WriteFile(filepath.Join(dir, label, "notes.txt"), []byte("skipped"), 0o600), test.ShouldBeNil)
+	}
+	test.That(t, os.WriteFile(filepath.Join(dir, "README"), []byte("skipped"), 0o600), test.ShouldBeNil)
+
+	m, err := NewModel("", "", 1)
+	test.That(t, err, test.ShouldBeNil)
+	n, err := m.AddFolder(dir)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, n, test.ShouldEqual, 4)
+	test.That(t, m.Train(), test.ShouldBeNil)
+	test.That(t, m.Labels(), test.ShouldResemble, []string{"blue", "red"})
+
+	_, err = m.AddFolder(filepath.Join(dir, "missing"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func writeCaptures(t *testing.T, dir string, tags []string, imgs ...image.Image) {
+	t.Helper()
+	test.That(t, os.MkdirAll(dir, 0o700), test.ShouldBeNil)
+	f, err := datacapture.NewFile(dir, &v1.DataCaptureMetadata{Type: v1.DataType_DATA_TYPE_BINARY_SENSOR, Tags: tags})
+	test.That(t, err, test.ShouldBeNil)
+	for _, img := range imgs {
+		var buf bytes.Buffer
+		test.That(t, png.Encode(&buf, img), test.ShouldBeNil)
+		test.That(t, f.WriteNext(&v1.SensorData{Data: &v1.SensorData_Binary{Binary: buf.Bytes()}}), test.ShouldBeNil)
+	}
+	test.That(t, f.Close(), test.ShouldBeNil)
+}
+
+func TestAddCaptureDir(t *testing.T) {
+	dir := t.TempDir()
+	writeCaptures(t, filepath.Join(dir, "red"), []string{"red", "factory"}, solid(red, 0), solid(red, 1))
+	writeCaptures(t, filepath.Join(dir, "blue"), []string{"blue"}, solid(blue, 0))
+	// untagged captures are not examples
+	writeCaptures(t, filepath.Join(dir, "untagged"), nil, solid(blue, 1))
+
+	m, err := NewModel("", "", 1)
+	test.That(t, err, test.ShouldBeNil)
+	n, err := m.AddCaptureDir(dir)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, n, test.ShouldEqual, 3)
+	test.That(t, m.Train(), test.ShouldBeNil)
+	test.That(t, m.Labels(), test.ShouldResemble, []string{"blue", "red"})
+	classes, err := m.Classify(solid(blue, 3))
+	test.That(t, err, test.ShouldBeNil)
+	top, err := classes.TopN(1)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, top[0].Label(), test.ShouldEqual, "blue")
+}
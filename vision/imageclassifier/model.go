@@ -0,0 +1,192 @@
+package imageclassifier
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/ml"
+	"go.viam.com/rdk/vision/classification"
+)
+
+// The classifiers a model can train.
+const (
+	// KNNClassifier labels an image by a vote of the K examples with the nearest features.
+	KNNClassifier = "knn"
+	// NeuralClassifier labels an image with a small neural network.
+	NeuralClassifier = "neural"
+)
+
+const defaultK = 3
+
+// Example is the features of a labeled image.
+type Example struct {
+	Label    string    `json:"label"`
+	Features []float64 `json:"features"`
+}
+
+// Model is an image classifier trained on labeled examples. The examples are what is persisted, as
+// the classifiers are quick to train again on them when the model is loaded.
+type Model struct {
+	Features   string    `json:"features"`
+	Classifier string    `json:"classifier"`
+	K          int       `json:"k,omitempty"`
+	Examples   []Example `json:"examples"`
+
+	labels  []string
+	trained ml.Classifier
+}
+
+// NewModel returns a model with no examples, of color histogram features and a KNN classifier of 3
+// neighbors by default.
+func NewModel(features, classifier string, k int) (*Model, error) {
+	m := &Model{Features: features, Classifier: classifier, K: k}
+	if err := m.setDefaults(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Model) setDefaults() error {
+	if err := ValidFeatures(m.Features); err != nil {
+		return err
+	}
+	if m.Features == "" {
+		m.Features = ColorHistogramFeatures
+	}
+	switch m.Classifier {
+	case "":
+		m.Classifier = KNNClassifier
+	case KNNClassifier, NeuralClassifier:
+	default:
+		return errors.Errorf("unknown classifier %q, expected %q or %q", m.Classifier, KNNClassifier, NeuralClassifier)
+	}
+	if m.K < 0 {
+		return errors.Errorf("k cannot be negative, got %d", m.K)
+	}
+	if m.K == 0 && m.Classifier == KNNClassifier {
+		m.K = defaultK
+	}
+	return nil
+}
+
+// AddImage adds the features of a labeled image to the examples. The model needs training again to
+// learn from it.
+func (m *Model) AddImage(img image.Image, label string) error {
+	if label == "" {
+		return errors.New("examples must have a label")
+	}
+	features, err := ExtractFeatures(m.Features, img)
+	if err != nil {
+		return err
+	}
+	m.Examples = append(m.Examples, Example{Label: label, Features: features})
+	return nil
+}
+
+// Labels returns the labels of the examples the model was last trained on, sorted.
+func (m *Model) Labels() []string {
+	return append([]string(nil), m.labels...)
+}
+
+// Train trains the classifier on the examples.
+func (m *Model) Train() error {
+	if len(m.Examples) == 0 {
+		return errors.New("no examples to train on")
+	}
+	index := map[string]int{}
+	for _, ex := range m.Examples {
+		index[ex.Label] = 0
+	}
+	labels := make([]string, 0, len(index))
+	for label := range index {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for i, label := range labels {
+		index[label] = i
+	}
+	data := make([][]float64, len(m.Examples))
+	correct := make([]int, len(m.Examples))
+	for i, ex := range m.Examples {
+		data[i] = ex.Features
+		correct[i] = index[ex.Label]
+	}
+	var classifier ml.Classifier
+	if m.Classifier == NeuralClassifier {
+		classifier = &ml.GoLearnNNClassifier{}
+	} else {
+		classifier = &ml.GoLearnClassifier{K: m.K}
+	}
+	if err := classifier.Train(data, correct); err != nil {
+		return errors.Wrap(err, "cannot train classifier")
+	}
+	m.labels = labels
+	m.trained = classifier
+	return nil
+}
+
+// Classify returns a classification of the image for each label of the model, scoring the label
+// the classifier predicts 1 and the others 0.
+func (m *Model) Classify(img image.Image) (classification.Classifications, error) {
+	if m.trained == nil {
+		return nil, errors.New("model is not trained")
+	}
+	features, err := ExtractFeatures(m.Features, img)
+	if err != nil {
+		return nil, err
+	}
+	class, err := m.trained.Classify(features)
+	if err != nil {
+		return nil, err
+	}
+	out := make(classification.Classifications, 0, len(m.labels))
+	for i, label := range m.labels {
+		score := 0.
+		if i == class {
+			score = 1
+		}
+		out = append(out, classification.NewClassification(score, label))
+	}
+	return out, nil
+}
+
+// Save writes the model to a JSON file, replacing the file only once it is fully written.
+func (m *Model) Save(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadModel reads a model saved to a file and trains it on its examples.
+func LoadModel(path string) (*Model, error) {
+	//nolint:gosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Model{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse model %q", path)
+	}
+	if err := m.setDefaults(); err != nil {
+		return nil, errors.Wrapf(err, "invalid model %q", path)
+	}
+	if err := m.Train(); err != nil {
+		return nil, errors.Wrapf(err, "cannot train model %q", path)
+	}
+	return m, nil
+}
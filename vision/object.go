@@ -34,3 +34,17 @@ func NewEmptyObject() *Object {
 	cloud := pc.New()
 	return &Object{PointCloud: cloud}
 }
+
+// NewObjectWithOrientedBox creates a new vision.Object from a point cloud with the given label whose
+// geometry is the box aligned with the principal components of the points, which fits the object
+// tighter than the axis aligned box of NewObjectWithLabel.
+func NewObjectWithOrientedBox(cloud pc.PointCloud, label string) (*Object, error) {
+	if cloud == nil {
+		return NewEmptyObject(), nil
+	}
+	box, err := pc.OrientedBoundingBoxFromPointCloud(cloud, label)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{cloud, box}, nil
+}
@@ -1,6 +1,7 @@
 package vision
 
 import (
+	"math"
 	"testing"
 
 	"github.com/golang/geo/r3"
@@ -34,3 +35,24 @@ func TestObjectCreation(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, obj.Geometry.AlmostEqual(expectedBox), test.ShouldBeTrue)
 }
+
+func TestObjectWithOrientedBox(t *testing.T) {
+	obj, err := NewObjectWithOrientedBox(nil, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, obj, test.ShouldResemble, NewEmptyObject())
+
+	// a diagonal bar, whose axis aligned box is a 10x10 square
+	pc := pointcloud.New()
+	for i := 0.; i <= 10; i++ {
+		test.That(t, pc.Set(pointcloud.NewVector(i, i, 0), nil), test.ShouldBeNil)
+		test.That(t, pc.Set(pointcloud.NewVector(i+0.5, i-0.5, 0), nil), test.ShouldBeNil)
+	}
+	obj, err = NewObjectWithOrientedBox(pc, "bar")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, obj.PointCloud, test.ShouldResemble, pc)
+	test.That(t, obj.Geometry.Label(), test.ShouldEqual, "bar")
+	dims := obj.Geometry.ToProtobuf().GetBox().GetDimsMm()
+	test.That(t, dims.X, test.ShouldAlmostEqual, 10*math.Sqrt2)
+	test.That(t, dims.Y, test.ShouldAlmostEqual, math.Sqrt2/2)
+	test.That(t, dims.Z, test.ShouldAlmostEqual, 0)
+}
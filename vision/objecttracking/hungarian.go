@@ -0,0 +1,95 @@
+package objecttracking
+
+import "math"
+
+// assign solves the assignment problem for the cost matrix with the Hungarian algorithm, returning
+// for each row the column assigned to it, or -1 if there are more rows than columns and the row is
+// left out. The total cost of the assignment is minimal.
+func assign(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+	if cols == 0 {
+		return filled(rows, -1)
+	}
+	if rows > cols {
+		// the algorithm needs at least as many columns as rows, so solve the transposed problem
+		transposed := make([][]float64, cols)
+		for j := range transposed {
+			transposed[j] = make([]float64, rows)
+			for i := range cost {
+				transposed[j][i] = cost[i][j]
+			}
+		}
+		out := filled(rows, -1)
+		for j, i := range assign(transposed) {
+			out[i] = j
+		}
+		return out
+	}
+
+	// potentials of the rows and columns and the row matched to each column, 1-indexed with 0 as
+	// the fictitious row and column of the algorithm
+	u := make([]float64, rows+1)
+	v := make([]float64, cols+1)
+	match := make([]int, cols+1)
+	way := make([]int, cols+1)
+	for i := 1; i <= rows; i++ {
+		match[0] = i
+		j0 := 0
+		minv := filled(cols+1, math.Inf(1))
+		used := make([]bool, cols+1)
+		for match[j0] != 0 {
+			used[j0] = true
+			i0 := match[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= cols; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= cols; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		// follow the augmenting path back to the fictitious column
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	out := filled(rows, -1)
+	for j := 1; j <= cols; j++ {
+		if match[j] != 0 {
+			out[match[j]-1] = j - 1
+		}
+	}
+	return out
+}
+
+func filled[T any](n int, v T) []T {
+	out := make([]T, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package objecttracking
+
+import (
+	"image"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// The noise of the filter scales with the height of the box, so that tracking behaves the same for
+// near and far objects. The velocity noise is per second, so it is independent of the frame rate.
+const (
+	stdMeasurement = 1. / 20
+	stdPosition    = 1. / 20
+	stdVelocity    = 1. / 2
+)
+
+// boxFilter is a Kalman filter of a bounding box moving at a constant velocity. Its state is the
+// center, width and height of the box followed by their rates of change in pixels per second.
+type boxFilter struct {
+	x *mat.VecDense
+	p *mat.Dense
+}
+
+// measurement is the bounding box as the center, width and height the filter measures.
+func measurement(box image.Rectangle) []float64 {
+	return []float64{
+		float64(box.Min.X+box.Max.X) / 2,
+		float64(box.Min.Y+box.Max.Y) / 2,
+		float64(box.Dx()),
+		float64(box.Dy()),
+	}
+}
+
+func newBoxFilter(box image.Rectangle) *boxFilter {
+	z := measurement(box)
+	x := mat.NewVecDense(8, append(z, 0, 0, 0, 0))
+	h := scale(z[3])
+	p := mat.NewDense(8, 8, nil)
+	for i := 0; i < 4; i++ {
+		p.Set(i, i, math.Pow(2*stdMeasurement*h, 2))
+		// the velocity is unknown at first, within a box height per second
+		p.Set(i+4, i+4, h*h)
+	}
+	return &boxFilter{x: x, p: p}
+}
+
+// scale returns the height the noise of a box scales with, at least a pixel.
+func scale(h float64) float64 {
+	return math.Max(h, 1)
+}
+
+// predict moves the state dt seconds forward.
+func (f *boxFilter) predict(dt float64) {
+	if dt <= 0 {
+		return
+	}
+	transition := mat.NewDense(8, 8, nil)
+	for i := 0; i < 8; i++ {
+		transition.Set(i, i, 1)
+	}
+	for i := 0; i < 4; i++ {
+		transition.Set(i, i+4, dt)
+	}
+	var x mat.VecDense
+	x.MulVec(transition, f.x)
+	f.x = &x
+
+	h := scale(f.x.AtVec(3))
+	var p mat.Dense
+	p.Product(transition, f.p, transition.T())
+	for i := 0; i < 4; i++ {
+		p.Set(i, i, p.At(i, i)+math.Pow(stdPosition*h, 2)*dt)
+		p.Set(i+4, i+4, p.At(i+4, i+4)+math.Pow(stdVelocity*h, 2)*dt)
+	}
+	f.p = &p
+}
+
+// update corrects the state with the measured box.
+func (f *boxFilter) update(box image.Rectangle) {
+	z := mat.NewVecDense(4, measurement(box))
+	// the measurement is the first half of the state
+	observation := mat.NewDense(4, 8, nil)
+	for i := 0; i < 4; i++ {
+		observation.Set(i, i, 1)
+	}
+	h := scale(f.x.AtVec(3))
+	var innovation mat.VecDense
+	innovation.MulVec(observation, f.x)
+	innovation.SubVec(z, &innovation)
+
+	var s mat.Dense
+	s.Product(observation, f.p, observation.T())
+	for i := 0; i < 4; i++ {
+		s.Set(i, i, s.At(i, i)+math.Pow(stdMeasurement*h, 2))
+	}
+	var sInv mat.Dense
+	if err := sInv.Inverse(&s); err != nil {
+		// the covariance is positive definite unless the filter diverged, start over from the box
+		*f = *newBoxFilter(box)
+		return
+	}
+	var gain mat.Dense
+	gain.Product(f.p, observation.T(), &sInv)
+
+	var correction mat.VecDense
+	correction.MulVec(&gain, &innovation)
+	var x mat.VecDense
+	x.AddVec(f.x, &correction)
+	f.x = &x
+
+	var kh, p mat.Dense
+	kh.Mul(&gain, observation)
+	identity := mat.NewDiagDense(8, filled(8, 1.))
+	var rest mat.Dense
+	rest.Sub(identity, &kh)
+	p.Mul(&rest, f.p)
+	f.p = &p
+}
+
+// box returns the bounding box of the state.
+func (f *boxFilter) box() image.Rectangle {
+	cx, cy := f.x.AtVec(0), f.x.AtVec(1)
+	w, h := math.Max(f.x.AtVec(2), 0), math.Max(f.x.AtVec(3), 0)
+	return image.Rect(
+		int(math.Round(cx-w/2)),
+		int(math.Round(cy-h/2)),
+		int(math.Round(cx+w/2)),
+		int(math.Round(cy+h/2)),
+	)
+}
+
+// velocity returns the velocity of the center of the box in pixels per second.
+func (f *boxFilter) velocity() (float64, float64) {
+	return f.x.AtVec(4), f.x.AtVec(5)
+}
@@ -0,0 +1,213 @@
+// Package objecttracking follows the objects detected in consecutive frames and gives each a
+// persistent track ID. Detections are matched to the tracks of the previous frames by the overlap of
+// their bounding boxes with the Hungarian algorithm, and the motion of each track is estimated with
+// a Kalman filter of constant velocity, which predicts where to look for the object next.
+package objecttracking
+
+import (
+	"image"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+// Config configures a Tracker. Zero values are replaced by the defaults.
+type Config struct {
+	// IoUThreshold is the smallest intersection over union of a detection with the predicted box of
+	// a track for the detection to continue the track, 0.3 by default.
+	IoUThreshold float64
+	// MinHits is the number of frames an object is detected in before its track is confirmed and
+	// reported, 3 by default.
+	MinHits int
+	// MaxAge is the number of consecutive frames a confirmed track is kept without being detected
+	// before it is dropped, 10 by default.
+	MaxAge int
+}
+
+const (
+	defaultIoUThreshold = 0.3
+	defaultMinHits      = 3
+	defaultMaxAge       = 10
+)
+
+// Track is the state of a tracked object.
+type Track struct {
+	ID    int
+	Label string
+	// Score and BoundingBox are those of the latest detection of the object.
+	Score       float64
+	BoundingBox image.Rectangle
+	// VelocityX and VelocityY are the estimated velocity of the center of the box in pixels per second.
+	VelocityX, VelocityY float64
+	// Hits is the number of frames the object was detected in, Missed the number of frames since it
+	// was last detected.
+	Hits, Missed int
+	FirstSeen    time.Time
+	LastSeen     time.Time
+}
+
+// Age returns how long the object has been tracked for.
+func (t Track) Age() time.Duration {
+	return t.LastSeen.Sub(t.FirstSeen)
+}
+
+type track struct {
+	Track
+	confirmed bool
+	filter    *boxFilter
+}
+
+// Tracker tracks the objects of the detections of successive frames of a camera.
+type Tracker struct {
+	conf Config
+
+	mu        sync.Mutex
+	tracks    []*track
+	nextID    int
+	lastFrame time.Time
+}
+
+// NewTracker returns a tracker with no tracks.
+func NewTracker(conf Config) (*Tracker, error) {
+	if conf.IoUThreshold < 0 || conf.IoUThreshold > 1 {
+		return nil, errors.Errorf("IoU threshold must be between 0 and 1, got %v", conf.IoUThreshold)
+	}
+	if conf.MinHits < 0 || conf.MaxAge < 0 {
+		return nil, errors.New("min hits and max age cannot be negative")
+	}
+	if conf.IoUThreshold == 0 {
+		conf.IoUThreshold = defaultIoUThreshold
+	}
+	if conf.MinHits == 0 {
+		conf.MinHits = defaultMinHits
+	}
+	if conf.MaxAge == 0 {
+		conf.MaxAge = defaultMaxAge
+	}
+	return &Tracker{conf: conf, nextID: 1}, nil
+}
+
+// Update matches the detections of the frame taken at the given time to the tracks, starting new
+// tracks for the unmatched ones, and returns the confirmed tracks detected in the frame, by ID.
+// Detections are only matched to tracks of the same label.
+func (t *Tracker) Update(dets []objectdetection.Detection, now time.Time) []Track {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var dt float64
+	if !t.lastFrame.IsZero() {
+		dt = now.Sub(t.lastFrame).Seconds()
+	}
+	t.lastFrame = now
+	predicted := make([]image.Rectangle, len(t.tracks))
+	for i, tr := range t.tracks {
+		tr.filter.predict(dt)
+		predicted[i] = tr.filter.box()
+	}
+
+	// the cost of a match is how little the boxes overlap, pairs that can't match cost more than any
+	// that can so that the assignment only pairs them when there is nothing better
+	cost := make([][]float64, len(t.tracks))
+	for i, tr := range t.tracks {
+		cost[i] = make([]float64, len(dets))
+		for j, d := range dets {
+			cost[i][j] = 1 - IoU(predicted[i], *d.BoundingBox())
+			if d.Label() != tr.Label || cost[i][j] > 1-t.conf.IoUThreshold {
+				cost[i][j] = 2
+			}
+		}
+	}
+	matched := make([]bool, len(dets))
+	var reported []Track
+	for i, j := range assign(cost) {
+		tr := t.tracks[i]
+		if j < 0 || cost[i][j] > 1 {
+			tr.Missed++
+			continue
+		}
+		matched[j] = true
+		tr.filter.update(*dets[j].BoundingBox())
+		tr.observe(dets[j], now)
+		if tr.Hits >= t.conf.MinHits {
+			tr.confirmed = true
+		}
+		if tr.confirmed {
+			reported = append(reported, tr.Track)
+		}
+	}
+	// drop the tracks that are lost, tentative ones as soon as they are missed
+	live := t.tracks[:0]
+	for _, tr := range t.tracks {
+		if tr.Missed == 0 || (tr.confirmed && tr.Missed <= t.conf.MaxAge) {
+			live = append(live, tr)
+		}
+	}
+	t.tracks = live
+	for j, d := range dets {
+		if matched[j] {
+			continue
+		}
+		tr := &track{
+			Track:  Track{ID: t.nextID, Label: d.Label(), FirstSeen: now},
+			filter: newBoxFilter(*d.BoundingBox()),
+		}
+		t.nextID++
+		tr.observe(d, now)
+		if tr.Hits >= t.conf.MinHits {
+			tr.confirmed = true
+			reported = append(reported, tr.Track)
+		}
+		t.tracks = append(t.tracks, tr)
+	}
+	sort.Slice(reported, func(a, b int) bool { return reported[a].ID < reported[b].ID })
+	return reported
+}
+
+func (tr *track) observe(d objectdetection.Detection, now time.Time) {
+	tr.Hits++
+	tr.Missed = 0
+	tr.Score = d.Score()
+	tr.BoundingBox = *d.BoundingBox()
+	tr.LastSeen = now
+	tr.VelocityX, tr.VelocityY = tr.filter.velocity()
+}
+
+// Tracks returns the confirmed tracks that are not lost yet, by ID, including the ones that were
+// not detected in the latest frames.
+func (t *Tracker) Tracks() []Track {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []Track
+	for _, tr := range t.tracks {
+		if tr.confirmed {
+			out = append(out, tr.Track)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].ID < out[b].ID })
+	return out
+}
+
+// Reset drops all the tracks. Track IDs keep increasing so they are not reused.
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tracks = nil
+	t.lastFrame = time.Time{}
+}
+
+// IoU returns the intersection over union of two boxes, 0 if either is empty.
+func IoU(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	interArea := float64(inter.Dx() * inter.Dy())
+	union := float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - interArea
+	if union <= 0 {
+		return 0
+	}
+	return interArea / union
+}
@@ -0,0 +1,122 @@
+package objecttracking
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+func TestAssign(t *testing.T) {
+	test.That(t, assign(nil), test.ShouldBeNil)
+	test.That(t, assign([][]float64{{}, {}}), test.ShouldResemble, []int{-1, -1})
+
+	// the greedy choice of 1 for the first row is not the best assignment
+	cost := [][]float64{
+		{1, 2, 3},
+		{2, 4, 6},
+		{3, 6, 9},
+	}
+	test.That(t, assign(cost), test.ShouldResemble, []int{2, 1, 0})
+
+	// more columns than rows
+	test.That(t, assign([][]float64{{5, 1, 9}, {1, 5, 9}}), test.ShouldResemble, []int{1, 0})
+	// more rows than columns leaves a row out, here the one that would cost more to match
+	test.That(t, assign([][]float64{{5, 1}, {1, 5}, {0.5, 0.5}}), test.ShouldResemble, []int{1, -1, 0})
+}
+
+func TestIoU(t *testing.T) {
+	a := image.Rect(0, 0, 10, 10)
+	test.That(t, IoU(a, a), test.ShouldEqual, 1)
+	test.That(t, IoU(a, image.Rect(5, 0, 15, 10)), test.ShouldAlmostEqual, 50./150)
+	test.That(t, IoU(a, image.Rect(20, 20, 30, 30)), test.ShouldEqual, 0)
+	test.That(t, IoU(a, image.Rectangle{}), test.ShouldEqual, 0)
+}
+
+func TestNewTracker(t *testing.T) {
+	_, err := NewTracker(Config{IoUThreshold: 1.5})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewTracker(Config{MaxAge: -1})
+	test.That(t, err, test.ShouldNotBeNil)
+	tr, err := NewTracker(Config{})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, tr.conf, test.ShouldResemble, Config{IoUThreshold: 0.3, MinHits: 3, MaxAge: 10})
+}
+
+func box(x, y int) image.Rectangle {
+	return image.Rect(x, y, x+40, y+80)
+}
+
+func TestTracking(t *testing.T) {
+	tracker, err := NewTracker(Config{MinHits: 3, MaxAge: 2})
+	test.That(t, err, test.ShouldBeNil)
+	start := time.Unix(1000, 0)
+	frame := func(i int) time.Time {
+		return start.Add(time.Duration(i) * 100 * time.Millisecond)
+	}
+
+	// a person walks right at 100 pixels per second while another walks left, passing under a car
+	var tracks []Track
+	for i := 0; i < 15; i++ {
+		tracks = tracker.Update([]objectdetection.Detection{
+			objectdetection.NewDetection(box(100+10*i, 100), 0.9, "person"),
+			objectdetection.NewDetection(box(400-10*i, 300), 0.8, "person"),
+			objectdetection.NewDetection(box(300, 300), 0.7, "car"),
+		}, frame(i))
+		if i < 2 {
+			// tracks are only reported once confirmed
+			test.That(t, tracks, test.ShouldBeEmpty)
+		}
+	}
+	test.That(t, tracks, test.ShouldHaveLength, 3)
+	test.That(t, tracks[0].ID, test.ShouldEqual, 1)
+	test.That(t, tracks[0].Label, test.ShouldEqual, "person")
+	test.That(t, tracks[0].Hits, test.ShouldEqual, 15)
+	test.That(t, tracks[0].Score, test.ShouldEqual, 0.9)
+	test.That(t, tracks[0].BoundingBox, test.ShouldResemble, box(240, 100))
+	test.That(t, tracks[0].Age(), test.ShouldEqual, 1400*time.Millisecond)
+	test.That(t, tracks[0].VelocityX, test.ShouldAlmostEqual, 100, 5)
+	test.That(t, tracks[0].VelocityY, test.ShouldAlmostEqual, 0, 5)
+	test.That(t, tracks[1].ID, test.ShouldEqual, 2)
+	test.That(t, tracks[1].VelocityX, test.ShouldAlmostEqual, -100, 5)
+	// the person overlapping the car is not confused with it
+	test.That(t, tracks[2].ID, test.ShouldEqual, 3)
+	test.That(t, tracks[2].Label, test.ShouldEqual, "car")
+	test.That(t, tracks[2].VelocityX, test.ShouldAlmostEqual, 0, 5)
+
+	// the first person is hidden for two frames and found where the motion model predicts
+	for i := 15; i < 17; i++ {
+		tracks = tracker.Update([]objectdetection.Detection{
+			objectdetection.NewDetection(box(300, 300), 0.7, "car"),
+		}, frame(i))
+		test.That(t, tracks, test.ShouldHaveLength, 1)
+	}
+	all := tracker.Tracks()
+	test.That(t, all, test.ShouldHaveLength, 3)
+	test.That(t, all[0].Missed, test.ShouldEqual, 2)
+	tracks = tracker.Update([]objectdetection.Detection{
+		objectdetection.NewDetection(box(270, 100), 0.9, "person"),
+	}, frame(17))
+	test.That(t, tracks, test.ShouldHaveLength, 1)
+	test.That(t, tracks[0].ID, test.ShouldEqual, 1)
+
+	// the second person was missed for three frames and is lost, so it comes back as a new track
+	for i := 18; i < 21; i++ {
+		tracks = tracker.Update([]objectdetection.Detection{
+			objectdetection.NewDetection(box(400-10*i, 300), 0.8, "person"),
+		}, frame(i))
+	}
+	test.That(t, tracks, test.ShouldHaveLength, 1)
+	test.That(t, tracks[0].ID, test.ShouldEqual, 4)
+
+	tracker.Reset()
+	test.That(t, tracker.Tracks(), test.ShouldBeEmpty)
+	tracks = tracker.Update([]objectdetection.Detection{
+		objectdetection.NewDetection(box(0, 0), 0.8, "person"),
+	}, frame(22))
+	test.That(t, tracks, test.ShouldBeEmpty)
+	test.That(t, tracker.nextID, test.ShouldEqual, 6)
+}
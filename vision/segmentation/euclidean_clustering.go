@@ -0,0 +1,207 @@
+package segmentation
+
+import (
+	"context"
+
+	"github.com/golang/geo/r3"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/components/camera"
+	pc "go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision"
+)
+
+const (
+	// OrientedBoundingBox makes the geometry of objects the box along the principal components of their points.
+	OrientedBoundingBox = "oriented"
+	// AxisAlignedBoundingBox makes the geometry of objects the box along the axes of the camera frame.
+	AxisAlignedBoundingBox = "axis_aligned"
+)
+
+// EuclideanClusteringConfig specifies the necessary parameters for Euclidean cluster extraction.
+type EuclideanClusteringConfig struct {
+	resource.TriviallyValidateConfig
+	// MinPtsInPlane is the number of points a plane needs for it to be removed before clustering,
+	// planes are left in the cloud when it is 0.
+	MinPtsInPlane      int     `json:"min_points_in_plane,omitempty"`
+	ClusterToleranceMm float64 `json:"cluster_tolerance_mm"`
+	MinClusterSize     int     `json:"min_cluster_size"`
+	// MaxClusterSize is the number of points above which clusters are dropped, there is no maximum
+	// when it is 0.
+	MaxClusterSize int `json:"max_cluster_size,omitempty"`
+	MeanKFiltering int `json:"mean_k_filtering,omitempty"`
+	// BoundingBox is either "oriented", the default, or "axis_aligned".
+	BoundingBox string `json:"bounding_box,omitempty"`
+	// ConvexHull reduces the point cloud of each object to the vertices of its convex hull.
+	ConvexHull bool   `json:"convex_hull,omitempty"`
+	Label      string `json:"label,omitempty"`
+}
+
+// CheckValid checks to see in the input values are valid.
+func (ecc *EuclideanClusteringConfig) CheckValid() error {
+	if ecc.MinPtsInPlane < 0 {
+		return errors.Errorf("min_points_in_plane cannot be negative, got %v", ecc.MinPtsInPlane)
+	}
+	if ecc.ClusterToleranceMm <= 0 {
+		return errors.Errorf("cluster_tolerance_mm must be greater than 0, got %v", ecc.ClusterToleranceMm)
+	}
+	if ecc.MinClusterSize <= 0 {
+		return errors.Errorf("min_cluster_size must be greater than 0, got %v", ecc.MinClusterSize)
+	}
+	if ecc.MaxClusterSize != 0 && ecc.MaxClusterSize < ecc.MinClusterSize {
+		return errors.Errorf("max_cluster_size must be at least min_cluster_size %v, got %v", ecc.MinClusterSize, ecc.MaxClusterSize)
+	}
+	switch ecc.BoundingBox {
+	case "", OrientedBoundingBox, AxisAlignedBoundingBox:
+	default:
+		return errors.Errorf("bounding_box must be %q or %q, got %q", OrientedBoundingBox, AxisAlignedBoundingBox, ecc.BoundingBox)
+	}
+	return nil
+}
+
+// ConvertAttributes changes the AttributeMap input into a EuclideanClusteringConfig.
+func (ecc *EuclideanClusteringConfig) ConvertAttributes(am utils.AttributeMap) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{TagName: "json", Result: ecc})
+	if err != nil {
+		return err
+	}
+	err = decoder.Decode(am)
+	if err == nil {
+		err = ecc.CheckValid()
+	}
+	return err
+}
+
+// NewEuclideanClustering returns a Segmenter that removes the planes (if configured) and returns
+// the clusters of points of the cloud as objects with oriented or axis aligned boxes.
+func NewEuclideanClustering(params utils.AttributeMap) (Segmenter, error) {
+	if params == nil {
+		return nil, errors.New("config for euclidean clustering segmentation cannot be nil")
+	}
+	cfg := &EuclideanClusteringConfig{}
+	err := cfg.ConvertAttributes(params)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.EuclideanClustering, nil
+}
+
+// EuclideanClustering applies Euclidean cluster extraction on the next point cloud of the source.
+func (ecc *EuclideanClusteringConfig) EuclideanClustering(ctx context.Context, src camera.VideoSource) ([]*vision.Object, error) {
+	cloud, err := src.NextPointCloud(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ecc.MinPtsInPlane > 0 {
+		ps := NewPointCloudPlaneSegmentation(cloud, 10, ecc.MinPtsInPlane)
+		_, cloud, err = ps.FindPlanes(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ecc.MeanKFiltering > 0 {
+		filter, err := pc.StatisticalOutlierFilter(ecc.MeanKFiltering, 1.25)
+		if err != nil {
+			return nil, err
+		}
+		cloud, err = filter(cloud)
+		if err != nil {
+			return nil, err
+		}
+	}
+	clusters, err := EuclideanClusters(cloud, ecc.ClusterToleranceMm, ecc.MinClusterSize, ecc.MaxClusterSize)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*vision.Object, 0, len(clusters))
+	for _, cluster := range clusters {
+		if ecc.ConvexHull {
+			if cluster, err = convexHullCloud(cluster); err != nil {
+				return nil, err
+			}
+		}
+		var obj *vision.Object
+		if ecc.BoundingBox == AxisAlignedBoundingBox {
+			obj, err = vision.NewObjectWithLabel(cluster, ecc.Label)
+		} else {
+			obj, err = vision.NewObjectWithOrientedBox(cluster, ecc.Label)
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// EuclideanClusters splits the cloud into the sets of points which are connected by chains of points
+// less than tolerance apart, the Euclidean cluster extraction of PCL. Clusters with fewer than
+// minSize points or, if maxSize is positive, more than maxSize points are dropped.
+// https://pcl.readthedocs.io/projects/tutorials/en/latest/cluster_extraction.html
+func EuclideanClusters(cloud pc.PointCloud, tolerance float64, minSize, maxSize int) ([]pc.PointCloud, error) {
+	if tolerance <= 0 {
+		return nil, errors.Errorf("argument tolerance must be a positive float, got %.2f", tolerance)
+	}
+	kdt, ok := cloud.(*pc.KDTree)
+	if !ok {
+		kdt = pc.ToKDTree(cloud)
+	}
+	visited := map[r3.Vector]bool{}
+	clusters := []pc.PointCloud{}
+	var err error
+	kdt.Iterate(0, 0, func(v r3.Vector, d pc.Data) bool {
+		if visited[v] {
+			return true
+		}
+		visited[v] = true
+		// grow the cluster breadth first from the point
+		queue := []*pc.PointAndData{{P: v, D: d}}
+		for i := 0; i < len(queue); i++ {
+			for _, neighbor := range kdt.RadiusNearestNeighbors(queue[i].P, tolerance, false) {
+				if !visited[neighbor.P] {
+					visited[neighbor.P] = true
+					queue = append(queue, neighbor)
+				}
+			}
+		}
+		if len(queue) < minSize || (maxSize > 0 && len(queue) > maxSize) {
+			return true
+		}
+		cluster := pc.NewWithPrealloc(len(queue))
+		for _, pd := range queue {
+			if err = cluster.Set(pd.P, pd.D); err != nil {
+				return false
+			}
+		}
+		clusters = append(clusters, cluster)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// convexHullCloud returns the points of the cloud that are vertices of its convex hull. Flat clouds
+// have no hull with volume and are returned whole.
+func convexHullCloud(cloud pc.PointCloud) (pc.PointCloud, error) {
+	hull, err := pc.ConvexHull(cloud)
+	if errors.Is(err, pc.ErrDegenerateHull) {
+		return cloud, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vertices := pc.HullVertices(hull)
+	hullCloud := pc.NewWithPrealloc(len(vertices))
+	for _, v := range vertices {
+		d, _ := cloud.At(v.X, v.Y, v.Z)
+		if err := hullCloud.Set(v, d); err != nil {
+			return nil, err
+		}
+	}
+	return hullCloud, nil
+}
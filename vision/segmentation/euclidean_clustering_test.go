@@ -0,0 +1,141 @@
+package segmentation_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	pc "go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/segmentation"
+)
+
+func TestEuclideanClusteringValidate(t *testing.T) {
+	cfg := segmentation.EuclideanClusteringConfig{}
+	err := cfg.CheckValid()
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cluster_tolerance_mm must be greater than 0")
+	cfg.ClusterToleranceMm = 5
+	err = cfg.CheckValid()
+	test.That(t, err.Error(), test.ShouldContainSubstring, "min_cluster_size must be greater than 0")
+	cfg.MinClusterSize = 10
+	cfg.MaxClusterSize = 5
+	err = cfg.CheckValid()
+	test.That(t, err.Error(), test.ShouldContainSubstring, "max_cluster_size must be at least min_cluster_size")
+	cfg.MaxClusterSize = 0
+	cfg.BoundingBox = "round"
+	err = cfg.CheckValid()
+	test.That(t, err.Error(), test.ShouldContainSubstring, "bounding_box must be")
+	cfg.BoundingBox = segmentation.AxisAlignedBoundingBox
+	test.That(t, cfg.CheckValid(), test.ShouldBeNil)
+}
+
+// makeBlocks returns a cloud of a large tilted block, a small block, and a lone point.
+func makeBlocks(t *testing.T) pc.PointCloud {
+	t.Helper()
+	cloud := pc.New()
+	pose := spatialmath.NewPose(r3.Vector{X: 100, Z: 500}, &spatialmath.EulerAngles{Yaw: math.Pi / 4})
+	for x := -50.; x <= 50; x += 5 {
+		for y := -10.; y <= 10; y += 5 {
+			for z := -10.; z <= 10; z += 5 {
+				p := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(r3.Vector{X: x, Y: y, Z: z})).Point()
+				test.That(t, cloud.Set(p, pc.NewValueData(1)), test.ShouldBeNil)
+			}
+		}
+	}
+	for x := -300.; x <= -290; x += 5 {
+		for y := 0.; y <= 10; y += 5 {
+			for z := 500.; z <= 510; z += 5 {
+				test.That(t, cloud.Set(r3.Vector{X: x, Y: y, Z: z}, pc.NewValueData(2)), test.ShouldBeNil)
+			}
+		}
+	}
+	test.That(t, cloud.Set(r3.Vector{X: 0, Y: 300, Z: 500}, pc.NewValueData(3)), test.ShouldBeNil)
+	return cloud
+}
+
+func TestEuclideanClusters(t *testing.T) {
+	cloud := makeBlocks(t)
+	_, err := segmentation.EuclideanClusters(cloud, 0, 1, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	clusters, err := segmentation.EuclideanClusters(cloud, 6, 1, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, clusters, test.ShouldHaveLength, 3)
+	sizes := map[int]bool{}
+	for _, c := range clusters {
+		sizes[c.Size()] = true
+	}
+	test.That(t, sizes, test.ShouldResemble, map[int]bool{21 * 5 * 5: true, 27: true, 1: true})
+
+	// the lone point and the large block are outside of the size range
+	clusters, err = segmentation.EuclideanClusters(cloud, 6, 2, 100)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, clusters, test.ShouldHaveLength, 1)
+	test.That(t, clusters[0].Size(), test.ShouldEqual, 27)
+
+	// with a tolerance below the spacing of the points every point is its own cluster
+	clusters, err = segmentation.EuclideanClusters(cloud, 4, 1, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, clusters, test.ShouldHaveLength, cloud.Size())
+}
+
+func TestEuclideanClusteringSegmenter(t *testing.T) {
+	cam := &inject.Camera{}
+	cam.NextPointCloudFunc = func(ctx context.Context) (pc.PointCloud, error) {
+		return makeBlocks(t), nil
+	}
+	_, err := segmentation.NewEuclideanClustering(nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	segmenter, err := segmentation.NewEuclideanClustering(utils.AttributeMap{
+		"cluster_tolerance_mm": 6,
+		"min_cluster_size":     20,
+		"label":                "block",
+	})
+	test.That(t, err, test.ShouldBeNil)
+	objects, err := segmenter(context.Background(), cam)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, objects, test.ShouldHaveLength, 2)
+	for _, obj := range objects {
+		test.That(t, obj.Geometry.Label(), test.ShouldEqual, "block")
+		dims := obj.Geometry.ToProtobuf().GetBox().GetDimsMm()
+		if obj.Size() == 27 {
+			test.That(t, dims.X, test.ShouldAlmostEqual, 10)
+			continue
+		}
+		// the oriented box fits the tilted block
+		test.That(t, dims.X, test.ShouldAlmostEqual, 100)
+		test.That(t, dims.Y, test.ShouldAlmostEqual, 20)
+		test.That(t, dims.Z, test.ShouldAlmostEqual, 20)
+	}
+
+	segmenter, err = segmentation.NewEuclideanClustering(utils.AttributeMap{
+		"cluster_tolerance_mm": 6,
+		"min_cluster_size":     20,
+		"bounding_box":         "axis_aligned",
+		"convex_hull":          true,
+	})
+	test.That(t, err, test.ShouldBeNil)
+	objects, err = segmenter(context.Background(), cam)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, objects, test.ShouldHaveLength, 2)
+	for _, obj := range objects {
+		// only the corners of the blocks are left
+		test.That(t, obj.Size(), test.ShouldEqual, 8)
+		obj.Iterate(0, 0, func(p r3.Vector, d pc.Data) bool {
+			test.That(t, d.HasValue(), test.ShouldBeTrue)
+			return true
+		})
+		dims := obj.Geometry.ToProtobuf().GetBox().GetDimsMm()
+		if dims.X < 20 {
+			test.That(t, dims.X, test.ShouldAlmostEqual, 10)
+			continue
+		}
+		// the axis aligned box of the block turned 45 degrees is wider than it
+		test.That(t, dims.X, test.ShouldAlmostEqual, 120/math.Sqrt2, 1e-6)
+	}
+}
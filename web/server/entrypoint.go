@@ -19,6 +19,7 @@ import (
 	"go.viam.com/utils/rpc"
 
 	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/robot"
 	robotimpl "go.viam.com/rdk/robot/impl"
 	"go.viam.com/rdk/robot/web"
 	weboptions "go.viam.com/rdk/robot/web/options"
@@ -40,6 +41,9 @@ type Arguments struct {
 	RevealSensitiveConfigDiffs bool   `flag:"reveal-sensitive-config-diffs,usage=show config diffs"`
 	UntrustedEnv               bool   `flag:"untrusted-env,usage=disable processes and shell from running in a untrusted environment"`
 	OutputTelemetry            bool   `flag:"output-telemetry,usage=print out telemetry data (metrics and spans)"`
+	ConfigHistory              int    `flag:"config-history,usage=number of applied configs to keep (0 keeps none)"`
+	RollbackThreshold          int    `flag:"rollback-threshold,usage=percent of failed resources rolling back to a kept config (0 disables)"`
+	Validate                   bool   `flag:"validate,usage=check the config file for errors, including in the attributes of models, and exit"`
 }
 
 type robotServer struct {
@@ -95,6 +99,15 @@ func RunServer(ctx context.Context, args []string, _ golog.Logger) (err error) {
 		return
 	}
 
+	// unlike the CLI, the server registers the built-in models and so can check their attributes
+	if argsParsed.Validate {
+		problems, err := config.ValidateFile(argsParsed.ConfigFile, true)
+		if err != nil {
+			return err
+		}
+		return config.PrintValidationProblems(os.Stdout, argsParsed.ConfigFile, problems)
+	}
+
 	if argsParsed.CPUProfile != "" {
 		f, err := os.Create(argsParsed.CPUProfile)
 		if err != nil {
@@ -254,6 +267,69 @@ func (s *robotServer) createWebOptions(cfg *config.Config) (weboptions.Options,
 	return options, nil
 }
 
+// recordConfig keeps the given config in the history, if there is one, returning its entry.
+func (s *robotServer) recordConfig(history *config.History, cfg *config.Config) *config.HistoryEntry {
+	if history == nil {
+		return nil
+	}
+	entry, err := history.Record(cfg, s.logger)
+	if err != nil {
+		s.logger.Warnw("failed to keep config in history; it cannot be rolled back", "error", err)
+		return nil
+	}
+	return entry
+}
+
+// tooManyFailed returns whether more of the components and services of the given config failed to
+// build than the rollback threshold allows.
+func (s *robotServer) tooManyFailed(myRobot robot.LocalRobot, cfg *config.Config) bool {
+	if s.args.RollbackThreshold <= 0 {
+		return false
+	}
+	total := len(cfg.Components) + len(cfg.Services)
+	failed := myRobot.FailedResources()
+	if total == 0 || len(failed)*100 <= s.args.RollbackThreshold*total {
+		return false
+	}
+	s.logger.Errorw("too many resources failed to build; rolling back config",
+		"failed", failed, "total", total, "threshold_percent", s.args.RollbackThreshold)
+	return true
+}
+
+func (s *robotServer) markFailed(history *config.History, entry *config.HistoryEntry) {
+	if err := history.MarkFailed(entry.Version); err != nil {
+		s.logger.Errorw("failed to mark config as failed", "version", entry.Version, "error", err)
+	}
+	entry.Failed = true
+}
+
+// lastGoodConfig returns the latest config kept before the given entry that was not rolled back,
+// or nil if there is none. The cloud settings of the current config are kept, since they may have
+// changed since.
+func (s *robotServer) lastGoodConfig(
+	history *config.History,
+	entry *config.HistoryEntry,
+	current *config.Config,
+) *config.Config {
+	good, err := history.LastGood(entry.Version)
+	if err != nil {
+		s.logger.Errorw("rollback aborted: error reading config history", "error", err)
+		return nil
+	}
+	if good == nil {
+		s.logger.Errorw("rollback aborted: no earlier config to roll back to", "version", entry.Version)
+		return nil
+	}
+	cfg, err := good.Process(s.logger)
+	if err != nil {
+		s.logger.Errorw("rollback aborted: error processing config", "version", good.Version, "error", err)
+		return nil
+	}
+	cfg.Cloud = current.Cloud
+	s.logger.Infow("rolling back config", "from_version", entry.Version, "to_version", good.Version)
+	return cfg
+}
+
 func (s *robotServer) serveWeb(ctx context.Context, cfg *config.Config) (err error) {
 	ctx, cancel := context.WithCancel(ctx)
 
@@ -331,6 +407,26 @@ func (s *robotServer) serveWeb(ctx context.Context, cfg *config.Config) (err err
 		robotOptions = append(robotOptions, robotimpl.WithRevealSensitiveConfigDiffs())
 	}
 
+	// the history holds configs as they were read, secrets included, so it is only kept on request
+	var history *config.History
+	if s.args.ConfigHistory > 0 {
+		history = config.NewHistory(config.DefaultHistoryDir, s.args.ConfigHistory)
+	} else if s.args.RollbackThreshold > 0 {
+		s.logger.Warn("configs cannot be rolled back without -config-history")
+	}
+	entry := s.recordConfig(history, cfg)
+	if entry != nil && entry.Failed {
+		// this config was rolled back before, so start with the last good one instead
+		if goodCfg := s.lastGoodConfig(history, entry, cfg); goodCfg != nil {
+			goodProcessedConfig, err := processConfig(goodCfg)
+			if err != nil {
+				s.logger.Errorw("rollback aborted: error processing config", "error", err)
+			} else {
+				processedConfig = goodProcessedConfig
+			}
+		}
+	}
+
 	myRobot, err := robotimpl.New(ctx, processedConfig, s.logger, robotOptions...)
 	if err != nil {
 		cancel()
@@ -340,6 +436,19 @@ func (s *robotServer) serveWeb(ctx context.Context, cfg *config.Config) (err err
 		err = multierr.Combine(err, myRobot.Close(context.Background()))
 	}()
 
+	if entry != nil && !entry.Failed && s.tooManyFailed(myRobot, processedConfig) {
+		s.markFailed(history, entry)
+		if goodCfg := s.lastGoodConfig(history, entry, cfg); goodCfg != nil {
+			goodProcessedConfig, err := processConfig(goodCfg)
+			if err != nil {
+				s.logger.Errorw("rollback aborted: error processing config", "error", err)
+			} else {
+				myRobot.Reconfigure(ctx, goodProcessedConfig)
+				processedConfig = goodProcessedConfig
+			}
+		}
+	}
+
 	// watch for and deliver changes to the robot
 	watcher, err := config.NewWatcher(ctx, cfg, s.logger)
 	if err != nil {
@@ -352,6 +461,7 @@ func (s *robotServer) serveWeb(ctx context.Context, cfg *config.Config) (err err
 	onWatchDone := make(chan struct{})
 	oldCfg := processedConfig
 	utils.ManagedGo(func() {
+		var ignoredVersion int
 		for {
 			select {
 			case <-ctx.Done():
@@ -362,6 +472,16 @@ func (s *robotServer) serveWeb(ctx context.Context, cfg *config.Config) (err err
 			case <-ctx.Done():
 				return
 			case cfg := <-watcher.Config():
+				entry := s.recordConfig(history, cfg)
+				if entry != nil && entry.Failed {
+					// the cloud sends the same config again until it is changed
+					if entry.Version != ignoredVersion {
+						s.logger.Warnw("ignoring config that was rolled back", "version", entry.Version)
+						ignoredVersion = entry.Version
+					}
+					continue
+				}
+
 				processedConfig, err := processConfig(cfg)
 				if err != nil {
 					s.logger.Errorw("reconfiguration aborted: error processing config", "error", err)
@@ -397,6 +517,27 @@ func (s *robotServer) serveWeb(ctx context.Context, cfg *config.Config) (err err
 					}
 				}
 				oldCfg = processedConfig
+
+				if entry != nil && s.tooManyFailed(myRobot, processedConfig) {
+					s.markFailed(history, entry)
+					goodCfg := s.lastGoodConfig(history, entry, cfg)
+					if goodCfg == nil {
+						continue
+					}
+					goodProcessedConfig, err := processConfig(goodCfg)
+					if err != nil {
+						s.logger.Errorw("rollback aborted: error processing config", "error", err)
+						continue
+					}
+					// only resources are rolled back, the web service keeps running as it is
+					rollbackDiff, err := config.DiffConfigs(*processedConfig, *goodProcessedConfig, false)
+					if err != nil || !rollbackDiff.NetworkEqual {
+						s.logger.Errorw("rollback aborted: network settings changed since the last good config", "error", err)
+						continue
+					}
+					myRobot.Reconfigure(ctx, goodProcessedConfig)
+					oldCfg = goodProcessedConfig
+				}
 			}
 		}
 	}, func() {